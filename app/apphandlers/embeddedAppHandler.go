@@ -15,13 +15,16 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	"github.com/rudderlabs/rudder-server/archiver"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/gateway"
 	gwThrottler "github.com/rudderlabs/rudder-server/gateway/throttler"
+	"github.com/rudderlabs/rudder-server/internal/deadletter"
 	drain_config "github.com/rudderlabs/rudder-server/internal/drain-config"
+	"github.com/rudderlabs/rudder-server/internal/jobstransition"
 	"github.com/rudderlabs/rudder-server/internal/pulsar"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/jobsdb/bench"
@@ -295,6 +298,7 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 		trackedUsersReporter,
 		pendingEventsRegistry,
 		processor.WithAdaptiveLimit(adaptiveLimit),
+		processor.WithDBHandle(dbPool),
 	)
 	throttlerFactory, err := rtThrottler.NewFactory(config, statsFactory)
 	if err != nil {
@@ -368,13 +372,24 @@ func (a *embeddedApp) StartRudderCore(ctx context.Context, options *app.Options)
 	g.Go(crash.Wrapper(func() (err error) {
 		return drainConfigManager.CleanupRoutine(ctx)
 	}))
+	archiveRestorer := archiver.NewRestorer(ctx, gatewayDB, fileUploaderProvider, rsourcesService, backendconfig.DefaultBackendConfig, config, statsFactory)
+	defer archiveRestorer.Wait()
+	admin.RegisterAdminHandler("Archiver", &archiver.RestoreAdmin{Restorer: archiveRestorer})
+	admin.RegisterAdminHandler("JobsDB", jobstransition.NewAdmin(reporting, config, logger.NewLogger().Child("jobs-transition"), gwDBForProcessor, routerDB, batchRouterDB))
 	streamMsgValidator := stream.NewMessageValidator()
 	gw := gateway.Handle{}
 	err = gw.Setup(ctx, config, logger.NewLogger().Child("gateway"), statsFactory, a.app, backendconfig.DefaultBackendConfig,
 		gatewayDB, errDBForWrite, rateLimiter, a.versionHandler, rsourcesService, transformerFeaturesService, sourceHandle,
-		streamMsgValidator, gateway.WithInternalHttpHandlers(
+		streamMsgValidator, gateway.WithPayloadValidators(a.app.Options().GatewayValidators...), gateway.WithInternalHttpHandlers(
 			map[string]http.Handler{
 				"/drain": drainConfigManager.DrainConfigHttpHandler(),
+				"/v1/dead-letter": deadletter.NewHandler(
+					map[string]jobsdb.JobsDB{"rt": routerDB, "batch_rt": batchRouterDB},
+					config,
+					logger.NewLogger().Child("dead-letter"),
+				),
+				"/v1/archive-restore": archiveRestorer.Handler(),
+				"/v1/jobsdb":          jobsdb.NewAdminHandler(gwDBForProcessor, routerDB, batchRouterDB, errDBForRead, schemaDB, archivalDB),
 			},
 		))
 	if err != nil {
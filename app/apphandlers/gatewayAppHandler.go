@@ -15,14 +15,17 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
+	"github.com/rudderlabs/rudder-server/archiver"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/gateway"
 	gwThrottler "github.com/rudderlabs/rudder-server/gateway/throttler"
 	drain_config "github.com/rudderlabs/rudder-server/internal/drain-config"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
 	"github.com/rudderlabs/rudder-server/services/transformer"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/types/deployment"
@@ -142,12 +145,18 @@ func (a *gatewayApp) StartRudderCore(ctx context.Context, options *app.Options)
 		defer drainConfigManager.Stop()
 		drainConfigHttpHandler = drainConfigManager.DrainConfigHttpHandler()
 	}
+	fileUploaderProvider := fileuploader.NewProvider(ctx, backendconfig.DefaultBackendConfig)
+	archiveRestorer := archiver.NewRestorer(ctx, gatewayDB, fileUploaderProvider, rsourcesService, backendconfig.DefaultBackendConfig, config, statsFactory)
+	defer archiveRestorer.Wait()
+	admin.RegisterAdminHandler("Archiver", &archiver.RestoreAdmin{Restorer: archiveRestorer})
 	streamMsgValidator := stream.NewMessageValidator()
 	err = gw.Setup(ctx, config, logger.NewLogger().Child("gateway"), statsFactory, a.app, backendconfig.DefaultBackendConfig,
 		gatewayDB, errDB, rateLimiter, a.versionHandler, rsourcesService, transformerFeaturesService, sourceHandle,
-		streamMsgValidator, gateway.WithInternalHttpHandlers(
+		streamMsgValidator, gateway.WithPayloadValidators(a.app.Options().GatewayValidators...), gateway.WithInternalHttpHandlers(
 			map[string]http.Handler{
-				"/drain": drainConfigHttpHandler,
+				"/drain":              drainConfigHttpHandler,
+				"/v1/archive-restore": archiveRestorer.Handler(),
+				"/v1/jobsdb":          jobsdb.NewAdminHandler(gatewayDB, errDB),
 			},
 		))
 	if err != nil {
@@ -16,11 +16,13 @@ import (
 	kithttputil "github.com/rudderlabs/rudder-go-kit/httputil"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/admin"
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/cluster"
 	"github.com/rudderlabs/rudder-server/archiver"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	drain_config "github.com/rudderlabs/rudder-server/internal/drain-config"
+	"github.com/rudderlabs/rudder-server/internal/jobstransition"
 	"github.com/rudderlabs/rudder-server/internal/pulsar"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	proc "github.com/rudderlabs/rudder-server/processor"
@@ -293,6 +295,7 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		trackedUsersReporter,
 		pendingEventsRegistry,
 		proc.WithAdaptiveLimit(adaptiveLimit),
+		proc.WithDBHandle(dbPool),
 	)
 	throttlerFactory, err := throttler.NewFactory(config, statsFactory)
 	if err != nil {
@@ -330,6 +333,7 @@ func (a *processorApp) StartRudderCore(ctx context.Context, options *app.Options
 		PendingEventsRegistry: pendingEventsRegistry,
 	}
 	rt := routerManager.New(rtFactory, brtFactory, backendconfig.DefaultBackendConfig, logger.NewLogger())
+	admin.RegisterAdminHandler("JobsDB", jobstransition.NewAdmin(reporting, config, logger.NewLogger().Child("jobs-transition"), gwDBForProcessor, routerDB, batchRouterDB))
 
 	dm := cluster.Dynamic{
 		Provider:         modeProvider,
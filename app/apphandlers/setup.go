@@ -153,12 +153,22 @@ func setupPipelineEnrichers(conf *config.Config, log logger.Logger, stats stats.
 	if conf.GetBool("BotEnrichment.enabled", true) {
 		log.Infon("Setting up the bot pipeline enricher")
 
-		botEnricher, err := enricher.NewBotEnricher()
+		botEnricher, err := enricher.NewBotEnricher(conf, log, stats)
 		if err != nil {
 			return nil, fmt.Errorf("starting bot enrichment process for pipeline: %w", err)
 		}
 		enrichers = append(enrichers, botEnricher)
 	}
 
+	if conf.GetBool("UserAgentEnrichment.enabled", true) {
+		log.Infon("Setting up the user agent pipeline enricher")
+
+		userAgentEnricher, err := enricher.NewUserAgentEnricher(conf, log, stats)
+		if err != nil {
+			return nil, fmt.Errorf("starting user agent enrichment process for pipeline: %w", err)
+		}
+		enrichers = append(enrichers, userAgentEnricher)
+	}
+
 	return enrichers, nil
 }
@@ -3,6 +3,8 @@ package app
 import (
 	"flag"
 	"os"
+
+	"github.com/rudderlabs/rudder-server/gateway/validator"
 )
 
 // Options contains application's initialisation options
@@ -14,6 +16,8 @@ type Options struct {
 	Memprofile      string
 	VersionFlag     bool
 	EnterpriseToken string
+	// GatewayValidators are additional validators for the events received by the gateway
+	GatewayValidators []validator.PayloadValidator
 }
 
 // LoadOptions loads application's initialisation options based on command line flags and environment
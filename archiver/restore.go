@@ -0,0 +1,515 @@
+package archiver
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	"github.com/rudderlabs/rudder-server/services/fileuploader"
+	"github.com/rudderlabs/rudder-server/services/rsources"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// ReplayedParam is the job parameter marking gateway jobs restored from an archive
+const ReplayedParam = "replayed"
+
+var (
+	errRestoreMissingWorkspace = errors.New("workspaceId is required")
+	errRestoreMissingSource    = errors.New("sourceId is required")
+	errRestoreInvalidRange     = errors.New("a time range with from before to is required")
+	errRestoreOverlapping      = errors.New("the time range overlaps with another restore of the same job run")
+	errRestoreTooMany          = errors.New("too many restores in progress")
+)
+
+// RestoreRequest selects the archived gateway jobs of a source to be restored
+type RestoreRequest struct {
+	WorkspaceID string    `json:"workspaceId"`
+	SourceID    string    `json:"sourceId"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	// DestinationIDs, if provided, restricts the delivery of restored jobs to these destinations.
+	// A copy of each archived job is stored for every destination, which the processor deduplicates separately per destination.
+	DestinationIDs []string `json:"destinationIds"`
+	// JobRunID is the rsources job run id for tracking the progress of the restore. A new one is generated if empty.
+	JobRunID string `json:"jobRunId"`
+}
+
+func (r RestoreRequest) validate() error {
+	if r.WorkspaceID == "" {
+		return errRestoreMissingWorkspace
+	}
+	if r.SourceID == "" {
+		return errRestoreMissingSource
+	}
+	if r.From.IsZero() || r.To.IsZero() || !r.From.Before(r.To) {
+		return errRestoreInvalidRange
+	}
+	return nil
+}
+
+// overlaps returns true if both requests restore jobs of the same source, created within a common period of time
+func (r RestoreRequest) overlaps(other RestoreRequest) bool {
+	return r.WorkspaceID == other.WorkspaceID && r.SourceID == other.SourceID &&
+		r.From.Before(other.To) && other.From.Before(r.To)
+}
+
+// RestoreResponse is returned once a restore has been started
+type RestoreResponse struct {
+	JobRunID string `json:"jobRunId"`
+}
+
+// restoreResult summarises a completed restore
+type restoreResult struct {
+	files int
+	jobs  int
+}
+
+// Restorer reads gateway jobs archived by the archiver back from object storage and stores them as new jobs in the gateway jobsdb.
+// Restored jobs carry the rsources job run id of the restore, so that its progress can be followed through the job-status api.
+type Restorer struct {
+	ctx             context.Context
+	jobsDB          jobsdb.JobsDB
+	storageProvider fileuploader.Provider
+	rsourcesService rsources.JobService
+	log             logger.Logger
+	stats           stats.Stats
+
+	archiveFrom string
+	config      struct {
+		batchSize             config.ValueLoader[int]
+		maxConcurrentRestores config.ValueLoader[int]
+		customVal             string
+	}
+
+	restoresMu sync.Mutex
+	restores   map[string][]RestoreRequest // restores started per job run id, so that the same jobs are not restored twice within a job run
+	running    int                         // number of restores in progress
+
+	sourceCategoriesMu   sync.RWMutex
+	sourceCategories     map[string]string // source category per source id
+	sourceCategoriesInit chan struct{}     // closed once the backend config has been received
+
+	wg sync.WaitGroup
+}
+
+// NewRestorer creates a new restorer for the gateway jobsdb. Restores started through it are stopped once ctx is cancelled.
+func NewRestorer(
+	ctx context.Context,
+	jobsDB jobsdb.JobsDB,
+	storageProvider fileuploader.Provider,
+	rsourcesService rsources.JobService,
+	backendConfig backendconfig.BackendConfig,
+	c *config.Config,
+	statHandle stats.Stats,
+) *Restorer {
+	r := &Restorer{
+		ctx:                  ctx,
+		jobsDB:               jobsDB,
+		storageProvider:      storageProvider,
+		rsourcesService:      rsourcesService,
+		log:                  logger.NewLogger().Child("archiver").Child("restore"),
+		stats:                statHandle,
+		archiveFrom:          "gw",
+		restores:             make(map[string][]RestoreRequest),
+		sourceCategoriesInit: make(chan struct{}),
+	}
+	r.config.batchSize = c.GetReloadableIntVar(1000, 1, "archival.Restore.batchSize")
+	r.config.maxConcurrentRestores = c.GetReloadableIntVar(1, 1, "archival.Restore.maxConcurrentRestores")
+	r.config.customVal = c.GetString("Gateway.CustomVal", "GW")
+	go r.updateSourceCategories(ctx, backendConfig)
+	return r
+}
+
+// Restore validates the request and starts restoring the matching archived jobs in the background.
+// A request is rejected if too many restores are in progress, or if it overlaps with a restore of the same job run which is in progress or has already been started,
+// regardless of its outcome, since a failed restore may have already stored some of its jobs.
+// Restores are only tracked in memory, for the lifetime of the restorer.
+func (r *Restorer) Restore(req RestoreRequest) (RestoreResponse, error) {
+	if err := req.validate(); err != nil {
+		return RestoreResponse{}, err
+	}
+	if req.JobRunID == "" {
+		req.JobRunID = uuid.NewString()
+	}
+	if err := r.begin(req); err != nil {
+		return RestoreResponse{}, err
+	}
+	log := r.log.Withn(
+		logger.NewStringField("workspaceId", req.WorkspaceID),
+		logger.NewStringField("sourceId", req.SourceID),
+		logger.NewStringField("jobRunId", req.JobRunID),
+	)
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer r.end()
+		ctx, span := r.stats.NewTracer("archiver").Start(r.ctx, "archiver.restore", stats.SpanKindInternal, stats.SpanWithTags(stats.Tags{
+			"workspaceId": req.WorkspaceID,
+			"sourceId":    req.SourceID,
+			"jobRunId":    req.JobRunID,
+		}))
+		defer span.End()
+		log.Infon("Starting archive restore", logger.NewTimeField("from", req.From), logger.NewTimeField("to", req.To))
+		res, err := r.restore(ctx, req)
+		if err != nil {
+			log.Errorn("Archive restore failed", logger.NewIntField("jobs", int64(res.jobs)), logger.NewErrorField(err))
+			return
+		}
+		log.Infon("Archive restore completed", logger.NewIntField("files", int64(res.files)), logger.NewIntField("jobs", int64(res.jobs)))
+	}()
+	return RestoreResponse{JobRunID: req.JobRunID}, nil
+}
+
+// begin reserves a slot for the restore, failing if there are too many restores in progress or if the restore overlaps with another one of the same job run
+func (r *Restorer) begin(req RestoreRequest) error {
+	r.restoresMu.Lock()
+	defer r.restoresMu.Unlock()
+	for _, other := range r.restores[req.JobRunID] {
+		if req.overlaps(other) {
+			return errRestoreOverlapping
+		}
+	}
+	if r.running >= r.config.maxConcurrentRestores.Load() {
+		return errRestoreTooMany
+	}
+	r.running++
+	r.restores[req.JobRunID] = append(r.restores[req.JobRunID], req)
+	return nil
+}
+
+// end releases the slot of a restore
+func (r *Restorer) end() {
+	r.restoresMu.Lock()
+	defer r.restoresMu.Unlock()
+	r.running--
+}
+
+// Wait waits for all started restores to finish
+func (r *Restorer) Wait() {
+	r.wg.Wait()
+}
+
+// Handler returns an http handler for starting restores, expecting a [RestoreRequest] as the body of a POST request
+func (r *Restorer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != http.MethodPost {
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		var restoreReq RestoreRequest
+		if err := jsonrs.NewDecoder(req.Body).Decode(&restoreReq); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+		res, err := r.Restore(restoreReq)
+		if err != nil {
+			status := http.StatusBadRequest
+			switch {
+			case errors.Is(err, errRestoreOverlapping):
+				status = http.StatusConflict
+			case errors.Is(err, errRestoreTooMany):
+				status = http.StatusTooManyRequests
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+		body, err := jsonrs.Marshal(res)
+		if err != nil {
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write(body)
+	})
+}
+
+// RestoreAdmin exposes the restorer over the admin rpc interface, used by rudder-cli
+type RestoreAdmin struct {
+	Restorer *Restorer
+}
+
+// Restore starts restoring archived jobs, replying with the rsources job run id of the restore
+func (a *RestoreAdmin) Restore(req RestoreRequest, reply *string) error {
+	res, err := a.Restorer.Restore(req)
+	if err != nil {
+		return err
+	}
+	*reply = fmt.Sprintf("Restore started with job run id: %s", res.JobRunID)
+	return nil
+}
+
+func (r *Restorer) restore(ctx context.Context, req RestoreRequest) (restoreResult, error) {
+	var res restoreResult
+	fm, err := r.storageProvider.GetFileManager(ctx, req.WorkspaceID)
+	if err != nil {
+		return res, fmt.Errorf("getting file manager: %w", err)
+	}
+	keys, err := r.archiveFiles(ctx, fm, req)
+	if err != nil {
+		return res, err
+	}
+	sourceCategory, err := r.sourceCategory(ctx, req.SourceID)
+	if err != nil {
+		return res, err
+	}
+	// same parameters as the ones of the jobs stored by the gateway
+	params := map[string]any{
+		"source_id":          req.SourceID,
+		"source_job_run_id":  req.JobRunID,
+		"source_task_run_id": req.JobRunID,
+		"traceparent":        stats.GetTraceParentFromContext(ctx),
+		"source_category":    sourceCategory,
+		ReplayedParam:        true,
+	}
+	tags := stats.Tags{"workspaceId": req.WorkspaceID, "sourceId": req.SourceID}
+	for _, key := range keys {
+		n, err := r.restoreFile(ctx, fm, key, req, params)
+		res.jobs += n
+		r.stats.NewTaggedStat("arc_restored_jobs", stats.CountType, tags).Count(n)
+		if err != nil {
+			return res, fmt.Errorf("restoring file %q: %w", key, err)
+		}
+		res.files++
+		r.stats.NewTaggedStat("arc_restored_files", stats.CountType, tags).Increment()
+	}
+	return res, nil
+}
+
+// archiveFiles lists the keys of the archive files of the requested source and workspace that may contain jobs within the requested time range.
+// Archive files are stored under {sourceID}/{archiveFrom}/{date}/{hour}/{instanceID}/{firstJobCreatedAt}_{lastJobCreatedAt}_{workspaceID}_{uuid}.json.gz,
+// thus files of the day before the range are also listed, for files starting before midnight.
+func (r *Restorer) archiveFiles(ctx context.Context, fm filemanager.FileManager, req RestoreRequest) ([]string, error) {
+	var keys []string
+	for day := req.From.UTC().Truncate(24*time.Hour).AddDate(0, 0, -1); day.Before(req.To); day = day.AddDate(0, 0, 1) {
+		prefix := path.Join(fm.Prefix(), req.SourceID, r.archiveFrom, day.Format("2006-01-02")) + "/"
+		session := fm.ListFilesWithPrefix(ctx, "", prefix, int64(r.config.batchSize.Load()))
+		for {
+			files, err := session.Next()
+			if err != nil {
+				return nil, fmt.Errorf("listing files with prefix %q: %w", prefix, err)
+			}
+			if len(files) == 0 {
+				break
+			}
+			for _, file := range files {
+				first, last, workspaceID, ok := parseArchiveFileName(path.Base(file.Key))
+				if !ok {
+					r.log.Warnn("Skipping unrecognised archive file", logger.NewStringField("key", file.Key))
+					continue
+				}
+				if workspaceID != req.WorkspaceID || last.Before(req.From.Truncate(time.Second)) || !first.Before(req.To) {
+					continue
+				}
+				keys = append(keys, file.Key)
+			}
+		}
+	}
+	return keys, nil
+}
+
+// restoreFile downloads an archive file and stores the jobs within the requested time range in batches, returning the number of jobs stored
+func (r *Restorer) restoreFile(ctx context.Context, fm filemanager.FileManager, key string, req RestoreRequest, params map[string]any) (int, error) {
+	tmpDir, err := misc.CreateTMPDIR()
+	if err != nil {
+		return 0, fmt.Errorf("creating tmp dir: %w", err)
+	}
+	localPath := filepath.Join(tmpDir, "rudder-restores", req.JobRunID, path.Base(key))
+	if err := os.MkdirAll(filepath.Dir(localPath), os.ModePerm); err != nil {
+		return 0, fmt.Errorf("creating directory for %q: %w", localPath, err)
+	}
+	f, err := os.Create(localPath)
+	if err != nil {
+		return 0, fmt.Errorf("creating file %q: %w", localPath, err)
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(localPath)
+	}()
+	if err := fm.Download(ctx, f, key); err != nil {
+		return 0, fmt.Errorf("downloading: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("seeking: %w", err)
+	}
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, fmt.Errorf("creating gzip reader: %w", err)
+	}
+	defer func() { _ = gzReader.Close() }()
+
+	var (
+		stored int
+		batch  []*jobsdb.JobT
+		reader = bufio.NewReader(gzReader)
+	)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := r.storeJobs(ctx, batch); err != nil {
+			return err
+		}
+		stored += len(batch)
+		batch = nil
+		return nil
+	}
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			jobs, ok, parseErr := r.newRestoredJobs(line, req, params)
+			if parseErr != nil {
+				return stored, parseErr
+			}
+			if ok {
+				batch = append(batch, jobs...)
+			}
+			if len(batch) >= r.config.batchSize.Load() {
+				if err := flush(); err != nil {
+					return stored, err
+				}
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return stored, fmt.Errorf("reading: %w", err)
+		}
+	}
+	return stored, flush()
+}
+
+// newRestoredJobs creates new gateway jobs with the provided parameters out of an archived job, if it was created within the requested time range
+func (r *Restorer) newRestoredJobs(line []byte, req RestoreRequest, params map[string]any) ([]*jobsdb.JobT, bool, error) {
+	var archived struct {
+		UserID       string          `json:"userId"`
+		EventPayload json.RawMessage `json:"payload"`
+		CreatedAt    time.Time       `json:"createdAt"`
+	}
+	if err := jsonrs.Unmarshal(line, &archived); err != nil {
+		return nil, false, fmt.Errorf("unmarshalling archived job: %w", err)
+	}
+	if archived.CreatedAt.Before(req.From) || !archived.CreatedAt.Before(req.To) {
+		return nil, false, nil
+	}
+	destinationIDs := req.DestinationIDs
+	if len(destinationIDs) == 0 {
+		destinationIDs = []string{""}
+	}
+	eventCount := len(gjson.GetBytes(archived.EventPayload, "batch").Array())
+	jobs := make([]*jobsdb.JobT, 0, len(destinationIDs))
+	for _, destinationID := range destinationIDs {
+		jobParams := params
+		if destinationID != "" {
+			jobParams = make(map[string]any, len(params)+1)
+			for k, v := range params {
+				jobParams[k] = v
+			}
+			jobParams["destination_id"] = destinationID
+		}
+		marshalledParams, err := jsonrs.Marshal(jobParams)
+		if err != nil {
+			return nil, false, fmt.Errorf("marshalling parameters: %w", err)
+		}
+		jobs = append(jobs, &jobsdb.JobT{
+			UUID:         uuid.New(),
+			UserID:       archived.UserID,
+			CustomVal:    r.config.customVal,
+			EventCount:   eventCount,
+			EventPayload: archived.EventPayload,
+			Parameters:   marshalledParams,
+			WorkspaceId:  req.WorkspaceID,
+		})
+	}
+	return jobs, true, nil
+}
+
+func (r *Restorer) storeJobs(ctx context.Context, jobs []*jobsdb.JobT) error {
+	return r.jobsDB.WithStoreSafeTx(ctx, func(tx jobsdb.StoreSafeTx) error {
+		if err := r.jobsDB.StoreInTx(ctx, tx, jobs); err != nil {
+			return fmt.Errorf("storing jobs: %w", err)
+		}
+		rsourcesStats := rsources.NewStatsCollector(
+			r.rsourcesService,
+			rsources.IgnoreDestinationID(),
+		)
+		rsourcesStats.JobsStoredWithErrors(jobs, nil)
+		return rsourcesStats.Publish(ctx, tx.SqlTx())
+	})
+}
+
+// updateSourceCategories keeps the source categories up to date with the backend config
+func (r *Restorer) updateSourceCategories(ctx context.Context, backendConfig backendconfig.BackendConfig) {
+	for data := range backendConfig.Subscribe(ctx, backendconfig.TopicProcessConfig) {
+		sourceCategories := make(map[string]string)
+		for _, wConfig := range data.Data.(map[string]backendconfig.ConfigT) {
+			for _, source := range wConfig.Sources {
+				sourceCategories[source.ID] = source.SourceDefinition.Category
+			}
+		}
+		r.sourceCategoriesMu.Lock()
+		r.sourceCategories = sourceCategories
+		r.sourceCategoriesMu.Unlock()
+		select {
+		case <-r.sourceCategoriesInit:
+		default:
+			close(r.sourceCategoriesInit)
+		}
+	}
+}
+
+// sourceCategory returns the category of a source, waiting for the backend config to be received
+func (r *Restorer) sourceCategory(ctx context.Context, sourceID string) (string, error) {
+	select {
+	case <-ctx.Done():
+		return "", ctx.Err()
+	case <-r.sourceCategoriesInit:
+	}
+	r.sourceCategoriesMu.RLock()
+	defer r.sourceCategoriesMu.RUnlock()
+	return r.sourceCategories[sourceID], nil
+}
+
+// parseArchiveFileName parses the name of an archive file, i.e. {firstJobCreatedAt}_{lastJobCreatedAt}_{workspaceID}_{uuid}.json.gz
+func parseArchiveFileName(name string) (first, last time.Time, workspaceID string, ok bool) {
+	parts := strings.SplitN(strings.TrimSuffix(name, ".json.gz"), "_", 3)
+	if len(parts) != 3 {
+		return
+	}
+	firstUnix, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return
+	}
+	lastUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return
+	}
+	idx := strings.LastIndex(parts[2], "_")
+	if idx <= 0 {
+		return
+	}
+	return time.Unix(firstUnix, 0).UTC(), time.Unix(lastUnix, 0).UTC(), parts[2][:idx], true
+}
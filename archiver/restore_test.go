@@ -0,0 +1,270 @@
+package archiver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/filemanager/mock_filemanager"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksBackendConfig "github.com/rudderlabs/rudder-server/mocks/backend-config"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+	"github.com/rudderlabs/rudder-server/services/rsources"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/pubsub"
+)
+
+type staticFileManagerProvider struct {
+	fm filemanager.FileManager
+}
+
+func (p staticFileManagerProvider) GetFileManager(context.Context, string) (filemanager.FileManager, error) {
+	return p.fm, nil
+}
+
+func (staticFileManagerProvider) GetStoragePreferences(context.Context, string) (backendconfig.StoragePreferences, error) {
+	return backendconfig.StoragePreferences{}, nil
+}
+
+type listSession struct {
+	pages [][]*filemanager.FileInfo
+}
+
+func (s *listSession) Next() ([]*filemanager.FileInfo, error) {
+	if len(s.pages) == 0 {
+		return nil, nil
+	}
+	page := s.pages[0]
+	s.pages = s.pages[1:]
+	return page, nil
+}
+
+func TestRestore(t *testing.T) {
+	misc.Init()
+	var (
+		workspaceID = "ws-1"
+		sourceID    = "src-1"
+		from        = time.Date(2024, 1, 2, 10, 0, 0, 0, time.UTC)
+		to          = from.Add(time.Hour)
+	)
+	archiveFile := func(t *testing.T, createdAt ...time.Time) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		gw := gzip.NewWriter(&buf)
+		for i, c := range createdAt {
+			j, err := marshalJob(&jobsdb.JobT{
+				UserID:       fmt.Sprintf("user-%d", i),
+				CreatedAt:    c,
+				EventPayload: []byte(fmt.Sprintf(`{"batch":[{"messageId":"msg-%d"},{"messageId":"msg-%d-2"}]}`, i, i)),
+			})
+			require.NoError(t, err)
+			_, err = gw.Write(append(j, '\n'))
+			require.NoError(t, err)
+		}
+		require.NoError(t, gw.Close())
+		return buf.Bytes()
+	}
+	fileName := func(first, last time.Time, workspaceID string) string {
+		return fmt.Sprintf("%d_%d_%s_%s.json.gz", first.Unix(), last.Unix(), workspaceID, "8a1d2b0c-uuid")
+	}
+
+	setup := func(t *testing.T, files map[string][]byte, batchSize int, opts ...func(*config.Config)) (*Restorer, *[]*jobsdb.JobT) {
+		ctrl := gomock.NewController(t)
+		fm := mock_filemanager.NewMockFileManager(ctrl)
+		fm.EXPECT().Prefix().Return("prefix").AnyTimes()
+		fm.EXPECT().ListFilesWithPrefix(gomock.Any(), "", gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _, prefix string, _ int64) filemanager.ListSession {
+				var page []*filemanager.FileInfo
+				for key := range files {
+					if strings.HasPrefix(key, prefix) {
+						page = append(page, &filemanager.FileInfo{Key: key})
+					}
+				}
+				return &listSession{pages: [][]*filemanager.FileInfo{page}}
+			}).AnyTimes()
+		fm.EXPECT().Download(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, w io.WriterAt, key string) error {
+				if strings.HasSuffix(key, "blocking.json.gz") { // keeps the restore in progress until it is stopped
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				_, err := w.WriteAt(files[key], 0)
+				return err
+			}).AnyTimes()
+
+		var stored []*jobsdb.JobT
+		db := mocksJobsDB.NewMockJobsDB(ctrl)
+		db.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) error {
+				return f(jobsdb.EmptyStoreSafeTx())
+			}).AnyTimes()
+		db.EXPECT().StoreInTx(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) error {
+				require.LessOrEqual(t, len(jobs), batchSize)
+				stored = append(stored, jobs...)
+				return nil
+			}).AnyTimes()
+		rsourcesService := rsources.NewMockJobService(ctrl)
+		rsourcesService.EXPECT().IncrementStats(gomock.Any(), gomock.Any(), "job-run-1", gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+
+		backendConfig := mocksBackendConfig.NewMockBackendConfig(ctrl)
+		backendConfig.EXPECT().Subscribe(gomock.Any(), backendconfig.TopicProcessConfig).DoAndReturn(
+			func(_ context.Context, topic backendconfig.Topic) pubsub.DataChannel {
+				ch := make(chan pubsub.DataEvent, 1)
+				ch <- pubsub.DataEvent{Data: map[string]backendconfig.ConfigT{workspaceID: {
+					Sources: []backendconfig.SourceT{{ID: sourceID, SourceDefinition: backendconfig.SourceDefinitionT{Category: "webhook"}}},
+				}}, Topic: string(topic)}
+				close(ch)
+				return ch
+			})
+
+		c := config.New()
+		c.Set("archival.Restore.batchSize", batchSize)
+		for _, opt := range opts {
+			opt(c)
+		}
+		return NewRestorer(context.Background(), db, staticFileManagerProvider{fm: fm}, rsourcesService, backendConfig, c, stats.NOP), &stored
+	}
+
+	t.Run("restores jobs within the time range of the requested workspace", func(t *testing.T) {
+		files := map[string][]byte{
+			// file of the previous day overlapping with the requested range
+			"prefix/src-1/gw/2024-01-01/23/1/" + fileName(from.Add(-11*time.Hour), from.Add(time.Minute), workspaceID): archiveFile(t, from.Add(-11*time.Hour), from.Add(time.Minute)),
+			"prefix/src-1/gw/2024-01-02/10/1/" + fileName(from.Add(2*time.Minute), to.Add(time.Minute), workspaceID):   archiveFile(t, from.Add(2*time.Minute), from.Add(3*time.Minute), to.Add(time.Minute)),
+			// file of another workspace
+			"prefix/src-1/gw/2024-01-02/10/1/" + fileName(from, to, "ws-2"): archiveFile(t, from),
+			// file outside of the requested range
+			"prefix/src-1/gw/2024-01-02/12/1/" + fileName(to.Add(time.Hour), to.Add(time.Hour), workspaceID): archiveFile(t, to.Add(time.Hour)),
+			"prefix/src-1/gw/2024-01-02/10/1/unrecognised.json.gz":                                           nil,
+		}
+		restorer, stored := setup(t, files, 2)
+
+		res, err := restorer.restore(context.Background(), RestoreRequest{
+			WorkspaceID: workspaceID,
+			SourceID:    sourceID,
+			From:        from,
+			To:          to,
+			JobRunID:    "job-run-1",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, res.files)
+		require.Equal(t, 3, res.jobs)
+		require.Len(t, *stored, 3)
+		for _, job := range *stored {
+			require.Equal(t, workspaceID, job.WorkspaceId)
+			require.Equal(t, "GW", job.CustomVal)
+			require.Equal(t, 2, job.EventCount)
+			require.JSONEq(t, `{"source_id":"src-1","source_job_run_id":"job-run-1","source_task_run_id":"job-run-1","traceparent":"","source_category":"webhook","replayed":true}`, string(job.Parameters))
+		}
+	})
+
+	t.Run("stores a copy of every job for each destination filter", func(t *testing.T) {
+		files := map[string][]byte{
+			"prefix/src-1/gw/2024-01-02/10/1/" + fileName(from, from, workspaceID): archiveFile(t, from),
+		}
+		restorer, stored := setup(t, files, 10)
+
+		res, err := restorer.restore(context.Background(), RestoreRequest{
+			WorkspaceID:    workspaceID,
+			SourceID:       sourceID,
+			From:           from,
+			To:             to,
+			DestinationIDs: []string{"dest-1", "dest-2"},
+			JobRunID:       "job-run-1",
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, res.jobs)
+		require.Equal(t, "dest-1", gjson.GetBytes((*stored)[0].Parameters, "destination_id").String())
+		require.Equal(t, "dest-2", gjson.GetBytes((*stored)[1].Parameters, "destination_id").String())
+		require.NotEqual(t, (*stored)[0].UUID, (*stored)[1].UUID)
+	})
+
+	t.Run("handler validates requests", func(t *testing.T) {
+		restorer, _ := setup(t, nil, 10)
+		for body, expected := range map[string]int{
+			`{"sourceId":"src-1","from":"2024-01-02T10:00:00Z","to":"2024-01-02T11:00:00Z"}`:                      http.StatusBadRequest,
+			`{"workspaceId":"ws-1","from":"2024-01-02T10:00:00Z","to":"2024-01-02T11:00:00Z"}`:                    http.StatusBadRequest,
+			`{"workspaceId":"ws-1","sourceId":"src-1","from":"2024-01-02T11:00:00Z","to":"2024-01-02T10:00:00Z"}`: http.StatusBadRequest,
+			`{"workspaceId":"ws-1","sourceId":"src-1","from":"2024-01-02T10:00:00Z","to":"2024-01-02T11:00:00Z"}`: http.StatusAccepted,
+			`not json`: http.StatusBadRequest,
+		} {
+			resp := httptest.NewRecorder()
+			restorer.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)))
+			require.Equal(t, expected, resp.Code, body)
+			if expected == http.StatusAccepted {
+				require.NotEmpty(t, gjson.GetBytes(resp.Body.Bytes(), "jobRunId").String())
+			}
+		}
+		restorer.Wait()
+	})
+	t.Run("rejects restores overlapping with another one of the same job run", func(t *testing.T) {
+		restorer, _ := setup(t, nil, 10, func(c *config.Config) {
+			c.Set("archival.Restore.maxConcurrentRestores", 10)
+		})
+		req := RestoreRequest{WorkspaceID: workspaceID, SourceID: sourceID, From: from, To: to, JobRunID: "job-run-1"}
+		_, err := restorer.Restore(req)
+		require.NoError(t, err)
+		restorer.Wait()
+
+		overlapping := req
+		overlapping.From, overlapping.To = from.Add(30*time.Minute), to.Add(30*time.Minute)
+		_, err = restorer.Restore(overlapping)
+		require.ErrorIs(t, err, errRestoreOverlapping, "restores which are done should be considered too")
+
+		adjacent := req
+		adjacent.From, adjacent.To = to, to.Add(time.Hour)
+		_, err = restorer.Restore(adjacent)
+		require.NoError(t, err)
+
+		otherJobRun := req
+		otherJobRun.JobRunID = "job-run-2"
+		_, err = restorer.Restore(otherJobRun)
+		require.NoError(t, err)
+
+		otherSource := req
+		otherSource.SourceID = "src-2"
+		_, err = restorer.Restore(otherSource)
+		require.NoError(t, err)
+		restorer.Wait()
+	})
+
+	t.Run("limits the number of restores in progress", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		files := map[string][]byte{
+			fmt.Sprintf("prefix/src-1/gw/2024-01-02/10/1/%d_%d_%s_blocking.json.gz", from.Unix(), from.Unix(), workspaceID): nil,
+		}
+		restorer, _ := setup(t, files, 10)
+		restorer.ctx = ctx
+
+		_, err := restorer.Restore(RestoreRequest{WorkspaceID: workspaceID, SourceID: sourceID, From: from, To: to})
+		require.NoError(t, err)
+		_, err = restorer.Restore(RestoreRequest{WorkspaceID: workspaceID, SourceID: sourceID, From: from, To: to})
+		require.ErrorIs(t, err, errRestoreTooMany)
+
+		resp := httptest.NewRecorder()
+		restorer.Handler().ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"workspaceId":"ws-1","sourceId":"src-1","from":"2024-01-02T10:00:00Z","to":"2024-01-02T11:00:00Z"}`)))
+		require.Equal(t, http.StatusTooManyRequests, resp.Code)
+
+		cancel()
+		restorer.Wait()
+		_, err = restorer.Restore(RestoreRequest{WorkspaceID: workspaceID, SourceID: sourceID, From: from, To: to})
+		require.NoError(t, err, "a slot should be released once a restore is done")
+		restorer.Wait()
+	})
+}
@@ -104,6 +104,9 @@ type SourceT struct {
 	GeoEnrichment              struct {
 		Enabled bool
 	}
+	UserAgentEnrichment struct {
+		Enabled bool
+	}
 }
 
 type Credential struct {
@@ -238,6 +241,20 @@ type DgSourceTrackingPlanConfigT struct {
 	MergedConfig        map[string]interface{}            `json:"mergedConfig"`
 	Deleted             bool                              `json:"deleted"`
 	TrackingPlan        TrackingPlanT                     `json:"trackingPlan"`
+	GatewayValidation   GatewaySchemaValidationT          `json:"gatewayValidation"`
+}
+
+// GatewaySchemaValidationT configures the validation of a source's events against the json schemas of its tracking plan at the gateway
+type GatewaySchemaValidationT struct {
+	Mode    string         `json:"mode"` // reject, drop or tag, validation is disabled if empty
+	Schemas []EventSchemaT `json:"schemas"`
+}
+
+// EventSchemaT is the json schema of events having the given type and name
+type EventSchemaT struct {
+	EventType string          `json:"eventType"`
+	EventName string          `json:"eventName"` // if empty, the schema applies to all events of the type not having a schema of their own
+	Schema    json.RawMessage `json:"schema"`
 }
 
 func (dgSourceTPConfigT *DgSourceTrackingPlanConfigT) GetMergedConfig(eventType string) map[string]interface{} {
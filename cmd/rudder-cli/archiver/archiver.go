@@ -0,0 +1,43 @@
+package archiver
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/client"
+)
+
+type RestoreInput struct {
+	WorkspaceID    string
+	SourceID       string
+	From           time.Time
+	To             time.Time
+	DestinationIDs []string
+	JobRunID       string
+}
+
+func Restore(c *cli.Context) (err error) {
+	var reply string
+
+	input := RestoreInput{
+		WorkspaceID:    c.String("workspace"),
+		SourceID:       c.String("source"),
+		DestinationIDs: c.StringSlice("dest"),
+		JobRunID:       c.String("job-run-id"),
+	}
+	if input.From, err = time.Parse(time.RFC3339, c.String("from")); err != nil {
+		return fmt.Errorf("invalid from: %w", err)
+	}
+	if input.To, err = time.Parse(time.RFC3339, c.String("to")); err != nil {
+		return fmt.Errorf("invalid to: %w", err)
+	}
+
+	err = client.GetUDSClient().Call("Archiver.Restore", input, &reply)
+	if err != nil {
+		return
+	}
+	fmt.Println(reply)
+	return
+}
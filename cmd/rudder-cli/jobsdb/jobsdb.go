@@ -0,0 +1,75 @@
+package jobsdb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/client"
+)
+
+type JobsFilter struct {
+	WorkspaceID   string
+	SourceID      string
+	DestinationID string
+	CustomVal     string
+	States        []string
+	MinJobID      int64
+	MaxJobID      int64
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
+
+type TransitionJobsRequest struct {
+	Filter        JobsFilter
+	TargetState   string
+	ErrorCode     string
+	ErrorResponse []byte
+	DryRun        bool
+}
+
+type TransitionJobsInput struct {
+	JobsDB                string
+	TransitionJobsRequest TransitionJobsRequest
+}
+
+func TransitionJobs(c *cli.Context) (err error) {
+	var reply string
+
+	input := TransitionJobsInput{
+		JobsDB: c.String("jobsdb"),
+		TransitionJobsRequest: TransitionJobsRequest{
+			Filter: JobsFilter{
+				WorkspaceID:   c.String("workspace"),
+				SourceID:      c.String("source"),
+				DestinationID: c.String("dest"),
+				CustomVal:     c.String("custom-val"),
+				States:        c.StringSlice("state"),
+				MinJobID:      c.Int64("min-job-id"),
+				MaxJobID:      c.Int64("max-job-id"),
+			},
+			TargetState:   c.String("target-state"),
+			ErrorCode:     c.String("error-code"),
+			ErrorResponse: []byte(c.String("error-response")),
+			DryRun:        c.Bool("dry-run"),
+		},
+	}
+	if v := c.String("created-after"); v != "" {
+		if input.TransitionJobsRequest.Filter.CreatedAfter, err = time.Parse(time.RFC3339, v); err != nil {
+			return fmt.Errorf("invalid created-after: %w", err)
+		}
+	}
+	if v := c.String("created-before"); v != "" {
+		if input.TransitionJobsRequest.Filter.CreatedBefore, err = time.Parse(time.RFC3339, v); err != nil {
+			return fmt.Errorf("invalid created-before: %w", err)
+		}
+	}
+
+	err = client.GetUDSClient().Call("JobsDB.TransitionJobs", input, &reply)
+	if err != nil {
+		return
+	}
+	fmt.Println(reply)
+	return
+}
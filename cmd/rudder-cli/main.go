@@ -8,7 +8,9 @@ import (
 
 	"github.com/urfave/cli/v2"
 
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/archiver"
 	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/client"
+	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/jobsdb"
 	"github.com/rudderlabs/rudder-server/cmd/rudder-cli/warehouse"
 )
 
@@ -80,6 +82,118 @@ func main() {
 				return err
 			},
 		},
+		{
+			Name:  "restore-archive",
+			Usage: "Restore archived gateway jobs of a source from object storage back into the gateway",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "workspace",
+					Usage:    `Specify workspace ID of the archived jobs`,
+					Aliases:  []string{"w"},
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "source",
+					Usage:    `Specify source ID of the archived jobs`,
+					Aliases:  []string{"src"},
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "from",
+					Usage:    `Restore jobs created at or after this time (RFC3339)`,
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "to",
+					Usage:    `Restore jobs created before this time (RFC3339)`,
+					Required: true,
+				},
+				&cli.StringSliceFlag{
+					Name:    "dest",
+					Usage:   `Restrict delivery of restored jobs to this destination ID, can be repeated`,
+					Aliases: []string{"d"},
+				},
+				&cli.StringFlag{
+					Name:  "job-run-id",
+					Usage: `Specify the job run ID for tracking the progress of the restore, a new one is generated if not provided`,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				err := archiver.Restore(c)
+				return err
+			},
+		},
+		{
+			Name:  "transition-jobs",
+			Usage: "Move all non-terminal jobs of a jobsdb matching the provided filters to a target state, e.g. for force-aborting the pending jobs of a destination",
+			Flags: []cli.Flag{
+				&cli.StringFlag{
+					Name:     "jobsdb",
+					Usage:    `Specify the jobsdb of the jobs, e.g. rt or batch_rt`,
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:     "target-state",
+					Usage:    `Specify the state to move the jobs to, one of failed, waiting, succeeded, aborted or filtered`,
+					Required: true,
+				},
+				&cli.StringFlag{
+					Name:  "error-code",
+					Usage: `Specify the error code of the new job statuses`,
+				},
+				&cli.StringFlag{
+					Name:  "error-response",
+					Usage: `Specify the error response (json) of the new job statuses`,
+				},
+				&cli.StringFlag{
+					Name:    "workspace",
+					Usage:   `Only transition jobs of this workspace ID`,
+					Aliases: []string{"w"},
+				},
+				&cli.StringFlag{
+					Name:    "source",
+					Usage:   `Only transition jobs of this source ID`,
+					Aliases: []string{"src"},
+				},
+				&cli.StringFlag{
+					Name:    "dest",
+					Usage:   `Only transition jobs of this destination ID`,
+					Aliases: []string{"d"},
+				},
+				&cli.StringFlag{
+					Name:  "custom-val",
+					Usage: `Only transition jobs with this custom val, i.e. destination type`,
+				},
+				&cli.StringSliceFlag{
+					Name:  "state",
+					Usage: `Only transition jobs in this state (not_picked_yet for jobs without a status), can be repeated. Defaults to not_picked_yet, failed and waiting, while executing and importing jobs are only transitioned if requested explicitly`,
+				},
+				&cli.Int64Flag{
+					Name:  "min-job-id",
+					Usage: `Only transition jobs with an ID greater than or equal to this one`,
+				},
+				&cli.Int64Flag{
+					Name:  "max-job-id",
+					Usage: `Only transition jobs with an ID less than or equal to this one`,
+				},
+				&cli.StringFlag{
+					Name:  "created-after",
+					Usage: `Only transition jobs created at or after this time (RFC3339)`,
+				},
+				&cli.StringFlag{
+					Name:  "created-before",
+					Usage: `Only transition jobs created before this time (RFC3339)`,
+				},
+				&cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: `Only count the jobs that would be transitioned`,
+				},
+			},
+			Action: func(c *cli.Context) error {
+				err := jobsdb.TransitionJobs(c)
+				return err
+			},
+		},
 		{
 			Name:  "logging",
 			Usage: "Set log level for module. It will affect the module and it's children",
@@ -35,6 +35,8 @@ var (
 	errRequestDropped    = errors.New("request dropped")
 	errRequestSuppressed = errors.New("request suppressed")
 	errEventSuppressed   = errors.New("event suppressed")
+	// errInvalidEventsDropped is returned when all events of a request got dropped for violating their schemas
+	errInvalidEventsDropped = errors.New("invalid events dropped")
 )
 
 //go:embed openapi/index.html
@@ -13,9 +13,11 @@ import (
 	"net/url"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/rudderlabs/rudder-server/gateway/throttler"
 	gwtypes "github.com/rudderlabs/rudder-server/gateway/types"
 
 	"github.com/rudderlabs/rudder-go-kit/jsonrs"
@@ -30,6 +32,7 @@ import (
 	"github.com/google/uuid"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -43,6 +46,7 @@ import (
 	"github.com/rudderlabs/rudder-server/app"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/enterprise/suppress-user/model"
+	"github.com/rudderlabs/rudder-server/gateway/internal/schemavalidator"
 	gwstats "github.com/rudderlabs/rudder-server/gateway/internal/stats"
 	"github.com/rudderlabs/rudder-server/gateway/response"
 	webhookModel "github.com/rudderlabs/rudder-server/gateway/webhook/model"
@@ -1008,7 +1012,7 @@ var _ = Describe("Gateway", func() {
 		})
 
 		It("should store messages successfully if rate limit is not reached for workspace", func() {
-			c.mockRateLimiter.EXPECT().CheckLimitReached(gomock.Any(), gomock.Any(), gomock.Any()).Return(false, nil).Times(1)
+			c.mockRateLimiter.EXPECT().CheckLimitReached(gomock.Any(), gomock.Any()).Return(false, throttler.Level(""), nil).Times(1)
 			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
 				_ = f(jobsdb.EmptyStoreSafeTx())
 			}).Return(nil)
@@ -1050,7 +1054,7 @@ var _ = Describe("Gateway", func() {
 
 		It("should reject messages if rate limit is reached for workspace", func() {
 			conf.Set("Gateway.allowReqsWithoutUserIDAndAnonymousID", true)
-			c.mockRateLimiter.EXPECT().CheckLimitReached(gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil).Times(1)
+			c.mockRateLimiter.EXPECT().CheckLimitReached(gomock.Any(), gomock.Any()).Return(true, throttler.LevelWorkspace, nil).Times(1)
 			expectHandlerResponse(
 				gateway.webAliasHandler(),
 				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(`{"data": "valid-json"}`)),
@@ -1078,6 +1082,244 @@ var _ = Describe("Gateway", func() {
 				1*time.Second,
 			).Should(BeTrue())
 		})
+
+		It("should reject messages if rate limit is reached for source or event", func() {
+			conf.Set("Gateway.allowReqsWithoutUserIDAndAnonymousID", true)
+			c.mockRateLimiter.EXPECT().CheckLimitReached(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, request throttler.Request) (bool, throttler.Level, error) {
+				Expect(request.WorkspaceID).To(Equal(rCtxEnabled.WorkspaceID))
+				Expect(request.SourceID).To(Equal(rCtxEnabled.SourceID))
+				Expect(request.EventCounts).To(Equal(map[string]int64{"Order Completed": 2, "identify": 1}))
+				return true, throttler.LevelSource, nil
+			}).Times(1)
+			expectHandlerResponse(
+				gateway.webBatchHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(`{"batch":[{"type":"track","event":"Order Completed"},{"type":"track","event":"Order Completed"},{"type":"identify"}]}`)),
+				http.StatusTooManyRequests,
+				response.TooManyRequestsForSource+"\n",
+				"batch",
+			)
+
+			c.mockRateLimiter.EXPECT().CheckLimitReached(gomock.Any(), gomock.Any()).Return(true, throttler.LevelEvent, nil).Times(1)
+			expectHandlerResponse(
+				gateway.webTrackHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(`{"event":"Order Completed"}`)),
+				http.StatusTooManyRequests,
+				response.TooManyRequestsForEvent+"\n",
+				"track",
+			)
+		})
+	})
+
+	Context("Schema validation", func() {
+		var (
+			err        error
+			gateway    *Handle
+			statsStore *memstats.Store
+		)
+
+		setSchemaValidationMode := func(mode schemavalidator.Mode) {
+			source := backendconfig.SourceT{ID: SourceIDEnabled}
+			source.DgSourceTrackingPlanConfig.TrackingPlan.Id = "tp-1"
+			source.DgSourceTrackingPlanConfig.GatewayValidation = backendconfig.GatewaySchemaValidationT{
+				Mode: string(mode),
+				Schemas: []backendconfig.EventSchemaT{{
+					EventType: "track",
+					EventName: "Order Completed",
+					Schema:    []byte(`{"type":"object","properties":{"properties":{"type":"object","required":["revenue"]}}}`),
+				}},
+			}
+			gateway.schemaValidator.Update([]backendconfig.SourceT{source})
+		}
+
+		BeforeEach(func() {
+			c.initializeAppFeatures()
+			statsStore, err = memstats.New()
+			Expect(err).To(BeNil())
+
+			gateway = &Handle{}
+			conf.Set("Gateway.schemaValidation.enabled", true)
+			err := gateway.Setup(context.Background(), conf, logger.NOP, statsStore, c.mockApp, c.mockBackendConfig, c.mockJobsDB, c.mockErrJobsDB, c.mockRateLimiter, c.mockVersionHandler, rsources.NewNoOpService(), transformer.NewNoOpService(), sourcedebugger.NewNoOpService(), nil)
+			Expect(err).To(BeNil())
+			waitForBackendConfigInit(gateway)
+		})
+
+		AfterEach(func() {
+			err := gateway.Shutdown()
+			Expect(err).To(BeNil())
+		})
+
+		It("should reject requests containing invalid events in reject mode", func() {
+			setSchemaValidationMode(schemavalidator.ModeReject)
+			rr := httptest.NewRecorder()
+			gateway.webBatchHandler().ServeHTTP(rr, authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(
+				`{"batch":[{"userId":"dummyId","type":"track","event":"Order Completed","properties":{"revenue":10}},{"userId":"dummyId","type":"track","event":"Order Completed","properties":{}}]}`,
+			)))
+			Expect(rr.Code).To(Equal(http.StatusBadRequest))
+			Expect(rr.Body.String()).To(HavePrefix(response.InvalidEventSchema + ": "))
+			violations := gjson.Get(strings.TrimPrefix(rr.Body.String(), response.InvalidEventSchema+": "), "#.type").Array()
+			Expect(violations).To(HaveLen(1))
+			Expect(violations[0].String()).To(Equal("required"))
+			Eventually(func() bool {
+				stat := statsStore.Get("gateway.schema_violation_events", stats.Tags{"workspaceId": WorkspaceID, "sourceID": SourceIDEnabled, "mode": "reject"})
+				return stat != nil && stat.LastValue() == float64(1)
+			}).Should(BeTrue())
+		})
+
+		It("should drop invalid events in drop mode", func() {
+			setSchemaValidationMode(schemavalidator.ModeDrop)
+			expectHandlerResponse(
+				gateway.webTrackHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(`{"userId":"dummyId","event":"Order Completed","properties":{}}`)),
+				http.StatusOK,
+				"ok",
+				"track",
+			)
+
+			var storedJobs []*jobsdb.JobT
+			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil)
+			c.mockJobsDB.EXPECT().StoreEachBatchRetryInTx(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs [][]*jobsdb.JobT) (map[uuid.UUID]string, error) {
+					storedJobs = lo.Flatten(jobs)
+					return jobsToEmptyErrors(ctx, tx, jobs)
+				}).Times(1)
+			expectHandlerResponse(
+				gateway.webBatchHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(
+					`{"batch":[{"userId":"dummyId","type":"track","event":"Order Completed","properties":{"revenue":10}},{"userId":"dummyId","type":"track","event":"Order Completed","properties":{}}]}`,
+				)),
+				http.StatusOK,
+				"ok",
+				"batch",
+			)
+			Expect(storedJobs).To(HaveLen(1))
+			Expect(gjson.GetBytes(storedJobs[0].EventPayload, "batch.0.properties.revenue").Int()).To(BeEquivalentTo(10))
+		})
+
+		It("should extract the batch context from the first event in drop mode, even if it is dropped", func() {
+			setSchemaValidationMode(schemavalidator.ModeDrop)
+			var storedJobs []*jobsdb.JobT
+			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil)
+			c.mockJobsDB.EXPECT().StoreEachBatchRetryInTx(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs [][]*jobsdb.JobT) (map[uuid.UUID]string, error) {
+					storedJobs = lo.Flatten(jobs)
+					return jobsToEmptyErrors(ctx, tx, jobs)
+				}).Times(1)
+			expectHandlerResponse(
+				gateway.webBatchHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(
+					`{"batch":[{"userId":"dummyId","type":"track","event":"Order Completed","properties":{},"context":{"sources":{"job_run_id":"job-run-1","task_run_id":"task-run-1"}}},{"userId":"dummyId","type":"track","event":"Order Completed","properties":{"revenue":10}}]}`,
+				)),
+				http.StatusOK,
+				"ok",
+				"batch",
+			)
+			Expect(storedJobs).To(HaveLen(1))
+			Expect(gjson.GetBytes(storedJobs[0].Parameters, "source_job_run_id").String()).To(Equal("job-run-1"))
+			Expect(gjson.GetBytes(storedJobs[0].Parameters, "source_task_run_id").String()).To(Equal("task-run-1"))
+		})
+
+		It("should not count dropped events towards the rate limits", func() {
+			setSchemaValidationMode(schemavalidator.ModeDrop)
+			conf.Set("Gateway.enableRateLimit", true)
+			c.mockRateLimiter.EXPECT().CheckLimitReached(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, request throttler.Request) (bool, throttler.Level, error) {
+				Expect(request.EventCounts).To(Equal(map[string]int64{"Order Completed": 1, "identify": 1}))
+				return true, throttler.LevelSource, nil
+			}).Times(1)
+			expectHandlerResponse(
+				gateway.webBatchHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(
+					`{"batch":[{"userId":"dummyId","type":"track","event":"Order Completed","properties":{"revenue":10}},{"userId":"dummyId","type":"track","event":"Order Completed","properties":{}},{"userId":"dummyId","type":"identify"}]}`,
+				)),
+				http.StatusTooManyRequests,
+				response.TooManyRequestsForSource+"\n",
+				"batch",
+			)
+		})
+
+		It("should tag invalid events with their violations in tag mode", func() {
+			setSchemaValidationMode(schemavalidator.ModeTag)
+			var storedJobs []*jobsdb.JobT
+			c.mockJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Times(1).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil)
+			c.mockJobsDB.EXPECT().StoreEachBatchRetryInTx(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs [][]*jobsdb.JobT) (map[uuid.UUID]string, error) {
+					storedJobs = lo.Flatten(jobs)
+					return jobsToEmptyErrors(ctx, tx, jobs)
+				}).Times(1)
+			expectHandlerResponse(
+				gateway.webBatchHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(
+					`{"batch":[{"userId":"dummyId","type":"track","event":"Order Completed","properties":{"revenue":10}},{"userId":"dummyId","type":"track","event":"Order Completed","properties":{}}]}`,
+				)),
+				http.StatusOK,
+				"ok",
+				"batch",
+			)
+			Expect(storedJobs).To(HaveLen(2))
+			Expect(gjson.GetBytes(storedJobs[0].EventPayload, "batch.0.context.violationErrors").Exists()).To(BeFalse())
+			Expect(gjson.GetBytes(storedJobs[1].EventPayload, "batch.0.context.violationErrors.0.type").String()).To(Equal("required"))
+			Expect(gjson.GetBytes(storedJobs[1].EventPayload, "batch.0.context.violationErrors.0.property").String()).To(Equal("properties"))
+		})
+	})
+
+	Context("Payload validators", func() {
+		var (
+			err        error
+			gateway    *Handle
+			statsStore *memstats.Store
+		)
+
+		BeforeEach(func() {
+			c.initializeAppFeatures()
+			statsStore, err = memstats.New()
+			Expect(err).To(BeNil())
+
+			gateway = &Handle{}
+			conf.Set("Gateway.validators.deniedEventNames", []string{"Denied Event"})
+			err := gateway.Setup(context.Background(), conf, logger.NOP, statsStore, c.mockApp, c.mockBackendConfig, c.mockJobsDB, c.mockErrJobsDB, c.mockRateLimiter, c.mockVersionHandler, rsources.NewNoOpService(), transformer.NewNoOpService(), sourcedebugger.NewNoOpService(), nil,
+				WithPayloadValidators(&noAnonymousIDValidator{}),
+			)
+			Expect(err).To(BeNil())
+			waitForBackendConfigInit(gateway)
+		})
+
+		AfterEach(func() {
+			err := gateway.Shutdown()
+			Expect(err).To(BeNil())
+		})
+
+		It("should reject requests containing events rejected by a declarative validator", func() {
+			expectHandlerResponse(
+				gateway.webBatchHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(
+					`{"batch":[{"userId":"dummyId","type":"track","event":"Order Completed"},{"userId":"dummyId","type":"track","event":"Denied Event"}]}`,
+				)),
+				http.StatusBadRequest,
+				response.NotRudderEvent+"\n",
+				"batch",
+			)
+			stat := statsStore.Get("gateway.validation_failed_events", stats.Tags{"workspaceId": WorkspaceID, "sourceID": SourceIDEnabled, "validator": "deniedEventName"})
+			Expect(stat).NotTo(BeNil())
+			Expect(stat.LastValue()).To(Equal(float64(1)))
+		})
+
+		It("should reject requests containing events rejected by a registered validator", func() {
+			expectHandlerResponse(
+				gateway.webTrackHandler(),
+				authorizedRequest(WriteKeyEnabled, bytes.NewBufferString(`{"userId":"dummyId","anonymousId":"anonId","event":"Order Completed"}`)),
+				http.StatusBadRequest,
+				response.NotRudderEvent+"\n",
+				"track",
+			)
+			stat := statsStore.Get("gateway.validation_failed_events", stats.Tags{"workspaceId": WorkspaceID, "sourceID": SourceIDEnabled, "validator": "noAnonymousID"})
+			Expect(stat).NotTo(BeNil())
+			Expect(stat.LastValue()).To(Equal(float64(1)))
+		})
 	})
 
 	Context("Invalid requests", func() {
@@ -2747,3 +2989,14 @@ func waitForBackendConfigInit(gw *Handle) {
 		2*time.Second,
 	).Should(BeTrue())
 }
+
+// noAnonymousIDValidator rejects events having an anonymousId
+type noAnonymousIDValidator struct{}
+
+func (*noAnonymousIDValidator) ValidatorName() string {
+	return "noAnonymousID"
+}
+
+func (*noAnonymousIDValidator) Validate(payload []byte, _ *stream.MessageProperties) (bool, error) {
+	return !gjson.GetBytes(payload, "anonymousId").Exists(), nil
+}
@@ -37,9 +37,11 @@ import (
 	"github.com/rudderlabs/rudder-server/app"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/gateway/internal/bot"
+	"github.com/rudderlabs/rudder-server/gateway/internal/schemavalidator"
 	gwstats "github.com/rudderlabs/rudder-server/gateway/internal/stats"
 	"github.com/rudderlabs/rudder-server/gateway/response"
 	"github.com/rudderlabs/rudder-server/gateway/throttler"
+	"github.com/rudderlabs/rudder-server/gateway/validator"
 	"github.com/rudderlabs/rudder-server/gateway/webhook"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	sourcedebugger "github.com/rudderlabs/rudder-server/services/debugger/source"
@@ -49,7 +51,7 @@ import (
 )
 
 type messageValidator interface {
-	Validate(payload []byte, message *stream.MessageProperties) (bool, error)
+	ValidateWithName(payload []byte, message *stream.MessageProperties) (string, bool, error)
 }
 
 type Handle struct {
@@ -118,6 +120,7 @@ type Handle struct {
 
 		maxReqSize                           config.ValueLoader[int]
 		enableRateLimit                      config.ValueLoader[bool]
+		enableSchemaValidation               config.ValueLoader[bool]
 		enableSuppressUserFeature            bool
 		diagnosisTickerTime                  time.Duration
 		ReadTimeout                          time.Duration
@@ -138,8 +141,15 @@ type Handle struct {
 
 	// internal batch validator
 	msgValidator messageValidator
+	// validator of the events of the public endpoints, running the declarative and additional validators only
+	eventValidator messageValidator
+	// additional validators for the events of all endpoints
+	payloadValidators []validator.PayloadValidator
 
 	webhookAuthMiddleware *auth.WebhookAuth
+
+	// validates events against the json schemas of their sources' tracking plans
+	schemaValidator *schemavalidator.Validator
 }
 
 // findUserWebRequestWorker finds and returns the worker that works on a particular `userID`.
@@ -214,11 +224,20 @@ func (gw *Handle) userWebRequestWorkerProcess(userWebRequestWorker *userWebReque
 			if err != nil {
 				switch {
 				case errors.Is(err, errRequestDropped):
-					req.done <- response.TooManyRequests
+					req.done <- throttledResponse(jobData.throttledAt)
 					sourceStats[sourceTag].RequestDropped()
 				case errors.Is(err, errRequestSuppressed):
 					req.done <- "" // no error
 					sourceStats[sourceTag].RequestSuppressed()
+				case errors.Is(err, errInvalidEventsDropped):
+					req.done <- "" // no error
+					sourceStats[sourceTag].RequestDropped()
+				case errors.As(err, new(*eventValidationError)):
+					req.done <- response.NotRudderEvent
+					sourceStats[sourceTag].RequestEventsFailed(jobData.numEvents, err.Error())
+				case errors.As(err, new(*schemaViolationError)):
+					req.done <- err.Error()
+					sourceStats[sourceTag].RequestEventsFailed(jobData.numEvents, response.InvalidEventSchema)
 				default:
 					req.done <- err.Error()
 					sourceStats[sourceTag].RequestEventsFailed(jobData.numEvents, err.Error())
@@ -347,16 +366,19 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 		marshalledParams []byte
 
 		// facts about the batch populated as we iterate over events
-		containsAudienceList, suppressed bool
+		containsAudienceList, suppressed, invalidEventsDropped bool
 	)
 
 	isUserSuppressed := gw.memoizedIsUserSuppressed()
+	eventCounts := make(map[string]int64) // number of events per event name, used for rate limiting
 	for idx, v := range eventsBatch {
 		toSet, ok := v.Value().(map[string]interface{})
 		if !ok {
 			err = errors.New((response.NotRudderEvent))
 			return
 		}
+		name := eventName(toSet)
+		eventCounts[name]++
 
 		anonIDFromReq := strings.TrimSpace(sanitize.Unicode(stringify.Any(toSet["anonymousId"])))
 		userIDFromReq := strings.TrimSpace(sanitize.Unicode(stringify.Any(toSet["userId"])))
@@ -370,36 +392,79 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 			return
 		}
 
-		eventContext, ok := misc.MapLookup(toSet, "context").(map[string]interface{})
-		if ok {
-			if idx == 0 {
-				if v, _ := misc.MapLookup(eventContext, "sources", "job_run_id").(string); v != "" {
-					sourcesJobRunID = v
-				}
-				if v, _ := misc.MapLookup(eventContext, "sources", "task_run_id").(string); v != "" {
-					sourcesTaskRunID = v
-				}
+		eventProperties := &stream.MessageProperties{
+			RequestType: req.reqType,
+			WorkspaceID: workspaceId,
+			SourceID:    sourceID,
+		}
+		if validatorName, ok, validationErr := gw.eventValidator.ValidateWithName([]byte(v.Raw), eventProperties); validationErr != nil || !ok {
+			gw.incrementValidationFailures(eventProperties, validatorName)
+			err = &eventValidationError{err: validationErr}
+			return
+		}
 
-				// calculate version
-				firstSDKName, _ := misc.MapLookup(
-					eventContext,
-					"library",
-					"name",
-				).(string)
-				firstSDKVersion, _ := misc.MapLookup(
-					eventContext,
-					"library",
-					"version",
-				).(string)
-
-				if firstSDKVersion != "" && !semverRegexp.Match([]byte(firstSDKVersion)) { // skipcq: CRT-A0007
-					firstSDKVersion = "invalid"
-				}
-				if firstSDKName != "" || firstSDKVersion != "" {
-					jobData.version = firstSDKName + "/" + firstSDKVersion
+		// the batch level context is extracted from the first event, even if it gets dropped for violating its schema
+		if firstEventContext, ok := misc.MapLookup(toSet, "context").(map[string]interface{}); ok && idx == 0 {
+			if v, _ := misc.MapLookup(firstEventContext, "sources", "job_run_id").(string); v != "" {
+				sourcesJobRunID = v
+			}
+			if v, _ := misc.MapLookup(firstEventContext, "sources", "task_run_id").(string); v != "" {
+				sourcesTaskRunID = v
+			}
+
+			// calculate version
+			firstSDKName, _ := misc.MapLookup(
+				firstEventContext,
+				"library",
+				"name",
+			).(string)
+			firstSDKVersion, _ := misc.MapLookup(
+				firstEventContext,
+				"library",
+				"version",
+			).(string)
+
+			if firstSDKVersion != "" && !semverRegexp.Match([]byte(firstSDKVersion)) { // skipcq: CRT-A0007
+				firstSDKVersion = "invalid"
+			}
+			if firstSDKName != "" || firstSDKVersion != "" {
+				jobData.version = firstSDKName + "/" + firstSDKVersion
+			}
+		}
+
+		if gw.conf.enableSchemaValidation.Load() {
+			eventNameFromReq, _ := toSet["event"].(string)
+			mode, violations := gw.schemaValidator.Validate(sourceID, eventTypeFromReq, eventNameFromReq, []byte(v.Raw))
+			if len(violations) > 0 {
+				gw.stats.NewTaggedStat("gateway.schema_violation_events", stats.CountType, stats.Tags{
+					"workspaceId": workspaceId,
+					"sourceID":    sourceID,
+					"mode":        string(mode),
+				}).Increment()
+				switch mode {
+				case schemavalidator.ModeReject:
+					err = &schemaViolationError{violations: violations}
+					return
+				case schemavalidator.ModeDrop:
+					invalidEventsDropped = true
+					// dropped events don't count towards the rate limits
+					if eventCounts[name]--; eventCounts[name] == 0 {
+						delete(eventCounts, name)
+					}
+					continue
+				case schemavalidator.ModeTag:
+					eventContext, ok := toSet["context"].(map[string]interface{})
+					if !ok {
+						eventContext = make(map[string]interface{})
+						toSet["context"] = eventContext
+					}
+					eventContext["violationErrors"] = violations
 				}
 			}
+		}
 
+		eventContext, ok := misc.MapLookup(toSet, "context").(map[string]interface{})
+		if ok {
 			userAgent, _ := misc.MapLookup(
 				eventContext,
 				"userAgent",
@@ -447,12 +512,21 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 
 	if gw.conf.enableRateLimit.Load() && sourcesJobRunID == "" && sourcesTaskRunID == "" {
 		// In case of "batch" requests, if rate-limiter returns true for LimitReached, just drop the event batch and continue.
-		ok, errCheck := gw.rateLimiter.CheckLimitReached(context.TODO(), workspaceId, int64(len(eventsBatch)))
+		ok, level, errCheck := gw.rateLimiter.CheckLimitReached(context.TODO(), throttler.Request{
+			WorkspaceID: workspaceId,
+			SourceID:    sourceID,
+			EventCounts: eventCounts,
+		})
 		if errCheck != nil {
-			gw.stats.NewTaggedStat("gateway.rate_limiter_error", stats.CountType, stats.Tags{"workspaceId": workspaceId}).Increment()
-			gw.logger.Errorf("Rate limiter error: %v Allowing the request", errCheck)
+			gw.stats.NewTaggedStat("gateway.rate_limiter_error", stats.CountType, stats.Tags{"workspaceId": workspaceId, "level": string(level)}).Increment()
+			if ok {
+				gw.logger.Errorf("Rate limiter error: %v Dropping the request", errCheck)
+			} else {
+				gw.logger.Errorf("Rate limiter error: %v Allowing the request", errCheck)
+			}
 		}
 		if ok {
+			jobData.throttledAt = level
 			return jobData, errRequestDropped
 		}
 	}
@@ -462,6 +536,11 @@ func (gw *Handle) getJobDataFromRequest(req *webRequestT) (jobData *jobFromReq,
 		return
 	}
 
+	if len(out) == 0 && invalidEventsDropped {
+		err = errInvalidEventsDropped
+		return
+	}
+
 	if len(body) > gw.conf.maxReqSize.Load() && !containsAudienceList {
 		err = errors.New((response.RequestBodyTooLarge))
 		return
@@ -551,6 +630,67 @@ func buildUserID(userIDHeader, anonIDFromReq, userIDFromReq string) string {
 	return userIDHeader + delimiter + anonIDFromReq + delimiter + userIDFromReq
 }
 
+// eventName returns the name of an event for rate limiting purposes, i.e. the event property of track events or the event type otherwise
+func eventName(event map[string]interface{}) string {
+	if name, _ := event["event"].(string); name != "" {
+		return name
+	}
+	eventType, _ := event["type"].(string)
+	return eventType
+}
+
+// schemaViolationError is returned when a request gets rejected because one of its events violates its schema
+type schemaViolationError struct {
+	violations []schemavalidator.Violation
+}
+
+// Error returns the response message of the request, containing the violations of the event
+func (e *schemaViolationError) Error() string {
+	violations, err := jsonrs.Marshal(e.violations)
+	if err != nil {
+		return response.InvalidEventSchema
+	}
+	return response.WithDetails(response.InvalidEventSchema, string(violations))
+}
+
+// eventValidationError is returned when a request gets rejected because one of its events failed a payload validator
+type eventValidationError struct {
+	err error
+}
+
+// Error returns the reason of the validation failure
+func (e *eventValidationError) Error() string {
+	if e.err == nil {
+		return "validations failed"
+	}
+	return e.err.Error()
+}
+
+func (e *eventValidationError) Unwrap() error {
+	return e.err
+}
+
+// incrementValidationFailures reports an event rejected by the payload validator with the given name
+func (gw *Handle) incrementValidationFailures(properties *stream.MessageProperties, validatorName string) {
+	gw.stats.NewTaggedStat("gateway.validation_failed_events", stats.CountType, stats.Tags{
+		"workspaceId": properties.WorkspaceID,
+		"sourceID":    properties.SourceID,
+		"validator":   validatorName,
+	}).Increment()
+}
+
+// throttledResponse returns the response for a request dropped due to a rate limit being reached at the given level
+func throttledResponse(level throttler.Level) string {
+	switch level {
+	case throttler.LevelSource:
+		return response.TooManyRequestsForSource
+	case throttler.LevelEvent:
+		return response.TooManyRequestsForEvent
+	default:
+		return response.TooManyRequests
+	}
+}
+
 // memoizedIsUserSuppressed is a memoized version of isUserSuppressed
 func (gw *Handle) memoizedIsUserSuppressed() func(workspaceID, userID, sourceID string) bool {
 	cache := map[string]bool{}
@@ -889,7 +1029,7 @@ func (gw *Handle) extractJobsFromInternalBatchPayload(reqType string, body []byt
 		}
 
 		if internalBatchValidatorEnabled {
-			ok, err := gw.msgValidator.Validate(msg.Payload, &msg.Properties)
+			validatorName, ok, err := gw.msgValidator.ValidateWithName(msg.Payload, &msg.Properties)
 			if err != nil || !ok {
 				errMsg := "validations failed"
 				if err != nil {
@@ -898,9 +1038,10 @@ func (gw *Handle) extractJobsFromInternalBatchPayload(reqType string, body []byt
 					err = errors.New(errMsg)
 				}
 				loggerFields := msg.Properties.LoggerFields()
-				loggerFields = append(loggerFields, obskit.Error(err))
+				loggerFields = append(loggerFields, obskit.Error(err), logger.NewStringField("validator", validatorName))
 				gw.logger.Errorn("invalid message in request",
 					loggerFields...)
+				gw.incrementValidationFailures(&msg.Properties, validatorName)
 				stat.RequestEventsFailed(1, errMsg)
 				stat.Report(gw.stats)
 				return nil, errors.New(response.NotRudderEvent)
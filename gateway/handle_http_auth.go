@@ -297,7 +297,7 @@ func (gw *Handle) handleFailureStats(errorMessage, reqType string, arctx *gwtype
 				ReqType:  reqType,
 				Source:   "noSourceIDInHeader",
 			}
-		case response.SourceDisabled, response.NoDestinationIDInHeader, response.InvalidDestinationID, response.DestinationDisabled:
+		case response.SourceDisabled, response.NoDestinationIDInHeader, response.InvalidDestinationID, response.DestinationDisabled, response.InvalidWebhookSignature:
 			stat = gwstats.SourceStat{
 				SourceID:      arctx.SourceID,
 				WriteKey:      arctx.WriteKey,
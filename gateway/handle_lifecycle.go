@@ -33,6 +33,7 @@ import (
 
 	"github.com/rudderlabs/rudder-server/app"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/gateway/internal/schemavalidator"
 	"github.com/rudderlabs/rudder-server/gateway/throttler"
 	"github.com/rudderlabs/rudder-server/gateway/webhook"
 	"github.com/rudderlabs/rudder-server/jobsdb"
@@ -99,6 +100,8 @@ func (gw *Handle) Setup(
 	gw.conf.maxReqSize = config.GetReloadableIntVar(4000, 1024, "Gateway.maxReqSizeInKB")
 	// Enable rate limit on incoming events. false by default
 	gw.conf.enableRateLimit = config.GetReloadableBoolVar(false, "Gateway.enableRateLimit")
+	// Enable validation of events against the json schemas of their sources' tracking plans. false by default
+	gw.conf.enableSchemaValidation = config.GetReloadableBoolVar(false, "Gateway.schemaValidation.enabled")
 	// Enable suppress user feature. false by default
 	gw.conf.enableSuppressUserFeature = config.GetBoolVar(true, "Gateway.enableSuppressUserFeature")
 	// Time period for diagnosis ticker
@@ -159,7 +162,13 @@ func (gw *Handle) Setup(
 	}
 	gw.streamMsgValidator = streamMsgValidator
 
-	gw.msgValidator = validator.NewValidateMediator(gw.logger, stream.NewMessagePropertiesValidator())
+	msgValidator := validator.NewValidateMediator(gw.logger, stream.NewMessagePropertiesValidator(), validator.WithConfig(config))
+	msgValidator.Register(gw.payloadValidators...)
+	gw.msgValidator = msgValidator
+	eventValidator := validator.NewEventMediator(gw.logger, validator.WithConfig(config))
+	eventValidator.Register(gw.payloadValidators...)
+	gw.eventValidator = eventValidator
+	gw.schemaValidator = schemavalidator.New(gw.logger)
 
 	gw.webhookAuthMiddleware = auth.NewWebhookAuth(
 		func(w http.ResponseWriter, r *http.Request, errorMessage string, authCtx *gwtypes.AuthRequestContext) {
@@ -172,7 +181,9 @@ func (gw *Handle) Setup(
 				return nil, auth.ErrSourceNotFound
 			}
 			return authCtx, nil
-		})
+		},
+		gw.conf.maxReqSize,
+	)
 
 	ctx, cancel := context.WithCancel(context.Background())
 	g, ctx := errgroup.WithContext(ctx)
@@ -213,6 +224,13 @@ func WithInternalHttpHandlers(handlers map[string]http.Handler) OptFunc {
 	}
 }
 
+// WithPayloadValidators registers additional validators for the events of all endpoints
+func WithPayloadValidators(validators ...validator.PayloadValidator) OptFunc {
+	return func(gw *Handle) {
+		gw.payloadValidators = append(gw.payloadValidators, validators...)
+	}
+}
+
 func WithNow(now func() time.Time) OptFunc {
 	return func(gw *Handle) {
 		gw.now = now
@@ -251,12 +269,14 @@ func (gw *Handle) backendConfigSubscriber(ctx context.Context) {
 		var (
 			writeKeysSourceMap = map[string]backendconfig.SourceT{}
 			sourceIDSourceMap  = map[string]backendconfig.SourceT{}
+			sources            []backendconfig.SourceT
 		)
 		configData := data.Data.(map[string]backendconfig.ConfigT)
 		for _, wsConfig := range configData {
 			for _, source := range wsConfig.Sources {
 				writeKeysSourceMap[source.WriteKey] = source
 				sourceIDSourceMap[source.ID] = source
+				sources = append(sources, source)
 				if !gw.conf.webhookV2HandlerEnabled {
 					if source.Enabled && source.SourceDefinition.Category == "webhook" {
 						gw.webhook.Register(source.SourceDefinition.Name)
@@ -264,6 +284,7 @@ func (gw *Handle) backendConfigSubscriber(ctx context.Context) {
 				}
 			}
 		}
+		gw.schemaValidator.Update(sources)
 		gw.configSubscriberLock.Lock()
 		gw.writeKeysSourceMap = writeKeysSourceMap
 		gw.sourceIDSourceMap = sourceIDSourceMap
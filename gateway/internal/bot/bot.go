@@ -1,19 +1,8 @@
 package bot
 
-import "strings"
-
-var botKeyWords = []string{
-	"bot",
-	"crawler",
-	"spider",
-}
+import "github.com/rudderlabs/rudder-server/internal/enricher/botdetector"
 
+// IsBotUserAgent returns whether the user agent belongs to a bot, according to the embedded bot detection rules
 func IsBotUserAgent(userAgent string) bool {
-	lowerUserAgent := strings.ToLower(userAgent)
-	for _, keyword := range botKeyWords {
-		if strings.Contains(lowerUserAgent, keyword) {
-			return true
-		}
-	}
-	return false
+	return botdetector.Default().IsBotUserAgent(userAgent)
 }
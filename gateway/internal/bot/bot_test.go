@@ -22,6 +22,16 @@ func TestDetectBot(t *testing.T) {
 			userAgent: "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)",
 			expected:  true,
 		},
+		{
+			name:      "Headless browser",
+			userAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36",
+			expected:  true,
+		},
+		{
+			name:      "App having bot in its name",
+			userAgent: "Botify/3.2 (iPhone; iOS 17.0; Scale/3.00)",
+			expected:  false,
+		},
 		{
 			name:      "Not a bot",
 			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.114 Safari/537.36",
@@ -0,0 +1,178 @@
+// Package schemavalidator validates events against the json schemas of their source's tracking plan at the gateway,
+// before they get persisted.
+package schemavalidator
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+)
+
+// Mode is the action to take for events violating their schema
+type Mode string
+
+const (
+	// ModeNone disables validation
+	ModeNone Mode = ""
+	// ModeReject rejects the whole request containing an invalid event
+	ModeReject Mode = "reject"
+	// ModeDrop drops invalid events, accepting the valid ones of the request
+	ModeDrop Mode = "drop"
+	// ModeTag accepts invalid events, adding their violations in context.violationErrors
+	ModeTag Mode = "tag"
+)
+
+// Violation is a violation of an event's schema, having the same shape as the tracking plan validation errors reported by the processor
+type Violation struct {
+	Type     string            `json:"type"`
+	Message  string            `json:"message"`
+	Meta     map[string]string `json:"meta"`
+	Property string            `json:"property"`
+}
+
+// eventKey identifies the schema of events having a type and name
+type eventKey struct {
+	eventType string
+	eventName string
+}
+
+// sourceSchemas are the compiled schemas of a source
+type sourceSchemas struct {
+	revision string
+	mode     Mode
+	schemas  map[eventKey]*gojsonschema.Schema
+}
+
+// Validator validates events against the schemas of their sources
+type Validator struct {
+	log logger.Logger
+
+	sourcesMu sync.RWMutex
+	sources   map[string]*sourceSchemas // map key is the source id
+}
+
+// New creates a validator without any schemas
+func New(log logger.Logger) *Validator {
+	return &Validator{
+		log:     log.Child("schemavalidator"),
+		sources: make(map[string]*sourceSchemas),
+	}
+}
+
+// Update replaces the schemas of all sources with the ones of the provided sources.
+// Schemas are compiled once per tracking plan revision: the compiled schemas of sources whose revision didn't change are reused.
+func (v *Validator) Update(sources []backendconfig.SourceT) {
+	v.sourcesMu.RLock()
+	previous := v.sources
+	v.sourcesMu.RUnlock()
+
+	updated := make(map[string]*sourceSchemas)
+	for _, source := range sources {
+		tpConfig := source.DgSourceTrackingPlanConfig
+		mode := Mode(tpConfig.GatewayValidation.Mode)
+		if mode == ModeNone || len(tpConfig.GatewayValidation.Schemas) == 0 {
+			continue
+		}
+		revision := revisionOf(tpConfig)
+		if ss, ok := previous[source.ID]; ok && ss.revision == revision && ss.mode == mode {
+			updated[source.ID] = ss
+			continue
+		}
+		switch mode {
+		case ModeReject, ModeDrop, ModeTag:
+		default:
+			v.log.Errorn("unsupported schema validation mode, skipping validation of source",
+				obskit.SourceID(source.ID),
+				logger.NewStringField("mode", string(mode)),
+			)
+			continue
+		}
+		ss := &sourceSchemas{
+			revision: revision,
+			mode:     mode,
+			schemas:  make(map[eventKey]*gojsonschema.Schema, len(tpConfig.GatewayValidation.Schemas)),
+		}
+		for _, eventSchema := range tpConfig.GatewayValidation.Schemas {
+			schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(eventSchema.Schema))
+			if err != nil {
+				v.log.Errorn("compiling event schema, events will not be validated against it",
+					obskit.SourceID(source.ID),
+					logger.NewStringField("eventType", eventSchema.EventType),
+					logger.NewStringField("eventName", eventSchema.EventName),
+					obskit.Error(err),
+				)
+				continue
+			}
+			ss.schemas[eventKey{eventType: eventSchema.EventType, eventName: eventSchema.EventName}] = schema
+		}
+		updated[source.ID] = ss
+	}
+
+	v.sourcesMu.Lock()
+	v.sources = updated
+	v.sourcesMu.Unlock()
+}
+
+// revisionOf returns the revision of a source's tracking plan config.
+// It includes a hash of the schemas, so that changes of their contents are applied even if the versions of the config stay the same.
+func revisionOf(tpConfig backendconfig.DgSourceTrackingPlanConfigT) string {
+	h := sha256.New()
+	for _, eventSchema := range tpConfig.GatewayValidation.Schemas {
+		for _, part := range [][]byte{[]byte(eventSchema.EventType), []byte(eventSchema.EventName), eventSchema.Schema} {
+			_ = binary.Write(h, binary.BigEndian, uint64(len(part))) // length-prefixed, so that parts cannot be shifted from one to the other
+			_, _ = h.Write(part)
+		}
+	}
+	return tpConfig.TrackingPlan.Id + ":" + strconv.Itoa(tpConfig.TrackingPlan.Version) + ":" + strconv.Itoa(tpConfig.SourceConfigVersion) + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Validate validates an event against the schema of its type and name, falling back to the schema of its type.
+// It returns the validation mode of the source along with the violations of the event, if any.
+// Events without a schema are considered valid.
+func (v *Validator) Validate(sourceID, eventType, eventName string, event []byte) (Mode, []Violation) {
+	v.sourcesMu.RLock()
+	ss, ok := v.sources[sourceID]
+	v.sourcesMu.RUnlock()
+	if !ok {
+		return ModeNone, nil
+	}
+	schema, ok := ss.schemas[eventKey{eventType: eventType, eventName: eventName}]
+	if !ok {
+		if schema, ok = ss.schemas[eventKey{eventType: eventType}]; !ok {
+			return ss.mode, nil
+		}
+	}
+	result, err := schema.Validate(gojsonschema.NewBytesLoader(event))
+	if err != nil {
+		return ss.mode, []Violation{{Type: "Invalid-JSON", Message: fmt.Sprintf("validating event: %v", err)}}
+	}
+	if result.Valid() {
+		return ss.mode, nil
+	}
+	violations := make([]Violation, 0, len(result.Errors()))
+	for _, resultErr := range result.Errors() {
+		meta := map[string]string{"schemaPath": resultErr.Context().String()}
+		for k, v := range resultErr.Details() {
+			if k == "context" || k == "field" {
+				continue
+			}
+			meta[k] = fmt.Sprint(v)
+		}
+		violations = append(violations, Violation{
+			Type:     resultErr.Type(),
+			Message:  resultErr.Description(),
+			Meta:     meta,
+			Property: resultErr.Field(),
+		})
+	}
+	return ss.mode, violations
+}
@@ -0,0 +1,102 @@
+package schemavalidator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+)
+
+func newSource(id, mode string, version int, schemas ...backendconfig.EventSchemaT) backendconfig.SourceT {
+	source := backendconfig.SourceT{ID: id}
+	source.DgSourceTrackingPlanConfig.TrackingPlan.Id = "tp-" + id
+	source.DgSourceTrackingPlanConfig.TrackingPlan.Version = version
+	source.DgSourceTrackingPlanConfig.GatewayValidation = backendconfig.GatewaySchemaValidationT{Mode: mode, Schemas: schemas}
+	return source
+}
+
+func TestValidator(t *testing.T) {
+	orderCompleted := backendconfig.EventSchemaT{
+		EventType: "track",
+		EventName: "Order Completed",
+		Schema:    []byte(`{"type":"object","properties":{"properties":{"type":"object","properties":{"revenue":{"type":"number"}},"required":["revenue"]}},"required":["properties"]}`),
+	}
+	identify := backendconfig.EventSchemaT{
+		EventType: "identify",
+		Schema:    []byte(`{"type":"object","properties":{"traits":{"type":"object","required":["email"]}},"required":["traits"]}`),
+	}
+
+	v := New(logger.NOP)
+	v.Update([]backendconfig.SourceT{
+		newSource("source-1", "reject", 1, orderCompleted, identify),
+		newSource("source-2", "", 1, orderCompleted),
+		newSource("source-3", "unknown", 1, orderCompleted),
+	})
+
+	t.Run("valid events", func(t *testing.T) {
+		mode, violations := v.Validate("source-1", "track", "Order Completed", []byte(`{"type":"track","event":"Order Completed","properties":{"revenue":10}}`))
+		require.Equal(t, ModeReject, mode)
+		require.Empty(t, violations)
+
+		mode, violations = v.Validate("source-1", "track", "Product Viewed", []byte(`{"type":"track","event":"Product Viewed"}`))
+		require.Equal(t, ModeReject, mode)
+		require.Empty(t, violations, "events without a schema should be valid")
+	})
+
+	t.Run("invalid events", func(t *testing.T) {
+		mode, violations := v.Validate("source-1", "track", "Order Completed", []byte(`{"type":"track","event":"Order Completed","properties":{"revenue":"10"}}`))
+		require.Equal(t, ModeReject, mode)
+		require.Len(t, violations, 1)
+		require.Equal(t, "invalid_type", violations[0].Type)
+		require.Equal(t, "properties.revenue", violations[0].Property)
+		require.Equal(t, "number", violations[0].Meta["expected"])
+
+		_, violations = v.Validate("source-1", "identify", "", []byte(`{"type":"identify","traits":{}}`))
+		require.Len(t, violations, 1, "schemas without an event name should apply to all events of their type")
+		require.Equal(t, "required", violations[0].Type)
+	})
+
+	t.Run("sources without validation", func(t *testing.T) {
+		for _, sourceID := range []string{"source-2", "source-3", "source-4"} {
+			mode, violations := v.Validate(sourceID, "track", "Order Completed", []byte(`{}`))
+			require.Equal(t, ModeNone, mode)
+			require.Empty(t, violations)
+		}
+	})
+
+	t.Run("schemas are compiled once per revision", func(t *testing.T) {
+		compiled := v.sources["source-1"]
+		v.Update([]backendconfig.SourceT{newSource("source-1", "reject", 1, orderCompleted, identify)})
+		require.Same(t, compiled, v.sources["source-1"])
+
+		v.Update([]backendconfig.SourceT{newSource("source-1", "tag", 1, orderCompleted, identify)})
+		require.NotSame(t, compiled, v.sources["source-1"], "changing the mode should be applied")
+		mode, _ := v.Validate("source-1", "track", "Order Completed", []byte(`{}`))
+		require.Equal(t, ModeTag, mode)
+
+		compiled = v.sources["source-1"]
+		v.Update([]backendconfig.SourceT{newSource("source-1", "tag", 2, identify)})
+		require.NotSame(t, compiled, v.sources["source-1"])
+		_, violations := v.Validate("source-1", "track", "Order Completed", []byte(`{}`))
+		require.Empty(t, violations, "schemas removed in a new revision should not be applied")
+
+		compiled = v.sources["source-1"]
+		changed := identify
+		changed.Schema = []byte(`{"type":"object","properties":{"traits":{"type":"object","required":["phone"]}},"required":["traits"]}`)
+		v.Update([]backendconfig.SourceT{newSource("source-1", "tag", 2, changed)})
+		require.NotSame(t, compiled, v.sources["source-1"], "changing the contents of a schema should be applied, even if the version stays the same")
+		_, violations = v.Validate("source-1", "identify", "", []byte(`{"type":"identify","traits":{"email":"a@b.c"}}`))
+		require.Len(t, violations, 1)
+	})
+
+	t.Run("invalid schemas are skipped", func(t *testing.T) {
+		v.Update([]backendconfig.SourceT{newSource("source-1", "drop", 1, backendconfig.EventSchemaT{EventType: "track", Schema: []byte(`{"type":"unknown"}`)}, identify)})
+		_, violations := v.Validate("source-1", "track", "Order Completed", []byte(`{}`))
+		require.Empty(t, violations)
+		mode, violations := v.Validate("source-1", "identify", "", []byte(`{}`))
+		require.Equal(t, ModeDrop, mode)
+		require.Len(t, violations, 1)
+	})
+}
@@ -3,6 +3,7 @@ package response
 import (
 	"fmt"
 	"net/http"
+	"strings"
 )
 
 const (
@@ -14,6 +15,10 @@ const (
 	InvalidRequestMethod = "invalid http request method"
 	// TooManyRequests - too many requests
 	TooManyRequests = "max requests limit reached"
+	// TooManyRequestsForSource - too many requests for the source
+	TooManyRequestsForSource = "max requests limit reached for source"
+	// TooManyRequestsForEvent - too many requests for the event
+	TooManyRequestsForEvent = "max requests limit reached for event"
 	// NoWriteKeyInBasicAuth - Failed to read writeKey from header
 	NoWriteKeyInBasicAuth = "failed to read writekey from header"
 	// NoWriteKeyInQueryParams - Failed to read writeKey from Query Params
@@ -78,6 +83,13 @@ const (
 	NoDestinationIDInHeader = "failed to read destination id from header"
 	// ErrAuthenticatingWebhookRequest = "error occurred while authenticating the webhook request"
 	ErrAuthenticatingWebhookRequest = "error occurred while authenticating the webhook request"
+	// InvalidWebhookSignature - the signature of the webhook request is missing, invalid or expired
+	InvalidWebhookSignature = "invalid webhook signature"
+	// InvalidEventSchema - event doesn't conform to the json schema of its source's tracking plan
+	InvalidEventSchema = "event violates its schema"
+
+	// detailsSeparator separates a status message from its details
+	detailsSeparator = ": "
 
 	transPixelResponse = "\x47\x49\x46\x38\x39\x61\x01\x00\x01\x00\x80\x00\x00\x00\x00\x00\x00\x00\x00\x21\xF9\x04" +
 		"\x01\x00\x00\x00\x00\x2C\x00\x00\x00\x00\x01\x00\x01\x00\x00\x02\x02\x44\x01\x00\x3B"
@@ -86,25 +98,28 @@ const (
 )
 
 var statusMap = map[string]status{
-	Ok:                      {message: Ok, code: http.StatusOK},
-	RequestBodyNil:          {message: RequestBodyNil, code: http.StatusBadRequest},
-	InvalidRequestMethod:    {message: InvalidRequestMethod, code: http.StatusBadRequest},
-	TooManyRequests:         {message: TooManyRequests, code: http.StatusTooManyRequests},
-	NoWriteKeyInBasicAuth:   {message: NoWriteKeyInBasicAuth, code: http.StatusUnauthorized},
-	NoWriteKeyInQueryParams: {message: NoWriteKeyInQueryParams, code: http.StatusUnauthorized},
-	RequestBodyReadFailed:   {message: RequestBodyReadFailed, code: http.StatusInternalServerError},
-	RequestBodyTooLarge:     {message: RequestBodyTooLarge, code: http.StatusRequestEntityTooLarge},
-	InvalidWriteKey:         {message: InvalidWriteKey, code: http.StatusUnauthorized},
-	SourceDisabled:          {message: SourceDisabled, code: http.StatusNotFound},
-	InvalidJSON:             {message: InvalidJSON, code: http.StatusBadRequest},
-	EmptyBatchPayload:       {message: EmptyBatchPayload, code: http.StatusBadRequest},
-	NoSourceIdInHeader:      {message: NoSourceIdInHeader, code: http.StatusUnauthorized},
-	InvalidSourceID:         {message: InvalidSourceID, code: http.StatusUnauthorized},
-	InvalidReplaySource:     {message: InvalidReplaySource, code: http.StatusUnauthorized},
-	DestinationDisabled:     {message: DestinationDisabled, code: http.StatusNotFound},
-	InvalidDestinationID:    {message: InvalidDestinationID, code: http.StatusBadRequest},
-	NoDestinationIDInHeader: {message: NoDestinationIDInHeader, code: http.StatusBadRequest},
-	InvalidStreamMessage:    {message: InvalidStreamMessage, code: http.StatusBadRequest},
+	Ok:                       {message: Ok, code: http.StatusOK},
+	RequestBodyNil:           {message: RequestBodyNil, code: http.StatusBadRequest},
+	InvalidRequestMethod:     {message: InvalidRequestMethod, code: http.StatusBadRequest},
+	TooManyRequests:          {message: TooManyRequests, code: http.StatusTooManyRequests},
+	TooManyRequestsForSource: {message: TooManyRequestsForSource, code: http.StatusTooManyRequests},
+	TooManyRequestsForEvent:  {message: TooManyRequestsForEvent, code: http.StatusTooManyRequests},
+	NoWriteKeyInBasicAuth:    {message: NoWriteKeyInBasicAuth, code: http.StatusUnauthorized},
+	NoWriteKeyInQueryParams:  {message: NoWriteKeyInQueryParams, code: http.StatusUnauthorized},
+	RequestBodyReadFailed:    {message: RequestBodyReadFailed, code: http.StatusInternalServerError},
+	RequestBodyTooLarge:      {message: RequestBodyTooLarge, code: http.StatusRequestEntityTooLarge},
+	InvalidWriteKey:          {message: InvalidWriteKey, code: http.StatusUnauthorized},
+	SourceDisabled:           {message: SourceDisabled, code: http.StatusNotFound},
+	InvalidJSON:              {message: InvalidJSON, code: http.StatusBadRequest},
+	EmptyBatchPayload:        {message: EmptyBatchPayload, code: http.StatusBadRequest},
+	NoSourceIdInHeader:       {message: NoSourceIdInHeader, code: http.StatusUnauthorized},
+	InvalidSourceID:          {message: InvalidSourceID, code: http.StatusUnauthorized},
+	InvalidReplaySource:      {message: InvalidReplaySource, code: http.StatusUnauthorized},
+	DestinationDisabled:      {message: DestinationDisabled, code: http.StatusNotFound},
+	InvalidDestinationID:     {message: InvalidDestinationID, code: http.StatusBadRequest},
+	NoDestinationIDInHeader:  {message: NoDestinationIDInHeader, code: http.StatusBadRequest},
+	InvalidStreamMessage:     {message: InvalidStreamMessage, code: http.StatusBadRequest},
+	InvalidEventSchema:       {message: InvalidEventSchema, code: http.StatusBadRequest},
 
 	// webhook specific status
 	InvalidWebhookSource:                           {message: InvalidWebhookSource, code: http.StatusNotFound},
@@ -123,6 +138,7 @@ var statusMap = map[string]status{
 	GatewayTimeout:                                 {message: GatewayTimeout, code: http.StatusGatewayTimeout},
 	ServiceUnavailable:                             {message: ServiceUnavailable, code: http.StatusServiceUnavailable},
 	ErrAuthenticatingWebhookRequest:                {message: ErrAuthenticatingWebhookRequest, code: http.StatusInternalServerError},
+	InvalidWebhookSignature:                        {message: InvalidWebhookSignature, code: http.StatusUnauthorized},
 }
 
 // status holds the gateway response status message and code
@@ -138,12 +154,31 @@ func GetStatus(key string) string {
 	return key
 }
 
+// WithDetails appends details to a status message, e.g. the reasons of a failure.
+// The resulting message keeps the status code of the original one, whereas its details are included in the response body.
+func WithDetails(key, details string) string {
+	return key + detailsSeparator + details
+}
+
+// statusOf returns the status of a message, taking into account messages having details
+func statusOf(key string) (status, bool) {
+	if status, ok := statusMap[key]; ok {
+		return status, true
+	}
+	if prefix, _, found := strings.Cut(key, detailsSeparator); found {
+		if status, ok := statusMap[prefix]; ok {
+			return status, true
+		}
+	}
+	return status{}, false
+}
+
 func GetPixelResponse() string {
 	return transPixelResponse
 }
 
 func GetErrorStatusCode(key string) int {
-	if status, ok := statusMap[key]; ok {
+	if status, ok := statusOf(key); ok {
 		return status.code
 	}
 	return http.StatusInternalServerError
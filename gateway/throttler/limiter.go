@@ -0,0 +1,199 @@
+package throttler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/rudderlabs/rudder-go-kit/cachettl"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// limit is the rate limit of a single key that a request is checked against
+type limit struct {
+	key    string
+	cost   int64
+	rate   int64
+	window int64 // in seconds
+}
+
+func (l limit) validate() error {
+	if l.cost < 1 {
+		return fmt.Errorf("cost must be greater than 0")
+	}
+	if l.rate < 1 {
+		return fmt.Errorf("rate must be greater than 0")
+	}
+	if l.window < 1 {
+		return fmt.Errorf("window must be greater than 0")
+	}
+	if l.key == "" {
+		return fmt.Errorf("key must not be empty")
+	}
+	return nil
+}
+
+// multiLimiter checks a request against the limits of several keys at once
+type multiLimiter interface {
+	// allowAll takes tokens from all limits only if none of them is exceeded.
+	// It returns the index of the first exceeded limit, or -1 if the request is allowed.
+	// On errors, the index of the limit that failed to be checked is returned, if any.
+	allowAll(ctx context.Context, limits []limit) (int, error)
+}
+
+// limitsTimer returns a function recording the time taken for checking the limits of a request, once for every limit.
+// The measurement and its tags are the same as the ones of rudder-go-kit's throttling limiters.
+func limitsTimer(stat stats.Stats, algo string, limits []limit) func() {
+	start := time.Now()
+	return func() {
+		for _, l := range limits {
+			stat.NewTaggedStat("throttling", stats.TimerType, stats.Tags{
+				"key":    l.key,
+				"algo":   algo,
+				"rate":   strconv.FormatInt(l.rate, 10),
+				"window": strconv.FormatInt(l.window, 10),
+			}).Since(start)
+		}
+	}
+}
+
+// memoryGCRA is an in-memory GCRA limiter, with a burst equal to the rate, which checks all limits of a request atomically
+type memoryGCRA struct {
+	mu    sync.Mutex
+	tats  *cachettl.Cache[string, time.Time] // theoretical arrival time per key, expiring once in the past
+	now   func() time.Time
+	stats stats.Stats
+}
+
+func newMemoryGCRA(stat stats.Stats) *memoryGCRA {
+	return &memoryGCRA{
+		tats:  cachettl.New[string, time.Time](cachettl.WithNoRefreshTTL),
+		now:   time.Now,
+		stats: stat,
+	}
+}
+
+func (g *memoryGCRA) allowAll(_ context.Context, limits []limit) (int, error) {
+	for _, l := range limits {
+		if err := l.validate(); err != nil {
+			return -1, err
+		}
+	}
+	defer limitsTimer(g.stats, throttlingAlgoTypeGCRA, limits)()
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.now()
+	newTATs := make([]time.Time, len(limits))
+	for i, l := range limits {
+		emissionInterval := time.Duration(l.window) * time.Second / time.Duration(l.rate)
+		tat := g.tats.Get(l.key)
+		if tat.Before(now) {
+			tat = now
+		}
+		newTATs[i] = tat.Add(emissionInterval * time.Duration(l.cost))
+		if allowAt := newTATs[i].Add(-emissionInterval * time.Duration(l.rate)); now.Before(allowAt) {
+			return i, nil
+		}
+	}
+	for i, l := range limits {
+		g.tats.Put(l.key, newTATs[i], newTATs[i].Sub(now))
+	}
+	return -1, nil
+}
+
+// redisGCRA is a GCRA limiter backed by redis, with a burst equal to the rate, which checks all limits of a request atomically using a lua script.
+// Its keys are compatible with the ones of the redis-gcra algorithm of rudder-go-kit's throttling package.
+type redisGCRA struct {
+	client *redis.Client
+	stats  stats.Stats
+}
+
+var gcraMultiKeyScript = redis.NewScript(`
+local jan_1_2017 = 1483228800 * 1000 * 1000 -- in microseconds precision
+local current_time = redis.call("TIME")
+local microseconds = current_time[2]
+while string.len(microseconds) < 6 do
+    microseconds = "0" .. microseconds
+end
+current_time = tonumber(current_time[1] .. microseconds) - jan_1_2017
+
+local new_tats = {}
+for i, key in ipairs(KEYS) do
+    local rate = tonumber(ARGV[(i - 1) * 3 + 1])
+    local period = tonumber(ARGV[(i - 1) * 3 + 2]) * 1000 * 1000 -- converting to microseconds
+    local cost = tonumber(ARGV[(i - 1) * 3 + 3])
+    local emission_interval = period / rate
+
+    local tat = redis.call("GET", key)
+    if not tat then
+        tat = current_time
+    else
+        tat = tonumber(tat)
+    end
+    tat = math.max(tat, current_time)
+
+    local new_tat = tat + emission_interval * cost
+    if current_time < new_tat - emission_interval * rate then
+        return i - 1 -- limit exceeded, no tokens are taken
+    end
+    new_tats[i] = new_tat
+end
+
+for i, key in ipairs(KEYS) do
+    redis.call("SET", key, new_tats[i], "PX", math.ceil((new_tats[i] - current_time) / 1000))
+end
+return -1
+`)
+
+func (g *redisGCRA) allowAll(ctx context.Context, limits []limit) (int, error) {
+	keys := make([]string, 0, len(limits))
+	args := make([]any, 0, 3*len(limits))
+	for _, l := range limits {
+		if err := l.validate(); err != nil {
+			return -1, err
+		}
+		keys = append(keys, l.key)
+		args = append(args, l.rate, l.window, l.cost)
+	}
+	defer limitsTimer(g.stats, throttlingAlgoTypeRedisGCRA, limits)()
+	res, err := gcraMultiKeyScript.Run(ctx, g.client, keys, args...).Int()
+	if err != nil {
+		return -1, fmt.Errorf("could not run GCRA Redis script: %w", err)
+	}
+	return res, nil
+}
+
+// sequentialLimiter checks limits one after the other, returning the tokens taken from the previous limits when one of them is exceeded.
+// It can only be used with limiters supporting token returns, e.g. the redis-sorted-set algorithm.
+type sequentialLimiter struct {
+	limiter Limiter
+}
+
+func (s *sequentialLimiter) allowAll(ctx context.Context, limits []limit) (int, error) {
+	var tokenReturners []func(context.Context) error
+	returnTokens := func() {
+		for _, tokenReturner := range tokenReturners {
+			_ = tokenReturner(ctx)
+		}
+	}
+	for i, l := range limits {
+		allowed, tokenReturner, err := s.limiter.Allow(ctx, l.cost, l.rate, l.window, l.key)
+		if err != nil {
+			returnTokens()
+			return i, err
+		}
+		if !allowed {
+			returnTokens()
+			return i, nil
+		}
+		if tokenReturner != nil {
+			tokenReturners = append(tokenReturners, tokenReturner)
+		}
+	}
+	return -1, nil
+}
@@ -5,16 +5,22 @@ package throttler
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/go-redis/redis/v8"
+	"github.com/samber/lo"
+
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	"github.com/rudderlabs/rudder-go-kit/throttling"
 )
 
 const (
-	throttlingAlgoTypeGCRA = "gcra"
+	throttlingAlgoTypeGCRA           = "gcra"
+	throttlingAlgoTypeRedisGCRA      = "redis-gcra"
+	throttlingAlgoTypeRedisSortedSet = "redis-sorted-set"
 )
 
 type Limiter interface {
@@ -22,15 +28,49 @@ type Limiter interface {
 	Allow(ctx context.Context, cost, rate, window int64, key string) (bool, func(context.Context) error, error)
 }
 
+// Level is the level of the hierarchy at which a rate limit is applied
+type Level string
+
+const (
+	// LevelWorkspace limits all events of a workspace
+	LevelWorkspace Level = "workspace"
+	// LevelSource limits all events of a source (write key)
+	LevelSource Level = "source"
+	// LevelEvent limits events of a source having the same event name
+	LevelEvent Level = "event"
+
+	// overflowEventName is the event name shared by the events of a source once it reaches the maximum number of limited event names
+	overflowEventName = "*"
+)
+
+// Request contains the events of a single gateway request that need to be checked against the rate limits
+type Request struct {
+	WorkspaceID string
+	SourceID    string
+	// EventCounts is the number of events in the request per event name
+	EventCounts map[string]int64
+}
+
+// EventCount returns the total number of events in the request
+func (r Request) EventCount() int64 {
+	return lo.Sum(lo.Values(r.EventCounts))
+}
+
 type Throttler interface {
-	CheckLimitReached(context context.Context, workspaceId string, eventCount int64) (bool, error)
+	// CheckLimitReached returns true if the request exceeds any of the workspace, source or event limits, along with the level of the limit that was reached
+	// If the limiter fails, the request is either allowed or limited depending on whether the throttler is configured to fail open or closed.
+	CheckLimitReached(ctx context.Context, request Request) (bool, Level, error)
 }
 
 type Factory struct {
-	Stats        stats.Stats
-	limiter      Limiter
-	throttlers   map[string]*throttler // map key is the workspaceId
-	throttlersMu sync.Mutex
+	Stats         stats.Stats
+	limiter       multiLimiter
+	failOpen      config.ValueLoader[bool] // whether to allow requests when the limiter fails, e.g. when redis is unavailable
+	throttlers    map[string]*throttler    // map key is the level along with the id of the entity being limited
+	throttlersMu  sync.Mutex
+	maxEventNames config.ValueLoader[int]        // maximum number of distinct event names limited per source
+	eventNames    map[string]map[string]struct{} // event names limited per source
+	eventNamesMu  sync.Mutex
 }
 
 // New constructs a new Throttler Factory
@@ -38,6 +78,7 @@ func New(stats stats.Stats) (*Factory, error) {
 	f := Factory{
 		Stats:      stats,
 		throttlers: make(map[string]*throttler),
+		eventNames: make(map[string]map[string]struct{}),
 	}
 	if err := f.initThrottlerFactory(); err != nil {
 		return nil, err
@@ -45,68 +86,156 @@ func New(stats stats.Stats) (*Factory, error) {
 	return &f, nil
 }
 
-func (f *Factory) CheckLimitReached(context context.Context, workspaceId string, eventCount int64) (bool, error) {
-	t := f.get(workspaceId)
-	return t.checkLimitReached(context, workspaceId, eventCount)
+// CheckLimitReached checks the request against the event, source and workspace limits.
+// Tokens are only taken if none of the limits is reached, so that rejected requests don't consume the quota of other levels.
+func (f *Factory) CheckLimitReached(ctx context.Context, request Request) (bool, Level, error) {
+	var (
+		limits []limit
+		levels []Level
+	)
+	add := func(level Level, t *throttler, key string, count int64) {
+		if t.config.limit <= 0 || count < 1 { // levels without a configured limit always allow events
+			return
+		}
+		limits = append(limits, limit{key: key, cost: count, rate: t.config.limit, window: getWindowInSecs(t.config.window)})
+		levels = append(levels, level)
+	}
+
+	eventCounts := f.boundEventCounts(request.SourceID, request.EventCounts)
+	eventNames := lo.Keys(eventCounts)
+	slices.Sort(eventNames)
+	for _, eventName := range eventNames {
+		add(LevelEvent, f.get(LevelEvent, request.SourceID), "event:"+request.SourceID+":"+eventName, eventCounts[eventName])
+	}
+	count := request.EventCount()
+	if request.SourceID != "" {
+		add(LevelSource, f.get(LevelSource, request.SourceID), "source:"+request.SourceID, count)
+	}
+	add(LevelWorkspace, f.get(LevelWorkspace, request.WorkspaceID), request.WorkspaceID, count)
+	if len(limits) == 0 {
+		return false, "", nil
+	}
+
+	i, err := f.limiter.allowAll(ctx, limits)
+	if err != nil {
+		level := LevelWorkspace
+		if i >= 0 && i < len(levels) {
+			level = levels[i]
+		}
+		return !f.failOpen.Load(), level, fmt.Errorf("could not limit: %w", err)
+	}
+	if i < 0 {
+		return false, "", nil
+	}
+	f.limitReachedStats(request, levels[i], limits[i].cost)
+	return true, levels[i], nil
 }
 
-func (f *Factory) get(workspaceId string) *throttler {
+// boundEventCounts bounds the number of distinct event names limited per source, since event names are user controlled.
+// Once a source reaches the maximum number of event names, events with new names share the limit of an overflow key.
+func (f *Factory) boundEventCounts(sourceID string, eventCounts map[string]int64) map[string]int64 {
+	f.eventNamesMu.Lock()
+	defer f.eventNamesMu.Unlock()
+	names, ok := f.eventNames[sourceID]
+	if !ok {
+		names = make(map[string]struct{})
+		f.eventNames[sourceID] = names
+	}
+	maxEventNames := f.maxEventNames.Load()
+	bounded := make(map[string]int64, len(eventCounts))
+	for eventName, count := range eventCounts {
+		if _, ok := names[eventName]; !ok {
+			if len(names) >= maxEventNames {
+				bounded[overflowEventName] += count
+				continue
+			}
+			names[eventName] = struct{}{}
+		}
+		bounded[eventName] += count
+	}
+	return bounded
+}
+
+func (f *Factory) limitReachedStats(request Request, level Level, count int64) {
+	if f.Stats == nil {
+		return
+	}
+	tags := stats.Tags{
+		"workspaceId": request.WorkspaceID,
+		"sourceID":    request.SourceID,
+		"level":       string(level),
+	}
+	f.Stats.NewTaggedStat("gateway.throttler_limited_requests", stats.CountType, tags).Increment()
+	f.Stats.NewTaggedStat("gateway.throttler_limited_events", stats.CountType, tags).Count(int(count))
+}
+
+func (f *Factory) get(level Level, id string) *throttler {
 	f.throttlersMu.Lock()
 	defer f.throttlersMu.Unlock()
-	if t, ok := f.throttlers[workspaceId]; ok {
+	key := string(level) + ":" + id
+	if t, ok := f.throttlers[key]; ok {
 		return t
 	}
 
 	var conf throttlingConfig
-	conf.readThrottlingConfig(workspaceId)
-	f.throttlers[workspaceId] = &throttler{
-		limiter: f.limiter,
-		config:  conf,
+	if level == LevelWorkspace {
+		conf.readThrottlingConfig(id)
+	} else {
+		conf.readLevelThrottlingConfig(level, id)
+	}
+	f.throttlers[key] = &throttler{
+		config: conf,
 	}
-	return f.throttlers[workspaceId]
+	return f.throttlers[key]
 }
 
 func (f *Factory) initThrottlerFactory() error {
 	throttlingAlgorithm := config.GetString("Gateway.throttler.algorithm", throttlingAlgoTypeGCRA)
+	f.failOpen = config.GetReloadableBoolVar(true, "Gateway.throttler.failOpen")
+	f.maxEventNames = config.GetReloadableIntVar(1000, 1, "RateLimit.event.maxEventNames")
 
-	var (
-		err  error
-		l    *throttling.Limiter
-		opts []throttling.Option
-	)
-	if f.Stats != nil {
-		opts = append(opts, throttling.WithStatsCollector(f.Stats))
+	var redisClient *redis.Client
+	if throttlingAlgorithm == throttlingAlgoTypeRedisGCRA || throttlingAlgorithm == throttlingAlgoTypeRedisSortedSet {
+		if !config.IsSet("Gateway.throttler.redis.addr") {
+			return fmt.Errorf("redis address is required with algorithm %s", throttlingAlgorithm)
+		}
+		timeout := config.GetDurationVar(1, time.Second, "Gateway.throttler.redis.timeout")
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:         config.GetString("Gateway.throttler.redis.addr", "localhost:6379"),
+			Username:     config.GetString("Gateway.throttler.redis.username", ""),
+			Password:     config.GetString("Gateway.throttler.redis.password", ""),
+			DB:           config.GetInt("Gateway.throttler.redis.db", 0),
+			DialTimeout:  timeout,
+			ReadTimeout:  timeout,
+			WriteTimeout: timeout,
+		})
+	}
+
+	statsCollector := f.Stats
+	if statsCollector == nil {
+		statsCollector = stats.Default
 	}
 	switch throttlingAlgorithm {
 	case throttlingAlgoTypeGCRA:
-		l, err = throttling.New(append(opts, throttling.WithInMemoryGCRA(0))...)
+		f.limiter = newMemoryGCRA(statsCollector)
+	case throttlingAlgoTypeRedisGCRA:
+		f.limiter = &redisGCRA{client: redisClient, stats: statsCollector}
+	case throttlingAlgoTypeRedisSortedSet:
+		l, err := throttling.New(throttling.WithStatsCollector(statsCollector), throttling.WithRedisSortedSet(redisClient))
+		if err != nil {
+			return fmt.Errorf("failed to create throttler: %w", err)
+		}
+		f.limiter = &sequentialLimiter{limiter: l}
 	default:
 		return fmt.Errorf("invalid throttling algorithm: %s", throttlingAlgorithm)
 	}
-	if err != nil {
-		return fmt.Errorf("failed to create throttler: %w", err)
-	}
-
-	f.limiter = l
 
 	return nil
 }
 
+// throttler holds the limit configuration of an entity being limited at some level
 type throttler struct {
-	limiter Limiter
-	config  throttlingConfig
-}
-
-// checkLimitReached returns true if we're not allowed to process the number of event
-func (t *throttler) checkLimitReached(ctx context.Context, key string, count int64) (limited bool, retErr error) {
-	allowed, _, err := t.limiter.Allow(ctx, count, t.config.limit, getWindowInSecs(t.config.window), key)
-	if err != nil {
-		return false, fmt.Errorf("could not limit: %w", err)
-	}
-	if !allowed {
-		return true, nil // no token to return when limited
-	}
-	return false, nil
+	config throttlingConfig
 }
 
 type throttlingConfig struct {
@@ -130,6 +259,14 @@ func (c *throttlingConfig) readThrottlingConfig(workspaceID string) {
 	}
 }
 
+// readLevelThrottlingConfig reads the throttling config of a source or event level, e.g. RateLimit.source.<sourceID>.eventLimit falling back to RateLimit.source.eventLimit.
+// Levels are disabled unless an event limit is configured for them.
+func (c *throttlingConfig) readLevelThrottlingConfig(level Level, id string) {
+	prefix := "RateLimit." + string(level)
+	c.limit = config.GetInt64Var(0, 1, prefix+"."+id+".eventLimit", prefix+".eventLimit")
+	c.window = config.GetDurationVar(60, time.Second, prefix+"."+id+".rateLimitWindow", prefix+".rateLimitWindow")
+}
+
 func getWindowInSecs(d time.Duration) int64 {
 	return int64(d.Seconds())
 }
@@ -5,11 +5,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ory/dockertest/v3"
 	"github.com/stretchr/testify/require"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/stats"
-	"github.com/rudderlabs/rudder-go-kit/throttling"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/redis"
 )
 
 func TestGateway_Throttler(t *testing.T) {
@@ -26,24 +28,20 @@ func TestGateway_Throttler(t *testing.T) {
 	require.Equal(t, conf.limit, int64(eventLimit))
 	require.Equal(t, conf.window, time.Duration(timeWindow)*time.Minute)
 
-	l, err := throttling.New(throttling.WithInMemoryGCRA(0))
-	require.NoError(t, err)
-	testThrottler := throttler{
-		config:  conf,
-		limiter: l,
-	}
+	l := newMemoryGCRA(stats.NOP)
+	request := []limit{{key: workspaceId, cost: 1, rate: conf.limit, window: getWindowInSecs(conf.window)}}
 
 	for i := 0; i < eventLimit; i++ {
-		_, err := testThrottler.checkLimitReached(context.TODO(), workspaceId, 1)
+		_, err := l.allowAll(context.TODO(), request)
 		require.NoError(t, err)
 	}
 
 	startTime := time.Now()
 	var passed int
 	for i := 0; i < 2*eventLimit; i++ {
-		allowed, err := testThrottler.checkLimitReached(context.TODO(), workspaceId, 1)
+		exceeded, err := l.allowAll(context.TODO(), request)
 		require.NoError(t, err)
-		if allowed {
+		if exceeded >= 0 {
 			passed++
 		}
 	}
@@ -68,15 +66,16 @@ func TestGateway_Factory(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, rateLimiter)
 
+	request := Request{WorkspaceID: workspaceId, SourceID: "sourceID", EventCounts: map[string]int64{"track": 1}}
 	for i := 0; i < eventLimit; i++ {
-		_, err := rateLimiter.CheckLimitReached(context.TODO(), workspaceId, 1)
+		_, _, err := rateLimiter.CheckLimitReached(context.TODO(), request)
 		require.NoError(t, err)
 	}
 
 	startTime := time.Now()
 	var passed int
 	for i := 0; i < 2*eventLimit; i++ {
-		allowed, err := rateLimiter.CheckLimitReached(context.TODO(), workspaceId, 1)
+		allowed, _, err := rateLimiter.CheckLimitReached(context.TODO(), request)
 		require.NoError(t, err)
 		if allowed {
 			passed++
@@ -103,3 +102,207 @@ func Test_readThrottlingConfig(t *testing.T) {
 	require.Equal(t, conf.limit, int64(eventLimit))
 	require.Equal(t, conf.window, time.Duration(timeWindow)*time.Minute)
 }
+
+func TestGateway_Factory_Levels(t *testing.T) {
+	newRequest := func(sourceID string, eventCounts map[string]int64) Request {
+		return Request{WorkspaceID: "workspaceID", SourceID: sourceID, EventCounts: eventCounts}
+	}
+
+	t.Run("event level", func(t *testing.T) {
+		config.Set("RateLimit.eventLimit", 1000)
+		config.Set("RateLimit.event.eventLimit", 5)
+		defer config.Reset()
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		rateLimiter, err := New(statsStore)
+		require.NoError(t, err)
+
+		limited, level, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"Order Completed": 5, "identify": 1}))
+		require.NoError(t, err)
+		require.False(t, limited)
+		require.Empty(t, level)
+
+		limited, level, err = rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"Order Completed": 2}))
+		require.NoError(t, err)
+		require.True(t, limited)
+		require.Equal(t, LevelEvent, level)
+
+		// other events and sources have their own limits
+		limited, _, err = rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"identify": 1}))
+		require.NoError(t, err)
+		require.False(t, limited)
+		limited, _, err = rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-2", map[string]int64{"Order Completed": 1}))
+		require.NoError(t, err)
+		require.False(t, limited)
+
+		tags := stats.Tags{"workspaceId": "workspaceID", "sourceID": "source-1", "level": string(LevelEvent)}
+		require.EqualValues(t, 1, statsStore.Get("gateway.throttler_limited_requests", tags).LastValue())
+		require.EqualValues(t, 2, statsStore.Get("gateway.throttler_limited_events", tags).LastValue())
+	})
+
+	t.Run("source level", func(t *testing.T) {
+		config.Set("RateLimit.eventLimit", 1000)
+		config.Set("RateLimit.source.eventLimit", 10)
+		config.Set("RateLimit.source.source-2.eventLimit", 20)
+		defer config.Reset()
+		rateLimiter, err := New(stats.NOP)
+		require.NoError(t, err)
+
+		limited, _, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"a": 5, "b": 5}))
+		require.NoError(t, err)
+		require.False(t, limited)
+		limited, level, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"c": 2}))
+		require.NoError(t, err)
+		require.True(t, limited)
+		require.Equal(t, LevelSource, level)
+
+		limited, _, err = rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-2", map[string]int64{"a": 20}))
+		require.NoError(t, err)
+		require.False(t, limited, "source specific limit should take precedence")
+	})
+
+	t.Run("workspace level", func(t *testing.T) {
+		config.Set("RateLimit.eventLimit", 10)
+		config.Set("RateLimit.source.eventLimit", 10)
+		defer config.Reset()
+		rateLimiter, err := New(stats.NOP)
+		require.NoError(t, err)
+
+		limited, _, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"a": 6}))
+		require.NoError(t, err)
+		require.False(t, limited)
+		limited, level, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-2", map[string]int64{"a": 6}))
+		require.NoError(t, err)
+		require.True(t, limited)
+		require.Equal(t, LevelWorkspace, level)
+	})
+
+	t.Run("rejected requests don't consume the quota of other levels", func(t *testing.T) {
+		config.Set("RateLimit.eventLimit", 10)
+		config.Set("RateLimit.source.eventLimit", 100)
+		config.Set("RateLimit.event.eventLimit", 100)
+		defer config.Reset()
+		rateLimiter, err := New(stats.NOP)
+		require.NoError(t, err)
+
+		for range 10 {
+			limited, level, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"a": 11}))
+			require.NoError(t, err)
+			require.True(t, limited)
+			require.Equal(t, LevelWorkspace, level)
+		}
+		// neither the event nor the source limits have been consumed by the rejected requests
+		limited, _, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"a": 10}))
+		require.NoError(t, err)
+		require.False(t, limited)
+	})
+
+	t.Run("event names are bounded per source", func(t *testing.T) {
+		config.Set("RateLimit.eventLimit", 1000)
+		config.Set("RateLimit.event.eventLimit", 5)
+		config.Set("RateLimit.event.maxEventNames", 2)
+		defer config.Reset()
+		rateLimiter, err := New(stats.NOP)
+		require.NoError(t, err)
+
+		limited, _, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"a": 5, "b": 5}))
+		require.NoError(t, err)
+		require.False(t, limited)
+		// events with new names share the same limit once the maximum number of event names is reached
+		limited, _, err = rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"c": 3, "d": 2}))
+		require.NoError(t, err)
+		require.False(t, limited)
+		limited, level, err := rateLimiter.CheckLimitReached(context.TODO(), newRequest("source-1", map[string]int64{"e": 1}))
+		require.NoError(t, err)
+		require.True(t, limited)
+		require.Equal(t, LevelEvent, level)
+		require.Len(t, rateLimiter.eventNames["source-1"], 2)
+	})
+}
+
+func TestGateway_Factory_Stats(t *testing.T) {
+	config.Set("RateLimit.eventLimit", 10)
+	defer config.Reset()
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	rateLimiter, err := New(statsStore)
+	require.NoError(t, err)
+
+	_, _, err = rateLimiter.CheckLimitReached(context.TODO(), Request{WorkspaceID: "workspaceID", SourceID: "sourceID", EventCounts: map[string]int64{"track": 1}})
+	require.NoError(t, err)
+	measurements := statsStore.GetByName("throttling")
+	require.NotEmpty(t, measurements, "the time taken for checking the limits should be recorded")
+	for _, m := range measurements {
+		require.Equal(t, throttlingAlgoTypeGCRA, m.Tags["algo"])
+	}
+	require.NotNil(t, statsStore.Get("throttling", stats.Tags{"key": "workspaceID", "algo": throttlingAlgoTypeGCRA, "rate": "10", "window": "60"}))
+}
+
+func TestGateway_Factory_Redis(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	redisContainer, err := redis.Setup(context.Background(), pool, t)
+	require.NoError(t, err)
+
+	for _, algorithm := range []string{throttlingAlgoTypeRedisGCRA, throttlingAlgoTypeRedisSortedSet} {
+		t.Run(algorithm, func(t *testing.T) {
+			config.Set("Gateway.throttler.algorithm", algorithm)
+			config.Set("Gateway.throttler.redis.addr", redisContainer.Addr)
+			config.Set("RateLimit."+algorithm+".eventLimit", 10)
+			defer config.Reset()
+
+			// two factories simulating different gateway replicas sharing the same limits
+			statsStore, err := memstats.New()
+			require.NoError(t, err)
+			replica1, err := New(statsStore)
+			require.NoError(t, err)
+			replica2, err := New(stats.NOP)
+			require.NoError(t, err)
+
+			request := Request{WorkspaceID: algorithm, SourceID: "sourceID", EventCounts: map[string]int64{"track": 6}}
+			limited, _, err := replica1.CheckLimitReached(context.TODO(), request)
+			require.NoError(t, err)
+			require.False(t, limited)
+			limited, level, err := replica2.CheckLimitReached(context.TODO(), request)
+			require.NoError(t, err)
+			require.True(t, limited, "limits should be enforced globally across replicas")
+			require.Equal(t, LevelWorkspace, level)
+			require.NotNil(t, statsStore.Get("throttling", stats.Tags{"key": algorithm, "algo": algorithm, "rate": "10", "window": "60"}))
+		})
+	}
+}
+
+func TestGateway_Factory_RedisUnavailable(t *testing.T) {
+	newFactory := func(t *testing.T, failOpen bool) *Factory {
+		config.Set("Gateway.throttler.algorithm", throttlingAlgoTypeRedisGCRA)
+		config.Set("Gateway.throttler.redis.addr", "127.0.0.1:1")
+		config.Set("Gateway.throttler.redis.timeout", "100ms")
+		config.Set("Gateway.throttler.failOpen", failOpen)
+		t.Cleanup(config.Reset)
+		f, err := New(stats.NOP)
+		require.NoError(t, err)
+		return f
+	}
+	request := Request{WorkspaceID: "workspaceID", SourceID: "sourceID", EventCounts: map[string]int64{"track": 1}}
+
+	t.Run("fail open", func(t *testing.T) {
+		limited, level, err := newFactory(t, true).CheckLimitReached(context.TODO(), request)
+		require.Error(t, err)
+		require.False(t, limited)
+		require.Equal(t, LevelWorkspace, level)
+	})
+
+	t.Run("fail closed", func(t *testing.T) {
+		limited, level, err := newFactory(t, false).CheckLimitReached(context.TODO(), request)
+		require.Error(t, err)
+		require.True(t, limited)
+		require.Equal(t, LevelWorkspace, level)
+	})
+
+	t.Run("redis address is required", func(t *testing.T) {
+		config.Set("Gateway.throttler.algorithm", throttlingAlgoTypeRedisSortedSet)
+		defer config.Reset()
+		_, err := New(stats.NOP)
+		require.Error(t, err)
+	})
+}
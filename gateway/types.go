@@ -8,6 +8,7 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/gateway/throttler"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 )
 
@@ -66,4 +67,6 @@ type jobFromReq struct {
 	numEvents int
 	botEvents int
 	version   string
+	// throttledAt is the level of the rate limit that was reached, if the request got dropped
+	throttledAt throttler.Level
 }
@@ -0,0 +1,209 @@
+package validator
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-schemas/go/stream"
+)
+
+// sourceSetting lazily registers the reloadable value of a declarative validator's setting for every source.
+// A setting is read from the following keys, in order:
+//
+//	Gateway.validators.<sourceID>.<name>
+//	Gateway.validators.<workspaceID>.<name>
+//	Gateway.validators.<name>
+type sourceSetting[T any] struct {
+	name      string
+	newLoader func(keys ...string) config.ValueLoader[T]
+	loadersMu sync.RWMutex
+	loaders   map[string]config.ValueLoader[T] // map key is the workspace id along with the source id
+}
+
+func newSourceSetting[T any](name string, newLoader func(keys ...string) config.ValueLoader[T]) *sourceSetting[T] {
+	return &sourceSetting[T]{
+		name:      name,
+		newLoader: newLoader,
+		loaders:   make(map[string]config.ValueLoader[T]),
+	}
+}
+
+func (s *sourceSetting[T]) get(properties *stream.MessageProperties) T {
+	if properties == nil {
+		var zero T
+		return zero
+	}
+	key := properties.WorkspaceID + ":" + properties.SourceID
+	s.loadersMu.RLock()
+	loader, ok := s.loaders[key]
+	s.loadersMu.RUnlock()
+	if ok {
+		return loader.Load()
+	}
+
+	s.loadersMu.Lock()
+	defer s.loadersMu.Unlock()
+	if loader, ok = s.loaders[key]; !ok {
+		loader = s.newLoader(
+			"Gateway.validators."+properties.SourceID+"."+s.name,
+			"Gateway.validators."+properties.WorkspaceID+"."+s.name,
+			"Gateway.validators."+s.name,
+		)
+		s.loaders[key] = loader
+	}
+	return loader.Load()
+}
+
+// maxPropertyCountValidator rejects events having more properties or traits than the configured limit, zero disables it
+type maxPropertyCountValidator struct {
+	limit *sourceSetting[int]
+}
+
+func newMaxPropertyCountValidator(conf *config.Config) *maxPropertyCountValidator {
+	return &maxPropertyCountValidator{
+		limit: newSourceSetting("maxPropertyCount", func(keys ...string) config.ValueLoader[int] {
+			return conf.GetReloadableIntVar(0, 1, keys...)
+		}),
+	}
+}
+
+func (v *maxPropertyCountValidator) ValidatorName() string {
+	return "maxPropertyCount"
+}
+
+func (v *maxPropertyCountValidator) Validate(payload []byte, properties *stream.MessageProperties) (bool, error) {
+	limit := v.limit.get(properties)
+	if limit <= 0 {
+		return true, nil
+	}
+	for _, result := range gjson.GetManyBytes(payload, "properties", "traits", "context.traits") {
+		if !result.IsObject() {
+			continue
+		}
+		count := 0
+		result.ForEach(func(_, _ gjson.Result) bool {
+			count++
+			return count <= limit
+		})
+		if count > limit {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// maxNestingDepthValidator rejects events nested deeper than the configured limit, zero disables it.
+// The depth of an event without any nested objects or arrays is 1.
+type maxNestingDepthValidator struct {
+	limit *sourceSetting[int]
+}
+
+func newMaxNestingDepthValidator(conf *config.Config) *maxNestingDepthValidator {
+	return &maxNestingDepthValidator{
+		limit: newSourceSetting("maxNestingDepth", func(keys ...string) config.ValueLoader[int] {
+			return conf.GetReloadableIntVar(0, 1, keys...)
+		}),
+	}
+}
+
+func (v *maxNestingDepthValidator) ValidatorName() string {
+	return "maxNestingDepth"
+}
+
+func (v *maxNestingDepthValidator) Validate(payload []byte, properties *stream.MessageProperties) (bool, error) {
+	limit := v.limit.get(properties)
+	if limit <= 0 {
+		return true, nil
+	}
+	return nestingDepth(payload, limit) <= limit, nil
+}
+
+// nestingDepth returns the maximum depth of objects and arrays in a json document, stopping as soon as the limit is exceeded
+func nestingDepth(payload []byte, limit int) int {
+	var depth, maxDepth int
+	var inString, escaped bool
+	for _, c := range payload {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				maxDepth = depth
+				if maxDepth > limit {
+					return maxDepth
+				}
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return maxDepth
+}
+
+// contextLibraryValidator rejects events without a context.library.name, if enabled
+type contextLibraryValidator struct {
+	required *sourceSetting[bool]
+}
+
+func newContextLibraryValidator(conf *config.Config) *contextLibraryValidator {
+	return &contextLibraryValidator{
+		required: newSourceSetting("requireContextLibrary", func(keys ...string) config.ValueLoader[bool] {
+			return conf.GetReloadableBoolVar(false, keys...)
+		}),
+	}
+}
+
+func (v *contextLibraryValidator) ValidatorName() string {
+	return "contextLibrary"
+}
+
+func (v *contextLibraryValidator) Validate(payload []byte, properties *stream.MessageProperties) (bool, error) {
+	if !v.required.get(properties) {
+		return true, nil
+	}
+	return gjson.GetBytes(payload, "context.library.name").String() != "", nil
+}
+
+// deniedEventNameValidator rejects events whose name is in the configured deny-list
+type deniedEventNameValidator struct {
+	eventNames *sourceSetting[[]string]
+}
+
+func newDeniedEventNameValidator(conf *config.Config) *deniedEventNameValidator {
+	return &deniedEventNameValidator{
+		eventNames: newSourceSetting("deniedEventNames", func(keys ...string) config.ValueLoader[[]string] {
+			return conf.GetReloadableStringSliceVar(nil, keys...)
+		}),
+	}
+}
+
+func (v *deniedEventNameValidator) ValidatorName() string {
+	return "deniedEventName"
+}
+
+func (v *deniedEventNameValidator) Validate(payload []byte, properties *stream.MessageProperties) (bool, error) {
+	eventNames := v.eventNames.get(properties)
+	if len(eventNames) == 0 {
+		return true, nil
+	}
+	event := gjson.GetBytes(payload, "event")
+	if event.Type != gjson.String {
+		return true, nil
+	}
+	return !slices.Contains(eventNames, event.Str), nil
+}
@@ -1,13 +1,14 @@
 package validator
 
 import (
+	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	"github.com/rudderlabs/rudder-schemas/go/stream"
 )
 
-// payloadValidator defines an interface for validating payloads and retrieving the validator's name.
-type payloadValidator interface {
+// PayloadValidator defines an interface for validating payloads and retrieving the validator's name.
+type PayloadValidator interface {
 	Validate(payload []byte, properties *stream.MessageProperties) (bool, error)
 	ValidatorName() string
 }
@@ -15,14 +16,29 @@ type payloadValidator interface {
 // Mediator centralizes the orchestration of multiple payload validator processes.
 type Mediator struct {
 	log        logger.Logger
-	validators []payloadValidator
+	validators []PayloadValidator
+}
+
+// Option configures the mediator
+type Option func(m *Mediator)
+
+// WithConfig enables the declarative validators, whose settings are read from the provided config
+func WithConfig(conf *config.Config) Option {
+	return func(m *Mediator) {
+		m.validators = append(m.validators,
+			newMaxPropertyCountValidator(conf),
+			newMaxNestingDepthValidator(conf),
+			newContextLibraryValidator(conf),
+			newDeniedEventNameValidator(conf),
+		)
+	}
 }
 
 // NewValidateMediator creates a new ValidatorMediator with default validators.
-func NewValidateMediator(log logger.Logger, validatorFn func(properties *stream.MessageProperties) error) *Mediator {
-	return &Mediator{
+func NewValidateMediator(log logger.Logger, validatorFn func(properties *stream.MessageProperties) error, opts ...Option) *Mediator {
+	m := &Mediator{
 		log: log.Withn(logger.NewStringField("component", "validator")),
-		validators: []payloadValidator{
+		validators: []PayloadValidator{
 			newMsgPropertiesValidator(validatorFn),
 			newMessageIDValidator(),
 			newReqTypeValidator(),
@@ -31,10 +47,37 @@ func NewValidateMediator(log logger.Logger, validatorFn func(properties *stream.
 			newRudderIDValidator(),
 		},
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// NewEventMediator creates a new mediator without the default validators, for validating events which haven't been enriched yet, e.g. the ones of the public endpoints.
+func NewEventMediator(log logger.Logger, opts ...Option) *Mediator {
+	m := &Mediator{
+		log: log.Withn(logger.NewStringField("component", "validator")),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Register adds validators to be run after the already registered ones.
+// It is not safe to call Register while payloads are being validated.
+func (m *Mediator) Register(validators ...PayloadValidator) {
+	m.validators = append(m.validators, validators...)
 }
 
 // Validate runs the payload through all registered validators.
 func (m *Mediator) Validate(payload []byte, properties *stream.MessageProperties) (bool, error) {
+	_, ok, err := m.ValidateWithName(payload, properties)
+	return ok, err
+}
+
+// ValidateWithName runs the payload through all registered validators, returning the name of the validator that rejected it, if any.
+func (m *Mediator) ValidateWithName(payload []byte, properties *stream.MessageProperties) (string, bool, error) {
 	for _, validator := range m.validators {
 		if ok, err := validator.Validate(payload, properties); err != nil || !ok {
 			loggerFields := properties.LoggerFields()
@@ -44,8 +87,8 @@ func (m *Mediator) Validate(payload []byte, properties *stream.MessageProperties
 				loggerFields = append(loggerFields, obskit.Error(err))
 			}
 			m.log.Errorn("failed to validate", loggerFields...)
-			return false, err
+			return validator.ValidatorName(), false, err
 		}
 	}
-	return true, nil
+	return "", true, nil
 }
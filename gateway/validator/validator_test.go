@@ -5,6 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 
 	"github.com/stretchr/testify/require"
@@ -178,3 +181,126 @@ func TestMediator_Validate(t *testing.T) {
 		})
 	}
 }
+
+type eventNameValidator struct{}
+
+func (eventNameValidator) ValidatorName() string { return "eventName" }
+
+func (eventNameValidator) Validate(payload []byte, _ *stream.MessageProperties) (bool, error) {
+	return gjson.GetBytes(payload, "event").String() != "", nil
+}
+
+func TestMediator_Register(t *testing.T) {
+	payload := []byte(`{"messageId":"msg123","type":"track","receivedAt":"2023-10-01T12:00:00Z","rudderId":"rud-id","request_ip":"192.168.1.1"}`)
+	props := &stream.MessageProperties{RequestType: "track", SourceID: "source-id"}
+
+	mediator := NewValidateMediator(logger.NOP, func(*stream.MessageProperties) error { return nil })
+	validatorName, ok, err := mediator.ValidateWithName(payload, props)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Empty(t, validatorName)
+
+	mediator.Register(eventNameValidator{})
+	validatorName, ok, err = mediator.ValidateWithName(payload, props)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, "eventName", validatorName)
+
+	validatorName, ok, err = mediator.ValidateWithName([]byte(`{"type":"track"}`), props)
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, "messageID", validatorName, "registered validators should run after the default ones")
+}
+
+func TestDeclarativeValidators(t *testing.T) {
+	conf := config.New()
+	conf.Set("Gateway.validators.maxPropertyCount", 2)
+	conf.Set("Gateway.validators.source-2.maxPropertyCount", 0)
+	conf.Set("Gateway.validators.maxNestingDepth", 3)
+	conf.Set("Gateway.validators.workspace-2.requireContextLibrary", true)
+	conf.Set("Gateway.validators.source-3.deniedEventNames", []string{"Spam Event", "Test Event"})
+
+	basePayload := `{"messageId":"msg123","type":"track","receivedAt":"2023-10-01T12:00:00Z","rudderId":"rud-id","request_ip":"192.168.1.1"`
+	tests := []struct {
+		name          string
+		payload       string
+		props         *stream.MessageProperties
+		wantValidator string
+	}{
+		{
+			name:    "valid event",
+			payload: basePayload + `,"event":"Order Completed","properties":{"a":1,"b":2},"context":{"traits":{"email":"a@b.c"}}}`,
+			props:   &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-1"},
+		},
+		{
+			name:          "too many properties",
+			payload:       basePayload + `,"properties":{"a":1,"b":2,"c":3}}`,
+			props:         &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-1"},
+			wantValidator: "maxPropertyCount",
+		},
+		{
+			name:          "too many traits",
+			payload:       basePayload + `,"context":{"traits":{"a":1,"b":2,"c":3}}}`,
+			props:         &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-1"},
+			wantValidator: "maxPropertyCount",
+		},
+		{
+			name:    "property count limit disabled for source",
+			payload: basePayload + `,"properties":{"a":1,"b":2,"c":3}}`,
+			props:   &stream.MessageProperties{RequestType: "track", SourceID: "source-2", WorkspaceID: "workspace-1"},
+		},
+		{
+			name:    "nested within limit",
+			payload: basePayload + `,"properties":{"a":{"b":"{[{[}"}}}`,
+			props:   &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-1"},
+		},
+		{
+			name:          "nested too deep",
+			payload:       basePayload + `,"properties":{"a":[{"b":1}]}}`,
+			props:         &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-1"},
+			wantValidator: "maxNestingDepth",
+		},
+		{
+			name:          "missing context library in workspace requiring it",
+			payload:       basePayload + `,"context":{"library":{"version":"1.0"}}}`,
+			props:         &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-2"},
+			wantValidator: "contextLibrary",
+		},
+		{
+			name:    "context library in workspace requiring it",
+			payload: basePayload + `,"context":{"library":{"name":"analytics-js"}}}`,
+			props:   &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-2"},
+		},
+		{
+			name:          "denied event name",
+			payload:       basePayload + `,"event":"Test Event"}`,
+			props:         &stream.MessageProperties{RequestType: "track", SourceID: "source-3", WorkspaceID: "workspace-1"},
+			wantValidator: "deniedEventName",
+		},
+		{
+			name:    "event name denied for another source",
+			payload: basePayload + `,"event":"Test Event"}`,
+			props:   &stream.MessageProperties{RequestType: "track", SourceID: "source-1", WorkspaceID: "workspace-1"},
+		},
+	}
+
+	mediator := NewValidateMediator(logger.NOP, func(*stream.MessageProperties) error { return nil }, WithConfig(conf))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validatorName, ok, err := mediator.ValidateWithName([]byte(tt.payload), tt.props)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantValidator == "", ok)
+			require.Equal(t, tt.wantValidator, validatorName)
+		})
+	}
+
+	t.Run("settings are reloadable", func(t *testing.T) {
+		payload := []byte(basePayload + `,"properties":{"a":1,"b":2,"c":3}}`)
+		props := &stream.MessageProperties{RequestType: "track", SourceID: "source-4", WorkspaceID: "workspace-1"}
+		_, ok, _ := mediator.ValidateWithName(payload, props)
+		require.False(t, ok)
+		conf.Set("Gateway.validators.source-4.maxPropertyCount", 3)
+		_, ok, _ = mediator.ValidateWithName(payload, props)
+		require.True(t, ok)
+	})
+}
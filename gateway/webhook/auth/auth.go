@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
 
 	gwtypes "github.com/rudderlabs/rudder-server/gateway/types"
 
@@ -15,15 +18,20 @@ var ErrSourceNotFound = errors.New("source not found")
 type WebhookAuth struct {
 	onFailure             func(w http.ResponseWriter, r *http.Request, errorMessage string, authCtx *gwtypes.AuthRequestContext)
 	authReqCtxForWriteKey func(writeKey string) (*gwtypes.AuthRequestContext, error)
+	maxReqSize            config.ValueLoader[int] // maximum size of the bodies read for verifying signatures
+	now                   func() time.Time
 }
 
 func NewWebhookAuth(
 	onFailure func(w http.ResponseWriter, r *http.Request, errorMessage string, authCtx *gwtypes.AuthRequestContext),
 	authReqCtxForWriteKey func(writeKey string) (*gwtypes.AuthRequestContext, error),
+	maxReqSize config.ValueLoader[int],
 ) *WebhookAuth {
 	return &WebhookAuth{
 		onFailure:             onFailure,
 		authReqCtxForWriteKey: authReqCtxForWriteKey,
+		maxReqSize:            maxReqSize,
+		now:                   time.Now,
 	}
 }
 
@@ -58,6 +66,36 @@ func (wa *WebhookAuth) AuthHandler(next http.HandlerFunc) http.HandlerFunc {
 			wa.onFailure(w, r, response.SourceDisabled, arctx)
 			return
 		}
+		if errorMessage := wa.verifySignature(w, r, arctx); errorMessage != "" {
+			wa.onFailure(w, r, errorMessage, arctx)
+			return
+		}
 		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), gwtypes.CtxParamAuthRequestContext, arctx)))
 	}
 }
+
+// verifySignature verifies the signature of the request if the source is configured with a signing secret,
+// returning the error message to respond with if the request can't be authenticated
+func (wa *WebhookAuth) verifySignature(w http.ResponseWriter, r *http.Request, arctx *gwtypes.AuthRequestContext) string {
+	signatureConfig, enabled, err := signatureConfigOf(arctx.SourceDetails.Config)
+	if err != nil {
+		return response.ErrAuthenticatingWebhookRequest
+	}
+	if !enabled {
+		return ""
+	}
+	if r.Body != nil {
+		r.Body = http.MaxBytesReader(w, r.Body, int64(wa.maxReqSize.Load()))
+	}
+	if err := signatureConfig.Verify(r, wa.now()); err != nil {
+		if errors.Is(err, ErrInvalidSignature) || errors.Is(err, ErrSignatureTimestampOutOfTolerance) {
+			return response.InvalidWebhookSignature
+		}
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return response.RequestBodyTooLarge
+		}
+		return response.RequestBodyReadFailed
+	}
+	return ""
+}
@@ -1,15 +1,26 @@
 package auth
 
 import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/rudderlabs/rudder-go-kit/config"
+
 	"github.com/rudderlabs/rudder-server/gateway/response"
 
 	gwtypes "github.com/rudderlabs/rudder-server/gateway/types"
@@ -110,7 +121,7 @@ func TestNewWebhookAuth(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			// Initialize WebhookAuth instance
-			webhookAuth := NewWebhookAuth(tt.mockOnFailure, tt.mockAuthReqCtxForWriteKey)
+			webhookAuth := NewWebhookAuth(tt.mockOnFailure, tt.mockAuthReqCtxForWriteKey, config.SingleValueLoader(4000*1024))
 
 			// Set up mock HTTP server
 			server := httptest.NewServer(webhookAuth.AuthHandler(func(w http.ResponseWriter, request *http.Request) {
@@ -141,3 +152,153 @@ func TestNewWebhookAuth(t *testing.T) {
 		})
 	}
 }
+
+func TestWebhookAuthSignature(t *testing.T) {
+	const (
+		secret  = "signing-secret"
+		payload = `{"event":"order.created"}`
+	)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	sign := func(newHash func() hash.Hash, content string) []byte {
+		mac := hmac.New(newHash, []byte(secret))
+		mac.Write([]byte(content))
+		return mac.Sum(nil)
+	}
+	stripeSignature := func(ts time.Time) string {
+		timestamp := strconv.FormatInt(ts.Unix(), 10)
+		return "t=" + timestamp + ",v1=" + hex.EncodeToString(sign(sha256.New, timestamp+"."+payload))
+	}
+
+	tests := []struct {
+		name                    string
+		signatureConfig         string
+		headers                 map[string]string
+		extraMaxReqSize         int // bytes allowed on top of the payload's size
+		expectedResponseCode    int
+		expectedResponseMessage string
+	}{
+		{
+			name:                    "no signing secret configured",
+			signatureConfig:         `{"header":"X-Hub-Signature-256"}`,
+			expectedResponseCode:    http.StatusOK,
+			expectedResponseMessage: payload,
+		},
+		{
+			name:                    "github style hex signature with algorithm prefix",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Hub-Signature-256","algorithm":"sha256"}`,
+			headers:                 map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, payload))},
+			expectedResponseCode:    http.StatusOK,
+			expectedResponseMessage: payload,
+		},
+		{
+			name:                    "shopify style base64 signature",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Shopify-Hmac-Sha256"}`,
+			headers:                 map[string]string{"X-Shopify-Hmac-Sha256": base64.StdEncoding.EncodeToString(sign(sha256.New, payload))},
+			expectedResponseCode:    http.StatusOK,
+			expectedResponseMessage: payload,
+		},
+		{
+			name:                    "sha1 signature",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Signature","algorithm":"sha1"}`,
+			headers:                 map[string]string{"X-Signature": hex.EncodeToString(sign(sha1.New, payload))},
+			expectedResponseCode:    http.StatusOK,
+			expectedResponseMessage: payload,
+		},
+		{
+			name:                    "stripe style signature within tolerance",
+			signatureConfig:         `{"secret":"signing-secret","header":"Stripe-Signature","toleranceInSeconds":300}`,
+			headers:                 map[string]string{"Stripe-Signature": stripeSignature(now.Add(-time.Minute))},
+			expectedResponseCode:    http.StatusOK,
+			expectedResponseMessage: payload,
+		},
+		{
+			name:            "signature with timestamp header",
+			signatureConfig: `{"secret":"signing-secret","header":"X-Signature","timestampHeader":"X-Timestamp","toleranceInSeconds":300}`,
+			headers: map[string]string{
+				"X-Signature": hex.EncodeToString(sign(sha256.New, strconv.FormatInt(now.Unix(), 10)+"."+payload)),
+				"X-Timestamp": strconv.FormatInt(now.Unix(), 10),
+			},
+			expectedResponseCode:    http.StatusOK,
+			expectedResponseMessage: payload,
+		},
+		{
+			name:                    "stripe style signature out of tolerance",
+			signatureConfig:         `{"secret":"signing-secret","header":"Stripe-Signature","toleranceInSeconds":300}`,
+			headers:                 map[string]string{"Stripe-Signature": stripeSignature(now.Add(-10 * time.Minute))},
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseMessage: response.InvalidWebhookSignature + "\n",
+		},
+		{
+			name:                    "missing timestamp with tolerance",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Signature","toleranceInSeconds":300}`,
+			headers:                 map[string]string{"X-Signature": hex.EncodeToString(sign(sha256.New, payload))},
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseMessage: response.InvalidWebhookSignature + "\n",
+		},
+		{
+			name:                    "invalid signature",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Hub-Signature-256"}`,
+			headers:                 map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha1.New, payload))},
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseMessage: response.InvalidWebhookSignature + "\n",
+		},
+		{
+			name:                    "missing signature",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Hub-Signature-256"}`,
+			expectedResponseCode:    http.StatusUnauthorized,
+			expectedResponseMessage: response.InvalidWebhookSignature + "\n",
+		},
+		{
+			name:                    "body larger than the maximum request size",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Hub-Signature-256"}`,
+			headers:                 map[string]string{"X-Hub-Signature-256": "sha256=" + hex.EncodeToString(sign(sha256.New, payload))},
+			extraMaxReqSize:         -1,
+			expectedResponseCode:    http.StatusRequestEntityTooLarge,
+			expectedResponseMessage: response.RequestBodyTooLarge + "\n",
+		},
+		{
+			name:                    "unsupported algorithm",
+			signatureConfig:         `{"secret":"signing-secret","header":"X-Signature","algorithm":"md5"}`,
+			headers:                 map[string]string{"X-Signature": "abc"},
+			expectedResponseCode:    http.StatusInternalServerError,
+			expectedResponseMessage: response.ErrAuthenticatingWebhookRequest + "\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var failures []string
+			webhookAuth := NewWebhookAuth(
+				func(w http.ResponseWriter, r *http.Request, errorMessage string, authCtx *gwtypes.AuthRequestContext) {
+					require.NotNil(t, authCtx)
+					failures = append(failures, errorMessage)
+					http.Error(w, errorMessage, response.GetErrorStatusCode(errorMessage))
+				},
+				func(writeKey string) (*gwtypes.AuthRequestContext, error) {
+					arctx := &gwtypes.AuthRequestContext{SourceCategory: "webhook", SourceEnabled: true}
+					arctx.SourceDetails.Config = []byte(`{"webhookSignature":` + tt.signatureConfig + `}`)
+					return arctx, nil
+				},
+				config.SingleValueLoader(len(payload)+tt.extraMaxReqSize),
+			)
+			webhookAuth.now = func() time.Time { return now }
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/webhook?writeKey=write-key", strings.NewReader(payload))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			resp := httptest.NewRecorder()
+			webhookAuth.AuthHandler(func(w http.ResponseWriter, r *http.Request) {
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				_, _ = w.Write(body)
+			}).ServeHTTP(resp, req)
+
+			require.Equal(t, tt.expectedResponseCode, resp.Code)
+			require.Equal(t, tt.expectedResponseMessage, resp.Body.String())
+			if tt.expectedResponseCode != http.StatusOK {
+				require.Equal(t, []string{strings.TrimSuffix(tt.expectedResponseMessage, "\n")}, failures)
+			}
+		})
+	}
+}
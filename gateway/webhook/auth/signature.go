@@ -0,0 +1,165 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+var (
+	// ErrInvalidSignature is returned when the signature of a webhook request is missing or doesn't match its payload
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	// ErrSignatureTimestampOutOfTolerance is returned when the timestamp of a signed webhook request is missing or too far from the current time
+	ErrSignatureTimestampOutOfTolerance = errors.New("webhook signature timestamp out of tolerance")
+)
+
+// signatureConfigKey is the key of the signature verification settings in a webhook source's config
+const signatureConfigKey = "webhookSignature"
+
+// SignatureConfig are the settings for verifying the HMAC signature of webhook requests, e.g.
+//
+//	"webhookSignature": {"secret": "...", "header": "X-Hub-Signature-256", "algorithm": "sha256"}
+//
+// The signature header may either hold the signature itself, hex or base64 encoded, optionally prefixed with the algorithm (sha256=...)
+// or a list of comma separated key value pairs with the timestamp under key t (t=1700000000,v1=...).
+// When a timestamp is available, either from the signature or from the timestamp header, the signed content is {timestamp}.{body}.
+type SignatureConfig struct {
+	Secret          string `json:"secret"`
+	Header          string `json:"header"`
+	Algorithm       string `json:"algorithm"` // sha1 or sha256 (default)
+	TimestampHeader string `json:"timestampHeader"`
+	// ToleranceInSeconds is the maximum difference between the current time and the signature's timestamp, for protecting against replays. Zero disables the check.
+	ToleranceInSeconds int64 `json:"toleranceInSeconds"`
+}
+
+// signatureConfigOf returns the signature verification settings in a source's config and whether signatures should be verified
+func signatureConfigOf(sourceConfig json.RawMessage) (config SignatureConfig, enabled bool, err error) {
+	raw := gjson.GetBytes(sourceConfig, signatureConfigKey)
+	if !raw.IsObject() {
+		return config, false, nil
+	}
+	if err := jsonrs.Unmarshal([]byte(raw.Raw), &config); err != nil {
+		return config, false, fmt.Errorf("unmarshalling signature config: %w", err)
+	}
+	if config.Secret == "" {
+		return config, false, nil
+	}
+	if config.Header == "" {
+		return config, false, errors.New("signature header is not configured")
+	}
+	if _, err := config.hash(); err != nil {
+		return config, false, err
+	}
+	return config, true, nil
+}
+
+func (c *SignatureConfig) hash() (func() hash.Hash, error) {
+	switch strings.ToLower(c.Algorithm) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil // skipcq: GO-S1025 some providers still sign webhooks using sha1
+	default:
+		return nil, fmt.Errorf("unsupported signature algorithm %q", c.Algorithm)
+	}
+}
+
+// Verify verifies the signature of the request against its body, which is restored so that it can be read again by the next handlers
+func (c *SignatureConfig) Verify(r *http.Request, now time.Time) error {
+	header := strings.TrimSpace(r.Header.Get(c.Header))
+	if header == "" {
+		return ErrInvalidSignature
+	}
+	signatures, timestamp := parseSignatureHeader(header)
+	if c.TimestampHeader != "" {
+		timestamp = strings.TrimSpace(r.Header.Get(c.TimestampHeader))
+	}
+	if c.ToleranceInSeconds > 0 {
+		ts, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil {
+			return ErrSignatureTimestampOutOfTolerance
+		}
+		if diff := now.Unix() - ts; diff > c.ToleranceInSeconds || diff < -c.ToleranceInSeconds {
+			return ErrSignatureTimestampOutOfTolerance
+		}
+	}
+
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return fmt.Errorf("reading request body: %w", err)
+		}
+		_ = r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	newHash, _ := c.hash()
+	mac := hmac.New(newHash, []byte(c.Secret))
+	if timestamp != "" {
+		mac.Write([]byte(timestamp + "."))
+	}
+	mac.Write(body)
+	expected := mac.Sum(nil)
+	for _, signature := range signatures {
+		if decoded, ok := decodeSignature(signature); ok && hmac.Equal(decoded, expected) {
+			return nil
+		}
+	}
+	return ErrInvalidSignature
+}
+
+// parseSignatureHeader returns the signatures and the timestamp (if any) contained in a signature header
+func parseSignatureHeader(header string) (signatures []string, timestamp string) {
+	if !strings.Contains(header, ",") || !strings.HasPrefix(header, "t=") && !strings.Contains(header, ",t=") {
+		return []string{stripAlgorithmPrefix(header)}, ""
+	}
+	for _, pair := range strings.Split(header, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found {
+			continue
+		}
+		if key == "t" {
+			timestamp = value
+			continue
+		}
+		signatures = append(signatures, value)
+	}
+	return signatures, timestamp
+}
+
+func stripAlgorithmPrefix(signature string) string {
+	for _, prefix := range []string{"sha256=", "sha1="} {
+		if strings.HasPrefix(strings.ToLower(signature), prefix) {
+			return signature[len(prefix):]
+		}
+	}
+	return signature
+}
+
+// decodeSignature decodes a hex or base64 encoded signature
+func decodeSignature(signature string) ([]byte, bool) {
+	if decoded, err := hex.DecodeString(signature); err == nil {
+		return decoded, true
+	}
+	if decoded, err := base64.StdEncoding.DecodeString(signature); err == nil {
+		return decoded, true
+	}
+	return nil, false
+}
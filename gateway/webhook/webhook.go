@@ -482,8 +482,8 @@ func (bt *batchWebhookTransformerT) batchTransformLoop() {
 func (bt *batchWebhookTransformerT) getWebhookFailureReason(errMessage, reason string) string {
 	if reason == "enqueueInGateway failed" {
 		switch errMessage {
-		case response.TooManyRequests:
-			return response.TooManyRequests
+		case response.TooManyRequests, response.TooManyRequestsForSource, response.TooManyRequestsForEvent:
+			return errMessage
 		case response.RequestBodyTooLarge:
 			return response.RequestBodyTooLarge
 		default:
@@ -0,0 +1,427 @@
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+const (
+	// ReplayedFromJobIDParam is the job parameter holding the id of the aborted job a replayed job originates from
+	ReplayedFromJobIDParam = "replayed_from_job_id"
+	// ReplayCountParam is the job parameter holding the number of times a job's original payload has been replayed
+	ReplayCountParam = "replay_count"
+	// ReplayedAsJobUUIDParam is the status parameter marking an aborted job as replayed, holding the uuid of the job it was replayed as
+	ReplayedAsJobUUIDParam = "replayed_as_job_uuid"
+)
+
+var errEmptyReplayFilter = errors.New("either jobIds or at least one filter needs to be provided for replaying jobs")
+
+// NewHandler creates a handler for listing and replaying aborted jobs of the provided jobsdbs, keyed by their identifier (e.g. rt, batch_rt)
+//
+//   - GET /{db}/jobs - lists aborted jobs matching the filter provided through query parameters, a page at a time
+//   - POST /{db}/replay - stores copies of aborted jobs as fresh jobs, either selected by their jobIds or all jobs matching the filter provided in the request body
+//
+// Aborted jobs can be filtered by workspaceId, destinationId, from & to (RFC3339 timestamps of the job's creation time) and errorCode.
+// Replayed jobs are marked as such, so that replaying the same jobs again doesn't store duplicate copies of them.
+func NewHandler(dbs map[string]jobsdb.JobsDB, conf *config.Config, log logger.Logger) http.Handler {
+	h := &handler{
+		dbs:          dbs,
+		logger:       log,
+		defaultLimit: conf.GetReloadableIntVar(100, 1, "DeadLetter.defaultLimit"),
+		batchSize:    conf.GetReloadableIntVar(1000, 1, "DeadLetter.batchSize"),
+	}
+	srvMux := chi.NewRouter()
+	srvMux.Get("/{db}/jobs", h.list)
+	srvMux.Post("/{db}/replay", h.replay)
+	return srvMux
+}
+
+type handler struct {
+	dbs          map[string]jobsdb.JobsDB
+	logger       logger.Logger
+	defaultLimit config.ValueLoader[int]
+	batchSize    config.ValueLoader[int] // number of aborted jobs queried at a time while paginating, which is also the number of jobs replayed per transaction
+	replayMu     sync.Mutex              // replays are serialised, so that concurrent replays of the same jobs don't store duplicate copies of them
+}
+
+// Filter selects the aborted jobs of a jobsdb
+type Filter struct {
+	WorkspaceID   string    `json:"workspaceId"`
+	DestinationID string    `json:"destinationId"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+	ErrorCode     string    `json:"errorCode"`
+	// Limit is the maximum number of jobs to be listed or replayed. Replays are unlimited by default.
+	Limit int `json:"limit"`
+}
+
+func (f Filter) empty() bool {
+	return f.WorkspaceID == "" && f.DestinationID == "" && f.From.IsZero() && f.To.IsZero() && f.ErrorCode == ""
+}
+
+func (f Filter) matches(job *jobsdb.JobT) bool {
+	if !f.From.IsZero() && job.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !job.CreatedAt.Before(f.To) {
+		return false
+	}
+	if f.ErrorCode != "" && job.LastJobStatus.ErrorCode != f.ErrorCode {
+		return false
+	}
+	return true
+}
+
+// ReplayRequest is the body of a replay request. If JobIDs are provided, only aborted jobs having one of these ids and matching the filter are replayed,
+// otherwise all aborted jobs matching the filter are replayed.
+type ReplayRequest struct {
+	Filter
+	JobIDs []int64 `json:"jobIds"`
+}
+
+// Job is an aborted job, along with the error it got aborted with
+type Job struct {
+	JobID         int64           `json:"jobId"`
+	UUID          uuid.UUID       `json:"uuid"`
+	UserID        string          `json:"userId"`
+	WorkspaceID   string          `json:"workspaceId"`
+	CustomVal     string          `json:"customVal"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	AbortedAt     time.Time       `json:"abortedAt"`
+	Attempts      int             `json:"attempts"`
+	ErrorCode     string          `json:"errorCode"`
+	ErrorResponse json.RawMessage `json:"errorResponse,omitempty"`
+	Parameters    json.RawMessage `json:"parameters,omitempty"`
+	EventPayload  json.RawMessage `json:"eventPayload,omitempty"`
+	// ReplayedAs is the uuid of the job this job has been replayed as, if any
+	ReplayedAs *uuid.UUID `json:"replayedAs,omitempty"`
+}
+
+// ListResponse is the response of a list request. If NextAfterJobID is set, the next page of jobs can be listed by passing it as the afterJobId query parameter.
+type ListResponse struct {
+	Jobs           []Job  `json:"jobs"`
+	NextAfterJobID *int64 `json:"nextAfterJobId,omitempty"`
+}
+
+// ReplayedJob links a replayed job to the aborted job it originates from
+type ReplayedJob struct {
+	JobID int64     `json:"jobId"`
+	UUID  uuid.UUID `json:"uuid"`
+}
+
+// ReplayResponse is the response of a replay request
+type ReplayResponse struct {
+	Replayed        []ReplayedJob `json:"replayed"`
+	AlreadyReplayed []int64       `json:"alreadyReplayed,omitempty"`
+	NotFound        []int64       `json:"notFound,omitempty"`
+}
+
+func (h *handler) list(w http.ResponseWriter, r *http.Request) {
+	db, ok := h.db(w, r)
+	if !ok {
+		return
+	}
+	filter, afterJobID, err := filterFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = h.defaultLimit.Load()
+	}
+	response := ListResponse{Jobs: make([]Job, 0)}
+	if err := h.scanAbortedJobs(r.Context(), db, filter, nil, afterJobID, func(jobs []*jobsdb.JobT) (bool, error) {
+		for _, job := range jobs {
+			response.Jobs = append(response.Jobs, Job{
+				JobID:         job.JobID,
+				UUID:          job.UUID,
+				UserID:        job.UserID,
+				WorkspaceID:   job.WorkspaceId,
+				CustomVal:     job.CustomVal,
+				CreatedAt:     job.CreatedAt,
+				AbortedAt:     job.LastJobStatus.ExecTime,
+				Attempts:      job.LastJobStatus.AttemptNum,
+				ErrorCode:     job.LastJobStatus.ErrorCode,
+				ErrorResponse: validJSON(job.LastJobStatus.ErrorResponse),
+				Parameters:    validJSON(job.Parameters),
+				EventPayload:  validJSON(job.EventPayload),
+				ReplayedAs:    replayedAs(job),
+			})
+			if len(response.Jobs) == limit {
+				response.NextAfterJobID = &job.JobID
+				return false, nil
+			}
+		}
+		return true, nil
+	}); err != nil {
+		h.logger.Errorn("listing aborted jobs", logger.NewStringField("db", db.Identifier()), logger.NewErrorField(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.writeResponse(w, response)
+}
+
+func (h *handler) replay(w http.ResponseWriter, r *http.Request) {
+	db, ok := h.db(w, r)
+	if !ok {
+		return
+	}
+	var req ReplayRequest
+	if err := jsonrs.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.JobIDs) == 0 && req.empty() {
+		http.Error(w, errEmptyReplayFilter.Error(), http.StatusBadRequest)
+		return
+	}
+	jobIDs := lo.Uniq(req.JobIDs)
+	slices.Sort(jobIDs)
+
+	h.replayMu.Lock()
+	defer h.replayMu.Unlock()
+	response := ReplayResponse{Replayed: make([]ReplayedJob, 0)}
+	found := make(map[int64]struct{})
+	err := h.scanAbortedJobs(r.Context(), db, req.Filter, jobIDs, nil, func(jobs []*jobsdb.JobT) (bool, error) {
+		var replayJobs []*jobsdb.JobT
+		var statusList []*jobsdb.JobStatusT
+		var replayed []ReplayedJob
+		for _, job := range jobs {
+			found[job.JobID] = struct{}{}
+			if replayedAs(job) != nil {
+				response.AlreadyReplayed = append(response.AlreadyReplayed, job.JobID)
+				continue
+			}
+			if req.Limit > 0 && len(response.Replayed)+len(replayed) == req.Limit {
+				break
+			}
+			replayJob, err := newReplayJob(job)
+			if err != nil {
+				return false, err
+			}
+			replayJobs = append(replayJobs, replayJob)
+			statusList = append(statusList, replayedStatus(job, replayJob.UUID))
+			replayed = append(replayed, ReplayedJob{JobID: job.JobID, UUID: replayJob.UUID})
+		}
+		if len(replayJobs) > 0 {
+			if err := h.storeReplayJobs(r.Context(), db, replayJobs, statusList); err != nil {
+				return false, err
+			}
+			response.Replayed = append(response.Replayed, replayed...)
+		}
+		return req.Limit <= 0 || len(response.Replayed) < req.Limit, nil
+	})
+	if err != nil {
+		h.logger.Errorn("replaying aborted jobs", logger.NewStringField("db", db.Identifier()), logger.NewIntField("replayed", int64(len(response.Replayed))), logger.NewErrorField(err))
+		http.Error(w, fmt.Sprintf("replayed %d jobs before failing: %v", len(response.Replayed), err), http.StatusInternalServerError)
+		return
+	}
+	if req.Limit <= 0 || len(response.Replayed) < req.Limit {
+		for _, jobID := range jobIDs {
+			if _, ok := found[jobID]; !ok {
+				response.NotFound = append(response.NotFound, jobID)
+			}
+		}
+	}
+	h.logger.Infon("replayed aborted jobs", logger.NewStringField("db", db.Identifier()), logger.NewIntField("count", int64(len(response.Replayed))))
+	h.writeResponse(w, response)
+}
+
+// storeReplayJobs stores the replay jobs and marks the aborted jobs they originate from as replayed, in the same transaction
+func (h *handler) storeReplayJobs(ctx context.Context, db jobsdb.JobsDB, replayJobs []*jobsdb.JobT, statusList []*jobsdb.JobStatusT) error {
+	customValFilters := lo.Uniq(lo.Map(replayJobs, func(job *jobsdb.JobT, _ int) string { return job.CustomVal }))
+	return db.WithUpdateSafeTx(ctx, func(tx jobsdb.UpdateSafeTx) error {
+		if err := db.UpdateJobStatusInTx(ctx, tx, statusList, customValFilters, nil); err != nil {
+			return fmt.Errorf("marking jobs as replayed: %w", err)
+		}
+		return db.WithStoreSafeTxFromTx(ctx, tx.Tx(), func(tx jobsdb.StoreSafeTx) error {
+			if err := db.StoreInTx(ctx, tx, replayJobs); err != nil {
+				return fmt.Errorf("storing replayed jobs: %w", err)
+			}
+			return nil
+		})
+	})
+}
+
+// db returns the jobsdb identified by the db url parameter, responding with not found if there is no such jobsdb
+func (h *handler) db(w http.ResponseWriter, r *http.Request) (jobsdb.JobsDB, bool) {
+	name := chi.URLParam(r, "db")
+	db, ok := h.dbs[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown jobsdb %q", name), http.StatusNotFound)
+	}
+	return db, ok
+}
+
+// scanAbortedJobs calls f with batches of aborted jobs matching the filter, in job ID order, until f returns false or there are no more jobs.
+// If jobIDs are provided, only these jobs are looked up, otherwise all aborted jobs having a job ID greater than afterJobID (if set) are scanned.
+// Workspace and destination filters are applied by jobsdb, whereas the rest are applied on the scanned jobs.
+func (h *handler) scanAbortedJobs(ctx context.Context, db jobsdb.JobsDB, filter Filter, jobIDs []int64, afterJobID *int64, f func(jobs []*jobsdb.JobT) (bool, error)) error {
+	batchSize := h.batchSize.Load()
+	params := jobsdb.GetQueryParams{
+		WorkspaceID: filter.WorkspaceID,
+		JobsLimit:   batchSize,
+		AfterJobID:  afterJobID,
+	}
+	if filter.DestinationID != "" {
+		params.ParameterFilters = []jobsdb.ParameterFilterT{{Name: "destination_id", Value: filter.DestinationID}}
+	}
+	next := func() (bool, error) {
+		result, err := db.GetAborted(ctx, params)
+		if err != nil {
+			return false, fmt.Errorf("getting aborted jobs: %w", err)
+		}
+		if len(result.Jobs) > 0 {
+			params.AfterJobID = &result.Jobs[len(result.Jobs)-1].JobID
+		}
+		more, err := f(lo.Filter(result.Jobs, func(job *jobsdb.JobT, _ int) bool { return filter.matches(job) }))
+		return more && len(result.Jobs) == batchSize, err
+	}
+
+	if len(jobIDs) > 0 {
+		for chunk := range slices.Chunk(jobIDs, batchSize) {
+			params.JobIDs = chunk
+			if more, err := next(); err != nil || !more {
+				return err
+			}
+		}
+		return nil
+	}
+	for {
+		if more, err := next(); err != nil || !more {
+			return err
+		}
+	}
+}
+
+func (h *handler) writeResponse(w http.ResponseWriter, response any) {
+	body, err := jsonrs.Marshal(response)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if _, err := w.Write(body); err != nil {
+		h.logger.Errorf("error while writing response body: %v", err)
+	}
+}
+
+// newReplayJob returns a fresh copy of an aborted job, having its parameters extended with the job's retry lineage
+func newReplayJob(job *jobsdb.JobT) (*jobsdb.JobT, error) {
+	parameters := job.Parameters
+	if !gjson.ValidBytes(parameters) {
+		parameters = []byte(`{}`)
+	}
+	parameters, err := sjson.SetBytes(parameters, ReplayedFromJobIDParam, job.JobID)
+	if err != nil {
+		return nil, fmt.Errorf("setting %s parameter: %w", ReplayedFromJobIDParam, err)
+	}
+	parameters, err = sjson.SetBytes(parameters, ReplayCountParam, gjson.GetBytes(parameters, ReplayCountParam).Int()+1)
+	if err != nil {
+		return nil, fmt.Errorf("setting %s parameter: %w", ReplayCountParam, err)
+	}
+	return &jobsdb.JobT{
+		UUID:         uuid.New(),
+		UserID:       job.UserID,
+		CustomVal:    job.CustomVal,
+		EventCount:   job.EventCount,
+		EventPayload: job.EventPayload,
+		Parameters:   parameters,
+		WorkspaceId:  job.WorkspaceId,
+	}, nil
+}
+
+// replayedAs returns the uuid of the job an aborted job has been replayed as, or nil if it hasn't been replayed
+func replayedAs(job *jobsdb.JobT) *uuid.UUID {
+	v := gjson.GetBytes(job.LastJobStatus.Parameters, ReplayedAsJobUUIDParam).String()
+	if v == "" {
+		return nil
+	}
+	replayedAs, err := uuid.Parse(v)
+	if err != nil {
+		return nil
+	}
+	return &replayedAs
+}
+
+// replayedStatus returns a status for marking an aborted job as replayed, which keeps the job aborted along with its error
+func replayedStatus(job *jobsdb.JobT, replayedAs uuid.UUID) *jobsdb.JobStatusT {
+	errorResponse := job.LastJobStatus.ErrorResponse
+	if !gjson.ValidBytes(errorResponse) {
+		errorResponse = []byte(`{}`)
+	}
+	now := time.Now()
+	return &jobsdb.JobStatusT{
+		JobID:         job.JobID,
+		JobState:      jobsdb.Aborted.State,
+		AttemptNum:    job.LastJobStatus.AttemptNum,
+		ExecTime:      now,
+		RetryTime:     now,
+		ErrorCode:     job.LastJobStatus.ErrorCode,
+		ErrorResponse: errorResponse,
+		Parameters:    []byte(fmt.Sprintf(`{%q:%q}`, ReplayedAsJobUUIDParam, replayedAs.String())),
+		JobParameters: job.Parameters,
+		WorkspaceId:   job.WorkspaceId,
+	}
+}
+
+func filterFromQuery(r *http.Request) (Filter, *int64, error) {
+	query := r.URL.Query()
+	filter := Filter{
+		WorkspaceID:   query.Get("workspaceId"),
+		DestinationID: query.Get("destinationId"),
+		ErrorCode:     query.Get("errorCode"),
+	}
+	var err error
+	if v := query.Get("from"); v != "" {
+		if filter.From, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, nil, fmt.Errorf("invalid from: %w", err)
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if filter.To, err = time.Parse(time.RFC3339, v); err != nil {
+			return filter, nil, fmt.Errorf("invalid to: %w", err)
+		}
+	}
+	if v := query.Get("limit"); v != "" {
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			return filter, nil, fmt.Errorf("invalid limit: %w", err)
+		}
+	}
+	var afterJobID *int64
+	if v := query.Get("afterJobId"); v != "" {
+		jobID, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return filter, nil, fmt.Errorf("invalid afterJobId: %w", err)
+		}
+		afterJobID = &jobID
+	}
+	return filter, afterJobID, nil
+}
+
+func validJSON(raw json.RawMessage) json.RawMessage {
+	if len(raw) == 0 || !gjson.ValidBytes(raw) {
+		return nil
+	}
+	return raw
+}
@@ -0,0 +1,250 @@
+package deadletter_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/internal/deadletter"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+	"github.com/rudderlabs/rudder-server/utils/tx"
+)
+
+func TestHandler(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	abortedJobs := []*jobsdb.JobT{
+		{
+			JobID: 1, UUID: uuid.New(), UserID: "user-1", WorkspaceId: "ws-1", CustomVal: "WEBHOOK", EventCount: 1, CreatedAt: now.Add(-2 * time.Hour),
+			EventPayload:  []byte(`{"event":"a"}`),
+			Parameters:    []byte(`{"destination_id":"dest-1"}`),
+			LastJobStatus: jobsdb.JobStatusT{ErrorCode: "500", AttemptNum: 3, ErrorResponse: []byte(`{"reason":"internal error"}`)},
+		},
+		{
+			JobID: 2, UUID: uuid.New(), UserID: "user-2", WorkspaceId: "ws-1", CustomVal: "WEBHOOK", EventCount: 1, CreatedAt: now.Add(-time.Hour),
+			EventPayload:  []byte(`{"event":"b"}`),
+			Parameters:    []byte(`{"destination_id":"dest-1","replayed_from_job_id":0,"replay_count":1}`),
+			LastJobStatus: jobsdb.JobStatusT{ErrorCode: "400", AttemptNum: 1},
+		},
+		{
+			JobID: 3, UUID: uuid.New(), UserID: "user-3", WorkspaceId: "ws-1", CustomVal: "WEBHOOK", EventCount: 1, CreatedAt: now,
+			EventPayload:  []byte(`{"event":"c"}`),
+			Parameters:    []byte(`{"destination_id":"dest-1"}`),
+			LastJobStatus: jobsdb.JobStatusT{ErrorCode: "500", AttemptNum: 3},
+		},
+	}
+
+	setupWithConfig := func(t *testing.T, conf *config.Config) (*mocksJobsDB.MockJobsDB, http.Handler) {
+		db := mocksJobsDB.NewMockJobsDB(gomock.NewController(t))
+		db.EXPECT().Identifier().Return("rt").AnyTimes()
+		return db, deadletter.NewHandler(map[string]jobsdb.JobsDB{"rt": db}, conf, logger.NOP)
+	}
+	setup := func(t *testing.T) (*mocksJobsDB.MockJobsDB, http.Handler) {
+		return setupWithConfig(t, config.New())
+	}
+	// expectStore expects the replayed jobs to be stored along with the statuses marking the aborted jobs as replayed, in the same transaction
+	expectStore := func(db *mocksJobsDB.MockJobsDB, stored *[]*jobsdb.JobT, marked *[]*jobsdb.JobStatusT) {
+		db.EXPECT().WithUpdateSafeTx(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, f func(jobsdb.UpdateSafeTx) error) error {
+			return f(jobsdb.EmptyUpdateSafeTx())
+		})
+		db.EXPECT().UpdateJobStatusInTx(gomock.Any(), gomock.Any(), gomock.Any(), []string{"WEBHOOK"}, gomock.Any()).DoAndReturn(func(_ context.Context, _ jobsdb.UpdateSafeTx, statusList []*jobsdb.JobStatusT, _ []string, _ []jobsdb.ParameterFilterT) error {
+			*marked = append(*marked, statusList...)
+			return nil
+		})
+		db.EXPECT().WithStoreSafeTxFromTx(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ *tx.Tx, f func(jobsdb.StoreSafeTx) error) error {
+			return f(jobsdb.EmptyStoreSafeTx())
+		})
+		db.EXPECT().StoreInTx(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) error {
+			*stored = append(*stored, jobs...)
+			return nil
+		})
+	}
+	do := func(handler http.Handler, method, target, body string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(method, target, strings.NewReader(body)))
+		return resp
+	}
+
+	t.Run("list aborted jobs with filters", func(t *testing.T) {
+		db, handler := setup(t)
+		db.EXPECT().GetAborted(gomock.Any(), jobsdb.GetQueryParams{
+			WorkspaceID:      "ws-1",
+			ParameterFilters: []jobsdb.ParameterFilterT{{Name: "destination_id", Value: "dest-1"}},
+			JobsLimit:        1000,
+		}).Return(jobsdb.JobsResult{Jobs: abortedJobs}, nil)
+
+		resp := do(handler, http.MethodGet, "/rt/jobs?workspaceId=ws-1&destinationId=dest-1&errorCode=500&to="+now.Format(time.RFC3339), "")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+
+		var listResponse deadletter.ListResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &listResponse))
+		require.Len(t, listResponse.Jobs, 1)
+		require.EqualValues(t, 1, listResponse.Jobs[0].JobID)
+		require.Equal(t, "500", listResponse.Jobs[0].ErrorCode)
+		require.Equal(t, 3, listResponse.Jobs[0].Attempts)
+		require.JSONEq(t, `{"reason":"internal error"}`, string(listResponse.Jobs[0].ErrorResponse))
+		require.JSONEq(t, `{"event":"a"}`, string(listResponse.Jobs[0].EventPayload))
+		require.Nil(t, listResponse.NextAfterJobID, "there are no more jobs to list")
+	})
+
+	t.Run("list honours the limit", func(t *testing.T) {
+		db, handler := setup(t)
+		db.EXPECT().GetAborted(gomock.Any(), gomock.Any()).Return(jobsdb.JobsResult{Jobs: abortedJobs}, nil)
+
+		resp := do(handler, http.MethodGet, "/rt/jobs?limit=2", "")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var listResponse deadletter.ListResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &listResponse))
+		require.Len(t, listResponse.Jobs, 2)
+		require.NotNil(t, listResponse.NextAfterJobID)
+		require.EqualValues(t, 2, *listResponse.NextAfterJobID)
+	})
+
+	t.Run("list pages through all jobs matching the filter", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("DeadLetter.batchSize", 1)
+		db, handler := setupWithConfig(t, conf)
+		afterJobID := func(jobID int64) any {
+			return gomock.Cond(func(params jobsdb.GetQueryParams) bool {
+				return params.JobsLimit == 1 && params.AfterJobID != nil && *params.AfterJobID == jobID
+			})
+		}
+		gomock.InOrder(
+			db.EXPECT().GetAborted(gomock.Any(), afterJobID(1)).Return(jobsdb.JobsResult{Jobs: abortedJobs[1:2]}, nil),
+			db.EXPECT().GetAborted(gomock.Any(), afterJobID(2)).Return(jobsdb.JobsResult{Jobs: abortedJobs[2:3]}, nil),
+			db.EXPECT().GetAborted(gomock.Any(), afterJobID(3)).Return(jobsdb.JobsResult{}, nil),
+		)
+
+		resp := do(handler, http.MethodGet, "/rt/jobs?errorCode=500&afterJobId=1", "")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var listResponse deadletter.ListResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &listResponse))
+		require.Len(t, listResponse.Jobs, 1, "jobs beyond the first batch should be listed")
+		require.EqualValues(t, 3, listResponse.Jobs[0].JobID)
+		require.Nil(t, listResponse.NextAfterJobID)
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, handler := setup(t)
+		require.Equal(t, http.StatusNotFound, do(handler, http.MethodGet, "/gw/jobs", "").Code)
+		require.Equal(t, http.StatusBadRequest, do(handler, http.MethodGet, "/rt/jobs?from=yesterday", "").Code)
+		require.Equal(t, http.StatusBadRequest, do(handler, http.MethodGet, "/rt/jobs?limit=ten", "").Code)
+		require.Equal(t, http.StatusBadRequest, do(handler, http.MethodGet, "/rt/jobs?afterJobId=ten", "").Code)
+		require.Equal(t, http.StatusBadRequest, do(handler, http.MethodPost, "/rt/replay", `{`).Code)
+		require.Equal(t, http.StatusBadRequest, do(handler, http.MethodPost, "/rt/replay", `{}`).Code, "replaying without any filter is not allowed")
+	})
+
+	t.Run("replay selected jobs", func(t *testing.T) {
+		db, handler := setup(t)
+		db.EXPECT().GetAborted(gomock.Any(), jobsdb.GetQueryParams{JobIDs: []int64{2, 3, 4}, JobsLimit: 1000}).Return(jobsdb.JobsResult{Jobs: abortedJobs[1:]}, nil)
+		var stored []*jobsdb.JobT
+		var marked []*jobsdb.JobStatusT
+		expectStore(db, &stored, &marked)
+
+		resp := do(handler, http.MethodPost, "/rt/replay", `{"jobIds":[4,3,2,3]}`)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var replayResponse deadletter.ReplayResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &replayResponse))
+		require.Equal(t, []int64{4}, replayResponse.NotFound)
+		require.Len(t, replayResponse.Replayed, 2)
+		require.Len(t, stored, 2)
+
+		for i, job := range stored {
+			original := abortedJobs[i+1]
+			require.Equal(t, original.JobID, replayResponse.Replayed[i].JobID)
+			require.Equal(t, job.UUID, replayResponse.Replayed[i].UUID)
+			require.NotEqual(t, original.UUID, job.UUID, "replayed jobs should be fresh jobs")
+			require.Equal(t, original.UserID, job.UserID)
+			require.Equal(t, original.WorkspaceId, job.WorkspaceId)
+			require.Equal(t, original.CustomVal, job.CustomVal)
+			require.Equal(t, original.EventPayload, job.EventPayload)
+			require.Equal(t, "dest-1", gjson.GetBytes(job.Parameters, "destination_id").String())
+			require.Equal(t, original.JobID, gjson.GetBytes(job.Parameters, deadletter.ReplayedFromJobIDParam).Int())
+		}
+		require.EqualValues(t, 2, gjson.GetBytes(stored[0].Parameters, deadletter.ReplayCountParam).Int(), "replay count should be incremented")
+		require.EqualValues(t, 1, gjson.GetBytes(stored[1].Parameters, deadletter.ReplayCountParam).Int())
+
+		require.Len(t, marked, 2, "replayed jobs should be marked as such")
+		for i, status := range marked {
+			require.Equal(t, abortedJobs[i+1].JobID, status.JobID)
+			require.Equal(t, jobsdb.Aborted.State, status.JobState)
+			require.Equal(t, abortedJobs[i+1].LastJobStatus.ErrorCode, status.ErrorCode)
+			require.Equal(t, stored[i].UUID.String(), gjson.GetBytes(status.Parameters, deadletter.ReplayedAsJobUUIDParam).String())
+		}
+	})
+
+	t.Run("replay jobs matching a filter", func(t *testing.T) {
+		db, handler := setup(t)
+		db.EXPECT().GetAborted(gomock.Any(), gomock.Any()).Return(jobsdb.JobsResult{Jobs: abortedJobs}, nil)
+		var stored []*jobsdb.JobT
+		var marked []*jobsdb.JobStatusT
+		expectStore(db, &stored, &marked)
+
+		resp := do(handler, http.MethodPost, "/rt/replay", `{"workspaceId":"ws-1","errorCode":"500"}`)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var replayResponse deadletter.ReplayResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &replayResponse))
+		require.Len(t, replayResponse.Replayed, 2)
+		require.EqualValues(t, 1, replayResponse.Replayed[0].JobID)
+		require.EqualValues(t, 3, replayResponse.Replayed[1].JobID)
+		require.Len(t, stored, 2)
+	})
+
+	t.Run("replay pages through all jobs matching a filter", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("DeadLetter.batchSize", 2)
+		db, handler := setupWithConfig(t, conf)
+		gomock.InOrder(
+			db.EXPECT().GetAborted(gomock.Any(), gomock.Cond(func(params jobsdb.GetQueryParams) bool { return params.AfterJobID == nil })).Return(jobsdb.JobsResult{Jobs: abortedJobs[:2]}, nil),
+			db.EXPECT().GetAborted(gomock.Any(), gomock.Cond(func(params jobsdb.GetQueryParams) bool { return *params.AfterJobID == 2 })).Return(jobsdb.JobsResult{Jobs: abortedJobs[2:]}, nil),
+		)
+		var stored []*jobsdb.JobT
+		var marked []*jobsdb.JobStatusT
+		expectStore(db, &stored, &marked)
+		expectStore(db, &stored, &marked)
+
+		resp := do(handler, http.MethodPost, "/rt/replay", `{"workspaceId":"ws-1"}`)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var replayResponse deadletter.ReplayResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &replayResponse))
+		require.Len(t, replayResponse.Replayed, 3)
+		require.Len(t, stored, 3)
+		require.Len(t, marked, 3)
+	})
+
+	t.Run("jobs are replayed only once", func(t *testing.T) {
+		replayedAs := uuid.New()
+		replayedJob := *abortedJobs[0]
+		replayedJob.LastJobStatus.Parameters = []byte(`{"` + deadletter.ReplayedAsJobUUIDParam + `":"` + replayedAs.String() + `"}`)
+
+		db, handler := setup(t)
+		db.EXPECT().GetAborted(gomock.Any(), gomock.Any()).Return(jobsdb.JobsResult{Jobs: []*jobsdb.JobT{&replayedJob}}, nil)
+
+		resp := do(handler, http.MethodPost, "/rt/replay", `{"jobIds":[1]}`)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var replayResponse deadletter.ReplayResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &replayResponse))
+		require.Empty(t, replayResponse.Replayed)
+		require.Equal(t, []int64{1}, replayResponse.AlreadyReplayed)
+		require.Empty(t, replayResponse.NotFound)
+
+		db.EXPECT().GetAborted(gomock.Any(), gomock.Any()).Return(jobsdb.JobsResult{Jobs: []*jobsdb.JobT{&replayedJob}}, nil)
+		resp = do(handler, http.MethodGet, "/rt/jobs", "")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var listResponse deadletter.ListResponse
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &listResponse))
+		require.Len(t, listResponse.Jobs, 1)
+		require.Equal(t, &replayedAs, listResponse.Jobs[0].ReplayedAs)
+	})
+}
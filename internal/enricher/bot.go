@@ -1,62 +1,288 @@
 package enricher
 
 import (
+	"context"
 	"errors"
+	"os"
+	"sync"
+	"time"
 
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/enricher/botdetector"
 	"github.com/rudderlabs/rudder-server/processor/types"
 )
 
+// Actions taken for events detected as coming from bots
+const (
+	// BotActionFlag flags bot events in context.isBot and context.bot
+	BotActionFlag = "flag"
+	// BotActionDrop drops bot events, which are kept in the batch flagged with this action so that they can be reported before being discarded, see [IsDroppedBotEvent]
+	BotActionDrop = "drop"
+	// BotActionRoute flags bot events, which are then only sent to destinations accepting bot events, see [IsRoutedBotEvent]
+	BotActionRoute = "route"
+)
+
 type botDetails struct {
 	Name             string `json:"name,omitempty"`
 	URL              string `json:"url,omitempty"`
 	IsInvalidBrowser bool   `json:"isInvalidBrowser,omitempty"`
+	Category         string `json:"category,omitempty"`
+	Action           string `json:"action,omitempty"`
+
+	detected bool // whether the bot was detected by the enricher, rather than upstream
+}
+
+type botEnricher struct {
+	log   logger.Logger
+	stats stats.Stats
+	conf  *config.Config
+
+	detectionEnabled config.ValueLoader[bool]
+	detector         *botdetector.Detector
+
+	actionsMu sync.RWMutex
+	actions   map[string]config.ValueLoader[string] // map key is the workspace id along with the source id
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewBotEnricher creates an enricher flagging events of bots.
+// Events are either classified as bots upstream, through the event params, or by the enricher itself using the rules of [botdetector],
+// if detection is enabled. The action taken for the latter can be configured per source or workspace, e.g. BotEnrichment.<sourceID>.action
+func NewBotEnricher(conf *config.Config, log logger.Logger, statsFactory stats.Stats) (PipelineEnricher, error) {
+	var (
+		rulesFile      = conf.GetString("BotEnrichment.detection.rulesFile", "")
+		replaceRules   = conf.GetBool("BotEnrichment.detection.replaceEmbeddedRules", false)
+		reloadInterval = conf.GetDuration("BotEnrichment.detection.rulesReloadInterval", 5, time.Minute)
+	)
+	var rulesModTime time.Time
+	rules, err := botdetector.EmbeddedRules()
+	if rulesFile != "" {
+		if info, statErr := os.Stat(rulesFile); statErr == nil {
+			rulesModTime = info.ModTime()
+		}
+		rules, err = botdetector.ReadRulesFile(rulesFile, replaceRules)
+	}
+	if err != nil {
+		return nil, err
+	}
+	detector, err := botdetector.New(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &botEnricher{
+		log:              log.Child("bot"),
+		stats:            statsFactory,
+		conf:             conf,
+		detectionEnabled: conf.GetReloadableBoolVar(false, "BotEnrichment.detection.enabled"),
+		detector:         detector,
+		actions:          make(map[string]config.ValueLoader[string]),
+		cancel:           cancel,
+	}
+	if rulesFile != "" && reloadInterval > 0 {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.reloadRules(ctx, rulesFile, replaceRules, rulesModTime, reloadInterval)
+		}()
+	}
+	return e, nil
 }
 
-type botEnricher struct{}
+// reloadRules updates the rules of the detector whenever the rules file gets modified
+func (e *botEnricher) reloadRules(ctx context.Context, rulesFile string, replaceRules bool, modTime time.Time, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(rulesFile)
+		if err != nil {
+			e.log.Warnn("checking bot detection rules file", obskit.Error(err))
+			continue
+		}
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+		rules, err := botdetector.ReadRulesFile(rulesFile, replaceRules)
+		if err == nil {
+			err = e.detector.Update(rules)
+		}
+		if err != nil {
+			e.log.Errorn("reloading bot detection rules, keeping the previous ones", obskit.Error(err))
+			continue
+		}
+		modTime = info.ModTime()
+		e.log.Infon("reloaded bot detection rules", logger.NewStringField("path", rulesFile))
+	}
+}
 
-func NewBotEnricher() (PipelineEnricher, error) {
-	return &botEnricher{}, nil
+// actionOf returns the action to take for bot events of a source
+func (e *botEnricher) actionOf(source *backendconfig.SourceT) string {
+	var key string
+	keys := []string{"BotEnrichment.action"}
+	if source != nil {
+		key = source.WorkspaceID + ":" + source.ID
+		keys = []string{
+			"BotEnrichment." + source.ID + ".action",
+			"BotEnrichment." + source.WorkspaceID + ".action",
+			"BotEnrichment.action",
+		}
+	}
+	e.actionsMu.RLock()
+	action, ok := e.actions[key]
+	e.actionsMu.RUnlock()
+	if !ok {
+		e.actionsMu.Lock()
+		if action, ok = e.actions[key]; !ok {
+			action = e.conf.GetReloadableStringVar(BotActionFlag, keys...)
+			e.actions[key] = action
+		}
+		e.actionsMu.Unlock()
+	}
+	switch a := action.Load(); a {
+	case BotActionDrop, BotActionRoute:
+		return a
+	default:
+		return BotActionFlag
+	}
 }
 
-func (e *botEnricher) Enrich(_ *backendconfig.SourceT, request *types.GatewayBatchRequest, eventParams *types.EventParams) error {
+func (e *botEnricher) Enrich(source *backendconfig.SourceT, request *types.GatewayBatchRequest, eventParams *types.EventParams) error {
 	var enrichErrs []error
+	detectionEnabled := e.detectionEnabled.Load()
+	batch := request.Batch[:0]
 	for _, event := range request.Batch {
-		// if the event is not a bot, we don't need to enrich it
-		if !eventParams.IsBot {
+		if eventParams.IsBot {
+			// BotAction empty check is for backward compatibility, BotAction field might be absent indicating ingestion service is not released with BotAction field
+			// TODO: remove the empty check after ingestion service is released with BotAction field
+			if eventParams.BotAction == BotActionFlag || eventParams.BotAction == "" {
+				enrichErrs = append(enrichErrs, flagBotEvent(event, botDetails{
+					Name:             eventParams.BotName,
+					URL:              eventParams.BotURL,
+					IsInvalidBrowser: eventParams.BotIsInvalidBrowser,
+				}))
+			}
+			batch = append(batch, event)
 			continue
 		}
 
-		// BotAction empty check is for backward compatibility, BotAction field might be absent indicating ingestion service is not released with BotAction field
-		// TODO: remove the empty check after ingestion service is released with BotAction field
-		if eventParams.BotAction != "flag" && eventParams.BotAction != "" {
+		// if the event is not a bot and detection is disabled, we don't need to enrich it
+		if !detectionEnabled {
+			batch = append(batch, event)
 			continue
 		}
-
-		// if the context section is missing on the event
-		// set it with default as map[string]any
-		if _, ok := event["context"]; !ok {
-			event["context"] = map[string]any{}
+		eventContext, _ := event["context"].(map[string]any)
+		userAgent, _ := eventContext["userAgent"].(string)
+		ip, _ := eventContext["ip"].(string)
+		if ip == "" {
+			ip = request.RequestIP
 		}
-
-		// if the context is other than map[string]any, add error and continue
-		context, ok := event["context"].(map[string]any)
-		if !ok {
-			enrichErrs = append(enrichErrs, errors.New("event doesn't have a valid context section"))
+		bot, isBot := e.detector.Detect(userAgent, ip)
+		if !isBot {
+			batch = append(batch, event)
 			continue
 		}
 
-		context["isBot"] = true
-		context["bot"] = botDetails{
-			Name:             eventParams.BotName,
-			URL:              eventParams.BotURL,
-			IsInvalidBrowser: eventParams.BotIsInvalidBrowser,
+		action := e.actionOf(source)
+		e.detectedEventsStat(source, bot.Category, action).Increment()
+		details := botDetails{Name: bot.Name, URL: bot.URL, Category: bot.Category, detected: true}
+		if action == BotActionRoute || action == BotActionDrop {
+			details.Action = action
+		}
+		if err := flagBotEvent(event, details); err != nil {
+			enrichErrs = append(enrichErrs, err)
+			if action == BotActionDrop {
+				continue // the event can't be flagged, so it is dropped without being reported
+			}
 		}
+		batch = append(batch, event)
 	}
+	request.Batch = batch
 
 	return errors.Join(enrichErrs...)
 }
 
+// flagBotEvent sets context.isBot and context.bot of the event
+func flagBotEvent(event types.SingularEventT, details botDetails) error {
+	// if the context section is missing on the event
+	// set it with default as map[string]any
+	if _, ok := event["context"]; !ok {
+		event["context"] = map[string]any{}
+	}
+
+	// if the context is other than map[string]any, return an error
+	context, ok := event["context"].(map[string]any)
+	if !ok {
+		return errors.New("event doesn't have a valid context section")
+	}
+
+	context["isBot"] = true
+	context["bot"] = details
+	return nil
+}
+
+func (e *botEnricher) detectedEventsStat(source *backendconfig.SourceT, category, action string) stats.Measurement {
+	tags := stats.Tags{"category": category, "action": action}
+	if source != nil {
+		tags["sourceId"] = source.ID
+		tags["workspaceId"] = source.WorkspaceID
+		tags["sourceType"] = source.SourceDefinition.Type
+	}
+	return e.stats.NewTaggedStat("proc_bot_enricher_detected_events", stats.CountType, tags)
+}
+
 func (e *botEnricher) Close() error {
+	e.cancel()
+	e.wg.Wait()
 	return nil
 }
+
+// IsRoutedBotEvent returns whether the event was detected as coming from a bot of a source whose bot events are routed,
+// i.e. they should only be sent to destinations accepting bot events
+func IsRoutedBotEvent(event types.SingularEventT) bool {
+	eventContext, ok := event["context"].(map[string]any)
+	if !ok {
+		return false
+	}
+	switch bot := eventContext["bot"].(type) {
+	case botDetails:
+		return bot.Action == BotActionRoute
+	case map[string]any:
+		return bot["action"] == BotActionRoute
+	default:
+		return false
+	}
+}
+
+// IsDroppedBotEvent returns whether the event was detected by the enricher as coming from a bot of a source whose bot events are dropped.
+// Such events are kept in the batch so that they can be reported, but should be discarded afterwards.
+func IsDroppedBotEvent(event types.SingularEventT) bool {
+	bot, ok := detectedBotOf(event)
+	return ok && bot.Action == BotActionDrop
+}
+
+// IsDetectedBotEvent returns whether the event was detected by the enricher as coming from a bot, rather than being classified upstream
+func IsDetectedBotEvent(event types.SingularEventT) bool {
+	_, ok := detectedBotOf(event)
+	return ok
+}
+
+func detectedBotOf(event types.SingularEventT) (botDetails, bool) {
+	eventContext, ok := event["context"].(map[string]any)
+	if !ok {
+		return botDetails{}, false
+	}
+	bot, ok := eventContext["bot"].(botDetails)
+	return bot, ok && bot.detected
+}
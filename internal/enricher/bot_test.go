@@ -1,10 +1,20 @@
 package enricher
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/processor/types"
 )
 
@@ -316,7 +326,7 @@ func TestBotEnricher(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			enricher, err := NewBotEnricher()
+			enricher, err := NewBotEnricher(config.New(), logger.NOP, stats.NOP)
 			require.NoError(t, err)
 
 			err = enricher.Enrich(nil, tt.request, tt.eventParams)
@@ -330,3 +340,181 @@ func TestBotEnricher(t *testing.T) {
 		})
 	}
 }
+
+func TestBotEnricherDetection(t *testing.T) {
+	const (
+		googlebotUA = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+		headlessUA  = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36"
+		browserUA   = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+	)
+	source := &backendconfig.SourceT{ID: "source-1", WorkspaceID: "workspace-1"}
+	newRequest := func() *types.GatewayBatchRequest {
+		return &types.GatewayBatchRequest{
+			RequestIP: "10.0.0.1",
+			Batch: []types.SingularEventT{
+				{"messageId": "1", "context": map[string]any{"userAgent": googlebotUA}},
+				{"messageId": "2", "context": map[string]any{"userAgent": browserUA}},
+				{"messageId": "3", "context": map[string]any{"userAgent": headlessUA}},
+				{"messageId": "4", "context": map[string]any{"userAgent": browserUA, "ip": "66.249.66.1"}},
+				{"messageId": "5"},
+			},
+		}
+	}
+	newEnricher := func(t *testing.T, conf *config.Config, statsStore stats.Stats) PipelineEnricher {
+		t.Helper()
+		e, err := NewBotEnricher(conf, logger.NOP, statsStore)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, e.Close()) })
+		return e
+	}
+	messageIDs := func(request *types.GatewayBatchRequest) []any {
+		var ids []any
+		for _, event := range request.Batch {
+			ids = append(ids, event["messageId"])
+		}
+		return ids
+	}
+
+	t.Run("detection is disabled by default", func(t *testing.T) {
+		request := newRequest()
+		require.NoError(t, newEnricher(t, config.New(), stats.NOP).Enrich(source, request, &types.EventParams{}))
+		require.Equal(t, newRequest(), request)
+	})
+
+	t.Run("flag", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+
+		request := newRequest()
+		require.NoError(t, newEnricher(t, conf, statsStore).Enrich(source, request, &types.EventParams{}))
+		require.Equal(t, []any{"1", "2", "3", "4", "5"}, messageIDs(request))
+		require.Equal(t, map[string]any{
+			"userAgent": googlebotUA,
+			"isBot":     true,
+			"bot": botDetails{
+				Name:     "Googlebot",
+				URL:      "https://developers.google.com/search/docs/crawling-indexing/googlebot",
+				Category: "search_engine",
+				detected: true,
+			},
+		}, request.Batch[0]["context"])
+		require.Equal(t, map[string]any{"userAgent": browserUA}, request.Batch[1]["context"])
+		require.Equal(t, "HeadlessChrome", request.Batch[2]["context"].(map[string]any)["bot"].(botDetails).Name)
+		require.Equal(t, "Googlebot", request.Batch[3]["context"].(map[string]any)["bot"].(botDetails).Name, "known crawler ip ranges should be detected")
+		require.NotContains(t, request.Batch[4], "context")
+		require.False(t, IsRoutedBotEvent(request.Batch[0]))
+		require.True(t, IsDetectedBotEvent(request.Batch[0]))
+		require.False(t, IsDroppedBotEvent(request.Batch[0]))
+		require.False(t, IsDetectedBotEvent(request.Batch[1]))
+
+		require.EqualValues(t, 2, statsStore.Get("proc_bot_enricher_detected_events", stats.Tags{
+			"sourceId": "source-1", "workspaceId": "workspace-1", "sourceType": "", "category": "search_engine", "action": "flag",
+		}).LastValue())
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		conf.Set("BotEnrichment.action", "drop")
+
+		request := newRequest()
+		require.NoError(t, newEnricher(t, conf, stats.NOP).Enrich(source, request, &types.EventParams{}))
+		require.Equal(t, []any{"1", "2", "3", "4", "5"}, messageIDs(request), "dropped events should be kept for reporting")
+		require.Equal(t, []any{"2", "5"}, messageIDs(&types.GatewayBatchRequest{Batch: lo.Reject(request.Batch, func(event types.SingularEventT, _ int) bool {
+			return IsDroppedBotEvent(event)
+		})}))
+		require.True(t, IsDetectedBotEvent(request.Batch[0]))
+		require.False(t, IsRoutedBotEvent(request.Batch[0]))
+	})
+
+	t.Run("drop with invalid context", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		conf.Set("BotEnrichment.action", "drop")
+
+		request := &types.GatewayBatchRequest{RequestIP: "66.249.66.1", Batch: []types.SingularEventT{{"messageId": "1", "context": "invalid"}}}
+		require.Error(t, newEnricher(t, conf, stats.NOP).Enrich(source, request, &types.EventParams{}))
+		require.Empty(t, request.Batch, "events that can't be flagged should be dropped right away")
+	})
+
+	t.Run("route", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		conf.Set("BotEnrichment.action", "drop")
+		conf.Set("BotEnrichment.workspace-1.action", "route")
+
+		request := newRequest()
+		require.NoError(t, newEnricher(t, conf, stats.NOP).Enrich(source, request, &types.EventParams{}))
+		require.Equal(t, []any{"1", "2", "3", "4", "5"}, messageIDs(request))
+		require.True(t, IsRoutedBotEvent(request.Batch[0]))
+		require.False(t, IsRoutedBotEvent(request.Batch[1]))
+		require.False(t, IsRoutedBotEvent(request.Batch[4]))
+
+		payload, err := jsonrs.Marshal(request.Batch[0])
+		require.NoError(t, err)
+		var event types.SingularEventT
+		require.NoError(t, jsonrs.Unmarshal(payload, &event))
+		require.True(t, IsRoutedBotEvent(event), "routed events should be recognised after being marshalled")
+	})
+
+	t.Run("source overrides", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		conf.Set("BotEnrichment.workspace-1.action", "route")
+		conf.Set("BotEnrichment.source-1.action", "drop")
+		e := newEnricher(t, conf, stats.NOP)
+
+		request := newRequest()
+		require.NoError(t, e.Enrich(source, request, &types.EventParams{}))
+		require.True(t, IsDroppedBotEvent(request.Batch[0]))
+
+		request = newRequest()
+		require.NoError(t, e.Enrich(&backendconfig.SourceT{ID: "source-2", WorkspaceID: "workspace-1"}, request, &types.EventParams{}))
+		require.Len(t, request.Batch, 5)
+		require.True(t, IsRoutedBotEvent(request.Batch[0]))
+	})
+
+	t.Run("upstream classification takes precedence", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		conf.Set("BotEnrichment.action", "drop")
+
+		request := newRequest()
+		require.NoError(t, newEnricher(t, conf, stats.NOP).Enrich(source, request, &types.EventParams{IsBot: true, BotName: "upstream-bot"}))
+		require.Len(t, request.Batch, 5)
+		for _, event := range request.Batch {
+			require.Equal(t, botDetails{Name: "upstream-bot"}, event["context"].(map[string]any)["bot"])
+		}
+	})
+
+	t.Run("rules file", func(t *testing.T) {
+		rulesFile := filepath.Join(t.TempDir(), "rules.json")
+		require.NoError(t, os.WriteFile(rulesFile, []byte(`{"patterns":[{"name":"Internal Monitor","category":"monitoring","keywords":["internal-monitor"]}]}`), 0o600))
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		conf.Set("BotEnrichment.detection.rulesFile", rulesFile)
+		conf.Set("BotEnrichment.detection.rulesReloadInterval", 10*time.Millisecond)
+		e := newEnricher(t, conf, stats.NOP)
+
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+			{"context": map[string]any{"userAgent": "internal-monitor/1.0"}},
+			{"context": map[string]any{"userAgent": googlebotUA}},
+		}}
+		require.NoError(t, e.Enrich(source, request, &types.EventParams{}))
+		require.Equal(t, "Internal Monitor", request.Batch[0]["context"].(map[string]any)["bot"].(botDetails).Name)
+		require.Equal(t, "Googlebot", request.Batch[1]["context"].(map[string]any)["bot"].(botDetails).Name, "embedded rules should be extended")
+
+		require.NoError(t, os.WriteFile(rulesFile, []byte(`{"exclusions":["internal-monitor"]}`), 0o600))
+		require.NoError(t, os.Chtimes(rulesFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute)))
+		require.Eventually(t, func() bool {
+			request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+				{"context": map[string]any{"userAgent": "internal-monitor/1.0 bot"}},
+			}}
+			require.NoError(t, e.Enrich(source, request, &types.EventParams{}))
+			_, isBot := request.Batch[0]["context"].(map[string]any)["isBot"]
+			return !isBot
+		}, 5*time.Second, 10*time.Millisecond, "rules should be reloaded when the file changes")
+	})
+}
@@ -0,0 +1,260 @@
+// Package botdetector classifies requests as coming from bots, based on their user agent and ip address.
+// Its rules are embedded in the binary and can be extended or replaced by a rules file, see [ReadRulesFile].
+package botdetector
+
+import (
+	_ "embed"
+	"fmt"
+	"net/netip"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+//go:embed rules.json
+var embeddedRules []byte
+
+// Bot is the classification of a detected bot
+type Bot struct {
+	Name     string `json:"name,omitempty"`
+	Category string `json:"category,omitempty"`
+	URL      string `json:"url,omitempty"`
+}
+
+// Rules are the rules for detecting bots:
+//   - patterns are matched against user agents, in order, the first matching one classifying the bot.
+//   - exclusions are keywords of user agents which should never be considered bots, even if a pattern matches them.
+//   - ip ranges are the CIDRs of known crawlers, used when the user agent doesn't match any pattern.
+//
+// Keywords are matched case-insensitively.
+type Rules struct {
+	Patterns   []PatternRule `json:"patterns"`
+	Exclusions []string      `json:"exclusions"`
+	IPRanges   []IPRangeRule `json:"ipRanges"`
+}
+
+// PatternRule classifies user agents containing any of its keywords and, if set, matching its pattern.
+// The pattern is a case-insensitive regular expression which is only evaluated against user agents containing a keyword,
+// so that most user agents are ruled out without evaluating any regular expressions.
+type PatternRule struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	URL      string   `json:"url"`
+	Keywords []string `json:"keywords"`
+	Pattern  string   `json:"pattern"`
+}
+
+// IPRangeRule classifies ip addresses belonging to any of its CIDRs
+type IPRangeRule struct {
+	Name     string   `json:"name"`
+	Category string   `json:"category"`
+	URL      string   `json:"url"`
+	CIDRs    []string `json:"cidrs"`
+}
+
+// EmbeddedRules returns the rules embedded in the binary
+func EmbeddedRules() (Rules, error) {
+	return ParseRules(embeddedRules)
+}
+
+// ParseRules parses rules from their json representation
+func ParseRules(data []byte) (Rules, error) {
+	var rules Rules
+	if err := jsonrs.Unmarshal(data, &rules); err != nil {
+		return rules, fmt.Errorf("unmarshalling bot detection rules: %w", err)
+	}
+	return rules, nil
+}
+
+// ReadRulesFile reads the rules of a json file having the same format as the embedded rules.
+// Unless replace is true, the file's rules extend the embedded ones, taking precedence over them.
+func ReadRulesFile(path string, replace bool) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Rules{}, fmt.Errorf("reading bot detection rules file: %w", err)
+	}
+	rules, err := ParseRules(data)
+	if err != nil {
+		return Rules{}, err
+	}
+	if replace {
+		return rules, nil
+	}
+	embedded, err := EmbeddedRules()
+	if err != nil {
+		return Rules{}, err
+	}
+	return rules.Merge(embedded), nil
+}
+
+// Merge returns the rules along with the other ones, which have a lower precedence
+func (r Rules) Merge(other Rules) Rules {
+	return Rules{
+		Patterns:   append(append([]PatternRule{}, r.Patterns...), other.Patterns...),
+		Exclusions: append(append([]string{}, r.Exclusions...), other.Exclusions...),
+		IPRanges:   append(append([]IPRangeRule{}, r.IPRanges...), other.IPRanges...),
+	}
+}
+
+type pattern struct {
+	bot      Bot
+	keywords []string
+	regexp   *regexp.Regexp // nil if the rule doesn't have a pattern
+}
+
+type ipRange struct {
+	bot    Bot
+	prefix netip.Prefix
+}
+
+// compiledRules are the compiled form of rules
+type compiledRules struct {
+	patterns   []pattern
+	exclusions []string
+	ipRanges   []ipRange
+}
+
+func compile(rules Rules) (*compiledRules, error) {
+	cr := &compiledRules{}
+	for _, rule := range rules.Patterns {
+		if len(rule.Keywords) == 0 {
+			return nil, fmt.Errorf("bot %q doesn't have any keywords", rule.Name)
+		}
+		p := pattern{
+			bot:      Bot{Name: rule.Name, Category: rule.Category, URL: rule.URL},
+			keywords: lowercase(rule.Keywords),
+		}
+		if rule.Pattern != "" {
+			re, err := regexp.Compile("(?i)" + rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("compiling pattern of bot %q: %w", rule.Name, err)
+			}
+			p.regexp = re
+		}
+		cr.patterns = append(cr.patterns, p)
+	}
+	cr.exclusions = lowercase(rules.Exclusions)
+	for _, rule := range rules.IPRanges {
+		for _, cidr := range rule.CIDRs {
+			prefix, err := netip.ParsePrefix(cidr)
+			if err != nil {
+				return nil, fmt.Errorf("parsing ip range of bot %q: %w", rule.Name, err)
+			}
+			cr.ipRanges = append(cr.ipRanges, ipRange{
+				bot:    Bot{Name: rule.Name, Category: rule.Category, URL: rule.URL},
+				prefix: prefix.Masked(),
+			})
+		}
+	}
+	return cr, nil
+}
+
+func lowercase(keywords []string) []string {
+	lowercased := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if keyword != "" {
+			lowercased = append(lowercased, strings.ToLower(keyword))
+		}
+	}
+	return lowercased
+}
+
+// Detector detects bots using a set of rules, which can be updated at any time
+type Detector struct {
+	rules atomic.Pointer[compiledRules]
+}
+
+// New creates a detector using the provided rules
+func New(rules Rules) (*Detector, error) {
+	d := &Detector{}
+	if err := d.Update(rules); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// Update replaces the rules of the detector. The previous rules are kept if the new ones are invalid.
+func (d *Detector) Update(rules Rules) error {
+	cr, err := compile(rules)
+	if err != nil {
+		return err
+	}
+	d.rules.Store(cr)
+	return nil
+}
+
+// Detect returns the classification of the bot making a request with the provided user agent from the provided ip address (if any),
+// along with whether the request is coming from a bot.
+func (d *Detector) Detect(userAgent, ip string) (Bot, bool) {
+	cr := d.rules.Load()
+	if bot, ok := cr.detectUserAgent(userAgent); ok {
+		return bot, true
+	}
+	if ip == "" || len(cr.ipRanges) == 0 {
+		return Bot{}, false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return Bot{}, false
+	}
+	addr = addr.Unmap()
+	for _, r := range cr.ipRanges {
+		if r.prefix.Contains(addr) {
+			return r.bot, true
+		}
+	}
+	return Bot{}, false
+}
+
+// IsBotUserAgent returns whether the user agent belongs to a bot
+func (d *Detector) IsBotUserAgent(userAgent string) bool {
+	_, ok := d.rules.Load().detectUserAgent(userAgent)
+	return ok
+}
+
+func (cr *compiledRules) detectUserAgent(userAgent string) (Bot, bool) {
+	if userAgent == "" {
+		return Bot{}, false
+	}
+	userAgent = strings.ToLower(userAgent)
+	for _, p := range cr.patterns {
+		if !containsAny(userAgent, p.keywords) || p.regexp != nil && !p.regexp.MatchString(userAgent) {
+			continue
+		}
+		if containsAny(userAgent, cr.exclusions) {
+			return Bot{}, false
+		}
+		return p.bot, true
+	}
+	return Bot{}, false
+}
+
+func containsAny(s string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if strings.Contains(s, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+var defaultDetector = sync.OnceValue(func() *Detector {
+	rules, err := EmbeddedRules()
+	if err != nil {
+		panic(err)
+	}
+	d, err := New(rules)
+	if err != nil {
+		panic(err)
+	}
+	return d
+})
+
+// Default returns a detector using the embedded rules
+func Default() *Detector {
+	return defaultDetector()
+}
@@ -0,0 +1,114 @@
+package botdetector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetector(t *testing.T) {
+	d := Default()
+
+	t.Run("user agents", func(t *testing.T) {
+		for _, tc := range []struct {
+			userAgent string
+			bot       string
+			category  string
+		}{
+			{userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", bot: "Googlebot", category: "search_engine"},
+			{userAgent: "Mozilla/5.0 (compatible; bingbot/2.0; +http://www.bing.com/bingbot.htm)", bot: "Bingbot", category: "search_engine"},
+			{userAgent: "Mozilla/5.0 AppleWebKit/537.36 (KHTML, like Gecko; compatible; GPTBot/1.2; +https://openai.com/gptbot)", bot: "GPTBot", category: "ai_crawler"},
+			{userAgent: "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/120.0.0.0 Safari/537.36", bot: "HeadlessChrome", category: "headless_browser"},
+			{userAgent: "Mozilla/5.0 (compatible; UptimeRobot/2.0; http://www.uptimerobot.com/)", bot: "UptimeRobot", category: "monitoring"},
+			{userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:120.0) Gecko/20100101 Firefox/120.0 Chrome-Lighthouse", bot: "Lighthouse", category: "monitoring"},
+			{userAgent: "curl/8.4.0", bot: "curl", category: "http_client"},
+			{userAgent: "python-requests/2.31.0", bot: "python-requests", category: "http_client"},
+			{userAgent: "Mozilla/5.0 (compatible; SomeNewBot/1.0)", bot: "Unknown bot", category: "unknown"},
+			{userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"},
+			{userAgent: "Mozilla/5.0 (Linux; Android 12; CUBOT KINGKONG 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36"},
+			{userAgent: "Botify/3.2 (iPhone; iOS 17.0; Scale/3.00)"},
+			{userAgent: "MyApp/1.0 (Linux; Android 13) okhttp/4.12.0 curl/8.4.0"},
+			{userAgent: ""},
+		} {
+			bot, isBot := d.Detect(tc.userAgent, "")
+			require.Equal(t, tc.bot != "", isBot, tc.userAgent)
+			require.Equal(t, tc.bot, bot.Name, tc.userAgent)
+			require.Equal(t, tc.category, bot.Category, tc.userAgent)
+			require.Equal(t, isBot, d.IsBotUserAgent(tc.userAgent), tc.userAgent)
+		}
+	})
+
+	t.Run("ip ranges", func(t *testing.T) {
+		bot, isBot := d.Detect("", "66.249.66.1")
+		require.True(t, isBot)
+		require.Equal(t, "Googlebot", bot.Name)
+
+		bot, isBot = d.Detect("", "::ffff:66.249.66.1")
+		require.True(t, isBot)
+		require.Equal(t, "Googlebot", bot.Name)
+
+		bot, isBot = d.Detect("", "2001:4860:4801:10::1")
+		require.True(t, isBot)
+		require.Equal(t, "Googlebot", bot.Name)
+
+		for _, ip := range []string{"", "10.0.0.1", "invalid"} {
+			_, isBot = d.Detect("", ip)
+			require.False(t, isBot, ip)
+		}
+		require.False(t, d.IsBotUserAgent("66.249.66.1"))
+	})
+}
+
+func TestRules(t *testing.T) {
+	t.Run("rules file extends the embedded rules", func(t *testing.T) {
+		rulesFile := filepath.Join(t.TempDir(), "rules.json")
+		require.NoError(t, os.WriteFile(rulesFile, []byte(`{
+			"patterns": [{"name": "Custom Bot", "category": "internal", "keywords": ["Googlebot"], "pattern": "googlebot/3"}],
+			"exclusions": ["trusted-partner"],
+			"ipRanges": [{"name": "Office", "category": "internal", "cidrs": ["192.168.0.0/16"]}]
+		}`), 0o600))
+
+		rules, err := ReadRulesFile(rulesFile, false)
+		require.NoError(t, err)
+		d, err := New(rules)
+		require.NoError(t, err)
+
+		bot, _ := d.Detect("Mozilla/5.0 (compatible; Googlebot/3.0)", "")
+		require.Equal(t, "Custom Bot", bot.Name, "rules of the file should take precedence")
+		bot, _ = d.Detect("Mozilla/5.0 (compatible; Googlebot/2.1)", "")
+		require.Equal(t, "Googlebot", bot.Name)
+		_, isBot := d.Detect("trusted-partner crawler", "")
+		require.False(t, isBot)
+		bot, _ = d.Detect("", "192.168.1.1")
+		require.Equal(t, "Office", bot.Name)
+
+		rules, err = ReadRulesFile(rulesFile, true)
+		require.NoError(t, err)
+		require.NoError(t, d.Update(rules))
+		_, isBot = d.Detect("Mozilla/5.0 (compatible; Googlebot/2.1)", "")
+		require.False(t, isBot, "embedded rules should be replaced")
+	})
+
+	t.Run("invalid rules", func(t *testing.T) {
+		_, err := ParseRules([]byte(`{"patterns": {}}`))
+		require.Error(t, err)
+
+		_, err = New(Rules{Patterns: []PatternRule{{Name: "no keywords", Pattern: "bot"}}})
+		require.EqualError(t, err, `bot "no keywords" doesn't have any keywords`)
+
+		_, err = New(Rules{Patterns: []PatternRule{{Name: "invalid", Keywords: []string{"bot"}, Pattern: "(bot"}}})
+		require.ErrorContains(t, err, `compiling pattern of bot "invalid"`)
+
+		_, err = New(Rules{IPRanges: []IPRangeRule{{Name: "invalid", CIDRs: []string{"10.0.0.1"}}}})
+		require.ErrorContains(t, err, `parsing ip range of bot "invalid"`)
+
+		d := Default()
+		require.Error(t, d.Update(Rules{IPRanges: []IPRangeRule{{Name: "invalid", CIDRs: []string{"invalid"}}}}))
+		require.True(t, d.IsBotUserAgent("Googlebot/2.1"), "previous rules should be kept")
+	})
+
+	_, err := ReadRulesFile("/nonexistent/rules.json", false)
+	require.ErrorContains(t, err, "reading bot detection rules file")
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
 	"github.com/samber/lo"
 
@@ -40,6 +41,13 @@ type geoEnricher struct {
 	stats   stats.Stats
 }
 
+// NewGeoEnricher creates an enricher locating events using a chain of geolocation databases, in order of precedence:
+//   - a csv file overriding the geolocation of ip ranges, e.g. corporate networks (Geolocation.overrides.path)
+//   - the MaxMind city database, downloaded from object storage
+//   - additional MMDB files, e.g. IP2Location or DB-IP databases (Geolocation.additionalDBs.paths)
+//   - a MaxMind ASN database (Geolocation.asnDB.path)
+//
+// Databases are reloaded whenever their files change on disk, unless Geolocation.reloadInterval is zero.
 func NewGeoEnricher(conf *config.Config, log logger.Logger, statClient stats.Stats) (PipelineEnricher, error) {
 	log.Infof("Setting up new event geo enricher")
 
@@ -48,13 +56,53 @@ func NewGeoEnricher(conf *config.Config, log logger.Logger, statClient stats.Sta
 		return nil, fmt.Errorf("downloading instance of maxmind db: %w", err)
 	}
 
-	fetcher, err := geolocation.NewMaxmindDBReader(dbPath)
-	if err != nil {
-		return nil, fmt.Errorf("creating new instance of maxmind's geolocation db reader: %w", err)
+	var (
+		overridesPath  = conf.GetString("Geolocation.overrides.path", "")
+		additionalDBs  = conf.GetStringSlice("Geolocation.additionalDBs.paths", nil)
+		asnDBPath      = conf.GetString("Geolocation.asnDB.path", "")
+		reloadInterval = conf.GetDuration("Geolocation.reloadInterval", 1, time.Minute)
+	)
+	openMMDB := func(path string) (geolocation.GeoFetcher, error) {
+		return geolocation.NewMaxmindDBReader(path)
+	}
+	openCSV := func(path string) (geolocation.GeoFetcher, error) {
+		return geolocation.NewCSVOverrideReader(path)
+	}
+	open := func(path string, open func(string) (geolocation.GeoFetcher, error)) (geolocation.GeoFetcher, error) {
+		if reloadInterval > 0 {
+			return geolocation.NewReloadingFetcher(path, reloadInterval, open, log.Child("geolocation"))
+		}
+		return open(path)
+	}
+
+	type database struct {
+		path string
+		open func(string) (geolocation.GeoFetcher, error)
+	}
+	var databases []database
+	if overridesPath != "" {
+		databases = append(databases, database{path: overridesPath, open: openCSV})
+	}
+	databases = append(databases, database{path: dbPath, open: openMMDB})
+	for _, path := range additionalDBs {
+		databases = append(databases, database{path: path, open: openMMDB})
+	}
+	if asnDBPath != "" {
+		databases = append(databases, database{path: asnDBPath, open: openMMDB})
+	}
+
+	fetchers := make([]geolocation.GeoFetcher, 0, len(databases))
+	for _, db := range databases {
+		fetcher, err := open(db.path, db.open)
+		if err != nil {
+			_ = geolocation.NewChainFetcher(fetchers...).Close()
+			return nil, fmt.Errorf("creating new instance of geolocation db reader for %q: %w", db.path, err)
+		}
+		fetchers = append(fetchers, fetcher)
 	}
 
 	return &geoEnricher{
-		fetcher: fetcher,
+		fetcher: geolocation.NewChainFetcher(fetchers...),
 		stats:   statClient,
 		logger:  log.Child("geolocation"),
 	}, nil
@@ -132,7 +180,7 @@ func (e *geoEnricher) Enrich(source *backendconfig.SourceT, request *types.Gatew
 			}).Increment()
 
 		// Set the empty data on the context nonetheless
-		context["geo"] = extractGeolocationData(ip, rawGeo)
+		context["geo"] = withNetworkData(extractGeolocationData(ip, rawGeo), rawGeo)
 	}
 
 	return errors.Join(enrichErrs...)
@@ -250,3 +298,31 @@ func extractGeolocationData(ip string, geoCity geolocation.GeoInfo) Geolocation
 
 	return toReturn
 }
+
+// withNetworkData adds the asn and organization of the network the ip belongs to, if any, to the geolocation.
+// Geolocation is kept unchanged for the destinations relying on its fields, so a map having the same keys is returned instead.
+func withNetworkData(geo Geolocation, geoInfo geolocation.GeoInfo) any {
+	organization, _ := lo.Find(
+		[]string{geoInfo.Organization, geoInfo.ASOrganization, geoInfo.ISP},
+		func(v string) bool { return v != "" },
+	)
+	if geoInfo.ASNumber == 0 && organization == "" {
+		return geo
+	}
+	toReturn := map[string]interface{}{
+		"ip":       geo.IP,
+		"city":     geo.City,
+		"country":  geo.Country,
+		"region":   geo.Region,
+		"postal":   geo.Postal,
+		"location": geo.Location,
+		"timezone": geo.Timezone,
+	}
+	if geoInfo.ASNumber > 0 {
+		toReturn["asn"] = geoInfo.ASNumber
+	}
+	if organization != "" {
+		toReturn["organization"] = organization
+	}
+	return toReturn
+}
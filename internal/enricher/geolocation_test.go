@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path"
 	"testing"
 
 	"github.com/ory/dockertest/v3"
@@ -384,6 +385,91 @@ func TestGeolocationEnrichment_Success(t *testing.T) {
 	})
 }
 
+func TestGeolocationEnrichment_Providers(t *testing.T) {
+	overridesPath := path.Join(t.TempDir(), "overrides.csv")
+	require.NoError(t, os.WriteFile(overridesPath, []byte("network,country,city,organization\n10.0.0.0/8,GB,London,Example Corp\n"), 0o600))
+
+	c := config.New()
+	c.Set("RUDDER_TMPDIR", "./testdata")
+	c.Set("Geolocation.db.key", "city_test.mmdb")
+	c.Set("Geolocation.asnDB.path", "./testdata/geolocation/asn_test.mmdb")
+	c.Set("Geolocation.overrides.path", overridesPath)
+
+	enricher, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+	require.NoError(t, err)
+	defer func() { require.NoError(t, enricher.Close()) }()
+
+	input := &types.GatewayBatchRequest{
+		RequestIP: "2.125.160.216",
+		Batch: []types.SingularEventT{
+			{"userId": "u1", "context": map[string]interface{}{}},
+			{"userId": "u2", "context": map[string]interface{}{"ip": "10.1.2.3"}},
+			{"userId": "u3", "context": map[string]interface{}{"ip": "2a02:ff40::1"}},
+		},
+	}
+	require.NoError(t, enricher.Enrich(NewSourceBuilder("source-id").WithGeoEnrichment(true).Build(), input, nil))
+
+	require.Equal(t, map[string]interface{}{
+		"ip":           "2.125.160.216",
+		"city":         "Boxford",
+		"country":      "GB",
+		"region":       "England",
+		"postal":       "OX1",
+		"location":     "51.750000,-1.250000",
+		"timezone":     "Europe/London",
+		"asn":          uint(5607),
+		"organization": "Sky UK Limited",
+	}, input.Batch[0]["context"].(map[string]interface{})["geo"])
+	require.Equal(t, map[string]interface{}{
+		"ip":           "10.1.2.3",
+		"city":         "London",
+		"country":      "GB",
+		"region":       "",
+		"postal":       "",
+		"location":     "",
+		"timezone":     "",
+		"organization": "Example Corp",
+	}, input.Batch[1]["context"].(map[string]interface{})["geo"], "overrides should take precedence")
+	require.Equal(t, map[string]interface{}{
+		"ip":           "2a02:ff40::1",
+		"city":         "",
+		"country":      "IM",
+		"region":       "",
+		"postal":       "",
+		"location":     "54.250000,-4.500000",
+		"timezone":     "Europe/Isle_of_Man",
+		"asn":          uint(64512),
+		"organization": "Example IPv6 Network",
+	}, input.Batch[2]["context"].(map[string]interface{})["geo"])
+
+	t.Run("geolocation is unchanged without network data", func(t *testing.T) {
+		c := config.New()
+		c.Set("RUDDER_TMPDIR", "./testdata")
+		c.Set("Geolocation.db.key", "city_test.mmdb")
+
+		enricher, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, enricher.Close()) }()
+
+		input := &types.GatewayBatchRequest{
+			RequestIP: "2.125.160.216",
+			Batch:     []types.SingularEventT{{"userId": "u1", "context": map[string]interface{}{}}},
+		}
+		require.NoError(t, enricher.Enrich(NewSourceBuilder("source-id").WithGeoEnrichment(true).Build(), input, nil))
+		require.IsType(t, Geolocation{}, input.Batch[0]["context"].(map[string]interface{})["geo"])
+	})
+
+	t.Run("enricher fails to setup if a database is invalid", func(t *testing.T) {
+		c := config.New()
+		c.Set("RUDDER_TMPDIR", "./testdata")
+		c.Set("Geolocation.db.key", "city_test.mmdb")
+		c.Set("Geolocation.asnDB.path", "./testdata/geolocation/corrupted_city_test.mmdb")
+
+		_, err := NewGeoEnricher(c, logger.NOP, stats.NOP)
+		require.ErrorIs(t, err, geolocation.ErrInvalidDatabase)
+	})
+}
+
 func TestMapUpstreamToGeolocation(t *testing.T) {
 	t.Run("it returns the extracted fields when input contains all the information", func(t *testing.T) {
 		t.Parallel()
@@ -509,6 +595,11 @@ func (sb *SourceBuilder) WithGeoEnrichment(enabled bool) *SourceBuilder {
 	return sb
 }
 
+func (sb *SourceBuilder) WithUserAgentEnrichment(enabled bool) *SourceBuilder {
+	sb.source.UserAgentEnrichment.Enabled = enabled
+	return sb
+}
+
 func (sb *SourceBuilder) Build() *backendconfig.SourceT {
 	return sb.source
 }
@@ -0,0 +1,127 @@
+package enricher
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/enricher/useragent"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+type userAgentEnricher struct {
+	cache  *lru.Cache[string, useragent.UserAgent] // parsed user agents, keyed by the raw user agent, nil if caching is disabled
+	logger logger.Logger
+	stats  stats.Stats
+}
+
+// NewUserAgentEnricher creates an enricher parsing the user agent of events into context.browser, context.os and context.device.
+// Only the fields not already provided by the SDK are filled in. Parsed user agents are cached in an LRU cache,
+// unless UserAgentEnrichment.cacheSize is not positive.
+func NewUserAgentEnricher(conf *config.Config, log logger.Logger, statClient stats.Stats) (PipelineEnricher, error) {
+	e := &userAgentEnricher{
+		logger: log.Child("useragent"),
+		stats:  statClient,
+	}
+	if cacheSize := conf.GetInt("UserAgentEnrichment.cacheSize", 10000); cacheSize > 0 {
+		cache, err := lru.New[string, useragent.UserAgent](cacheSize)
+		if err != nil {
+			return nil, fmt.Errorf("creating user agent cache: %w", err)
+		}
+		e.cache = cache
+	}
+	return e, nil
+}
+
+// Enrich parses the context.userAgent of the events of sources having user agent enrichment enabled
+func (e *userAgentEnricher) Enrich(source *backendconfig.SourceT, request *types.GatewayBatchRequest, _ *types.EventParams) error {
+	if source == nil || !source.UserAgentEnrichment.Enabled {
+		return nil
+	}
+
+	var hits, misses int
+	for _, event := range request.Batch {
+		context, ok := event["context"].(map[string]any)
+		if !ok {
+			continue
+		}
+		userAgent, _ := context["userAgent"].(string)
+		if userAgent == "" {
+			continue
+		}
+
+		ua, ok := e.cachedUserAgent(userAgent)
+		if ok {
+			hits++
+		} else {
+			misses++
+			ua = useragent.Parse(userAgent)
+			if e.cache != nil {
+				e.cache.Add(userAgent, ua)
+			}
+		}
+
+		fillMissing(context, "browser", map[string]string{
+			"name":    ua.Browser.Name,
+			"version": ua.Browser.Version,
+		})
+		fillMissing(context, "os", map[string]string{
+			"name":    ua.OS.Name,
+			"version": ua.OS.Version,
+		})
+		fillMissing(context, "device", map[string]string{
+			"type":         ua.Device.Type,
+			"manufacturer": ua.Device.Manufacturer,
+			"model":        ua.Device.Model,
+		})
+	}
+
+	tags := stats.Tags{
+		"sourceId":    source.ID,
+		"workspaceId": source.WorkspaceID,
+		"sourceType":  source.SourceDefinition.Type,
+	}
+	e.stats.NewTaggedStat("proc_ua_enricher_cache_hits", stats.CountType, tags).Count(hits)
+	e.stats.NewTaggedStat("proc_ua_enricher_cache_misses", stats.CountType, tags).Count(misses)
+	return nil
+}
+
+// cachedUserAgent returns the cached parsed user agent, if caching is enabled
+func (e *userAgentEnricher) cachedUserAgent(userAgent string) (useragent.UserAgent, bool) {
+	if e.cache == nil {
+		return useragent.UserAgent{}, false
+	}
+	return e.cache.Get(userAgent)
+}
+
+// fillMissing sets the non-empty values in context.<key>, unless they are already present.
+// Sections which are present with a type other than map[string]any are left untouched.
+func fillMissing(context map[string]any, key string, values map[string]string) {
+	section, ok := context[key].(map[string]any)
+	if !ok {
+		if _, exists := context[key]; exists && context[key] != nil {
+			return
+		}
+		section = map[string]any{}
+	}
+	for k, v := range values {
+		if v == "" {
+			continue
+		}
+		if existing, ok := section[k]; ok && existing != nil && existing != "" {
+			continue
+		}
+		section[k] = v
+	}
+	if len(section) > 0 {
+		context[key] = section
+	}
+}
+
+func (e *userAgentEnricher) Close() error {
+	return nil
+}
@@ -0,0 +1,236 @@
+// Package useragent parses user agents into the browser, operating system and device they belong to.
+package useragent
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Device types
+const (
+	DeviceTypeMobile   = "mobile"
+	DeviceTypeTablet   = "tablet"
+	DeviceTypeDesktop  = "desktop"
+	DeviceTypeTV       = "tv"
+	DeviceTypeConsole  = "console"
+	DeviceTypeWearable = "wearable"
+)
+
+// UserAgent is the parsed form of a user agent, fields which cannot be determined are left empty
+type UserAgent struct {
+	Browser Browser
+	OS      OS
+	Device  Device
+}
+
+type Browser struct {
+	Name    string
+	Version string
+}
+
+type OS struct {
+	Name    string
+	Version string
+}
+
+type Device struct {
+	Type         string
+	Manufacturer string
+	Model        string
+}
+
+// browserRule identifies a browser by a regular expression whose first group, if any, is the browser's version
+type browserRule struct {
+	name   string
+	regexp *regexp.Regexp
+}
+
+// browserRules are ordered so that browsers based on other ones, which include their tokens, come first
+var browserRules = []browserRule{
+	{name: "Facebook", regexp: regexp.MustCompile(`FBAV/([\d.]+)`)},
+	{name: "Instagram", regexp: regexp.MustCompile(`Instagram ([\d.]+)`)},
+	{name: "Edge", regexp: regexp.MustCompile(`(?:Edg|EdgA|EdgiOS|Edge)/([\d.]+)`)},
+	{name: "Opera", regexp: regexp.MustCompile(`(?:OPR|OPiOS|Opera)/([\d.]+)`)},
+	{name: "Samsung Internet", regexp: regexp.MustCompile(`SamsungBrowser/([\d.]+)`)},
+	{name: "Yandex", regexp: regexp.MustCompile(`YaBrowser/([\d.]+)`)},
+	{name: "UC Browser", regexp: regexp.MustCompile(`UCBrowser/([\d.]+)`)},
+	{name: "Vivaldi", regexp: regexp.MustCompile(`Vivaldi/([\d.]+)`)},
+	{name: "Firefox", regexp: regexp.MustCompile(`(?:Firefox|FxiOS)/([\d.]+)`)},
+	{name: "Chrome WebView", regexp: regexp.MustCompile(`; wv\).*Chrome/([\d.]+)`)},
+	{name: "Chromium", regexp: regexp.MustCompile(`Chromium/([\d.]+)`)},
+	{name: "Chrome", regexp: regexp.MustCompile(`(?:Chrome|CriOS)/([\d.]+)`)},
+	{name: "Mobile Safari", regexp: regexp.MustCompile(`Version/([\d.]+).*Mobile.*Safari/`)},
+	{name: "Safari", regexp: regexp.MustCompile(`Version/([\d.]+).*Safari/`)},
+	{name: "Internet Explorer", regexp: regexp.MustCompile(`(?:MSIE |Trident/.*rv:)([\d.]+)`)},
+}
+
+var (
+	windowsPhoneRegexp = regexp.MustCompile(`Windows Phone(?: OS)? ([\d.]+)`)
+	windowsRegexp      = regexp.MustCompile(`Windows NT ([\d.]+)`)
+	iOSRegexp          = regexp.MustCompile(`(?:iPhone|CPU) OS ([\d_]+)`)
+	macOSRegexp        = regexp.MustCompile(`Mac OS X ([\d_.]+)`)
+	androidRegexp      = regexp.MustCompile(`Android ([\d.]+)`)
+	chromeOSRegexp     = regexp.MustCompile(`CrOS \S+ ([\d.]+)`)
+	harmonyOSRegexp    = regexp.MustCompile(`HarmonyOS(?: ([\d.]+))?`)
+	tizenRegexp        = regexp.MustCompile(`Tizen ([\d.]+)`)
+	kaiOSRegexp        = regexp.MustCompile(`KAIOS/([\d.]+)`)
+	androidModelRegexp = regexp.MustCompile(`Android [\d.]+;(?: [a-zA-Z]{2}[-_][a-zA-Z]{2};)? ([^;)]+?)(?: Build/[^;)]*)?[;)]`)
+
+	tvRegexp       = regexp.MustCompile(`(?i)smart-?tv|googletv|appletv|crkey|roku|bravia|web0s|webos.*tv|tizen.*tv|hbbtv|aft[a-z]`)
+	consoleRegexp  = regexp.MustCompile(`PlayStation|Xbox|Nintendo`)
+	wearableRegexp = regexp.MustCompile(`(?i)watch os|watchos|wear os|; wearable`)
+)
+
+// windowsVersions maps the versions of Windows NT to the marketing versions of Windows
+var windowsVersions = map[string]string{
+	"10.0": "10",
+	"6.3":  "8.1",
+	"6.2":  "8",
+	"6.1":  "7",
+	"6.0":  "Vista",
+	"5.2":  "XP",
+	"5.1":  "XP",
+}
+
+// manufacturerPrefixes maps prefixes of android device models to their manufacturers
+var manufacturerPrefixes = []struct {
+	prefix       string
+	manufacturer string
+}{
+	{prefix: "sm-", manufacturer: "Samsung"},
+	{prefix: "galaxy", manufacturer: "Samsung"},
+	{prefix: "samsung", manufacturer: "Samsung"},
+	{prefix: "pixel", manufacturer: "Google"},
+	{prefix: "nexus", manufacturer: "Google"},
+	{prefix: "redmi", manufacturer: "Xiaomi"},
+	{prefix: "xiaomi", manufacturer: "Xiaomi"},
+	{prefix: "mi ", manufacturer: "Xiaomi"},
+	{prefix: "poco", manufacturer: "Xiaomi"},
+	{prefix: "oneplus", manufacturer: "OnePlus"},
+	{prefix: "huawei", manufacturer: "Huawei"},
+	{prefix: "honor", manufacturer: "Honor"},
+	{prefix: "moto", manufacturer: "Motorola"},
+	{prefix: "nokia", manufacturer: "Nokia"},
+	{prefix: "lm-", manufacturer: "LG"},
+	{prefix: "lg-", manufacturer: "LG"},
+	{prefix: "cph", manufacturer: "OPPO"},
+	{prefix: "oppo", manufacturer: "OPPO"},
+	{prefix: "rmx", manufacturer: "Realme"},
+	{prefix: "vivo", manufacturer: "vivo"},
+	{prefix: "sony", manufacturer: "Sony"},
+	{prefix: "kfs", manufacturer: "Amazon"},
+	{prefix: "kft", manufacturer: "Amazon"},
+}
+
+// Parse parses a user agent
+func Parse(userAgent string) UserAgent {
+	var ua UserAgent
+	if userAgent == "" {
+		return ua
+	}
+	for _, rule := range browserRules {
+		if match := rule.regexp.FindStringSubmatch(userAgent); match != nil {
+			ua.Browser = Browser{Name: rule.name, Version: match[1]}
+			break
+		}
+	}
+	ua.OS = parseOS(userAgent)
+	ua.Device = parseDevice(userAgent, ua.OS)
+	return ua
+}
+
+func parseOS(userAgent string) OS {
+	if match := windowsPhoneRegexp.FindStringSubmatch(userAgent); match != nil {
+		return OS{Name: "Windows Phone", Version: match[1]}
+	}
+	if match := windowsRegexp.FindStringSubmatch(userAgent); match != nil {
+		version, ok := windowsVersions[match[1]]
+		if !ok {
+			version = match[1]
+		}
+		return OS{Name: "Windows", Version: version}
+	}
+	if match := harmonyOSRegexp.FindStringSubmatch(userAgent); match != nil {
+		return OS{Name: "HarmonyOS", Version: match[1]}
+	}
+	if match := androidRegexp.FindStringSubmatch(userAgent); match != nil {
+		return OS{Name: "Android", Version: match[1]}
+	}
+	if strings.Contains(userAgent, "AppleTV") {
+		return OS{Name: "tvOS"}
+	}
+	if strings.Contains(userAgent, "Watch OS") || strings.Contains(userAgent, "watchOS") {
+		return OS{Name: "watchOS"}
+	}
+	if match := iOSRegexp.FindStringSubmatch(userAgent); match != nil && (strings.Contains(userAgent, "iPhone") || strings.Contains(userAgent, "iPad") || strings.Contains(userAgent, "iPod")) {
+		return OS{Name: "iOS", Version: strings.ReplaceAll(match[1], "_", ".")}
+	}
+	if match := macOSRegexp.FindStringSubmatch(userAgent); match != nil {
+		return OS{Name: "macOS", Version: strings.ReplaceAll(match[1], "_", ".")}
+	}
+	if match := chromeOSRegexp.FindStringSubmatch(userAgent); match != nil {
+		return OS{Name: "Chrome OS", Version: match[1]}
+	}
+	if match := tizenRegexp.FindStringSubmatch(userAgent); match != nil {
+		return OS{Name: "Tizen", Version: match[1]}
+	}
+	if match := kaiOSRegexp.FindStringSubmatch(userAgent); match != nil {
+		return OS{Name: "KaiOS", Version: match[1]}
+	}
+	if strings.Contains(userAgent, "Ubuntu") {
+		return OS{Name: "Ubuntu"}
+	}
+	if strings.Contains(userAgent, "Linux") || strings.Contains(userAgent, "X11") {
+		return OS{Name: "Linux"}
+	}
+	return OS{}
+}
+
+func parseDevice(userAgent string, os OS) Device {
+	switch {
+	case consoleRegexp.MatchString(userAgent):
+		return Device{Type: DeviceTypeConsole}
+	case tvRegexp.MatchString(userAgent):
+		return Device{Type: DeviceTypeTV}
+	case wearableRegexp.MatchString(userAgent):
+		return Device{Type: DeviceTypeWearable}
+	case strings.Contains(userAgent, "iPad"):
+		return Device{Type: DeviceTypeTablet, Manufacturer: "Apple", Model: "iPad"}
+	case strings.Contains(userAgent, "iPhone"):
+		return Device{Type: DeviceTypeMobile, Manufacturer: "Apple", Model: "iPhone"}
+	case strings.Contains(userAgent, "iPod"):
+		return Device{Type: DeviceTypeMobile, Manufacturer: "Apple", Model: "iPod"}
+	}
+
+	switch os.Name {
+	case "Android", "HarmonyOS":
+		device := Device{Type: DeviceTypeTablet}
+		if strings.Contains(userAgent, "Mobile") {
+			device.Type = DeviceTypeMobile
+		}
+		if match := androidModelRegexp.FindStringSubmatch(userAgent); match != nil && match[1] != "K" { // K is the model of reduced user agents
+			device.Model = strings.TrimSpace(match[1])
+			device.Manufacturer = manufacturerOf(device.Model)
+		}
+		return device
+	case "Windows Phone", "KaiOS":
+		return Device{Type: DeviceTypeMobile}
+	case "Windows", "macOS", "Linux", "Ubuntu", "Chrome OS":
+		device := Device{Type: DeviceTypeDesktop}
+		if os.Name == "macOS" {
+			device.Manufacturer = "Apple"
+		}
+		return device
+	}
+	return Device{}
+}
+
+func manufacturerOf(model string) string {
+	model = strings.ToLower(model)
+	for _, mp := range manufacturerPrefixes {
+		if strings.HasPrefix(model, mp.prefix) {
+			return mp.manufacturer
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		userAgent string
+		expected  UserAgent
+	}{
+		{
+			name:      "empty",
+			userAgent: "",
+			expected:  UserAgent{},
+		},
+		{
+			name:      "chrome on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.6099.109 Safari/537.36",
+			expected: UserAgent{
+				Browser: Browser{Name: "Chrome", Version: "120.0.6099.109"},
+				OS:      OS{Name: "Windows", Version: "10"},
+				Device:  Device{Type: DeviceTypeDesktop},
+			},
+		},
+		{
+			name:      "edge on windows",
+			userAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.2210.91",
+			expected: UserAgent{
+				Browser: Browser{Name: "Edge", Version: "120.0.2210.91"},
+				OS:      OS{Name: "Windows", Version: "10"},
+				Device:  Device{Type: DeviceTypeDesktop},
+			},
+		},
+		{
+			name:      "safari on macos",
+			userAgent: "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1 Safari/605.1.15",
+			expected: UserAgent{
+				Browser: Browser{Name: "Safari", Version: "17.1"},
+				OS:      OS{Name: "macOS", Version: "10.15.7"},
+				Device:  Device{Type: DeviceTypeDesktop, Manufacturer: "Apple"},
+			},
+		},
+		{
+			name:      "firefox on linux",
+			userAgent: "Mozilla/5.0 (X11; Ubuntu; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			expected: UserAgent{
+				Browser: Browser{Name: "Firefox", Version: "121.0"},
+				OS:      OS{Name: "Ubuntu"},
+				Device:  Device{Type: DeviceTypeDesktop},
+			},
+		},
+		{
+			name:      "mobile safari on iphone",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1.2 Mobile/15E148 Safari/604.1",
+			expected: UserAgent{
+				Browser: Browser{Name: "Mobile Safari", Version: "17.1.2"},
+				OS:      OS{Name: "iOS", Version: "17.1.2"},
+				Device:  Device{Type: DeviceTypeMobile, Manufacturer: "Apple", Model: "iPhone"},
+			},
+		},
+		{
+			name:      "chrome on ipad",
+			userAgent: "Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0.6099.119 Mobile/15E148 Safari/604.1",
+			expected: UserAgent{
+				Browser: Browser{Name: "Chrome", Version: "120.0.6099.119"},
+				OS:      OS{Name: "iOS", Version: "16.6"},
+				Device:  Device{Type: DeviceTypeTablet, Manufacturer: "Apple", Model: "iPad"},
+			},
+		},
+		{
+			name:      "samsung internet on android phone",
+			userAgent: "Mozilla/5.0 (Linux; Android 13; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36",
+			expected: UserAgent{
+				Browser: Browser{Name: "Samsung Internet", Version: "23.0"},
+				OS:      OS{Name: "Android", Version: "13"},
+				Device:  Device{Type: DeviceTypeMobile, Manufacturer: "Samsung", Model: "SM-S911B"},
+			},
+		},
+		{
+			name:      "webview on android phone with build",
+			userAgent: "Mozilla/5.0 (Linux; Android 12; Pixel 6 Build/SD1A.210817.036; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/94.0.4606.71 Mobile Safari/537.36",
+			expected: UserAgent{
+				Browser: Browser{Name: "Chrome WebView", Version: "94.0.4606.71"},
+				OS:      OS{Name: "Android", Version: "12"},
+				Device:  Device{Type: DeviceTypeMobile, Manufacturer: "Google", Model: "Pixel 6"},
+			},
+		},
+		{
+			name:      "chrome on android tablet with reduced user agent",
+			userAgent: "Mozilla/5.0 (Linux; Android 10; K) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: UserAgent{
+				Browser: Browser{Name: "Chrome", Version: "120.0.0.0"},
+				OS:      OS{Name: "Android", Version: "10"},
+				Device:  Device{Type: DeviceTypeTablet},
+			},
+		},
+		{
+			name:      "facebook in-app browser",
+			userAgent: "Mozilla/5.0 (iPhone; CPU iPhone OS 16_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [FBAN/FBIOS;FBDV/iPhone14,5;FBMD/iPhone;FBSN/iOS;FBSV/16.5;FBSS/3;FBID/phone;FBLC/en_US;FBOP/5;FBAV/420.0.0.32.105]",
+			expected: UserAgent{
+				Browser: Browser{Name: "Facebook", Version: "420.0.0.32.105"},
+				OS:      OS{Name: "iOS", Version: "16.5"},
+				Device:  Device{Type: DeviceTypeMobile, Manufacturer: "Apple", Model: "iPhone"},
+			},
+		},
+		{
+			name:      "internet explorer 11",
+			userAgent: "Mozilla/5.0 (Windows NT 6.1; WOW64; Trident/7.0; rv:11.0) like Gecko",
+			expected: UserAgent{
+				Browser: Browser{Name: "Internet Explorer", Version: "11.0"},
+				OS:      OS{Name: "Windows", Version: "7"},
+				Device:  Device{Type: DeviceTypeDesktop},
+			},
+		},
+		{
+			name:      "chrome os",
+			userAgent: "Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+			expected: UserAgent{
+				Browser: Browser{Name: "Chrome", Version: "120.0.0.0"},
+				OS:      OS{Name: "Chrome OS", Version: "14541.0.0"},
+				Device:  Device{Type: DeviceTypeDesktop},
+			},
+		},
+		{
+			name:      "smart tv",
+			userAgent: "Mozilla/5.0 (SMART-TV; LINUX; Tizen 6.0) AppleWebKit/537.36 (KHTML, like Gecko) 76.0.3809.146/6.0 TV Safari/537.36",
+			expected: UserAgent{
+				OS:     OS{Name: "Tizen", Version: "6.0"},
+				Device: Device{Type: DeviceTypeTV},
+			},
+		},
+		{
+			name:      "console",
+			userAgent: "Mozilla/5.0 (PlayStation; PlayStation 5/2.26) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/13.0 Safari/605.1.15",
+			expected: UserAgent{
+				Browser: Browser{Name: "Safari", Version: "13.0"},
+				Device:  Device{Type: DeviceTypeConsole},
+			},
+		},
+		{
+			name:      "server side client",
+			userAgent: "okhttp/4.12.0",
+			expected:  UserAgent{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, Parse(tc.userAgent))
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	userAgent := "Mozilla/5.0 (Linux; Android 13; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0.0.0 Mobile Safari/537.36"
+	for i := 0; i < b.N; i++ {
+		Parse(userAgent)
+	}
+}
@@ -0,0 +1,102 @@
+package enricher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+func TestUserAgentEnricher(t *testing.T) {
+	const iPhoneUserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_1_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.1.2 Mobile/15E148 Safari/604.1"
+
+	t.Run("source without user agent enrichment", func(t *testing.T) {
+		e, err := NewUserAgentEnricher(config.New(), logger.NOP, stats.NOP)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, e.Close()) }()
+
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+			{"context": map[string]any{"userAgent": iPhoneUserAgent}},
+		}}
+		require.NoError(t, e.Enrich(nil, request, &types.EventParams{}))
+		require.NoError(t, e.Enrich(NewSourceBuilder("source-id").WithUserAgentEnrichment(false).Build(), request, &types.EventParams{}))
+		require.Equal(t, []types.SingularEventT{
+			{"context": map[string]any{"userAgent": iPhoneUserAgent}},
+		}, request.Batch)
+	})
+
+	t.Run("source with user agent enrichment", func(t *testing.T) {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		e, err := NewUserAgentEnricher(config.New(), logger.NOP, statsStore)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, e.Close()) }()
+
+		source := NewSourceBuilder("source-id").WithUserAgentEnrichment(true).Build()
+		source.WorkspaceID = "workspace-id"
+		source.SourceDefinition.Type = "ios"
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+			{"context": map[string]any{"userAgent": iPhoneUserAgent}},
+			{"context": map[string]any{
+				"userAgent": iPhoneUserAgent,
+				"os":        map[string]any{"name": "iPadOS"},
+				"device":    "custom device",
+			}},
+			{"context": map[string]any{"userAgent": ""}},
+			{"context": map[string]any{"userAgent": "okhttp/4.12.0"}},
+			{"event": "no context"},
+		}}
+		require.NoError(t, e.Enrich(source, request, &types.EventParams{}))
+		require.Equal(t, []types.SingularEventT{
+			{"context": map[string]any{
+				"userAgent": iPhoneUserAgent,
+				"browser":   map[string]any{"name": "Mobile Safari", "version": "17.1.2"},
+				"os":        map[string]any{"name": "iOS", "version": "17.1.2"},
+				"device":    map[string]any{"type": "mobile", "manufacturer": "Apple", "model": "iPhone"},
+			}},
+			{"context": map[string]any{
+				"userAgent": iPhoneUserAgent,
+				"browser":   map[string]any{"name": "Mobile Safari", "version": "17.1.2"},
+				"os":        map[string]any{"name": "iPadOS", "version": "17.1.2"},
+				"device":    "custom device",
+			}},
+			{"context": map[string]any{"userAgent": ""}},
+			{"context": map[string]any{"userAgent": "okhttp/4.12.0"}},
+			{"event": "no context"},
+		}, request.Batch)
+
+		tags := stats.Tags{"sourceId": "source-id", "workspaceId": "workspace-id", "sourceType": "ios"}
+		require.EqualValues(t, 1, statsStore.Get("proc_ua_enricher_cache_hits", tags).LastValue())
+		require.EqualValues(t, 2, statsStore.Get("proc_ua_enricher_cache_misses", tags).LastValue())
+	})
+	t.Run("caching is disabled if the cache size is not positive", func(t *testing.T) {
+		for _, cacheSize := range []int{0, -1} {
+			conf := config.New()
+			conf.Set("UserAgentEnrichment.cacheSize", cacheSize)
+			statsStore, err := memstats.New()
+			require.NoError(t, err)
+			e, err := NewUserAgentEnricher(conf, logger.NOP, statsStore)
+			require.NoError(t, err)
+
+			source := NewSourceBuilder("source-id").WithUserAgentEnrichment(true).Build()
+			request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{
+				{"context": map[string]any{"userAgent": iPhoneUserAgent}},
+				{"context": map[string]any{"userAgent": iPhoneUserAgent}},
+			}}
+			require.NoError(t, e.Enrich(source, request, &types.EventParams{}))
+			for _, event := range request.Batch {
+				require.Equal(t, map[string]any{"name": "iOS", "version": "17.1.2"}, event["context"].(map[string]any)["os"])
+			}
+
+			tags := stats.Tags{"sourceId": "source-id", "workspaceId": "", "sourceType": ""}
+			require.EqualValues(t, 0, statsStore.Get("proc_ua_enricher_cache_hits", tags).LastValue())
+			require.EqualValues(t, 2, statsStore.Get("proc_ua_enricher_cache_misses", tags).LastValue())
+			require.NoError(t, e.Close())
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package jobstransition
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	routerutils "github.com/rudderlabs/rudder-server/router/utils"
+	"github.com/rudderlabs/rudder-server/utils/types"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// reportedBy is the reporting unit of the jobsdbs whose transitions are reported, keyed by their identifier
+var reportedBy = map[string]string{
+	"rt":       types.ROUTER,
+	"batch_rt": types.BATCH_ROUTER,
+}
+
+// Request is a request for transitioning the jobs of a jobsdb, identified by its table prefix (e.g. rt, batch_rt)
+type Request struct {
+	JobsDB string
+	jobsdb.TransitionJobsRequest
+}
+
+// Admin is an admin rpc handler for moving jobs matching a filter to a target state, e.g. for force-aborting
+// the pending jobs of a destination. Transitions of router and batch router jobs are recorded in reporting metrics.
+//
+//	admin.RegisterAdminHandler("JobsDB", jobstransition.NewAdmin(...))
+type Admin struct {
+	dbs              map[string]jobsdb.JobsDB
+	reporting        types.Reporting
+	reportingEnabled bool
+	timeout          time.Duration
+	logger           logger.Logger
+}
+
+// NewAdmin creates an admin handler for transitioning the jobs of the provided jobsdbs
+func NewAdmin(reporting types.Reporting, conf *config.Config, log logger.Logger, dbs ...jobsdb.JobsDB) *Admin {
+	a := &Admin{
+		dbs:              make(map[string]jobsdb.JobsDB, len(dbs)),
+		reporting:        reporting,
+		reportingEnabled: conf.GetBoolVar(types.DefaultReportingEnabled, "Reporting.enabled"),
+		timeout:          conf.GetDurationVar(1, time.Hour, "JobsDB.transitionJobsTimeout"),
+		logger:           log,
+	}
+	for _, db := range dbs {
+		a.dbs[db.Identifier()] = db
+	}
+	return a
+}
+
+// TransitionJobs moves the jobs matching the request's filter to its target state, or only counts them in dry-run mode
+func (a *Admin) TransitionJobs(req Request, reply *string) error {
+	db, ok := a.dbs[req.JobsDB]
+	if !ok {
+		return fmt.Errorf("unknown jobsdb %q", req.JobsDB)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	var onTransition func(tx jobsdb.UpdateSafeTx, jobs []*jobsdb.JobT, statusList []*jobsdb.JobStatusT) error
+	if pu, ok := reportedBy[req.JobsDB]; ok && a.reporting != nil && a.reportingEnabled {
+		onTransition = func(tx jobsdb.UpdateSafeTx, jobs []*jobsdb.JobT, statusList []*jobsdb.JobStatusT) error {
+			if err := a.reporting.Report(ctx, reportMetrics(pu, jobs, statusList), tx.Tx()); err != nil {
+				return fmt.Errorf("reporting metrics: %w", err)
+			}
+			return nil
+		}
+	}
+	count, err := db.TransitionJobs(ctx, req.TransitionJobsRequest, onTransition)
+	if err != nil {
+		if count > 0 {
+			return fmt.Errorf("transitioning jobs of %q, %d jobs already transitioned to %q: %w", req.JobsDB, count, req.TargetState, err)
+		}
+		return fmt.Errorf("transitioning jobs of %q: %w", req.JobsDB, err)
+	}
+	if req.DryRun {
+		*reply = fmt.Sprintf("%d jobs of %q would be transitioned to %q", count, req.JobsDB, req.TargetState)
+		return nil
+	}
+	a.logger.Infon("Transitioned jobs",
+		logger.NewStringField("jobsdb", req.JobsDB),
+		logger.NewStringField("targetState", req.TargetState),
+		logger.NewIntField("count", int64(count)),
+	)
+	*reply = fmt.Sprintf("%d jobs of %q transitioned to %q", count, req.JobsDB, req.TargetState)
+	return nil
+}
+
+// reportMetrics creates the reporting metrics of a batch of transitioned jobs. Similar to routers, failures are only reported on their first attempt
+// and jobs moved to waiting are not reported.
+func reportMetrics(pu string, jobs []*jobsdb.JobT, statusList []*jobsdb.JobStatusT) []*types.PUReportedMetric {
+	var metrics []*types.PUReportedMetric
+	metricsByKey := make(map[string]*types.PUReportedMetric)
+	for i, status := range statusList {
+		if status.JobState == jobsdb.Waiting.State || (status.JobState == jobsdb.Failed.State && status.AttemptNum > 1) {
+			continue
+		}
+		var params routerutils.JobParameters
+		_ = jsonrs.Unmarshal(jobs[i].Parameters, &params)
+		statusCode, _ := strconv.Atoi(status.ErrorCode)
+		key := params.SourceID + ":" + params.DestinationID + ":" + params.SourceJobRunID + ":" + params.TransformAt + ":" + status.JobState + ":" + status.ErrorCode + ":" + params.EventName + ":" + params.EventType
+		m, ok := metricsByKey[key]
+		if !ok {
+			inPU := types.EVENT_FILTER
+			if params.TransformAt == "processor" {
+				inPU = types.DEST_TRANSFORMER
+			}
+			// warehouse jobs are further processed by the warehouse service
+			_, isWarehouse := warehouseutils.WarehouseDestinationMap[jobs[i].CustomVal]
+			terminalPU := !(pu == types.BATCH_ROUTER && isWarehouse)
+			m = &types.PUReportedMetric{
+				ConnectionDetails: types.ConnectionDetails{
+					SourceID:                params.SourceID,
+					DestinationID:           params.DestinationID,
+					SourceTaskRunID:         params.SourceTaskRunID,
+					SourceJobID:             params.SourceJobID,
+					SourceJobRunID:          params.SourceJobRunID,
+					SourceDefinitionID:      params.SourceDefinitionID,
+					DestinationDefinitionID: params.DestinationDefinitionID,
+					SourceCategory:          params.SourceCategory,
+				},
+				PUDetails: *types.CreatePUDetails(inPU, pu, terminalPU, false),
+				StatusDetail: &types.StatusDetail{
+					Status:         status.JobState,
+					StatusCode:     statusCode,
+					SampleResponse: string(status.ErrorResponse),
+					EventName:      params.EventName,
+					EventType:      params.EventType,
+				},
+			}
+			metricsByKey[key] = m
+			metrics = append(metrics, m)
+		}
+		m.StatusDetail.Count++
+	}
+	return metrics
+}
@@ -0,0 +1,97 @@
+package jobstransition
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+	mockreportingtypes "github.com/rudderlabs/rudder-server/mocks/utils/types"
+	"github.com/rudderlabs/rudder-server/utils/tx"
+	"github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestAdmin(t *testing.T) {
+	setup := func(t *testing.T, identifier string) (*Admin, *mocksJobsDB.MockJobsDB, *mockreportingtypes.MockReporting) {
+		ctrl := gomock.NewController(t)
+		db := mocksJobsDB.NewMockJobsDB(ctrl)
+		db.EXPECT().Identifier().Return(identifier).AnyTimes()
+		reporting := mockreportingtypes.NewMockReporting(ctrl)
+		return NewAdmin(reporting, config.New(), logger.NOP, db), db, reporting
+	}
+
+	t.Run("unknown jobsdb", func(t *testing.T) {
+		a, _, _ := setup(t, "rt")
+		var reply string
+		require.Error(t, a.TransitionJobs(Request{JobsDB: "gw"}, &reply))
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		a, db, _ := setup(t, "rt")
+		req := Request{JobsDB: "rt", TransitionJobsRequest: jobsdb.TransitionJobsRequest{TargetState: jobsdb.Aborted.State, DryRun: true}}
+		db.EXPECT().TransitionJobs(gomock.Any(), req.TransitionJobsRequest, gomock.Any()).Return(3, nil)
+		var reply string
+		require.NoError(t, a.TransitionJobs(req, &reply))
+		require.Equal(t, `3 jobs of "rt" would be transitioned to "aborted"`, reply)
+	})
+
+	t.Run("transitions are reported in the same transaction", func(t *testing.T) {
+		a, db, reporting := setup(t, "batch_rt")
+		jobs := []*jobsdb.JobT{
+			{JobID: 1, CustomVal: "S3", Parameters: []byte(`{"source_id":"src-1","destination_id":"dest-1","transform_at":"processor","event_name":"e","event_type":"track"}`)},
+			{JobID: 2, CustomVal: "S3", Parameters: []byte(`{"source_id":"src-1","destination_id":"dest-1","transform_at":"processor","event_name":"e","event_type":"track"}`)},
+			{JobID: 3, CustomVal: "RS", Parameters: []byte(`{"source_id":"src-1","destination_id":"dest-2","transform_at":"router"}`)},
+		}
+		statusList := []*jobsdb.JobStatusT{
+			{JobID: 1, JobState: jobsdb.Aborted.State, AttemptNum: 1, ErrorCode: "410", ErrorResponse: []byte(`{"reason":"drained"}`)},
+			{JobID: 2, JobState: jobsdb.Aborted.State, AttemptNum: 3, ErrorCode: "410", ErrorResponse: []byte(`{"reason":"drained"}`)},
+			{JobID: 3, JobState: jobsdb.Aborted.State, AttemptNum: 1, ErrorCode: "410", ErrorResponse: []byte(`{"reason":"drained"}`)},
+		}
+		updateSafeTx := jobsdb.EmptyUpdateSafeTx()
+		db.EXPECT().TransitionJobs(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, _ jobsdb.TransitionJobsRequest, onTransition func(jobsdb.UpdateSafeTx, []*jobsdb.JobT, []*jobsdb.JobStatusT) error) (int, error) {
+				require.NotNil(t, onTransition)
+				return len(jobs), onTransition(updateSafeTx, jobs, statusList)
+			})
+		reporting.EXPECT().Report(gomock.Any(), gomock.Any(), updateSafeTx.Tx()).DoAndReturn(
+			func(_ context.Context, metrics []*types.PUReportedMetric, _ *tx.Tx) error {
+				require.Len(t, metrics, 2)
+				require.Equal(t, "dest-1", metrics[0].DestinationID)
+				require.Equal(t, *types.CreatePUDetails(types.DEST_TRANSFORMER, types.BATCH_ROUTER, true, false), metrics[0].PUDetails)
+				require.Equal(t, &types.StatusDetail{
+					Status:         jobsdb.Aborted.State,
+					Count:          2,
+					StatusCode:     410,
+					SampleResponse: `{"reason":"drained"}`,
+					EventName:      "e",
+					EventType:      "track",
+				}, metrics[0].StatusDetail)
+
+				require.Equal(t, "dest-2", metrics[1].DestinationID)
+				require.Equal(t, *types.CreatePUDetails(types.EVENT_FILTER, types.BATCH_ROUTER, false, false), metrics[1].PUDetails, "warehouse jobs are not terminal in the batch router")
+				require.EqualValues(t, 1, metrics[1].StatusDetail.Count)
+				return nil
+			})
+		var reply string
+		require.NoError(t, a.TransitionJobs(Request{JobsDB: "batch_rt", TransitionJobsRequest: jobsdb.TransitionJobsRequest{TargetState: jobsdb.Aborted.State}}, &reply))
+		require.Equal(t, `3 jobs of "batch_rt" transitioned to "aborted"`, reply)
+	})
+
+	t.Run("failures are only reported on their first attempt", func(t *testing.T) {
+		metrics := reportMetrics(types.ROUTER,
+			[]*jobsdb.JobT{{JobID: 1, Parameters: []byte(`{}`)}, {JobID: 2, Parameters: []byte(`{}`)}, {JobID: 3, Parameters: []byte(`{}`)}},
+			[]*jobsdb.JobStatusT{
+				{JobID: 1, JobState: jobsdb.Failed.State, AttemptNum: 1},
+				{JobID: 2, JobState: jobsdb.Failed.State, AttemptNum: 2},
+				{JobID: 3, JobState: jobsdb.Waiting.State, AttemptNum: 1},
+			},
+		)
+		require.Len(t, metrics, 1)
+		require.EqualValues(t, 1, metrics[0].StatusDetail.Count)
+	})
+}
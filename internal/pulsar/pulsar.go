@@ -44,6 +44,19 @@ func NewClient(config *config.Config) (Client, error) {
 	return client, nil
 }
 
+// NewClientWithOptions returns a new instance of Pulsar client using the provided options, logging through the provided logger
+func NewClientWithOptions(opts pulsar.ClientOptions, log logger.Logger) (Client, error) {
+	if opts.URL == "" {
+		return Client{}, errors.New("pulsar url is empty")
+	}
+	opts.Logger = &pulsarLogAdapter{Logger: log}
+	client, err := pulsar.NewClient(opts)
+	if err != nil {
+		return Client{}, fmt.Errorf("error creating pulsar client : %w", err)
+	}
+	return Client{client}, nil
+}
+
 // NewProducer returns a new instance of Pulsar producer
 func (c *Client) NewProducer(opts pulsar.ProducerOptions) (ProducerAdapter, error) {
 	producer, err := c.CreateProducer(opts)
@@ -0,0 +1,415 @@
+package jobsdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+)
+
+// ErrMaintenanceUnavailable is returned when an on-demand maintenance operation is requested from a jobsdb which is not running a migration loop,
+// i.e. a jobsdb which is not started or a write-only one.
+var ErrMaintenanceUnavailable = errors.New("jobsdb is not running a migration loop")
+
+// ErrMaintenancePaused is returned when an on-demand maintenance operation is requested while migrations are paused, e.g. while pile up counts are being computed
+var ErrMaintenancePaused = errors.New("jobsdb migrations are paused")
+
+// maintenanceRequest is an on-demand maintenance operation to be run by the migration loop
+type maintenanceRequest struct {
+	operation string
+	run       func(ctx context.Context) error
+	done      chan error
+}
+
+// DatasetStats describes a dataset of a jobsdb
+type DatasetStats struct {
+	Index          string `json:"index"`
+	JobTable       string `json:"jobTable"`
+	JobStatusTable string `json:"jobStatusTable"`
+	// Jobs is the number of jobs in the dataset
+	Jobs int64 `json:"jobs"`
+	// StatusRows is the number of rows in the dataset's status table, including non-latest statuses
+	StatusRows int64 `json:"statusRows"`
+	// States is the number of jobs per latest job state, jobs without a status are counted as unprocessed
+	States             map[string]int64 `json:"states"`
+	JobTableSize       int64            `json:"jobTableSizeBytes"`
+	JobStatusTableSize int64            `json:"jobStatusTableSizeBytes"`
+	// MigrationEligible is true if the dataset would be migrated by the next migration run
+	MigrationEligible bool `json:"migrationEligible"`
+	// PendingJobs is the number of non-terminal jobs which would be copied over if the dataset was migrated
+	PendingJobs int `json:"pendingJobs"`
+}
+
+// PendingJobsCount is the number of pending jobs of a workspace and destination in a given state
+type PendingJobsCount struct {
+	WorkspaceID   string `json:"workspaceId"`
+	DestinationID string `json:"destinationId"`
+	State         string `json:"state"`
+	Count         int64  `json:"count"`
+}
+
+// JournalEntryInfo is an entry of a jobsdb's journal
+type JournalEntryInfo struct {
+	OpID      int64           `json:"opId"`
+	OpType    string          `json:"opType"`
+	OpDone    bool            `json:"opDone"`
+	OpPayload json.RawMessage `json:"opPayload"`
+	Owner     string          `json:"owner"`
+	StartTime time.Time       `json:"startTime"`
+	EndTime   *time.Time      `json:"endTime,omitempty"`
+}
+
+// NewAdminHandler creates an http handler for inspecting and maintaining the provided jobsdbs, each one identified by its table prefix (e.g. gw, rt, batch_rt)
+//
+//   - GET /{db}/datasets - lists the datasets of the jobsdb along with their job & status counts, sizes and migration eligibility
+//   - GET /{db}/pending - lists the number of pending jobs per workspace, destination and state, optionally filtered by workspaceId and destinationId
+//   - GET /{db}/journal - lists the latest journal entries, optionally only the pending ones (pending=true), up to limit (default 100)
+//   - POST /{db}/migrate - runs a dataset migration, unless migrations are paused
+//   - POST /{db}/cleanup-status-tables - compacts the status tables of the datasets
+func NewAdminHandler(handles ...*Handle) http.Handler {
+	h := &adminHandler{handles: make(map[string]*Handle, len(handles))}
+	for _, handle := range handles {
+		h.handles[handle.Identifier()] = handle
+	}
+	srvMux := chi.NewRouter()
+	srvMux.Get("/", h.list)
+	srvMux.Get("/{db}/datasets", h.datasets)
+	srvMux.Get("/{db}/pending", h.pending)
+	srvMux.Get("/{db}/journal", h.journal)
+	srvMux.Post("/{db}/migrate", h.migrate)
+	srvMux.Post("/{db}/cleanup-status-tables", h.cleanupStatusTables)
+	return srvMux
+}
+
+type adminHandler struct {
+	handles map[string]*Handle
+}
+
+func (h *adminHandler) list(w http.ResponseWriter, _ *http.Request) {
+	identifiers := make([]string, 0, len(h.handles))
+	for identifier := range h.handles {
+		identifiers = append(identifiers, identifier)
+	}
+	slices.Sort(identifiers)
+	writeAdminResponse(w, identifiers)
+}
+
+func (h *adminHandler) datasets(w http.ResponseWriter, r *http.Request) {
+	jd, ok := h.handle(w, r)
+	if !ok {
+		return
+	}
+	datasets, err := jd.DatasetStats(r.Context())
+	if err != nil {
+		jd.logger.Errorn("admin: getting dataset stats", logger.NewErrorField(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeAdminResponse(w, datasets)
+}
+
+func (h *adminHandler) pending(w http.ResponseWriter, r *http.Request) {
+	jd, ok := h.handle(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	counts, err := jd.PendingJobsCounts(r.Context(), query.Get("workspaceId"), query.Get("destinationId"))
+	if err != nil {
+		jd.logger.Errorn("admin: getting pending jobs counts", logger.NewErrorField(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeAdminResponse(w, counts)
+}
+
+func (h *adminHandler) journal(w http.ResponseWriter, r *http.Request) {
+	jd, ok := h.handle(w, r)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		var err error
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			http.Error(w, fmt.Sprintf("invalid limit: %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+	entries, err := jd.JournalEntries(r.Context(), query.Get("pending") == "true", limit)
+	if err != nil {
+		jd.logger.Errorn("admin: getting journal entries", logger.NewErrorField(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeAdminResponse(w, entries)
+}
+
+func (h *adminHandler) migrate(w http.ResponseWriter, r *http.Request) {
+	jd, ok := h.handle(w, r)
+	if !ok {
+		return
+	}
+	h.maintenanceResponse(w, jd, "migrate", jd.MigrateDS(r.Context()))
+}
+
+func (h *adminHandler) cleanupStatusTables(w http.ResponseWriter, r *http.Request) {
+	jd, ok := h.handle(w, r)
+	if !ok {
+		return
+	}
+	h.maintenanceResponse(w, jd, "cleanup status tables", jd.CleanupStatusTables(r.Context()))
+}
+
+func (*adminHandler) maintenanceResponse(w http.ResponseWriter, jd *Handle, operation string, err error) {
+	switch {
+	case errors.Is(err, ErrMaintenanceUnavailable), errors.Is(err, ErrMaintenancePaused):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		jd.logger.Errorn("admin: running maintenance operation", logger.NewStringField("operation", operation), logger.NewErrorField(err))
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeAdminResponse(w, map[string]string{"status": "done"})
+	}
+}
+
+// handle returns the jobsdb identified by the db url parameter, responding with not found if there is no such jobsdb
+func (h *adminHandler) handle(w http.ResponseWriter, r *http.Request) (*Handle, bool) {
+	name := chi.URLParam(r, "db")
+	jd, ok := h.handles[name]
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown jobsdb %q", name), http.StatusNotFound)
+	}
+	return jd, ok
+}
+
+func writeAdminResponse(w http.ResponseWriter, response any) {
+	body, err := jsonrs.Marshal(response)
+	if err != nil {
+		http.Error(w, "internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_, _ = w.Write(body)
+}
+
+// DatasetStats returns statistics about each dataset of the jobsdb
+func (jd *Handle) DatasetStats(ctx context.Context) ([]DatasetStats, error) {
+	// pause migration to avoid any read locks being blocked while getting the stats
+	jd.migrateDSPaused.Store(true)
+	defer jd.migrateDSPaused.Store(false)
+	// datasets cannot be dropped while holding a migration read lock
+	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
+		return nil, fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.RUnlock()
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return nil, fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	migrateFrom, _, _, err := jd.getMigrationList(dsList)
+	if err != nil {
+		return nil, fmt.Errorf("getting migration list: %w", err)
+	}
+	res := make([]DatasetStats, 0, len(dsList))
+	for _, ds := range dsList {
+		dsStats := DatasetStats{
+			Index:          ds.Index,
+			JobTable:       ds.JobTable,
+			JobStatusTable: ds.JobStatusTable,
+			States:         make(map[string]int64),
+		}
+		if err := jd.dbHandle.QueryRowContext(
+			ctx,
+			fmt.Sprintf(`SELECT
+				(SELECT count(*) FROM %[1]q),
+				(SELECT count(*) FROM %[2]q),
+				pg_total_relation_size($1),
+				pg_total_relation_size($2)`, ds.JobTable, ds.JobStatusTable),
+			ds.JobTable, ds.JobStatusTable,
+		).Scan(&dsStats.Jobs, &dsStats.StatusRows, &dsStats.JobTableSize, &dsStats.JobStatusTableSize); err != nil {
+			return nil, fmt.Errorf("getting counts and sizes of %q: %w", ds.JobTable, err)
+		}
+		rows, err := jd.dbHandle.QueryContext(ctx, fmt.Sprintf(`SELECT job_state, count(*) FROM "v_last_%s" GROUP BY job_state`, ds.JobStatusTable))
+		if err != nil {
+			return nil, fmt.Errorf("getting state counts of %q: %w", ds.JobStatusTable, err)
+		}
+		var withStatus int64
+		for rows.Next() {
+			var (
+				state string
+				count int64
+			)
+			if err := rows.Scan(&state, &count); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("scanning state counts of %q: %w", ds.JobStatusTable, err)
+			}
+			dsStats.States[state] = count
+			withStatus += count
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("iterating state counts of %q: %w", ds.JobStatusTable, err)
+		}
+		if unprocessed := dsStats.Jobs - withStatus; unprocessed > 0 {
+			dsStats.States["unprocessed"] = unprocessed
+		}
+		for _, m := range migrateFrom {
+			if m.ds.Index == ds.Index {
+				dsStats.MigrationEligible = true
+				dsStats.PendingJobs = m.numJobsPending
+			}
+		}
+		res = append(res, dsStats)
+	}
+	return res, nil
+}
+
+// PendingJobsCounts returns the number of non-terminal jobs grouped by workspace, destination and state.
+// Results can optionally be filtered by workspaceID and destinationID.
+func (jd *Handle) PendingJobsCounts(ctx context.Context, workspaceID, destinationID string) ([]PendingJobsCount, error) {
+	// pause migration to avoid any read locks being blocked while counting
+	jd.migrateDSPaused.Store(true)
+	defer jd.migrateDSPaused.Store(false)
+	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
+		return nil, fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.RUnlock()
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return nil, fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	type key struct{ workspaceID, destinationID, state string }
+	counts := make(map[key]int64)
+	var keys []key
+	for _, ds := range dsList {
+		rows, err := jd.dbHandle.QueryContext(ctx, fmt.Sprintf(`SELECT
+				j.workspace_id,
+				COALESCE(j.parameters->>'destination_id', ''),
+				COALESCE(s.job_state, 'unprocessed'),
+				count(*)
+			FROM %[1]q j LEFT JOIN "v_last_%[2]s" s ON j.job_id = s.job_id
+			WHERE (s.job_id IS NULL OR s.job_state = ANY($1))
+			AND ($2 = '' OR j.workspace_id = $2)
+			AND ($3 = '' OR j.parameters->>'destination_id' = $3)
+			GROUP BY 1, 2, 3`, ds.JobTable, ds.JobStatusTable),
+			pq.Array(validNonTerminalStates), workspaceID, destinationID,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("getting pending jobs of %q: %w", ds.JobTable, err)
+		}
+		for rows.Next() {
+			var (
+				k     key
+				count int64
+			)
+			if err := rows.Scan(&k.workspaceID, &k.destinationID, &k.state, &count); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("scanning pending jobs of %q: %w", ds.JobTable, err)
+			}
+			if _, ok := counts[k]; !ok {
+				keys = append(keys, k)
+			}
+			counts[k] += count
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("iterating pending jobs of %q: %w", ds.JobTable, err)
+		}
+	}
+	res := make([]PendingJobsCount, 0, len(keys))
+	for _, k := range keys {
+		res = append(res, PendingJobsCount{WorkspaceID: k.workspaceID, DestinationID: k.destinationID, State: k.state, Count: counts[k]})
+	}
+	return res, nil
+}
+
+// JournalEntries returns up to limit of the latest journal entries, optionally only the ones not done yet
+func (jd *Handle) JournalEntries(ctx context.Context, pendingOnly bool, limit int) ([]JournalEntryInfo, error) {
+	rows, err := jd.dbHandle.QueryContext(ctx, fmt.Sprintf(`SELECT id, operation, COALESCE(done, false), operation_payload, COALESCE(owner, ''), start_time, end_time
+		FROM %q
+		WHERE NOT $1 OR NOT COALESCE(done, false)
+		ORDER BY id DESC
+		LIMIT $2`, jd.tablePrefix+"_journal"),
+		pendingOnly, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("getting journal entries: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	entries := make([]JournalEntryInfo, 0)
+	for rows.Next() {
+		var entry JournalEntryInfo
+		if err := rows.Scan(&entry.OpID, &entry.OpType, &entry.OpDone, &entry.OpPayload, &entry.Owner, &entry.StartTime, &entry.EndTime); err != nil {
+			return nil, fmt.Errorf("scanning journal entries: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating journal entries: %w", err)
+	}
+	return entries, nil
+}
+
+// MigrateDS runs a dataset migration on demand, waiting for it to complete
+func (jd *Handle) MigrateDS(ctx context.Context) error {
+	return jd.runMaintenance(ctx, "migrate", func(ctx context.Context) error {
+		defer jd.getTimerStat(
+			"jobsdb_admin_migrate_time",
+			&statTags{CustomValFilters: []string{jd.tablePrefix}},
+		).RecordDuration()()
+		return jd.doMigrateDS(ctx)
+	})
+}
+
+// CleanupStatusTables compacts the status tables of the datasets on demand, waiting for it to complete
+func (jd *Handle) CleanupStatusTables(ctx context.Context) error {
+	return jd.runMaintenance(ctx, "cleanup_status_tables", func(ctx context.Context) error {
+		if !jd.dsListLock.RTryLockWithCtx(ctx) {
+			return fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+		}
+		dsList := jd.getDSList()
+		jd.dsListLock.RUnlock()
+		return jd.cleanupStatusTables(ctx, dsList)
+	})
+}
+
+// runMaintenance hands over an operation to the migration loop and waits for its result
+func (jd *Handle) runMaintenance(ctx context.Context, operation string, run func(ctx context.Context) error) error {
+	jd.lifecycle.mu.Lock()
+	started := jd.lifecycle.started
+	jd.lifecycle.mu.Unlock()
+	if !started || jd.ownerType == Write {
+		return ErrMaintenanceUnavailable
+	}
+	req := &maintenanceRequest{operation: operation, run: run, done: make(chan error, 1)}
+	select {
+	case jd.maintenanceRequests <- req:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-req.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,139 @@
+package jobsdb
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+)
+
+func TestAdminHandler(t *testing.T) {
+	_ = startPostgres(t)
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.maxDSSize", 1)
+
+	triggerAddNewDS := make(chan time.Time)
+	jobDB := Handle{
+		TriggerAddNewDS: func() <-chan time.Time {
+			return triggerAddNewDS
+		},
+		TriggerMigrateDS: func() <-chan time.Time {
+			return make(chan time.Time) // migrations only run on demand
+		},
+		config: c,
+	}
+	tablePrefix := strings.ToLower(rand.String(5))
+	require.NoError(t, jobDB.Setup(ReadWrite, true, tablePrefix))
+	defer jobDB.TearDown()
+
+	customVal := rand.String(5)
+	jobs := genJobs(defaultWorkspaceID, customVal, 6, 1)
+	require.NoError(t, jobDB.Store(context.Background(), jobs[:4]))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[:4], Succeeded.State), []string{customVal}, nil))
+	triggerAddNewDS <- time.Now()
+	triggerAddNewDS <- time.Now() // waits for the previous addNewDS to complete
+	require.NoError(t, jobDB.Store(context.Background(), jobs[4:]))
+	require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[5:], Failed.State), []string{customVal}, nil))
+
+	handler := NewAdminHandler(&jobDB)
+	do := func(method, target string) *httptest.ResponseRecorder {
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, httptest.NewRequest(method, target, http.NoBody))
+		return resp
+	}
+	getDatasets := func(t *testing.T) []DatasetStats {
+		resp := do(http.MethodGet, "/"+tablePrefix+"/datasets")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var datasets []DatasetStats
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &datasets))
+		return datasets
+	}
+
+	t.Run("unknown jobsdb", func(t *testing.T) {
+		require.Equal(t, http.StatusNotFound, do(http.MethodGet, "/unknown/datasets").Code)
+	})
+
+	t.Run("datasets", func(t *testing.T) {
+		datasets := getDatasets(t)
+		require.Len(t, datasets, 2)
+
+		require.EqualValues(t, 4, datasets[0].Jobs)
+		require.EqualValues(t, 4, datasets[0].StatusRows)
+		require.Equal(t, map[string]int64{Succeeded.State: 4}, datasets[0].States)
+		require.True(t, datasets[0].MigrationEligible)
+		require.Zero(t, datasets[0].PendingJobs)
+		require.Positive(t, datasets[0].JobTableSize)
+
+		require.EqualValues(t, 2, datasets[1].Jobs)
+		require.Equal(t, map[string]int64{Failed.State: 1, "unprocessed": 1}, datasets[1].States)
+		require.False(t, datasets[1].MigrationEligible, "the last dataset is never eligible for migration")
+	})
+
+	t.Run("pending", func(t *testing.T) {
+		resp := do(http.MethodGet, "/"+tablePrefix+"/pending?workspaceId="+defaultWorkspaceID)
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var counts []PendingJobsCount
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &counts))
+		require.ElementsMatch(t, []PendingJobsCount{
+			{WorkspaceID: defaultWorkspaceID, State: Failed.State, Count: 1},
+			{WorkspaceID: defaultWorkspaceID, State: "unprocessed", Count: 1},
+		}, counts)
+
+		resp = do(http.MethodGet, "/"+tablePrefix+"/pending?workspaceId=other")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		require.JSONEq(t, `[]`, resp.Body.String())
+	})
+
+	t.Run("journal", func(t *testing.T) {
+		resp := do(http.MethodGet, "/"+tablePrefix+"/journal?limit=1")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		var entries []JournalEntryInfo
+		require.NoError(t, jsonrs.Unmarshal(resp.Body.Bytes(), &entries))
+		require.Len(t, entries, 1)
+		require.Equal(t, addDSOperation, entries[0].OpType)
+		require.True(t, entries[0].OpDone)
+
+		require.Equal(t, http.StatusBadRequest, do(http.MethodGet, "/"+tablePrefix+"/journal?limit=x").Code)
+	})
+
+	t.Run("cleanup status tables", func(t *testing.T) {
+		resp := do(http.MethodPost, "/"+tablePrefix+"/cleanup-status-tables")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+	})
+
+	t.Run("migrate is rejected while migrations are paused", func(t *testing.T) {
+		jobDB.migrateDSPaused.Store(true)
+		defer jobDB.migrateDSPaused.Store(false)
+		resp := do(http.MethodPost, "/"+tablePrefix+"/migrate")
+		require.Equal(t, http.StatusConflict, resp.Code, resp.Body.String())
+		require.Len(t, getDatasets(t), 2, "no dataset should have been migrated")
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		require.False(t, jobDB.migrateDSPaused.Load(), "migrations should be resumed after getting the dataset stats")
+		resp := do(http.MethodPost, "/"+tablePrefix+"/migrate")
+		require.Equal(t, http.StatusOK, resp.Code, resp.Body.String())
+		datasets := getDatasets(t)
+		require.Len(t, datasets, 1, "the dataset with terminal jobs only should have been dropped")
+		require.EqualValues(t, 2, datasets[0].Jobs)
+	})
+
+	t.Run("maintenance is unavailable for write-only jobsdbs", func(t *testing.T) {
+		writeDB := NewForWrite(tablePrefix, WithConfig(c))
+		require.NoError(t, writeDB.Start())
+		defer writeDB.Close()
+		defer writeDB.Stop()
+		resp := httptest.NewRecorder()
+		NewAdminHandler(writeDB).ServeHTTP(resp, httptest.NewRequest(http.MethodPost, "/"+tablePrefix+"/migrate", http.NoBody))
+		require.Equal(t, http.StatusConflict, resp.Code, resp.Body.String())
+	})
+}
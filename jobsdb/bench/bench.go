@@ -22,6 +22,12 @@ func New(conf *config.Config, stat stats.Stats, log logger.Logger, db *sql.DB) (
 		return scenario.NewSimple(conf, stat, log, db), nil
 	case "two_stage":
 		return scenario.NewTwoStage(conf, stat, log, db), nil
+	case "multi_tenant":
+		return scenario.NewMultiTenant(conf, stat, log, db), nil
+	case "large_payload":
+		return scenario.NewLargePayload(conf, stat, log, db), nil
+	case "failed_retry":
+		return scenario.NewFailedRetry(conf, stat, log, db), nil
 	default:
 		return nil, fmt.Errorf("unknown jobsdb bench scenario name: %s", conf.GetStringVar("processor", "JobsDB.bench.scenario"))
 	}
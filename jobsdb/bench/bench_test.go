@@ -2,6 +2,7 @@ package bench_test
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
 	"testing"
 	"time"
@@ -159,4 +160,125 @@ func TestBench(t *testing.T) {
 		})
 		require.NoError(t, g.Wait())
 	})
+
+	t.Run("multi_tenant", func(t *testing.T) {
+		db := startPostgres(t)
+
+		c := config.New()
+
+		// JobsDB configuration
+		c.Set("JobsDB.maxWriters", 4)
+		c.Set("JobsDB.maxReaders", 8)
+		c.Set("JobsDB.refreshDSListLoopSleepDuration", 1*time.Second)
+
+		// Bench configuration
+		c.Set("JobsDB.Bench.scenario", "multi_tenant")
+		c.Set("JobsDB.Bench.noOfWorkspaces", 1000) // default: 100
+		c.Set("JobsDB.Bench.zipfExponent", 1.5)    // default: 1.1
+		c.Set("JobsDB.Bench.noOfCustomVals", 8)    // default: 4
+		c.Set("JobsDB.Bench.writerConcurrency", 4)
+		c.Set("JobsDB.Bench.writerBatchSize", 10)
+		c.Set("JobsDB.Bench.insertRateLimit", 1000)
+
+		runBench(t, c, db)
+	})
+
+	t.Run("large_payload", func(t *testing.T) {
+		db := startPostgres(t)
+
+		c := config.New()
+
+		// JobsDB configuration
+		c.Set("JobsDB.maxWriters", 4)
+		c.Set("JobsDB.maxReaders", 8)
+		c.Set("JobsDB.Compression.enabled", true)
+		c.Set("JobsDB.Compression.algorithm", "lz4")
+		c.Set("JobsDB.refreshDSListLoopSleepDuration", 1*time.Second)
+
+		// Bench configuration
+		c.Set("JobsDB.Bench.scenario", "large_payload")
+		c.Set("JobsDB.Bench.payloadLimit", 1*bytesize.MB) // default: 10MB
+		c.Set("JobsDB.Bench.noOfSources", 2)              // default: 4
+		c.Set("JobsDB.Bench.insertRateLimit", 100)
+
+		runBench(t, c, db)
+	})
+
+	t.Run("failed_retry", func(t *testing.T) {
+		db := startPostgres(t)
+
+		c := config.New()
+
+		// JobsDB configuration
+		c.Set("JobsDB.maxWriters", 4)
+		c.Set("JobsDB.maxReaders", 8)
+		c.Set("JobsDB.maxDSSize", 500) // small datasets, so that there is something to migrate
+		c.Set("JobsDB.addNewDSLoopSleepDuration", 500*time.Millisecond)
+		c.Set("JobsDB.refreshDSListLoopSleepDuration", 1*time.Second)
+
+		// Bench configuration
+		c.Set("JobsDB.Bench.scenario", "failed_retry")
+		c.Set("JobsDB.Bench.noOfSources", 5)        // default: 10
+		c.Set("JobsDB.Bench.failurePercentage", 70) // default: 50
+		c.Set("JobsDB.Bench.maxAttempts", 3)
+		c.Set("JobsDB.Bench.writerBatchSize", 10)
+		c.Set("JobsDB.Bench.migrateInterval", 1*time.Second) // default: 5s
+		c.Set("JobsDB.Bench.insertRateLimit", 1000)
+
+		runBench(t, c, db)
+	})
+}
+
+// startPostgres starts a postgres container tuned for benchmarking
+func startPostgres(t *testing.T) *sql.DB {
+	t.Helper()
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	postgresContainer, err := postgres.Setup(pool, t, postgres.WithOptions(
+		"max_connections=200",
+		"max_wal_size=2GB",
+		"checkpoint_timeout=30",
+		"shared_buffers=512MB",
+		"work_mem=64MB",
+		"hash_mem_multiplier=4",
+		"maintenance_work_mem=200MB",
+		"effective_cache_size=4GB",
+		"wal_buffers=64MB",
+		"random_page_cost=1.1",
+		"autovacuum_vacuum_cost_delay=1",
+		"autovacuum_naptime=20",
+		"checkpoint_warning=0",
+	),
+		postgres.WithTag("17-alpine"),
+		postgres.WithShmSize(256*bytesize.MB),
+	)
+	require.NoError(t, err)
+	postgresContainer.DB.SetMaxOpenConns(60)
+	postgresContainer.DB.SetMaxIdleConns(20)
+	return postgresContainer.DB
+}
+
+// runBench runs the configured bench scenario for 3 seconds
+func runBench(t *testing.T, c *config.Config, db *sql.DB) {
+	t.Helper()
+	l := logger.NewFactory(c)
+	stat := stats.NewStats(c, l, statsmetric.Instance)
+	b, err := bench.New(c, stat, l.NewLogger(), db)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	g, ctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("context cancelled")
+		case <-time.After(3 * time.Second):
+			cancel()
+			return nil
+		}
+	})
+	g.Go(func() error {
+		return b.Run(ctx)
+	})
+	require.NoError(t, g.Wait())
 }
@@ -0,0 +1,269 @@
+package scenario
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	mathrand "math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+// NewFailedRetry creates a jobsdb bench scenario which emulates a pipeline with a failing destination, while datasets are being migrated:
+// 1. It creates a new jobsdb instance, benchfr.
+// 2. It spawns w*s writer go-routines, where [w] is the number of write concurrency and [s] is the number of sources.
+// 3. It spawns s reader go-routines, one for each source, which read jobs using GetToProcess and mark [f] jobs as failed, where [f] is the failure percentage, so that they are retried.
+// Jobs failing for the [a]th time are marked as aborted, where [a] is the maximum number of attempts, and the rest are marked as succeeded.
+// 4. It triggers a dataset migration every [m], where [m] is the migration interval, so that migrations compete with readers and writers.
+// 5. At the end it prints the throughput, the latency percentiles of each operation (including migrations) and the number of datasets.
+func NewFailedRetry(conf *config.Config, stats stats.Stats, log logger.Logger, db *sql.DB) *failedRetry {
+	return &failedRetry{
+		stats: stats,
+		log:   log,
+		conf:  conf,
+		db:    db,
+	}
+}
+
+type failedRetry struct {
+	stats stats.Stats
+	log   logger.Logger
+	conf  *config.Config
+	db    *sql.DB
+}
+
+func (p *failedRetry) Run(ctx context.Context) error {
+	eventPayloadSize := p.conf.GetInt64Var(1*bytesize.KB, 1, "JobsDB.Bench.payloadSize")     // size of the event payload
+	noOfSources := p.conf.GetIntVar(10, 1, "JobsDB.Bench.noOfSources")                       // number of sources
+	writerConcurrency := p.conf.GetIntVar(2, 1, "JobsDB.Bench.writerConcurrency")            // number of jobs writers go-routines for each source
+	writerBatchSize := p.conf.GetIntVar(1000, 1, "JobsDB.Bench.writerBatchSize")             // number of jobs writers write in one go
+	readerReadSize := p.conf.GetIntVar(10000, 1, "JobsDB.Bench.readerReadSize")              // number of jobs readers read in one go
+	payloadLimit := p.conf.GetInt64Var(100*bytesize.MB, 1, "JobsDB.Bench.payloadLimit")      // if 0, no limit will be applied on the size of the payload queried
+	dsLimit := p.conf.GetReloadableIntVar(0, 1, "JobsDB.Bench.dsLimit", "JobsDB.dsLimit")    // if 0, no limit will be applied on the number of data sets queried
+	failurePercentage := p.conf.GetIntVar(50, 1, "JobsDB.Bench.failurePercentage")           // percentage of jobs that will fail, i.e. be marked as failed or aborted
+	maxAttempts := p.conf.GetIntVar(3, 1, "JobsDB.Bench.maxAttempts")                        // number of attempts after which failing jobs are marked as aborted
+	migrateInterval := p.conf.GetDurationVar(5, time.Second, "JobsDB.Bench.migrateInterval") // interval between triggered dataset migrations
+	migrateTimeout := p.conf.GetDurationVar(5, time.Minute, "JobsDB.Bench.migrateTimeout")   // timeout of each triggered dataset migration
+
+	insertLimiter, err := rateLimiter(p.conf.GetIntVar(0, 1, "JobsDB.Bench.insertRateLimit"))
+	if err != nil {
+		return fmt.Errorf("could not create insert rate limiter: %w", err)
+	}
+
+	db := jobsdb.NewForReadWrite(
+		"benchfr",
+		jobsdb.WithClearDB(true),
+		jobsdb.WithStats(p.stats),
+		jobsdb.WithDBHandle(p.db),
+		jobsdb.WithConfig(p.conf),
+		jobsdb.WithDSLimit(dsLimit),
+		jobsdb.WithSkipMaintenanceErr(true),
+	)
+	defer db.Close()
+	if err := db.Start(); err != nil {
+		return fmt.Errorf("could not start benchfr jobsdb: %w", err)
+	}
+	defer db.Stop()
+
+	customVal := "benchmark"
+	eventPayload := newEventPayload(eventPayloadSize)
+
+	var (
+		latencies = newLatencies()
+		written   atomic.Int64 // total number of jobs written
+		processed atomic.Int64 // total number of jobs marked as succeeded or aborted
+		retried   atomic.Int64 // total number of jobs read again after failing
+		migrated  atomic.Int64 // total number of triggered dataset migrations
+
+		write     atomic.Int64 // number of jobs written in the last second
+		succeeded atomic.Int64 // number of jobs marked as succeeded in the last second
+		failed    atomic.Int64 // number of jobs marked as failed in the last second
+		aborted   atomic.Int64 // number of jobs marked as aborted in the last second
+	)
+	start := time.Now()
+	g, ctx := errgroup.WithContext(ctx)
+	for i := range noOfSources {
+		sourceID := fmt.Sprintf("source-%d", i)
+
+		for range writerConcurrency {
+			g.Go(func() error {
+				for {
+					select {
+					case <-ctx.Done():
+						return nil
+					default:
+						jobs := make([]*jobsdb.JobT, 0, writerBatchSize)
+						for range writerBatchSize {
+							jobs = append(jobs, &jobsdb.JobT{
+								UUID:         uuid.New(),
+								UserID:       uuid.New().String(),
+								CreatedAt:    time.Now().UTC(),
+								EventCount:   1,
+								WorkspaceId:  "workspace",
+								Parameters:   []byte(fmt.Sprintf(`{"source_id": %q}`, sourceID)),
+								CustomVal:    customVal,
+								EventPayload: eventPayload,
+							})
+						}
+						if err := insertLimiter(ctx, "benchfr", len(jobs)); err != nil {
+							return fmt.Errorf("could not check insert rate limit: %w", err)
+						}
+						storeStart := time.Now()
+						if err := db.Store(ctx, jobs); err != nil {
+							if ctx.Err() != nil {
+								return nil // nolint: nilerr
+							}
+							return fmt.Errorf("could not write jobs: %w", err)
+						}
+						latencies.since("store", storeStart)
+						write.Add(int64(len(jobs)))
+					}
+				}
+			})
+		}
+
+		g.Go(func() error { // we can only have one reader per source
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				default:
+					getStart := time.Now()
+					jobs, err := db.GetToProcess(ctx, jobsdb.GetQueryParams{
+						CustomValFilters: []string{customVal},
+						JobsLimit:        readerReadSize,
+						EventsLimit:      readerReadSize,
+						PayloadSizeLimit: payloadLimit,
+						ParameterFilters: []jobsdb.ParameterFilterT{{Name: "source_id", Value: sourceID}},
+					}, nil)
+					if err != nil {
+						if ctx.Err() != nil {
+							return nil // nolint: nilerr
+						}
+						return fmt.Errorf("could not get jobs: %w", err)
+					}
+					latencies.since("get", getStart)
+					if len(jobs.Jobs) == 0 {
+						continue
+					}
+
+					statusList := make([]*jobsdb.JobStatusT, 0, len(jobs.Jobs))
+					var succeededCount, failedCount, abortedCount, retriedCount int64
+					for _, job := range jobs.Jobs {
+						attempt := job.LastJobStatus.AttemptNum + 1
+						if attempt > 1 {
+							retriedCount++
+						}
+						status := jobsdb.Succeeded.State
+						errorCode := "200"
+						// respect failure percentage
+						if mathrand.Float64()*100 < float64(failurePercentage) {
+							status = jobsdb.Failed.State
+							errorCode = "500"
+							if attempt >= maxAttempts {
+								status = jobsdb.Aborted.State
+							}
+						}
+						switch status {
+						case jobsdb.Succeeded.State:
+							succeededCount++
+						case jobsdb.Failed.State:
+							failedCount++
+						default:
+							abortedCount++
+						}
+						statusList = append(statusList, &jobsdb.JobStatusT{
+							JobID:         job.JobID,
+							JobState:      status,
+							AttemptNum:    attempt,
+							ExecTime:      time.Now(),
+							RetryTime:     time.Now(),
+							ErrorCode:     errorCode,
+							ErrorResponse: []byte(`{"success":"OK"}`),
+							Parameters:    []byte(`{}`),
+							JobParameters: job.Parameters,
+							WorkspaceId:   job.WorkspaceId,
+						})
+					}
+					updateStart := time.Now()
+					if err := db.UpdateJobStatus(ctx, statusList, []string{customVal}, nil); err != nil {
+						if ctx.Err() != nil {
+							return nil // nolint: nilerr
+						}
+						return fmt.Errorf("could not update jobs: %w", err)
+					}
+					latencies.since("update", updateStart)
+					processed.Add(succeededCount + abortedCount)
+					retried.Add(retriedCount)
+					succeeded.Add(succeededCount)
+					failed.Add(failedCount)
+					aborted.Add(abortedCount)
+				}
+			}
+		})
+	}
+
+	// trigger dataset migrations while jobs are being written, read and retried
+	g.Go(func() error {
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(migrateInterval):
+				migrateCtx, cancel := context.WithTimeout(ctx, migrateTimeout)
+				migrateStart := time.Now()
+				err := db.MigrateDS(migrateCtx)
+				cancel()
+				if err != nil {
+					if ctx.Err() != nil {
+						return nil // nolint: nilerr
+					}
+					return fmt.Errorf("could not migrate datasets: %w", err)
+				}
+				latencies.since("migrate", migrateStart)
+				migrated.Add(1)
+			}
+		}
+	})
+
+	// print stats every second
+	g.Go(func() error {
+		previousTime := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(1 * time.Second):
+				currentTime := time.Now()
+				dur := currentTime.Sub(previousTime)
+				previousTime = currentTime
+				writtenS := write.Swap(0)
+				written.Add(writtenS)
+				fmt.Printf("[%[1]s] Processed %[2]d/%[3]d events in %[4]s. write: %.2[5]f events/second, succeeded: %.2[6]f events/second, failed: %.2[7]f events/second, aborted: %.2[8]f events/second, migrations: %[9]d\n",
+					currentTime.Format("15:04:05"),
+					processed.Load(),
+					written.Load(),
+					time.Since(start),
+					float64(writtenS)/dur.Seconds(),
+					float64(succeeded.Swap(0))/dur.Seconds(),
+					float64(failed.Swap(0))/dur.Seconds(),
+					float64(aborted.Swap(0))/dur.Seconds(),
+					migrated.Load(),
+				)
+			}
+		}
+	})
+	err = g.Wait()
+	printSummary("failed_retry", time.Since(start), written.Load()+write.Load(), processed.Load(), latencies, db)
+	fmt.Printf("  retried: %d events, migrations: %d\n", retried.Load(), migrated.Load())
+	return err
+}
@@ -0,0 +1,216 @@
+package scenario
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	mathrand "math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+// NewLargePayload creates a jobsdb bench scenario which emulates a pipeline with payloads close to the payload size limit of readers:
+// 1. It creates a new jobsdb instance, benchlp.
+// 2. It spawns w*s writer go-routines, where [w] is the number of write concurrency and [s] is the number of sources.
+// Writers store jobs with payloads between half the payload size and the payload size, which defaults to 90% of the payload limit.
+// 3. It spawns s reader go-routines, one for each source, which read jobs using GetToProcess with the payload limit and mark them as succeeded.
+// 4. At the end it prints the throughput, the latency percentiles of each operation and the number of datasets.
+func NewLargePayload(conf *config.Config, stats stats.Stats, log logger.Logger, db *sql.DB) *largePayload {
+	return &largePayload{
+		stats: stats,
+		log:   log,
+		conf:  conf,
+		db:    db,
+	}
+}
+
+type largePayload struct {
+	stats stats.Stats
+	log   logger.Logger
+	conf  *config.Config
+	db    *sql.DB
+}
+
+func (p *largePayload) Run(ctx context.Context) error {
+	payloadLimit := p.conf.GetInt64Var(10*bytesize.MB, 1, "JobsDB.Bench.payloadLimit")       // limit of the size of the payload queried
+	eventPayloadSize := p.conf.GetInt64Var(payloadLimit*9/10, 1, "JobsDB.Bench.payloadSize") // maximum size of the event payload
+	noOfPayloads := p.conf.GetIntVar(10, 1, "JobsDB.Bench.noOfPayloads")                     // number of distinct event payloads of random size to choose from
+	noOfSources := p.conf.GetIntVar(4, 1, "JobsDB.Bench.noOfSources")                        // number of sources
+	writerConcurrency := p.conf.GetIntVar(2, 1, "JobsDB.Bench.writerConcurrency")            // number of jobs writers go-routines for each source
+	writerBatchSize := p.conf.GetIntVar(1, 1, "JobsDB.Bench.writerBatchSize")                // number of jobs writers write in one go
+	readerReadSize := p.conf.GetIntVar(100, 1, "JobsDB.Bench.readerReadSize")                // number of jobs readers read in one go
+	dsLimit := p.conf.GetReloadableIntVar(0, 1, "JobsDB.Bench.dsLimit", "JobsDB.dsLimit")    // if 0, no limit will be applied on the number of data sets queried
+
+	insertLimiter, err := rateLimiter(p.conf.GetIntVar(0, 1, "JobsDB.Bench.insertRateLimit"))
+	if err != nil {
+		return fmt.Errorf("could not create insert rate limiter: %w", err)
+	}
+
+	db := jobsdb.NewForReadWrite(
+		"benchlp",
+		jobsdb.WithClearDB(true),
+		jobsdb.WithStats(p.stats),
+		jobsdb.WithDBHandle(p.db),
+		jobsdb.WithConfig(p.conf),
+		jobsdb.WithDSLimit(dsLimit),
+		jobsdb.WithSkipMaintenanceErr(true),
+	)
+	defer db.Close()
+	if err := db.Start(); err != nil {
+		return fmt.Errorf("could not start benchlp jobsdb: %w", err)
+	}
+	defer db.Stop()
+
+	customVal := "benchmark"
+	eventPayloads := make([][]byte, noOfPayloads)
+	for i := range eventPayloads {
+		eventPayloads[i] = newEventPayload(eventPayloadSize/2 + mathrand.Int64N(eventPayloadSize/2+1))
+	}
+
+	var (
+		latencies = newLatencies()
+		written   atomic.Int64 // total number of jobs written
+		processed atomic.Int64 // total number of jobs marked as succeeded
+		readBytes atomic.Int64 // total size of the payloads read
+
+		write atomic.Int64 // number of jobs written in the last second
+		read  atomic.Int64 // number of jobs read in the last second
+	)
+	start := time.Now()
+	g, ctx := errgroup.WithContext(ctx)
+	for i := range noOfSources {
+		sourceID := fmt.Sprintf("source-%d", i)
+
+		for range writerConcurrency {
+			g.Go(func() error {
+				for {
+					select {
+					case <-ctx.Done():
+						return nil
+					default:
+						jobs := make([]*jobsdb.JobT, 0, writerBatchSize)
+						for range writerBatchSize {
+							jobs = append(jobs, &jobsdb.JobT{
+								UUID:         uuid.New(),
+								UserID:       uuid.New().String(),
+								CreatedAt:    time.Now().UTC(),
+								EventCount:   1,
+								WorkspaceId:  "workspace",
+								Parameters:   []byte(fmt.Sprintf(`{"source_id": %q}`, sourceID)),
+								CustomVal:    customVal,
+								EventPayload: eventPayloads[mathrand.IntN(noOfPayloads)],
+							})
+						}
+						if err := insertLimiter(ctx, "benchlp", len(jobs)); err != nil {
+							return fmt.Errorf("could not check insert rate limit: %w", err)
+						}
+						storeStart := time.Now()
+						if err := db.Store(ctx, jobs); err != nil {
+							if ctx.Err() != nil {
+								return nil // nolint: nilerr
+							}
+							return fmt.Errorf("could not write jobs: %w", err)
+						}
+						latencies.since("store", storeStart)
+						write.Add(int64(len(jobs)))
+					}
+				}
+			})
+		}
+
+		g.Go(func() error { // we can only have one reader per source
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				default:
+					getStart := time.Now()
+					jobs, err := db.GetToProcess(ctx, jobsdb.GetQueryParams{
+						CustomValFilters: []string{customVal},
+						JobsLimit:        readerReadSize,
+						EventsLimit:      readerReadSize,
+						PayloadSizeLimit: payloadLimit,
+						ParameterFilters: []jobsdb.ParameterFilterT{{Name: "source_id", Value: sourceID}},
+					}, nil)
+					if err != nil {
+						if ctx.Err() != nil {
+							return nil // nolint: nilerr
+						}
+						return fmt.Errorf("could not get jobs: %w", err)
+					}
+					latencies.since("get", getStart)
+					if len(jobs.Jobs) == 0 {
+						continue
+					}
+					read.Add(int64(len(jobs.Jobs)))
+					readBytes.Add(jobs.PayloadSize)
+
+					statusList := make([]*jobsdb.JobStatusT, 0, len(jobs.Jobs))
+					for _, job := range jobs.Jobs {
+						statusList = append(statusList, &jobsdb.JobStatusT{
+							JobID:         job.JobID,
+							JobState:      jobsdb.Succeeded.State,
+							AttemptNum:    1,
+							ExecTime:      time.Now(),
+							RetryTime:     time.Now(),
+							ErrorCode:     "200",
+							ErrorResponse: []byte(`{"success":"OK"}`),
+							Parameters:    []byte(`{}`),
+							JobParameters: job.Parameters,
+							WorkspaceId:   job.WorkspaceId,
+						})
+					}
+					updateStart := time.Now()
+					if err := db.UpdateJobStatus(ctx, statusList, []string{customVal}, nil); err != nil {
+						if ctx.Err() != nil {
+							return nil // nolint: nilerr
+						}
+						return fmt.Errorf("could not update jobs: %w", err)
+					}
+					latencies.since("update", updateStart)
+					processed.Add(int64(len(statusList)))
+				}
+			}
+		})
+	}
+
+	// print stats every second
+	g.Go(func() error {
+		previousTime := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(1 * time.Second):
+				currentTime := time.Now()
+				dur := currentTime.Sub(previousTime)
+				previousTime = currentTime
+				writtenS := write.Swap(0)
+				written.Add(writtenS)
+				fmt.Printf("[%[1]s] Processed %[2]d/%[3]d events in %[4]s. write: %.2[5]f events/second, read: %.2[6]f events/second, mean payload read rate: %.2[7]f MB/second\n",
+					currentTime.Format("15:04:05"),
+					processed.Load(),
+					written.Load(),
+					time.Since(start),
+					float64(writtenS)/dur.Seconds(),
+					float64(read.Swap(0))/dur.Seconds(),
+					float64(readBytes.Load())/float64(bytesize.MB)/time.Since(start).Seconds(),
+				)
+			}
+		}
+	})
+	err = g.Wait()
+	elapsed := time.Since(start)
+	printSummary("large_payload", elapsed, written.Load()+write.Load(), processed.Load(), latencies, db)
+	fmt.Printf("  read payloads: %.2f MB (%.2f MB/second)\n", float64(readBytes.Load())/float64(bytesize.MB), float64(readBytes.Load())/float64(bytesize.MB)/elapsed.Seconds())
+	return err
+}
@@ -0,0 +1,230 @@
+package scenario
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	mathrand "math/rand/v2"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+// NewMultiTenant creates a jobsdb bench scenario which emulates a multi-tenant router with skewed traffic:
+// 1. It creates a new jobsdb instance, benchmt.
+// 2. It spawns w writer go-routines, where [w] is the number of write concurrency. Writers distribute jobs across [n] workspaces following a zipf distribution with exponent [z],
+// so that a few workspaces generate most of the traffic, and across [c] custom vals uniformly.
+// 3. It spawns c reader go-routines, one for each custom val, which read jobs using GetToProcess and mark [100-f] jobs as succeeded and [f] jobs as failed, where [f] is the failure percentage.
+// 4. At the end it prints the throughput, the latency percentiles of each operation and the number of datasets.
+func NewMultiTenant(conf *config.Config, stats stats.Stats, log logger.Logger, db *sql.DB) *multiTenant {
+	return &multiTenant{
+		stats: stats,
+		log:   log,
+		conf:  conf,
+		db:    db,
+	}
+}
+
+type multiTenant struct {
+	stats stats.Stats
+	log   logger.Logger
+	conf  *config.Config
+	db    *sql.DB
+}
+
+func (p *multiTenant) Run(ctx context.Context) error {
+	eventPayloadSize := p.conf.GetInt64Var(1*bytesize.KB, 1, "JobsDB.Bench.payloadSize")  // size of the event payload
+	noOfWorkspaces := p.conf.GetIntVar(100, 1, "JobsDB.Bench.noOfWorkspaces")             // number of workspaces
+	zipfExponent := p.conf.GetFloat64Var(1.1, "JobsDB.Bench.zipfExponent")                // exponent of the zipf distribution of jobs across workspaces, must be greater than 1
+	noOfCustomVals := p.conf.GetIntVar(4, 1, "JobsDB.Bench.noOfCustomVals")               // number of custom vals, i.e. destination types, with a reader each
+	writerConcurrency := p.conf.GetIntVar(4, 1, "JobsDB.Bench.writerConcurrency")         // number of jobs writers go-routines
+	writerBatchSize := p.conf.GetIntVar(1000, 1, "JobsDB.Bench.writerBatchSize")          // number of jobs writers write in one go
+	readerReadSize := p.conf.GetIntVar(10000, 1, "JobsDB.Bench.readerReadSize")           // number of jobs readers read in one go
+	payloadLimit := p.conf.GetInt64Var(100*bytesize.MB, 1, "JobsDB.Bench.payloadLimit")   // if 0, no limit will be applied on the size of the payload queried
+	dsLimit := p.conf.GetReloadableIntVar(0, 1, "JobsDB.Bench.dsLimit", "JobsDB.dsLimit") // if 0, no limit will be applied on the number of data sets queried
+	failurePercentage := p.conf.GetIntVar(1, 1, "JobsDB.Bench.failurePercentage")         // percentage of jobs that will fail, i.e. be marked as failed
+	if zipfExponent <= 1 {
+		return fmt.Errorf("zipf exponent must be greater than 1: %v", zipfExponent)
+	}
+
+	insertLimiter, err := rateLimiter(p.conf.GetIntVar(0, 1, "JobsDB.Bench.insertRateLimit"))
+	if err != nil {
+		return fmt.Errorf("could not create insert rate limiter: %w", err)
+	}
+
+	db := jobsdb.NewForReadWrite(
+		"benchmt",
+		jobsdb.WithClearDB(true),
+		jobsdb.WithStats(p.stats),
+		jobsdb.WithDBHandle(p.db),
+		jobsdb.WithConfig(p.conf),
+		jobsdb.WithDSLimit(dsLimit),
+		jobsdb.WithSkipMaintenanceErr(true),
+	)
+	defer db.Close()
+	if err := db.Start(); err != nil {
+		return fmt.Errorf("could not start benchmt jobsdb: %w", err)
+	}
+	defer db.Stop()
+
+	eventPayload := newEventPayload(eventPayloadSize)
+	customVals := make([]string, noOfCustomVals)
+	for i := range customVals {
+		customVals[i] = fmt.Sprintf("custom-val-%d", i)
+	}
+
+	var (
+		latencies = newLatencies()
+		written   atomic.Int64 // total number of jobs written
+		processed atomic.Int64 // total number of jobs marked as succeeded
+
+		write  atomic.Int64 // number of jobs written in the last second
+		read   atomic.Int64 // number of jobs read in the last second
+		failed atomic.Int64 // number of jobs marked as failed in the last second
+	)
+	start := time.Now()
+	g, ctx := errgroup.WithContext(ctx)
+	for range writerConcurrency {
+		g.Go(func() error {
+			zipf := mathrand.NewZipf(mathrand.New(mathrand.NewPCG(mathrand.Uint64(), mathrand.Uint64())), zipfExponent, 1, uint64(noOfWorkspaces-1))
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				default:
+					jobs := make([]*jobsdb.JobT, 0, writerBatchSize)
+					for range writerBatchSize {
+						workspace := zipf.Uint64()
+						customVal := customVals[mathrand.IntN(noOfCustomVals)]
+						jobs = append(jobs, &jobsdb.JobT{
+							UUID:         uuid.New(),
+							UserID:       uuid.New().String(),
+							CreatedAt:    time.Now().UTC(),
+							EventCount:   1,
+							WorkspaceId:  fmt.Sprintf("workspace-%d", workspace),
+							Parameters:   []byte(fmt.Sprintf(`{"source_id": "source-%[1]d", "destination_id": "%[2]s-%[1]d"}`, workspace, customVal)),
+							CustomVal:    customVal,
+							EventPayload: eventPayload,
+						})
+					}
+					if err := insertLimiter(ctx, "benchmt", len(jobs)); err != nil {
+						return fmt.Errorf("could not check insert rate limit: %w", err)
+					}
+					storeStart := time.Now()
+					if err := db.Store(ctx, jobs); err != nil {
+						if ctx.Err() != nil {
+							return nil // nolint: nilerr
+						}
+						return fmt.Errorf("could not write jobs: %w", err)
+					}
+					latencies.since("store", storeStart)
+					write.Add(int64(len(jobs)))
+				}
+			}
+		})
+	}
+
+	for _, customVal := range customVals {
+		g.Go(func() error { // we can only have one reader per custom val
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				default:
+					getStart := time.Now()
+					jobs, err := db.GetToProcess(ctx, jobsdb.GetQueryParams{
+						CustomValFilters: []string{customVal},
+						JobsLimit:        readerReadSize,
+						EventsLimit:      readerReadSize,
+						PayloadSizeLimit: payloadLimit,
+					}, nil)
+					if err != nil {
+						if ctx.Err() != nil {
+							return nil // nolint: nilerr
+						}
+						return fmt.Errorf("could not get jobs: %w", err)
+					}
+					latencies.since("get", getStart)
+					if len(jobs.Jobs) == 0 {
+						continue
+					}
+					read.Add(int64(len(jobs.Jobs)))
+
+					statusList := make([]*jobsdb.JobStatusT, 0, len(jobs.Jobs))
+					var succeededCount, failedCount int64
+					for _, job := range jobs.Jobs {
+						status := jobsdb.Succeeded.State
+						errorCode := "200"
+						// respect failure percentage
+						if mathrand.Float64()*100 < float64(failurePercentage) {
+							status = jobsdb.Failed.State
+							errorCode = "500"
+							failedCount++
+						} else {
+							succeededCount++
+						}
+						statusList = append(statusList, &jobsdb.JobStatusT{
+							JobID:         job.JobID,
+							JobState:      status,
+							AttemptNum:    job.LastJobStatus.AttemptNum + 1,
+							ExecTime:      time.Now(),
+							RetryTime:     time.Now(),
+							ErrorCode:     errorCode,
+							ErrorResponse: []byte(`{"success":"OK"}`),
+							Parameters:    []byte(`{}`),
+							JobParameters: job.Parameters,
+							WorkspaceId:   job.WorkspaceId,
+						})
+					}
+					updateStart := time.Now()
+					if err := db.UpdateJobStatus(ctx, statusList, []string{customVal}, nil); err != nil {
+						if ctx.Err() != nil {
+							return nil // nolint: nilerr
+						}
+						return fmt.Errorf("could not update jobs: %w", err)
+					}
+					latencies.since("update", updateStart)
+					processed.Add(succeededCount)
+					failed.Add(failedCount)
+				}
+			}
+		})
+	}
+
+	// print stats every second
+	g.Go(func() error {
+		previousTime := time.Now()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(1 * time.Second):
+				currentTime := time.Now()
+				dur := currentTime.Sub(previousTime)
+				previousTime = currentTime
+				writtenS := write.Swap(0)
+				written.Add(writtenS)
+				fmt.Printf("[%[1]s] Processed %[2]d/%[3]d events in %[4]s. write: %.2[5]f events/second, read: %.2[6]f events/second, failed: %.2[7]f events/second\n",
+					currentTime.Format("15:04:05"),
+					processed.Load(),
+					written.Load(),
+					time.Since(start),
+					float64(writtenS)/dur.Seconds(),
+					float64(read.Swap(0))/dur.Seconds(),
+					float64(failed.Swap(0))/dur.Seconds(),
+				)
+			}
+		}
+	})
+	err = g.Wait()
+	printSummary("multi_tenant", time.Since(start), written.Load()+write.Load(), processed.Load(), latencies, db)
+	return err
+}
@@ -110,7 +110,10 @@ func (p *simple) Run(ctx context.Context) error {
 		write  atomic.Int64 // number of jobs written in the last second
 		read   atomic.Int64 // number of jobs read in the last second
 		update atomic.Int64 // number of jobs updated in the last second
+
+		latencies = newLatencies()
 	)
+	start := time.Now()
 	for i := 0; i < noOfSources; i++ {
 		sourceID := fmt.Sprintf("source-%d", i)
 		for range writerConcurrency {
@@ -138,12 +141,14 @@ func (p *simple) Run(ctx context.Context) error {
 						if err := insertLimiter(ctx, "bench", len(jobs)); err != nil {
 							return fmt.Errorf("could not check insert rate limit: %w", err)
 						}
+						storeStart := time.Now()
 						if err := writerDB.Store(ctx, jobs); err != nil {
 							if ctx.Err() != nil {
 								return nil // nolint: nilerr
 							}
 							return fmt.Errorf("could not write jobs: %w", err)
 						}
+						latencies.since("store", storeStart)
 						write.Add(int64(len(jobs)))
 					}
 				}
@@ -164,6 +169,7 @@ func (p *simple) Run(ctx context.Context) error {
 						}
 					}
 
+					getStart := time.Now()
 					jobs, err := readerDB.GetUnprocessed(ctx, jobsdb.GetQueryParams{
 						CustomValFilters: []string{customVal},
 						JobsLimit:        readerReadSize,
@@ -177,6 +183,7 @@ func (p *simple) Run(ctx context.Context) error {
 						}
 						return fmt.Errorf("could not get jobs: %w", err)
 					}
+					latencies.since("get", getStart)
 					read.Add(int64(len(jobs.Jobs)))
 					g, ctx := errgroup.WithContext(ctx)
 					noOfChunks := len(jobs.Jobs) / updateConcurrency
@@ -202,12 +209,14 @@ func (p *simple) Run(ctx context.Context) error {
 									WorkspaceId:   job.WorkspaceId,
 								})
 							}
+							updateStart := time.Now()
 							if err := readerDB.UpdateJobStatus(ctx, statusList, []string{customVal}, nil); err != nil {
 								if ctx.Err() != nil {
 									return nil // nolint: nilerr
 								}
 								return fmt.Errorf("could not write jobs: %w", err)
 							}
+							latencies.since("update", updateStart)
 							update.Add(int64(len(statusList)))
 							return nil
 						})
@@ -222,7 +231,6 @@ func (p *simple) Run(ctx context.Context) error {
 
 	g.Go(func() error { // print stats every second
 		var backlogReached bool
-		start := time.Now()
 		previousTime := time.Now()
 		for {
 			select {
@@ -260,5 +268,7 @@ func (p *simple) Run(ctx context.Context) error {
 			}
 		}
 	})
-	return g.Wait()
+	err = g.Wait()
+	printSummary("simple", time.Since(start), total.Load()+write.Load(), processed.Load()+update.Load(), latencies, writerDB)
+	return err
 }
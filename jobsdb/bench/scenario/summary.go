@@ -0,0 +1,101 @@
+package scenario
+
+import (
+	"context"
+	"fmt"
+	mathrand "math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+)
+
+// latencySampleSize is the maximum number of latencies kept per operation for calculating percentiles
+const latencySampleSize = 10000
+
+// latencies records the latencies of jobsdb operations (e.g. store, get, update), keeping a uniform sample of up to [latencySampleSize] latencies per operation
+type latencies struct {
+	mu      sync.Mutex
+	ops     []string
+	samples map[string]*latencySample
+}
+
+type latencySample struct {
+	count  int64
+	values []time.Duration
+}
+
+func newLatencies() *latencies {
+	return &latencies{samples: make(map[string]*latencySample)}
+}
+
+// since records the latency of an operation started at start
+func (l *latencies) since(op string, start time.Time) {
+	d := time.Since(start)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	s, ok := l.samples[op]
+	if !ok {
+		s = &latencySample{}
+		l.samples[op] = s
+		l.ops = append(l.ops, op)
+	}
+	s.count++
+	if len(s.values) < latencySampleSize {
+		s.values = append(s.values, d)
+	} else if i := mathrand.Int64N(s.count); i < latencySampleSize { // reservoir sampling
+		s.values[i] = d
+	}
+}
+
+// String returns the latency percentiles of all recorded operations, one line per operation
+func (l *latencies) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var sb strings.Builder
+	for _, op := range l.ops {
+		s := l.samples[op]
+		values := slices.Clone(s.values)
+		slices.Sort(values)
+		percentile := func(p float64) time.Duration {
+			return values[int(float64(len(values)-1)*p)]
+		}
+		fmt.Fprintf(&sb, "  %-12s count: %d, p50: %s, p90: %s, p95: %s, p99: %s, max: %s\n",
+			op+":", s.count, percentile(0.5), percentile(0.9), percentile(0.95), percentile(0.99), values[len(values)-1])
+	}
+	return sb.String()
+}
+
+// printSummary prints the throughput, latency percentiles and dataset counts of a scenario's run
+func printSummary(scenario string, elapsed time.Duration, written, processed int64, l *latencies, dbs ...*jobsdb.Handle) {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%s] Summary after %s\n", scenario, elapsed.Round(time.Millisecond))
+	fmt.Fprintf(&sb, "  written: %d events (%.2f events/second), processed: %d events (%.2f events/second)\n",
+		written, float64(written)/elapsed.Seconds(), processed, float64(processed)/elapsed.Seconds())
+	sb.WriteString(l.String())
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, db := range dbs {
+		datasets, err := db.DatasetStats(ctx)
+		if err != nil {
+			fmt.Fprintf(&sb, "  %s datasets: could not get dataset stats: %v\n", db.Identifier(), err)
+			continue
+		}
+		var jobs, statusRows int64
+		for _, ds := range datasets {
+			jobs += ds.Jobs
+			statusRows += ds.StatusRows
+		}
+		fmt.Fprintf(&sb, "  %s datasets: %d, jobs: %d, status rows: %d\n", db.Identifier(), len(datasets), jobs, statusRows)
+	}
+	fmt.Print(sb.String())
+}
+
+// newEventPayload returns a json event payload of the given size
+func newEventPayload(size int64) []byte {
+	const envelope = `{"value":""}`
+	return []byte(`{"value":"` + rand.String(max(int(size)-len(envelope), 0)) + `"}`)
+}
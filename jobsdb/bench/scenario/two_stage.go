@@ -130,7 +130,10 @@ func (p *twoStage) Run(ctx context.Context) error {
 		write  atomic.Int64 // number of jobs written to benchone in the last second
 		read   atomic.Int64 // number of jobs read from benchone in the last second
 		update atomic.Int64 // number of jobs updated in benchone in the last second
+
+		latencies = newLatencies()
 	)
+	start := time.Now()
 	for i := range noOfSources {
 		sourceID := fmt.Sprintf("source-%d", i)
 
@@ -159,12 +162,14 @@ func (p *twoStage) Run(ctx context.Context) error {
 						if err := insertLimiter(ctx, "benchone", len(jobs)); err != nil {
 							return fmt.Errorf("could not check insert rate limit: %w", err)
 						}
+						storeStart := time.Now()
 						if err := writerDB1.Store(ctx, jobs); err != nil {
 							if ctx.Err() != nil {
 								return nil // nolint: nilerr
 							}
 							return fmt.Errorf("could not write jobs: %w", err)
 						}
+						latencies.since("store1", storeStart)
 						write.Add(int64(len(jobs)))
 					}
 				}
@@ -184,6 +189,7 @@ func (p *twoStage) Run(ctx context.Context) error {
 						return nil
 					case <-backlogCh:
 					}
+					getStart := time.Now()
 					jobs, err := readerDB1.GetUnprocessed(ctx, jobsdb.GetQueryParams{
 						CustomValFilters: []string{customVal},
 						JobsLimit:        readerReadSize,
@@ -197,6 +203,7 @@ func (p *twoStage) Run(ctx context.Context) error {
 						}
 						return fmt.Errorf("could not get jobs: %w", err)
 					}
+					latencies.since("get1", getStart)
 					read.Add(int64(len(jobs.Jobs)))
 
 					g, ctx := errgroup.WithContext(ctx)
@@ -209,12 +216,14 @@ func (p *twoStage) Run(ctx context.Context) error {
 						chunk := chunk
 						g.Go(func() error {
 							// store jobs in benchtwo
+							storeStart := time.Now()
 							if err := db2.Store(ctx, chunk); err != nil {
 								if ctx.Err() != nil {
 									return nil // nolint: nilerr
 								}
 								return fmt.Errorf("could not write jobs: %w", err)
 							}
+							latencies.since("store2", storeStart)
 
 							// mark benchone jobs as complete
 							var statusList []*jobsdb.JobStatusT
@@ -232,12 +241,14 @@ func (p *twoStage) Run(ctx context.Context) error {
 									WorkspaceId:   job.WorkspaceId,
 								})
 							}
+							updateStart := time.Now()
 							if err := readerDB1.UpdateJobStatus(ctx, statusList, []string{customVal}, nil); err != nil {
 								if ctx.Err() != nil {
 									return nil // nolint: nilerr
 								}
 								return fmt.Errorf("could not write jobs: %w", err)
 							}
+							latencies.since("update1", updateStart)
 							update.Add(int64(len(statusList)))
 							return nil
 						})
@@ -256,6 +267,7 @@ func (p *twoStage) Run(ctx context.Context) error {
 				case <-ctx.Done():
 					return nil
 				default:
+					getStart := time.Now()
 					jobs, err := db2.GetToProcess(ctx, jobsdb.GetQueryParams{
 						CustomValFilters: []string{customVal},
 						JobsLimit:        readerReadSize,
@@ -269,6 +281,7 @@ func (p *twoStage) Run(ctx context.Context) error {
 						}
 						return fmt.Errorf("could not get jobs: %w", err)
 					}
+					latencies.since("get2", getStart)
 
 					// mark benchtwo jobs as complete/aborted/failed
 					g, ctx := errgroup.WithContext(ctx)
@@ -307,12 +320,14 @@ func (p *twoStage) Run(ctx context.Context) error {
 									WorkspaceId:   job.WorkspaceId,
 								})
 							}
+							updateStart := time.Now()
 							if err := db2.UpdateJobStatus(ctx, statusList, []string{customVal}, nil); err != nil {
 								if ctx.Err() != nil {
 									return nil // nolint: nilerr
 								}
 								return fmt.Errorf("could not write jobs: %w", err)
 							}
+							latencies.since("update2", updateStart)
 							succeeded.Add(int64(succeededCount))
 							failed.Add(int64(failedCount))
 							return nil
@@ -329,7 +344,6 @@ func (p *twoStage) Run(ctx context.Context) error {
 	// print stats every second
 	g.Go(func() error {
 		var backlogReached bool
-		start := time.Now()
 		previousTime := time.Now()
 		for {
 			select {
@@ -372,5 +386,7 @@ func (p *twoStage) Run(ctx context.Context) error {
 			}
 		}
 	})
-	return g.Wait()
+	err = g.Wait()
+	printSummary("two_stage", time.Since(start), total.Load()+write.Load(), processed.Load()+succeeded.Load(), latencies, writerDB1, db2)
+	return err
 }
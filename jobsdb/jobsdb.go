@@ -92,8 +92,11 @@ type GetQueryParams struct {
 	WorkspaceID                   string
 	CustomValFilters              []string
 	ParameterFilters              []ParameterFilterT
-	stateFilters                  []string
-	afterJobID                    *int64
+	// AfterJobID, if set, only returns jobs having a greater job ID, for paginating through jobs
+	AfterJobID *int64
+	// JobIDs, if set, only returns jobs having one of the provided job IDs
+	JobIDs       []int64
+	stateFilters []string
 
 	// query limits
 
@@ -306,6 +309,10 @@ type JobsDB interface {
 	DeleteExecuting()
 	FailExecuting()
 
+	// TransitionJobs moves all jobs matching the request's filter to the request's target state, calling onTransition within each batch's transaction.
+	// It returns the number of jobs transitioned, or the number of matching jobs in dry-run mode.
+	TransitionJobs(ctx context.Context, req TransitionJobsRequest, onTransition func(tx UpdateSafeTx, jobs []*JobT, statusList []*JobStatusT) error) (int, error)
+
 	/* Journal */
 
 	GetJournalEntries(opType string) (entries []JournalEntryT)
@@ -480,6 +487,8 @@ type Handle struct {
 	dsMigrationLock     *lock.Locker
 	noResultsCache      *cache.NoResultsCache[ParameterFilterT]
 
+	expiredPayloadsChecks *expiredPayloadsChecks
+
 	// table count stats
 	statTableCount        stats.Measurement
 	statPreDropTableCount stats.Measurement
@@ -505,6 +514,11 @@ type Handle struct {
 	migrateDSPaused  atomic.Bool
 	TriggerMigrateDS func() <-chan time.Time
 	TriggerRefreshDS func() <-chan time.Time
+	// TriggerPurgePayloads triggers the purging of expired payloads, see [Handle.purgeExpiredPayloads]
+	TriggerPurgePayloads func() <-chan time.Time
+
+	// maintenanceRequests carries on-demand maintenance operations (e.g. triggered through the admin api) to the migration loop
+	maintenanceRequests chan *maintenanceRequest
 
 	lifecycle struct {
 		mu      sync.Mutex
@@ -548,6 +562,14 @@ type Handle struct {
 		backup struct {
 			masterBackupEnabled config.ValueLoader[bool]
 		}
+		payloadRetention struct {
+			retentions             config.ValueLoader[[]string]
+			mode                   config.ValueLoader[string]
+			purgeLoopSleepDuration config.ValueLoader[time.Duration]
+			purgeTimeout           config.ValueLoader[time.Duration]
+			purgeBatchSize         config.ValueLoader[int]
+			checkInterval          config.ValueLoader[time.Duration]
+		}
 	}
 }
 
@@ -777,10 +799,12 @@ func (jd *Handle) Setup(
 func (jd *Handle) init() {
 	jd.dsListLock = lock.NewLocker()
 	jd.dsMigrationLock = lock.NewLocker()
+	jd.expiredPayloadsChecks = newExpiredPayloadsChecks()
 	if jd.logger == nil {
 		jd.logger = logger.NewLogger().Child("jobsdb").Child(jd.tablePrefix)
 	}
 	jd.dsRangeFuncMap = make(map[string]func() (dsRangeMinMax, error))
+	jd.maintenanceRequests = make(chan *maintenanceRequest)
 	jd.distinctValuesCache = NewDistinctValuesCache()
 
 	if jd.config == nil {
@@ -965,6 +989,17 @@ func (jd *Handle) loadConfig() {
 
 	jd.conf.indexOptimizations = jd.config.GetReloadableBoolVar(true, jd.configKeys("indexOptimizations")...)
 
+	// payloadRetention.workspaces: Payload retention period of terminal jobs per workspace, e.g. ["workspace-1:24h"]
+	jd.conf.payloadRetention.retentions = jd.config.GetReloadableStringSliceVar(nil, jd.configKeys("payloadRetention.workspaces")...)
+	// payloadRetention.mode: Either purge (expired payloads are replaced in place) or migrate (datasets with expired payloads are migrated early)
+	jd.conf.payloadRetention.mode = jd.config.GetReloadableStringVar(payloadRetentionModePurge, jd.configKeys("payloadRetention.mode")...)
+	jd.conf.payloadRetention.purgeLoopSleepDuration = jd.config.GetReloadableDurationVar(5, time.Minute, jd.configKeys("payloadRetention.purgeLoopSleepDuration")...)
+	jd.conf.payloadRetention.purgeTimeout = jd.config.GetReloadableDurationVar(10, time.Minute, jd.configKeys("payloadRetention.purgeTimeout")...)
+	// payloadRetention.purgeBatchSize: Maximum number of payloads purged in a single transaction
+	jd.conf.payloadRetention.purgeBatchSize = jd.config.GetReloadableIntVar(10000, 1, jd.configKeys("payloadRetention.purgeBatchSize")...)
+	// payloadRetention.checkInterval: Minimum interval between checking a dataset without expired payloads again, in migrate mode
+	jd.conf.payloadRetention.checkInterval = jd.config.GetReloadableDurationVar(5, time.Minute, jd.configKeys("payloadRetention.checkInterval")...)
+
 	if jd.TriggerAddNewDS == nil {
 		jd.TriggerAddNewDS = func() <-chan time.Time {
 			return time.After(jd.conf.addNewDSLoopSleepDuration.Load())
@@ -983,6 +1018,12 @@ func (jd *Handle) loadConfig() {
 		}
 	}
 
+	if jd.TriggerPurgePayloads == nil {
+		jd.TriggerPurgePayloads = func() <-chan time.Time {
+			return time.After(jd.conf.payloadRetention.purgeLoopSleepDuration.Load())
+		}
+	}
+
 	if jd.conf.jobMaxAge == nil {
 		jd.conf.jobMaxAge = jd.config.GetReloadableDurationVar(720, time.Hour, jd.configKeys("jobMaxAge")...)
 	}
@@ -1059,6 +1100,7 @@ func (jd *Handle) readerSetup(ctx context.Context, l lock.LockToken) {
 	}))
 
 	jd.startMigrateDSLoop(ctx)
+	jd.startPurgePayloadsLoop(ctx)
 }
 
 func (jd *Handle) writerSetup(ctx context.Context, l lock.LockToken) {
@@ -1092,6 +1134,7 @@ func (jd *Handle) readerWriterSetup(ctx context.Context, l lock.LockToken) {
 	}())
 
 	jd.startMigrateDSLoop(ctx)
+	jd.startPurgePayloadsLoop(ctx)
 }
 
 // Stop stops the background goroutines and waits until they finish.
@@ -2097,7 +2140,7 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 	defer jd.getTimerStat("jobsdb_get_jobs_ds_time", &tags).RecordDuration()()
 
 	containsUnprocessed := lo.Contains(stateFilters, Unprocessed.State)
-	skipCacheResult := params.afterJobID != nil
+	skipCacheResult := params.AfterJobID != nil || len(params.JobIDs) > 0
 	cacheTx := map[string]*cache.NoResultTx[ParameterFilterT]{}
 	if !skipCacheResult {
 		for _, state := range stateFilters {
@@ -2121,8 +2164,14 @@ func (jd *Handle) getJobsDS(ctx context.Context, ds dataSetT, lastDS bool, param
 	}), additionalPredicates...)
 	filterConditions = append(filterConditions, stateQuery)
 
-	if params.afterJobID != nil {
-		filterConditions = append(filterConditions, fmt.Sprintf("jobs.job_id > %d", *params.afterJobID))
+	if params.AfterJobID != nil {
+		filterConditions = append(filterConditions, fmt.Sprintf("jobs.job_id > %d", *params.AfterJobID))
+	}
+
+	if len(params.JobIDs) > 0 {
+		filterConditions = append(filterConditions, fmt.Sprintf("jobs.job_id IN (%s)", strings.Join(lo.Map(params.JobIDs, func(jobID int64, _ int) string {
+			return strconv.FormatInt(jobID, 10)
+		}), ",")))
 	}
 
 	if len(customValFilters) > 0 && !params.IgnoreCustomValFiltersInQuery {
@@ -3115,7 +3164,7 @@ func (jd *Handle) getJobs(ctx context.Context, params GetQueryParams, more MoreT
 	}
 
 	if mtoken.afterJobID != nil {
-		params.afterJobID = mtoken.afterJobID
+		params.AfterJobID = mtoken.afterJobID
 	}
 
 	if params.JobsLimit <= 0 {
@@ -3161,10 +3210,10 @@ func (jd *Handle) getJobs(ctx context.Context, params GetQueryParams, more MoreT
 		dsLimit = jd.conf.dsLimit.Load()
 	}
 	for idx, ds := range dsList {
-		if params.afterJobID != nil {
+		if params.AfterJobID != nil {
 			if idx < len(dsRangeList) { // ranges are not stored for the last ds
 				// so the following condition cannot be applied the last ds
-				if *params.afterJobID > dsRangeList[idx].maxJobID {
+				if *params.AfterJobID > dsRangeList[idx].maxJobID {
 					continue
 				}
 			}
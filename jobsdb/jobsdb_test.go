@@ -1042,11 +1042,11 @@ func TestAfterJobIDQueryParam(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, 2, len(unprocessed.Jobs))
 
-		unprocessed1, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, afterJobID: &unprocessed.Jobs[0].JobID})
+		unprocessed1, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, AfterJobID: &unprocessed.Jobs[0].JobID})
 		require.NoError(t, err)
 		require.Equal(t, 1, len(unprocessed1.Jobs))
 
-		unprocessed2, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, afterJobID: &unprocessed.Jobs[1].JobID})
+		unprocessed2, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, AfterJobID: &unprocessed.Jobs[1].JobID})
 		require.NoError(t, err)
 		require.Equal(t, 0, len(unprocessed2.Jobs))
 	})
@@ -1079,14 +1079,33 @@ func TestAfterJobIDQueryParam(t *testing.T) {
 		}
 		require.NoError(t, jobsDB.UpdateJobStatus(context.Background(), statuses, []string{customVal}, []ParameterFilterT{}))
 
-		processed1, err := jobsDB.GetFailed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, ParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: destinationID}}, JobsLimit: 100, afterJobID: &unprocessed.Jobs[0].JobID})
+		processed1, err := jobsDB.GetFailed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, ParameterFilters: []ParameterFilterT{{Name: "destination_id", Value: destinationID}}, JobsLimit: 100, AfterJobID: &unprocessed.Jobs[0].JobID})
 		require.NoError(t, err)
 		require.Equal(t, 1, len(processed1.Jobs))
 
-		processed2, err := jobsDB.GetFailed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, afterJobID: &unprocessed.Jobs[1].JobID})
+		processed2, err := jobsDB.GetFailed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, AfterJobID: &unprocessed.Jobs[1].JobID})
 		require.NoError(t, err)
 		require.Equal(t, 0, len(processed2.Jobs))
 	})
+
+	t.Run("get by job ids", func(t *testing.T) {
+		var jobsDB *Handle
+		prefix := strings.ToLower(rsRand.String(5))
+		destinationID := strings.ToLower(rsRand.String(5))
+		jobsDB = NewForReadWrite(prefix)
+		require.NoError(t, jobsDB.Start())
+		defer jobsDB.TearDown()
+		require.NoError(t, jobsDB.Store(context.Background(), generateJobs(3, destinationID)))
+		unprocessed, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100})
+		require.NoError(t, err)
+		require.Equal(t, 3, len(unprocessed.Jobs))
+
+		unprocessed1, err := jobsDB.GetUnprocessed(context.Background(), GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100, JobIDs: []int64{unprocessed.Jobs[0].JobID, unprocessed.Jobs[2].JobID, -1}})
+		require.NoError(t, err)
+		require.Equal(t, 2, len(unprocessed1.Jobs))
+		require.Equal(t, unprocessed.Jobs[0].JobID, unprocessed1.Jobs[0].JobID)
+		require.Equal(t, unprocessed.Jobs[2].JobID, unprocessed1.Jobs[1].JobID)
+	})
 }
 
 func TestDeleteExecuting(t *testing.T) {
@@ -33,15 +33,29 @@ func (jd *Handle) startMigrateDSLoop(ctx context.Context) {
 
 func (jd *Handle) migrateDSLoop(ctx context.Context) {
 	for {
+		var req *maintenanceRequest
 		select {
 		case <-jd.TriggerMigrateDS():
 			if jd.migrateDSPaused.Load() {
 				jd.logger.Debugn("migration loop paused")
 				continue
 			}
+		case req = <-jd.maintenanceRequests:
 		case <-ctx.Done():
 			return
 		}
+		if req != nil { // on-demand maintenance operations are run by the migration loop, so that they never run concurrently with a migration
+			if jd.migrateDSPaused.Load() {
+				jd.logger.Infon("on-demand maintenance operation rejected, migration loop paused", logger.NewStringField("operation", req.operation))
+				req.done <- ErrMaintenancePaused
+				continue
+			}
+			jd.logger.Infon("running on-demand maintenance operation", logger.NewStringField("operation", req.operation))
+			timeoutCtx, cancel := context.WithTimeout(ctx, jd.conf.migration.migrateDSTimeout.Load())
+			req.done <- req.run(timeoutCtx)
+			cancel()
+			continue
+		}
 		migrate := func() error {
 			start := time.Now()
 			jd.logger.Debugw("Start", "operation", "migrateDSLoop")
@@ -195,6 +209,17 @@ func (jd *Handle) doMigrateDS(ctx context.Context) error {
 					jd.distinctValuesCache.RemoveDataset(ds.JobTable)
 				}
 			})
+			var reclaimed []reclaimedPayloads // expired payloads which are dropped along with the datasets
+			for _, ds := range migrateFromDatasets {
+				dsReclaimed, err := jd.expiredPayloadsInTx(ctx, tx, ds)
+				if err != nil {
+					return fmt.Errorf("get expired payloads of %q: %w", ds.JobTable, err)
+				}
+				reclaimed = append(reclaimed, dsReclaimed...)
+			}
+			tx.AddSuccessListener(func() {
+				jd.recordReclaimedPayloads(payloadRetentionModeMigrate, reclaimed)
+			})
 			if err = jd.postMigrateHandleDS(tx, migrateFromDatasets); err != nil {
 				return fmt.Errorf("post migrate handle ds: %w", err)
 			}
@@ -628,6 +653,7 @@ func computeInsertIdx(beforeIndex, afterIndex string) (string, error) {
 // checkIfMigrateDS checks when DB is full or DB needs to be migrated.
 // We migrate the DB ONCE most of the jobs have been processed (succeeded/aborted)
 // Or when the job_status table gets too big because of lots of retries/failures
+// Or when it contains payloads exceeding their workspace's payload retention (migrate mode)
 func (jd *Handle) checkIfMigrateDS(ds dataSetT) (
 	migrate, needsPair bool, recordsLeft int, err error,
 ) {
@@ -661,6 +687,15 @@ func (jd *Handle) checkIfMigrateDS(ds dataSetT) (
 
 	recordsLeft = totalCount - delCount
 
+	// datasets containing payloads which exceeded their workspace's payload retention are migrated early, so that their terminal jobs get dropped
+	expiredPayloads, err := jd.hasExpiredPayloads(ds)
+	if err != nil {
+		return false, false, 0, err
+	}
+	if expiredPayloads {
+		return true, false, recordsLeft, nil
+	}
+
 	if jd.conf.minDSRetentionPeriod.Load() > 0 && time.Since(maxCreatedAt) < jd.conf.minDSRetentionPeriod.Load() {
 		return false, false, recordsLeft, nil
 	}
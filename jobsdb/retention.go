@@ -0,0 +1,285 @@
+package jobsdb
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-server/utils/crash"
+	. "github.com/rudderlabs/rudder-server/utils/tx" //nolint:staticcheck
+)
+
+// Payload retention modes, i.e. how the payloads of terminal jobs are disposed of once they exceed their workspace's payload retention
+const (
+	// payloadRetentionModePurge replaces expired payloads with an empty json object, in place
+	payloadRetentionModePurge = "purge"
+	// payloadRetentionModeMigrate makes datasets containing expired payloads eligible for migration, so that their terminal jobs are dropped early
+	payloadRetentionModeMigrate = "migrate"
+)
+
+// purgedPayload is the value that expired payloads are replaced with
+const purgedPayload = `{}`
+
+// reclaimedPayloads is the number of expired payloads and their size in bytes which got reclaimed for a workspace
+type reclaimedPayloads struct {
+	workspaceID string
+	jobs        int
+	bytes       int64
+}
+
+// payloadRetentions returns the configured payload retention periods, keyed by workspace id.
+// Retentions are configured as a list of workspace id and duration pairs, e.g.
+//
+//	JobsDB.payloadRetention.workspaces: ["workspace-1:24h", "workspace-2:30m"]
+func (jd *Handle) payloadRetentions() map[string]time.Duration {
+	conf := jd.conf.payloadRetention.retentions.Load()
+	if len(conf) == 0 {
+		return nil
+	}
+	retentions := make(map[string]time.Duration, len(conf))
+	for _, v := range conf {
+		workspaceID, s, _ := strings.Cut(v, ":")
+		retention, err := time.ParseDuration(s)
+		if workspaceID == "" || err != nil || retention <= 0 {
+			jd.logger.Warnn("Ignoring invalid payload retention", logger.NewStringField("retention", v))
+			continue
+		}
+		retentions[workspaceID] = retention
+	}
+	return retentions
+}
+
+// expiredPayloadsQuery returns the from and where clauses for selecting the jobs (j) of a dataset with an expired payload,
+// i.e. terminal jobs of workspaces with a payload retention, created before their workspace's retention cutoff, along with their arguments.
+// Payloads of aborted jobs never expire, so that they can still be replayed from the dead letter queue.
+func expiredPayloadsQuery(ds dataSetT, retentions map[string]time.Duration) (string, []any) {
+	now := time.Now()
+	workspaceIDs := make([]string, 0, len(retentions))
+	cutoffs := make([]string, 0, len(retentions))
+	for workspaceID, retention := range retentions {
+		workspaceIDs = append(workspaceIDs, workspaceID)
+		cutoffs = append(cutoffs, now.Add(-retention).Format(time.RFC3339Nano))
+	}
+	return fmt.Sprintf(`%[1]q j
+			JOIN "v_last_%[2]s" s ON j.job_id = s.job_id
+			JOIN unnest($1::text[], $2::timestamptz[]) AS r(workspace_id, cutoff) ON j.workspace_id = r.workspace_id
+		WHERE s.job_state = ANY($3) AND j.created_at < r.cutoff AND j.event_payload <> '%[3]s'`, ds.JobTable, ds.JobStatusTable, purgedPayload),
+		[]any{pq.StringArray(workspaceIDs), pq.StringArray(cutoffs), pq.Array(lo.Without(validTerminalStates, Aborted.State))}
+}
+
+// expiredPayloadsChecks keeps track of the datasets found without expired payloads, so that they aren't checked again on every migration loop
+type expiredPayloadsChecks struct {
+	mu        sync.Mutex
+	checkedAt map[string]time.Time // time of the last check which found no expired payloads, per jobs table
+}
+
+func newExpiredPayloadsChecks() *expiredPayloadsChecks {
+	return &expiredPayloadsChecks{checkedAt: make(map[string]time.Time)}
+}
+
+// recent returns true if the jobs table was found without expired payloads within the interval
+func (c *expiredPayloadsChecks) recent(jobTable string, interval time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	checkedAt, ok := c.checkedAt[jobTable]
+	return ok && time.Since(checkedAt) < interval
+}
+
+// none records that the jobs table was found without expired payloads, forgetting the checks which are older than the interval
+func (c *expiredPayloadsChecks) none(jobTable string, interval time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for table, checkedAt := range c.checkedAt {
+		if time.Since(checkedAt) >= interval {
+			delete(c.checkedAt, table)
+		}
+	}
+	c.checkedAt[jobTable] = time.Now()
+}
+
+// hasExpiredPayloads returns true if the dataset contains jobs with expired payloads. It always returns false if payload retention isn't configured in migrate mode.
+// A dataset found without expired payloads isn't checked again until payloadRetention.checkInterval has elapsed.
+func (jd *Handle) hasExpiredPayloads(ds dataSetT) (bool, error) {
+	if jd.conf.payloadRetention.mode.Load() != payloadRetentionModeMigrate {
+		return false, nil
+	}
+	retentions := jd.payloadRetentions()
+	if len(retentions) == 0 {
+		return false, nil
+	}
+	checkInterval := jd.conf.payloadRetention.checkInterval.Load()
+	if jd.expiredPayloadsChecks.recent(ds.JobTable, checkInterval) {
+		return false, nil
+	}
+	from, args := expiredPayloadsQuery(ds, retentions)
+	var exists bool
+	if err := jd.dbHandle.QueryRow(fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s)`, from), args...).Scan(&exists); err != nil {
+		return false, fmt.Errorf("checking for expired payloads in %q: %w", ds.JobTable, err)
+	}
+	if !exists {
+		jd.expiredPayloadsChecks.none(ds.JobTable, checkInterval)
+	}
+	return exists, nil
+}
+
+// expiredPayloadsInTx returns the expired payloads of a dataset, grouped by workspace. It is used for reporting the payloads reclaimed when a dataset gets migrated.
+func (jd *Handle) expiredPayloadsInTx(ctx context.Context, tx *Tx, ds dataSetT) ([]reclaimedPayloads, error) {
+	if jd.conf.payloadRetention.mode.Load() != payloadRetentionModeMigrate {
+		return nil, nil
+	}
+	retentions := jd.payloadRetentions()
+	if len(retentions) == 0 {
+		return nil, nil
+	}
+	from, args := expiredPayloadsQuery(ds, retentions)
+	return jd.queryReclaimedPayloads(ctx, tx, fmt.Sprintf(`SELECT j.workspace_id, count(*), COALESCE(sum(pg_column_size(j.event_payload)), 0) FROM %s GROUP BY j.workspace_id`, from), args)
+}
+
+// purgeExpiredPayloads replaces the expired payloads of all datasets with an empty json object, unless payload retention is configured in migrate mode.
+// Migrations are paused while purging, and payloads are purged in batches, each one in its own transaction while holding a migration read lock,
+// so that purging neither blocks nor gets blocked by migrations for long.
+func (jd *Handle) purgeExpiredPayloads(ctx context.Context) error {
+	if jd.conf.payloadRetention.mode.Load() != payloadRetentionModePurge {
+		return nil
+	}
+	retentions := jd.payloadRetentions()
+	if len(retentions) == 0 {
+		return nil
+	}
+	defer jd.getTimerStat(
+		"jobsdb_purge_payloads_time",
+		&statTags{CustomValFilters: []string{jd.tablePrefix}},
+	).RecordDuration()()
+
+	// pause migration to avoid any read locks being blocked while purging
+	jd.migrateDSPaused.Store(true)
+	defer jd.migrateDSPaused.Store(false)
+
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	dsList := jd.getDSList()
+	jd.dsListLock.RUnlock()
+
+	batchSize := jd.conf.payloadRetention.purgeBatchSize.Load()
+	for _, ds := range dsList {
+		for {
+			purged, err := jd.purgeExpiredPayloadsDS(ctx, ds, retentions, batchSize)
+			if err != nil {
+				return err
+			}
+			if purged < batchSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// purgeExpiredPayloadsDS purges up to batchSize expired payloads of a dataset, returning the number of purged payloads
+func (jd *Handle) purgeExpiredPayloadsDS(ctx context.Context, ds dataSetT, retentions map[string]time.Duration, batchSize int) (int, error) {
+	if !jd.dsMigrationLock.RTryLockWithCtx(ctx) {
+		return 0, fmt.Errorf("could not acquire a migration read lock: %w", ctx.Err())
+	}
+	defer jd.dsMigrationLock.RUnlock()
+	if !jd.dsListLock.RTryLockWithCtx(ctx) {
+		return 0, fmt.Errorf("could not acquire a dslist read lock: %w", ctx.Err())
+	}
+	exists := slices.ContainsFunc(jd.getDSList(), func(d dataSetT) bool { return d.Index == ds.Index })
+	jd.dsListLock.RUnlock()
+	if !exists { // dataset got migrated in the meantime
+		return 0, nil
+	}
+
+	from, args := expiredPayloadsQuery(ds, retentions)
+	var purged int
+	err := jd.WithTx(func(tx *Tx) error {
+		reclaimed, err := jd.queryReclaimedPayloads(ctx, tx, fmt.Sprintf(`WITH expired AS (
+				SELECT j.job_id, j.workspace_id, pg_column_size(j.event_payload) AS size FROM %[1]s ORDER BY j.job_id LIMIT $4
+			), purged AS (
+				UPDATE %[2]q j SET event_payload = '%[3]s' FROM expired e WHERE j.job_id = e.job_id RETURNING e.workspace_id, e.size
+			)
+			SELECT workspace_id, count(*), COALESCE(sum(size), 0) FROM purged GROUP BY workspace_id`, from, ds.JobTable, purgedPayload), append(args, batchSize))
+		if err != nil {
+			return fmt.Errorf("purging expired payloads of %q: %w", ds.JobTable, err)
+		}
+		for _, r := range reclaimed {
+			purged += r.jobs
+		}
+		tx.AddSuccessListener(func() {
+			jd.recordReclaimedPayloads(payloadRetentionModePurge, reclaimed)
+		})
+		return nil
+	})
+	return purged, err
+}
+
+func (*Handle) queryReclaimedPayloads(ctx context.Context, tx *Tx, query string, args []any) ([]reclaimedPayloads, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = rows.Close() }()
+	var res []reclaimedPayloads
+	for rows.Next() {
+		var r reclaimedPayloads
+		if err := rows.Scan(&r.workspaceID, &r.jobs, &r.bytes); err != nil {
+			return nil, err
+		}
+		res = append(res, r)
+	}
+	return res, rows.Err()
+}
+
+func (jd *Handle) recordReclaimedPayloads(mode string, reclaimed []reclaimedPayloads) {
+	for _, r := range reclaimed {
+		tags := stats.Tags{"customVal": jd.tablePrefix, "workspaceId": r.workspaceID, "mode": mode}
+		jd.stats.NewTaggedStat("jobsdb_payload_retention_reclaimed_jobs", stats.CountType, tags).Count(r.jobs)
+		jd.stats.NewTaggedStat("jobsdb_payload_retention_reclaimed_bytes", stats.CountType, tags).Count(int(r.bytes))
+		jd.logger.Infon("Reclaimed expired payloads",
+			logger.NewStringField("workspaceId", r.workspaceID),
+			logger.NewStringField("mode", mode),
+			logger.NewIntField("jobs", int64(r.jobs)),
+			logger.NewIntField("bytes", r.bytes),
+		)
+	}
+}
+
+func (jd *Handle) startPurgePayloadsLoop(ctx context.Context) {
+	jd.backgroundGroup.Go(crash.Wrapper(func() error {
+		jd.purgePayloadsLoop(ctx)
+		return nil
+	}))
+}
+
+func (jd *Handle) purgePayloadsLoop(ctx context.Context) {
+	for {
+		select {
+		case <-jd.TriggerPurgePayloads():
+		case <-ctx.Done():
+			return
+		}
+		timeoutCtx, cancel := context.WithTimeout(ctx, jd.conf.payloadRetention.purgeTimeout.Load())
+		err := jd.purgeExpiredPayloads(timeoutCtx)
+		timedOut := timeoutCtx.Err() != nil
+		cancel()
+		if err != nil && ctx.Err() == nil {
+			if timedOut { // purged batches are committed, so the remaining payloads are purged in the next iteration
+				jd.logger.Warnn("Timed out purging expired payloads", logger.NewErrorField(err))
+				continue
+			}
+			if !jd.conf.skipMaintenanceError {
+				panic(err)
+			}
+			jd.logger.Errorn("Failed to purge expired payloads", logger.NewErrorField(err))
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package jobsdb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+)
+
+func TestPayloadRetention(t *testing.T) {
+	_ = startPostgres(t)
+
+	const (
+		retainedWorkspace = "Retained"
+		otherWorkspace    = "other"
+	)
+	setup := func(t *testing.T, mode string) (*Handle, chan time.Time, chan time.Time, *memstats.Store) {
+		config.Reset()
+		c := config.New()
+		c.Set("JobsDB.maxDSSize", 1)
+		c.Set("JobsDB.payloadRetention.mode", mode)
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+
+		triggerAddNewDS := make(chan time.Time)
+		triggerMigrateDS := make(chan time.Time)
+		jobDB := &Handle{
+			TriggerAddNewDS: func() <-chan time.Time {
+				return triggerAddNewDS
+			},
+			TriggerMigrateDS: func() <-chan time.Time {
+				return triggerMigrateDS
+			},
+			TriggerPurgePayloads: func() <-chan time.Time {
+				return make(chan time.Time) // purging is triggered explicitly
+			},
+			config: c,
+			stats:  statsStore,
+		}
+		require.NoError(t, jobDB.Setup(ReadWrite, true, strings.ToLower(rand.String(5))))
+		t.Cleanup(jobDB.TearDown)
+		return jobDB, triggerAddNewDS, triggerMigrateDS, statsStore
+	}
+	// storeJobs stores 3 jobs of the retained workspace (ids 1-3) and 2 jobs of another workspace (ids 4-5).
+	// Jobs 1, 2, 4 & 5 succeed and job 3 fails.
+	storeJobs := func(t *testing.T, jobDB *Handle, customVal string) {
+		jobs := append(genJobs(retainedWorkspace, customVal, 3, 1), genJobs(otherWorkspace, customVal, 2, 1)...)
+		for i := range jobs {
+			jobs[i].JobID = int64(i) + 1
+		}
+		require.NoError(t, jobDB.Store(context.Background(), jobs))
+		require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses([]*JobT{jobs[0], jobs[1], jobs[3], jobs[4]}, Succeeded.State), []string{customVal}, nil))
+		require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(jobs[2:3], Failed.State), []string{customVal}, nil))
+	}
+	payloads := func(t *testing.T, jobDB *Handle, customVal, state string) map[string][]string {
+		res, err := jobDB.GetJobs(context.Background(), []string{state}, GetQueryParams{CustomValFilters: []string{customVal}, JobsLimit: 100})
+		require.NoError(t, err)
+		payloads := make(map[string][]string)
+		for _, job := range res.Jobs {
+			payloads[job.WorkspaceId] = append(payloads[job.WorkspaceId], string(job.EventPayload))
+		}
+		return payloads
+	}
+
+	t.Run("purge", func(t *testing.T) {
+		jobDB, _, _, statsStore := setup(t, payloadRetentionModePurge)
+		customVal := rand.String(5)
+		storeJobs(t, jobDB, customVal)
+		abortedJobs := genJobs(retainedWorkspace, customVal, 1, 1)
+		require.NoError(t, jobDB.Store(context.Background(), abortedJobs))
+		require.NoError(t, jobDB.UpdateJobStatus(context.Background(), genJobStatuses(abortedJobs, Aborted.State), []string{customVal}, nil))
+
+		require.NoError(t, jobDB.purgeExpiredPayloads(context.Background()))
+		require.NotContains(t, payloads(t, jobDB, customVal, Succeeded.State)[retainedWorkspace], purgedPayload, "payloads shouldn't be purged without a retention")
+
+		jobDB.config.Set("JobsDB.payloadRetention.workspaces", []string{retainedWorkspace + ":1ms", "invalid"})
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, jobDB.purgeExpiredPayloads(context.Background()))
+
+		succeeded := payloads(t, jobDB, customVal, Succeeded.State)
+		require.Equal(t, []string{purgedPayload, purgedPayload}, succeeded[retainedWorkspace])
+		require.Len(t, succeeded[otherWorkspace], 2)
+		require.NotContains(t, succeeded[otherWorkspace], purgedPayload, "payloads of workspaces without a retention shouldn't be purged")
+		require.NotContains(t, payloads(t, jobDB, customVal, Failed.State)[retainedWorkspace], purgedPayload, "payloads of non-terminal jobs shouldn't be purged")
+		require.NotContains(t, payloads(t, jobDB, customVal, Aborted.State)[retainedWorkspace], purgedPayload, "payloads of aborted jobs shouldn't be purged")
+
+		tags := stats.Tags{"customVal": jobDB.tablePrefix, "workspaceId": retainedWorkspace, "mode": payloadRetentionModePurge}
+		require.EqualValues(t, 2, statsStore.Get("jobsdb_payload_retention_reclaimed_jobs", tags).LastValue())
+		reclaimedBytes := statsStore.Get("jobsdb_payload_retention_reclaimed_bytes", tags).LastValue()
+		require.Positive(t, reclaimedBytes)
+
+		// purged payloads are not purged again
+		require.NoError(t, jobDB.purgeExpiredPayloads(context.Background()))
+		require.EqualValues(t, 2, statsStore.Get("jobsdb_payload_retention_reclaimed_jobs", tags).LastValue())
+		require.EqualValues(t, reclaimedBytes, statsStore.Get("jobsdb_payload_retention_reclaimed_bytes", tags).LastValue())
+	})
+
+	t.Run("purge in batches", func(t *testing.T) {
+		jobDB, _, _, statsStore := setup(t, payloadRetentionModePurge)
+		jobDB.config.Set("JobsDB.payloadRetention.purgeBatchSize", 1)
+		customVal := rand.String(5)
+		storeJobs(t, jobDB, customVal)
+
+		jobDB.config.Set("JobsDB.payloadRetention.workspaces", []string{retainedWorkspace + ":1ms", otherWorkspace + ":1ms"})
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, jobDB.purgeExpiredPayloads(context.Background()))
+		require.False(t, jobDB.migrateDSPaused.Load(), "migrations should be resumed after purging")
+
+		succeeded := payloads(t, jobDB, customVal, Succeeded.State)
+		require.Equal(t, []string{purgedPayload, purgedPayload}, succeeded[retainedWorkspace])
+		require.Equal(t, []string{purgedPayload, purgedPayload}, succeeded[otherWorkspace])
+		for _, workspaceID := range []string{retainedWorkspace, otherWorkspace} {
+			tags := stats.Tags{"customVal": jobDB.tablePrefix, "workspaceId": workspaceID, "mode": payloadRetentionModePurge}
+			require.EqualValues(t, 2, statsStore.Get("jobsdb_payload_retention_reclaimed_jobs", tags).LastValue())
+		}
+	})
+
+	t.Run("migrate", func(t *testing.T) {
+		jobDB, triggerAddNewDS, triggerMigrateDS, statsStore := setup(t, payloadRetentionModeMigrate)
+		customVal := rand.String(5)
+		storeJobs(t, jobDB, customVal)
+		triggerAddNewDS <- time.Now()
+		triggerAddNewDS <- time.Now() // waits for the previous addNewDS to complete
+		require.Len(t, jobDB.getDSList(), 2)
+		ds := jobDB.getDSList()[0]
+
+		migrate, _, recordsLeft, err := jobDB.checkIfMigrateDS(ds)
+		require.NoError(t, err)
+		require.Equal(t, 1, recordsLeft)
+		require.False(t, migrate, "dataset with pending jobs shouldn't be migrated without a payload retention")
+
+		jobDB.config.Set("JobsDB.payloadRetention.workspaces", []string{retainedWorkspace + ":1ms"})
+		time.Sleep(10 * time.Millisecond)
+		require.NoError(t, jobDB.purgeExpiredPayloads(context.Background()))
+		require.NotContains(t, payloads(t, jobDB, customVal, Succeeded.State)[retainedWorkspace], purgedPayload, "payloads shouldn't be purged in migrate mode")
+
+		migrate, needsPair, _, err := jobDB.checkIfMigrateDS(ds)
+		require.NoError(t, err)
+		require.True(t, migrate, "dataset with expired payloads should be migrated")
+		require.False(t, needsPair)
+
+		triggerMigrateDS <- time.Now()
+		triggerMigrateDS <- time.Now() // waits for the previous migration to complete
+		dsList := jobDB.getDSList()
+		require.Len(t, dsList, 2)
+		require.NotEqual(t, ds.Index, dsList[0].Index, "dataset should have been migrated")
+		require.Empty(t, payloads(t, jobDB, customVal, Succeeded.State), "terminal jobs should have been dropped")
+		require.Len(t, payloads(t, jobDB, customVal, Failed.State)[retainedWorkspace], 1, "pending jobs should have been migrated")
+
+		tags := stats.Tags{"customVal": jobDB.tablePrefix, "workspaceId": retainedWorkspace, "mode": payloadRetentionModeMigrate}
+		require.EqualValues(t, 2, statsStore.Get("jobsdb_payload_retention_reclaimed_jobs", tags).LastValue())
+		require.Positive(t, statsStore.Get("jobsdb_payload_retention_reclaimed_bytes", tags).LastValue())
+	})
+	t.Run("migrate checks datasets without expired payloads once per interval", func(t *testing.T) {
+		jobDB, triggerAddNewDS, _, _ := setup(t, payloadRetentionModeMigrate)
+		customVal := rand.String(5)
+		storeJobs(t, jobDB, customVal)
+		triggerAddNewDS <- time.Now()
+		triggerAddNewDS <- time.Now() // waits for the previous addNewDS to complete
+		ds := jobDB.getDSList()[0]
+
+		jobDB.config.Set("JobsDB.payloadRetention.workspaces", []string{retainedWorkspace + ":1h"})
+		migrate, _, _, err := jobDB.checkIfMigrateDS(ds)
+		require.NoError(t, err)
+		require.False(t, migrate, "dataset without expired payloads shouldn't be migrated")
+
+		jobDB.config.Set("JobsDB.payloadRetention.workspaces", []string{retainedWorkspace + ":1ms"})
+		time.Sleep(10 * time.Millisecond)
+		migrate, _, _, err = jobDB.checkIfMigrateDS(ds)
+		require.NoError(t, err)
+		require.False(t, migrate, "dataset shouldn't be checked again for expired payloads before the check interval elapses")
+
+		jobDB.config.Set("JobsDB.payloadRetention.checkInterval", "0s")
+		migrate, _, _, err = jobDB.checkIfMigrateDS(ds)
+		require.NoError(t, err)
+		require.True(t, migrate, "dataset should be checked again for expired payloads after the check interval elapses")
+	})
+}
@@ -0,0 +1,228 @@
+package jobsdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/samber/lo"
+)
+
+// JobsFilter selects jobs for bulk operations, empty fields don't filter
+type JobsFilter struct {
+	WorkspaceID   string `json:"workspaceId"`
+	SourceID      string `json:"sourceId"`
+	DestinationID string `json:"destinationId"`
+	CustomVal     string `json:"customVal"`
+	// States filters jobs on their latest state, [Unprocessed] for jobs without a status. Defaults to [Unprocessed], [Failed] and [Waiting].
+	// Jobs which are [Executing] or [Importing] are only transitioned if their state is requested explicitly.
+	States []string `json:"states"`
+	// MinJobID and MaxJobID form an inclusive job ID range
+	MinJobID int64 `json:"minJobId"`
+	MaxJobID int64 `json:"maxJobId"`
+	// CreatedAfter (inclusive) and CreatedBefore (exclusive) form a createdAt range
+	CreatedAfter  time.Time `json:"createdAfter"`
+	CreatedBefore time.Time `json:"createdBefore"`
+}
+
+// TransitionJobsRequest is a request for moving all jobs matching a filter to a target state
+type TransitionJobsRequest struct {
+	Filter        JobsFilter      `json:"filter"`
+	TargetState   string          `json:"targetState"`
+	ErrorCode     string          `json:"errorCode"`
+	ErrorResponse json.RawMessage `json:"errorResponse"`
+	// DryRun only counts the jobs matching the filter, without transitioning them
+	DryRun bool `json:"dryRun"`
+}
+
+// transitionTargetStates are the states that jobs can be transitioned to by [TransitionJobsRequest]
+var transitionTargetStates = []string{Failed.State, Waiting.State, Succeeded.State, Aborted.State, Filtered.State}
+
+// transitionDefaultStates are the states of the jobs transitioned by [TransitionJobsRequest] when its filter doesn't specify any,
+// leaving out the jobs which are being processed
+var transitionDefaultStates = []string{Unprocessed.State, Failed.State, Waiting.State}
+
+// Validate validates the request and populates its defaults
+func (r *TransitionJobsRequest) Validate() error {
+	if !slices.Contains(transitionTargetStates, r.TargetState) {
+		return fmt.Errorf("invalid target state %q, expected one of %v", r.TargetState, transitionTargetStates)
+	}
+	if len(r.ErrorResponse) == 0 {
+		r.ErrorResponse = []byte(`{}`)
+	}
+	if !json.Valid(r.ErrorResponse) {
+		return errors.New("error response is not a valid json")
+	}
+	if len(r.Filter.States) == 0 {
+		r.Filter.States = slices.Clone(transitionDefaultStates)
+	}
+	for _, state := range r.Filter.States {
+		if state != Unprocessed.State && !slices.Contains(validNonTerminalStates, state) {
+			return fmt.Errorf("invalid state %q, only jobs in non-terminal states can be transitioned", state)
+		}
+	}
+	if r.Filter.MaxJobID > 0 && r.Filter.MaxJobID < r.Filter.MinJobID {
+		return errors.New("maxJobId must not be less than minJobId")
+	}
+	if !r.Filter.CreatedAfter.IsZero() && !r.Filter.CreatedBefore.IsZero() && !r.Filter.CreatedBefore.After(r.Filter.CreatedAfter) {
+		return errors.New("createdBefore must be after createdAfter")
+	}
+	return nil
+}
+
+// TransitionJobs moves all jobs matching the request's filter to the request's target state and returns the number of jobs transitioned.
+// Jobs are transitioned in batches, each one in its own transaction through [Handle.UpdateJobStatusInTx]. The optional onTransition function
+// is called within each batch's transaction, with jobs carrying their previous status in LastJobStatus.
+// In dry-run mode the matching jobs are only counted.
+func (jd *Handle) TransitionJobs(ctx context.Context, req TransitionJobsRequest, onTransition func(tx UpdateSafeTx, jobs []*JobT, statusList []*JobStatusT) error) (int, error) {
+	if err := req.Validate(); err != nil {
+		return 0, err
+	}
+	defer jd.getTimerStat(
+		"jobsdb_transition_jobs_time",
+		&statTags{CustomValFilters: []string{jd.tablePrefix}},
+	).RecordDuration()()
+
+	conditions, args := transitionJobsConditions(req.Filter)
+	if req.DryRun {
+		var total int
+		err := jd.WithUpdateSafeTx(ctx, func(tx UpdateSafeTx) error {
+			for _, ds := range tx.getDSList() {
+				var count int
+				if err := tx.SqlTx().QueryRowContext(ctx, fmt.Sprintf(`SELECT count(*) FROM %[1]q j LEFT JOIN "v_last_%[2]s" s ON j.job_id = s.job_id WHERE %[3]s`,
+					ds.JobTable, ds.JobStatusTable, conditions),
+					args...,
+				).Scan(&count); err != nil {
+					return fmt.Errorf("counting jobs of %q: %w", ds.JobTable, err)
+				}
+				total += count
+			}
+			return nil
+		})
+		return total, err
+	}
+
+	batchSize := jd.config.GetIntVar(10000, 1, jd.configKeys("transitionJobsBatchSize")...)
+	afterJobID := max(req.Filter.MinJobID-1, 0)
+	var total int
+	for {
+		var jobs []*JobT
+		if err := jd.WithUpdateSafeTx(ctx, func(tx UpdateSafeTx) error {
+			var err error
+			if jobs, err = jd.transitionJobsBatchInTx(ctx, tx, req, conditions, args, afterJobID, batchSize); err != nil {
+				return err
+			}
+			if len(jobs) == 0 {
+				return nil
+			}
+			now := time.Now()
+			statusList := lo.Map(jobs, func(job *JobT, _ int) *JobStatusT {
+				return &JobStatusT{
+					JobID:         job.JobID,
+					JobState:      req.TargetState,
+					AttemptNum:    job.LastJobStatus.AttemptNum + 1,
+					ExecTime:      now,
+					RetryTime:     now,
+					ErrorCode:     req.ErrorCode,
+					ErrorResponse: req.ErrorResponse,
+					Parameters:    []byte(`{}`),
+					JobParameters: job.Parameters,
+					WorkspaceId:   job.WorkspaceId,
+				}
+			})
+			customValFilters := lo.Uniq(lo.Map(jobs, func(job *JobT, _ int) string { return job.CustomVal }))
+			if err := jd.UpdateJobStatusInTx(ctx, tx, statusList, customValFilters, nil); err != nil {
+				return fmt.Errorf("updating job statuses: %w", err)
+			}
+			if onTransition != nil {
+				return onTransition(tx, jobs, statusList)
+			}
+			return nil
+		}); err != nil {
+			return total, err
+		}
+		total += len(jobs)
+		if len(jobs) < batchSize {
+			return total, nil
+		}
+		afterJobID = jobs[len(jobs)-1].JobID
+	}
+}
+
+// transitionJobsBatchInTx returns up to limit jobs matching the conditions with a job ID greater than afterJobID, ordered by job ID
+func (*Handle) transitionJobsBatchInTx(ctx context.Context, tx UpdateSafeTx, req TransitionJobsRequest, conditions string, args []any, afterJobID int64, limit int) ([]*JobT, error) {
+	var jobs []*JobT
+	for _, ds := range tx.getDSList() {
+		if len(jobs) == limit {
+			break
+		}
+		if req.Filter.MaxJobID > 0 && afterJobID >= req.Filter.MaxJobID {
+			break
+		}
+		rows, err := tx.SqlTx().QueryContext(ctx, fmt.Sprintf(`SELECT j.job_id, j.uuid, j.user_id, j.workspace_id, j.custom_val, j.parameters, j.event_count, j.created_at,
+				COALESCE(s.job_state, '%[4]s'), COALESCE(s.attempt, 0)
+			FROM %[1]q j LEFT JOIN "v_last_%[2]s" s ON j.job_id = s.job_id
+			WHERE %[3]s AND j.job_id > $%[5]d
+			ORDER BY j.job_id
+			LIMIT $%[6]d`, ds.JobTable, ds.JobStatusTable, conditions, Unprocessed.State, len(args)+1, len(args)+2),
+			append(args, afterJobID, limit-len(jobs))...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("getting jobs of %q: %w", ds.JobTable, err)
+		}
+		for rows.Next() {
+			var job JobT
+			if err := rows.Scan(&job.JobID, &job.UUID, &job.UserID, &job.WorkspaceId, &job.CustomVal, &job.Parameters, &job.EventCount, &job.CreatedAt,
+				&job.LastJobStatus.JobState, &job.LastJobStatus.AttemptNum); err != nil {
+				_ = rows.Close()
+				return nil, fmt.Errorf("scanning jobs of %q: %w", ds.JobTable, err)
+			}
+			job.LastJobStatus.JobID = job.JobID
+			jobs = append(jobs, &job)
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, fmt.Errorf("iterating jobs of %q: %w", ds.JobTable, err)
+		}
+		if len(jobs) > 0 {
+			afterJobID = jobs[len(jobs)-1].JobID
+		}
+	}
+	return jobs, nil
+}
+
+// transitionJobsConditions returns the sql conditions for selecting jobs (j) joined with their latest status (s) according to the filter, along with their arguments
+func transitionJobsConditions(filter JobsFilter) (string, []any) {
+	var createdAfter, createdBefore *time.Time
+	if !filter.CreatedAfter.IsZero() {
+		createdAfter = &filter.CreatedAfter
+	}
+	if !filter.CreatedBefore.IsZero() {
+		createdBefore = &filter.CreatedBefore
+	}
+	return fmt.Sprintf(`COALESCE(s.job_state, '%s') = ANY($1)
+			AND ($2 = '' OR j.workspace_id = $2)
+			AND ($3 = '' OR j.parameters->>'source_id' = $3)
+			AND ($4 = '' OR j.parameters->>'destination_id' = $4)
+			AND ($5 = '' OR j.custom_val = $5)
+			AND ($6::bigint = 0 OR j.job_id >= $6)
+			AND ($7::bigint = 0 OR j.job_id <= $7)
+			AND ($8::timestamptz IS NULL OR j.created_at >= $8)
+			AND ($9::timestamptz IS NULL OR j.created_at < $9)`, Unprocessed.State),
+		[]any{
+			pq.Array(filter.States),
+			filter.WorkspaceID,
+			filter.SourceID,
+			filter.DestinationID,
+			filter.CustomVal,
+			filter.MinJobID,
+			filter.MaxJobID,
+			createdAfter,
+			createdBefore,
+		}
+}
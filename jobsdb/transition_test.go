@@ -0,0 +1,137 @@
+package jobsdb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
+)
+
+func TestTransitionJobs(t *testing.T) {
+	_ = startPostgres(t)
+	config.Reset()
+	c := config.New()
+	c.Set("JobsDB.maxDSSize", 1)
+	c.Set("JobsDB.transitionJobsBatchSize", 2)
+
+	triggerAddNewDS := make(chan time.Time)
+	jobDB := Handle{
+		TriggerAddNewDS: func() <-chan time.Time {
+			return triggerAddNewDS
+		},
+		config: c,
+	}
+	require.NoError(t, jobDB.Setup(ReadWrite, true, strings.ToLower(rand.String(5))))
+	defer jobDB.TearDown()
+
+	ctx := context.Background()
+	customValA, customValB := rand.String(5), rand.String(5)
+	jobsA := genJobs(defaultWorkspaceID, customValA, 4, 1)
+	require.NoError(t, jobDB.Store(ctx, jobsA))
+	triggerAddNewDS <- time.Now()
+	triggerAddNewDS <- time.Now() // waits for the previous addNewDS to complete
+	require.NoError(t, jobDB.Store(ctx, genJobs("other", customValB, 2, 1)))
+
+	unprocessed, err := jobDB.GetUnprocessed(ctx, GetQueryParams{CustomValFilters: []string{customValA}, JobsLimit: 10})
+	require.NoError(t, err)
+	require.Len(t, unprocessed.Jobs, 4)
+	jobsA = unprocessed.Jobs
+	require.NoError(t, jobDB.UpdateJobStatus(ctx, genJobStatuses(jobsA[:1], Succeeded.State), []string{customValA}, nil))
+	require.NoError(t, jobDB.UpdateJobStatus(ctx, genJobStatuses(jobsA[1:2], Failed.State), []string{customValA}, nil))
+
+	count := func(t *testing.T, filter JobsFilter) int {
+		t.Helper()
+		n, err := jobDB.TransitionJobs(ctx, TransitionJobsRequest{Filter: filter, TargetState: Aborted.State, DryRun: true}, nil)
+		require.NoError(t, err)
+		return n
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		require.Equal(t, 5, count(t, JobsFilter{}), "terminal jobs are never transitioned")
+		require.Equal(t, 3, count(t, JobsFilter{CustomVal: customValA}))
+		require.Equal(t, 2, count(t, JobsFilter{WorkspaceID: "other"}))
+		require.Equal(t, 1, count(t, JobsFilter{CustomVal: customValA, States: []string{Failed.State}}))
+		require.Equal(t, 4, count(t, JobsFilter{States: []string{Unprocessed.State}}))
+		require.Equal(t, 5, count(t, JobsFilter{SourceID: "sourceID"}))
+		require.Zero(t, count(t, JobsFilter{SourceID: "other"}))
+		require.Equal(t, 2, count(t, JobsFilter{MinJobID: jobsA[1].JobID, MaxJobID: jobsA[2].JobID}))
+		require.Equal(t, 5, count(t, JobsFilter{CreatedAfter: time.Now().Add(-time.Hour), CreatedBefore: time.Now().Add(time.Hour)}))
+		require.Zero(t, count(t, JobsFilter{CreatedBefore: time.Now().Add(-time.Hour)}))
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		for _, req := range []TransitionJobsRequest{
+			{TargetState: Executing.State},
+			{TargetState: Aborted.State, Filter: JobsFilter{States: []string{Succeeded.State}}},
+			{TargetState: Aborted.State, ErrorResponse: []byte(`not json`)},
+			{TargetState: Aborted.State, Filter: JobsFilter{MinJobID: 2, MaxJobID: 1}},
+		} {
+			_, err := jobDB.TransitionJobs(ctx, req, nil)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("transition is rolled back if onTransition fails", func(t *testing.T) {
+		_, err := jobDB.TransitionJobs(ctx, TransitionJobsRequest{Filter: JobsFilter{CustomVal: customValA}, TargetState: Aborted.State}, func(UpdateSafeTx, []*JobT, []*JobStatusT) error {
+			return errors.New("failed")
+		})
+		require.Error(t, err)
+		require.Equal(t, 3, count(t, JobsFilter{CustomVal: customValA}))
+	})
+
+	t.Run("transition", func(t *testing.T) {
+		// cache an empty result for aborted jobs, which needs to be invalidated by the transition
+		aborted, err := jobDB.GetAborted(ctx, GetQueryParams{CustomValFilters: []string{customValA}, JobsLimit: 10})
+		require.NoError(t, err)
+		require.Empty(t, aborted.Jobs)
+
+		var transitioned []*JobT
+		n, err := jobDB.TransitionJobs(ctx, TransitionJobsRequest{
+			Filter:        JobsFilter{CustomVal: customValA},
+			TargetState:   Aborted.State,
+			ErrorCode:     "410",
+			ErrorResponse: []byte(`{"reason":"force aborted"}`),
+		}, func(tx UpdateSafeTx, jobs []*JobT, statusList []*JobStatusT) error {
+			require.Len(t, statusList, len(jobs))
+			transitioned = append(transitioned, jobs...)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, 3, n)
+		require.Len(t, transitioned, 3)
+		require.Equal(t, Failed.State, transitioned[0].LastJobStatus.JobState)
+		require.Equal(t, 1, transitioned[0].LastJobStatus.AttemptNum)
+		require.Equal(t, Unprocessed.State, transitioned[1].LastJobStatus.JobState)
+
+		aborted, err = jobDB.GetAborted(ctx, GetQueryParams{CustomValFilters: []string{customValA}, JobsLimit: 10})
+		require.NoError(t, err)
+		require.Len(t, aborted.Jobs, 3)
+		for _, job := range aborted.Jobs {
+			require.Equal(t, "410", job.LastJobStatus.ErrorCode)
+			require.JSONEq(t, `{"reason":"force aborted"}`, string(job.LastJobStatus.ErrorResponse))
+		}
+		require.Zero(t, count(t, JobsFilter{CustomVal: customValA}))
+		require.Equal(t, 2, count(t, JobsFilter{}))
+	})
+}
+
+func TestTransitionJobsRequestValidate(t *testing.T) {
+	t.Run("defaults to jobs which aren't being processed", func(t *testing.T) {
+		req := TransitionJobsRequest{TargetState: Aborted.State}
+		require.NoError(t, req.Validate())
+		require.ElementsMatch(t, []string{Unprocessed.State, Failed.State, Waiting.State}, req.Filter.States)
+		require.JSONEq(t, `{}`, string(req.ErrorResponse))
+	})
+
+	t.Run("jobs being processed are transitioned if requested explicitly", func(t *testing.T) {
+		req := TransitionJobsRequest{TargetState: Aborted.State, Filter: JobsFilter{States: []string{Executing.State, Importing.State}}}
+		require.NoError(t, req.Validate())
+		require.Equal(t, []string{Executing.State, Importing.State}, req.Filter.States)
+	})
+}
@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	throttler "github.com/rudderlabs/rudder-server/gateway/throttler"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -41,16 +42,17 @@ func (m *MockThrottler) EXPECT() *MockThrottlerMockRecorder {
 }
 
 // CheckLimitReached mocks base method.
-func (m *MockThrottler) CheckLimitReached(context context.Context, workspaceId string, eventCount int64) (bool, error) {
+func (m *MockThrottler) CheckLimitReached(ctx context.Context, request throttler.Request) (bool, throttler.Level, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "CheckLimitReached", context, workspaceId, eventCount)
+	ret := m.ctrl.Call(m, "CheckLimitReached", ctx, request)
 	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(throttler.Level)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // CheckLimitReached indicates an expected call of CheckLimitReached.
-func (mr *MockThrottlerMockRecorder) CheckLimitReached(context, workspaceId, eventCount any) *gomock.Call {
+func (mr *MockThrottlerMockRecorder) CheckLimitReached(ctx, request any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckLimitReached", reflect.TypeOf((*MockThrottler)(nil).CheckLimitReached), context, workspaceId, eventCount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckLimitReached", reflect.TypeOf((*MockThrottler)(nil).CheckLimitReached), ctx, request)
 }
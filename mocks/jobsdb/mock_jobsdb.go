@@ -373,6 +373,21 @@ func (mr *MockJobsDBMockRecorder) StoreInTx(ctx, tx, jobList any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreInTx", reflect.TypeOf((*MockJobsDB)(nil).StoreInTx), ctx, tx, jobList)
 }
 
+// TransitionJobs mocks base method.
+func (m *MockJobsDB) TransitionJobs(ctx context.Context, req jobsdb.TransitionJobsRequest, onTransition func(jobsdb.UpdateSafeTx, []*jobsdb.JobT, []*jobsdb.JobStatusT) error) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransitionJobs", ctx, req, onTransition)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransitionJobs indicates an expected call of TransitionJobs.
+func (mr *MockJobsDBMockRecorder) TransitionJobs(ctx, req, onTransition any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransitionJobs", reflect.TypeOf((*MockJobsDB)(nil).TransitionJobs), ctx, req, onTransition)
+}
+
 // UpdateJobStatus mocks base method.
 func (m *MockJobsDB) UpdateJobStatus(ctx context.Context, statusList []*jobsdb.JobStatusT, customValFilters []string, parameterFilters []jobsdb.ParameterFilterT) error {
 	m.ctrl.T.Helper()
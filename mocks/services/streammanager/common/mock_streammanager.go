@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/rudderlabs/rudder-server/services/streammanager/common (interfaces: StreamProducer)
+// Source: github.com/rudderlabs/rudder-server/services/streammanager/common (interfaces: StreamProducer,BatchProducer)
 //
 // Generated by this command:
 //
-//	mockgen --build_flags=--mod=mod -destination=../../../mocks/services/streammanager/common/mock_streammanager.go -package mock_streammanager github.com/rudderlabs/rudder-server/services/streammanager/common StreamProducer
+//	mockgen --build_flags=--mod=mod -destination=../../../mocks/services/streammanager/common/mock_streammanager.go -package mock_streammanager github.com/rudderlabs/rudder-server/services/streammanager/common StreamProducer,BatchProducer
 //
 
 // Package mock_streammanager is a generated GoMock package.
@@ -13,6 +13,7 @@ import (
 	json "encoding/json"
 	reflect "reflect"
 
+	common "github.com/rudderlabs/rudder-server/services/streammanager/common"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -69,3 +70,71 @@ func (mr *MockStreamProducerMockRecorder) Produce(jsonData, destConfig any) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Produce", reflect.TypeOf((*MockStreamProducer)(nil).Produce), jsonData, destConfig)
 }
+
+// MockBatchProducer is a mock of BatchProducer interface.
+type MockBatchProducer struct {
+	ctrl     *gomock.Controller
+	recorder *MockBatchProducerMockRecorder
+	isgomock struct{}
+}
+
+// MockBatchProducerMockRecorder is the mock recorder for MockBatchProducer.
+type MockBatchProducerMockRecorder struct {
+	mock *MockBatchProducer
+}
+
+// NewMockBatchProducer creates a new mock instance.
+func NewMockBatchProducer(ctrl *gomock.Controller) *MockBatchProducer {
+	mock := &MockBatchProducer{ctrl: ctrl}
+	mock.recorder = &MockBatchProducerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockBatchProducer) EXPECT() *MockBatchProducerMockRecorder {
+	return m.recorder
+}
+
+// Close mocks base method.
+func (m *MockBatchProducer) Close() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Close")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Close indicates an expected call of Close.
+func (mr *MockBatchProducerMockRecorder) Close() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Close", reflect.TypeOf((*MockBatchProducer)(nil).Close))
+}
+
+// Produce mocks base method.
+func (m *MockBatchProducer) Produce(jsonData json.RawMessage, destConfig any) (int, string, string) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Produce", jsonData, destConfig)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(string)
+	return ret0, ret1, ret2
+}
+
+// Produce indicates an expected call of Produce.
+func (mr *MockBatchProducerMockRecorder) Produce(jsonData, destConfig any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Produce", reflect.TypeOf((*MockBatchProducer)(nil).Produce), jsonData, destConfig)
+}
+
+// ProduceBatch mocks base method.
+func (m *MockBatchProducer) ProduceBatch(messages []json.RawMessage, destConfig any) []common.ProduceResult {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProduceBatch", messages, destConfig)
+	ret0, _ := ret[0].([]common.ProduceResult)
+	return ret0
+}
+
+// ProduceBatch indicates an expected call of ProduceBatch.
+func (mr *MockBatchProducerMockRecorder) ProduceBatch(messages, destConfig any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProduceBatch", reflect.TypeOf((*MockBatchProducer)(nil).ProduceBatch), messages, destConfig)
+}
@@ -54,3 +54,18 @@ func (mr *MockFireHoseClientV1MockRecorder) PutRecord(input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecord", reflect.TypeOf((*MockFireHoseClientV1)(nil).PutRecord), input)
 }
+
+// PutRecordBatch mocks base method.
+func (m *MockFireHoseClientV1) PutRecordBatch(input *firehose.PutRecordBatchInput) (*firehose.PutRecordBatchOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutRecordBatch", input)
+	ret0, _ := ret[0].(*firehose.PutRecordBatchOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRecordBatch indicates an expected call of PutRecordBatch.
+func (mr *MockFireHoseClientV1MockRecorder) PutRecordBatch(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecordBatch", reflect.TypeOf((*MockFireHoseClientV1)(nil).PutRecordBatch), input)
+}
@@ -60,3 +60,23 @@ func (mr *MockFireHoseClientV2MockRecorder) PutRecord(ctx, input any, opts ...an
 	varargs := append([]any{ctx, input}, opts...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecord", reflect.TypeOf((*MockFireHoseClientV2)(nil).PutRecord), varargs...)
 }
+
+// PutRecordBatch mocks base method.
+func (m *MockFireHoseClientV2) PutRecordBatch(ctx context.Context, input *firehose.PutRecordBatchInput, opts ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutRecordBatch", varargs...)
+	ret0, _ := ret[0].(*firehose.PutRecordBatchOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRecordBatch indicates an expected call of PutRecordBatch.
+func (mr *MockFireHoseClientV2MockRecorder) PutRecordBatch(ctx, input any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecordBatch", reflect.TypeOf((*MockFireHoseClientV2)(nil).PutRecordBatch), varargs...)
+}
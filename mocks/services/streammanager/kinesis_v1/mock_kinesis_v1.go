@@ -54,3 +54,18 @@ func (mr *MockKinesisClientV1MockRecorder) PutRecord(input any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecord", reflect.TypeOf((*MockKinesisClientV1)(nil).PutRecord), input)
 }
+
+// PutRecords mocks base method.
+func (m *MockKinesisClientV1) PutRecords(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PutRecords", input)
+	ret0, _ := ret[0].(*kinesis.PutRecordsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRecords indicates an expected call of PutRecords.
+func (mr *MockKinesisClientV1MockRecorder) PutRecords(input any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecords", reflect.TypeOf((*MockKinesisClientV1)(nil).PutRecords), input)
+}
@@ -60,3 +60,23 @@ func (mr *MockKinesisClientV2MockRecorder) PutRecord(ctx, input any, opts ...any
 	varargs := append([]any{ctx, input}, opts...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecord", reflect.TypeOf((*MockKinesisClientV2)(nil).PutRecord), varargs...)
 }
+
+// PutRecords mocks base method.
+func (m *MockKinesisClientV2) PutRecords(ctx context.Context, input *kinesis.PutRecordsInput, opts ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, input}
+	for _, a := range opts {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "PutRecords", varargs...)
+	ret0, _ := ret[0].(*kinesis.PutRecordsOutput)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PutRecords indicates an expected call of PutRecords.
+func (mr *MockKinesisClientV2MockRecorder) PutRecords(ctx, input any, opts ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, input}, opts...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PutRecords", reflect.TypeOf((*MockKinesisClientV2)(nil).PutRecords), varargs...)
+}
@@ -0,0 +1,43 @@
+package processor
+
+import (
+	"github.com/samber/lo"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/enricher"
+	"github.com/rudderlabs/rudder-server/processor/types"
+	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
+)
+
+// acceptBotEventsConfigKey is the key of the destination config opting in for bot events routed by the bot enricher
+const acceptBotEventsConfigKey = "acceptBotEvents"
+
+// getBotFilteredDestinations filters out the destinations not accepting bot events, if the event is a bot event routed by the bot enricher
+func getBotFilteredDestinations(event types.SingularEventT, destinations []backendconfig.DestinationT) []backendconfig.DestinationT {
+	if !enricher.IsRoutedBotEvent(event) {
+		return destinations
+	}
+	return lo.Filter(destinations, func(dest backendconfig.DestinationT, _ int) bool {
+		acceptBotEvents, _ := dest.Config[acceptBotEventsConfigKey].(bool)
+		return acceptBotEvents
+	})
+}
+
+// botReportingStatus returns the status bot events are reported with, or an empty string if the event isn't a bot event
+func botReportingStatus(event types.SingularEventT, eventParams types.EventParams) string {
+	if eventParams.IsBot {
+		// TODO: remove the empty check after ingestion service is released with BotAction field
+		if eventParams.BotAction == enricher.BotActionFlag || eventParams.BotAction == "" {
+			return reportingtypes.BotFlaggedStatus
+		}
+		return reportingtypes.BotDetectedStatus
+	}
+	switch {
+	case enricher.IsDroppedBotEvent(event):
+		return reportingtypes.BotDroppedStatus
+	case enricher.IsDetectedBotEvent(event):
+		return reportingtypes.BotFlaggedStatus
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,120 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/internal/enricher"
+	"github.com/rudderlabs/rudder-server/processor/types"
+	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func TestGetBotFilteredDestinations(t *testing.T) {
+	destinations := []backendconfig.DestinationT{
+		{ID: "dest-1", Config: map[string]any{}},
+		{ID: "dest-2", Config: map[string]any{"acceptBotEvents": true}},
+		{ID: "dest-3", Config: map[string]any{"acceptBotEvents": false}},
+	}
+	destIDs := func(destinations []backendconfig.DestinationT) []string {
+		return lo.Map(destinations, func(dest backendconfig.DestinationT, _ int) string { return dest.ID })
+	}
+
+	for _, tc := range []struct {
+		name            string
+		event           types.SingularEventT
+		expectedDestIDs []string
+	}{
+		{
+			name:            "non-bot event",
+			event:           types.SingularEventT{"context": map[string]any{}},
+			expectedDestIDs: []string{"dest-1", "dest-2", "dest-3"},
+		},
+		{
+			name:            "flagged bot event",
+			event:           types.SingularEventT{"context": map[string]any{"isBot": true, "bot": map[string]any{"name": "Googlebot"}}},
+			expectedDestIDs: []string{"dest-1", "dest-2", "dest-3"},
+		},
+		{
+			name:            "routed bot event",
+			event:           types.SingularEventT{"context": map[string]any{"isBot": true, "bot": map[string]any{"name": "Googlebot", "action": "route"}}},
+			expectedDestIDs: []string{"dest-2"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expectedDestIDs, destIDs(getBotFilteredDestinations(tc.event, destinations)))
+		})
+	}
+}
+
+func TestBotReportingStatus(t *testing.T) {
+	const googlebotUA = "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+	detectedEvent := func(t *testing.T, action string) types.SingularEventT {
+		t.Helper()
+		conf := config.New()
+		conf.Set("BotEnrichment.detection.enabled", true)
+		conf.Set("BotEnrichment.action", action)
+		e, err := enricher.NewBotEnricher(conf, logger.NOP, stats.NOP)
+		require.NoError(t, err)
+		t.Cleanup(func() { require.NoError(t, e.Close()) })
+
+		request := &types.GatewayBatchRequest{Batch: []types.SingularEventT{{"context": map[string]any{"userAgent": googlebotUA}}}}
+		require.NoError(t, e.Enrich(&backendconfig.SourceT{ID: "source-1"}, request, &types.EventParams{}))
+		require.Len(t, request.Batch, 1)
+		return request.Batch[0]
+	}
+
+	for _, tc := range []struct {
+		name        string
+		event       types.SingularEventT
+		eventParams types.EventParams
+		expected    string
+	}{
+		{
+			name:     "non-bot event",
+			event:    types.SingularEventT{"context": map[string]any{}},
+			expected: "",
+		},
+		{
+			name:        "upstream flagged bot event",
+			event:       types.SingularEventT{},
+			eventParams: types.EventParams{IsBot: true, BotAction: "flag"},
+			expected:    reportingtypes.BotFlaggedStatus,
+		},
+		{
+			name:        "upstream detected bot event",
+			event:       types.SingularEventT{},
+			eventParams: types.EventParams{IsBot: true, BotAction: "disable"},
+			expected:    reportingtypes.BotDetectedStatus,
+		},
+		{
+			name:     "flagged bot event",
+			event:    detectedEvent(t, enricher.BotActionFlag),
+			expected: reportingtypes.BotFlaggedStatus,
+		},
+		{
+			name:     "routed bot event",
+			event:    detectedEvent(t, enricher.BotActionRoute),
+			expected: reportingtypes.BotFlaggedStatus,
+		},
+		{
+			name:     "dropped bot event",
+			event:    detectedEvent(t, enricher.BotActionDrop),
+			expected: reportingtypes.BotDroppedStatus,
+		},
+		{
+			name:     "bot details sent by the client",
+			event:    types.SingularEventT{"context": map[string]any{"isBot": true, "bot": map[string]any{"name": "Googlebot", "action": "drop"}}},
+			expected: "",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, botReportingStatus(tc.event, tc.eventParams))
+		})
+	}
+}
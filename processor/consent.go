@@ -2,14 +2,31 @@ package processor
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/samber/lo"
 
 	"github.com/rudderlabs/rudder-go-kit/jsonrs"
 
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/internal/tcf"
 	"github.com/rudderlabs/rudder-server/processor/types"
 	"github.com/rudderlabs/rudder-server/utils/misc"
+	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
+)
+
+// iabConsentProvider is the provider of the consents read from the IAB TCF consent string of events (context.consent.tcString)
+const iabConsentProvider = "iab"
+
+// Reasons of destinations being dropped for lacking consent, reported as the error type of the dropped events
+const (
+	consentDroppedReasonInvalidTCString = "tcf_invalid_consent_string"
+	consentDroppedReasonTCFPurposes     = "tcf_purposes_not_consented"
+	consentDroppedReasonTCFVendors      = "tcf_vendors_not_consented"
+	// consentDroppedReasonDeniedSuffix is appended to the provider of the denied consent ids, e.g. oneTrust_consent_denied
+	consentDroppedReasonDeniedSuffix = "_consent_denied"
 )
 
 type ConsentManagementInfo struct {
@@ -22,75 +39,218 @@ type ConsentManagementInfo struct {
 type GenericConsentManagementProviderData struct {
 	ResolutionStrategy string
 	Consents           []string
+
+	// IAB TCF purposes and vendors the user must consent to
+	Purposes                []int
+	Vendors                 []int
+	AllowLegitimateInterest bool
 }
 
 type GenericConsentsConfig struct {
 	Consent string `json:"consent"`
 }
 
+type IABPurposeConfig struct {
+	Purpose string `json:"purpose"`
+}
+
+type IABVendorConfig struct {
+	Vendor string `json:"vendor"`
+}
+
 type GenericConsentManagementProviderConfig struct {
 	Provider           string                  `json:"provider"`
 	ResolutionStrategy string                  `json:"resolutionStrategy"`
 	Consents           []GenericConsentsConfig `json:"consents"`
+
+	// Only used by the iab provider
+	Purposes                []IABPurposeConfig `json:"purposes"`
+	Vendors                 []IABVendorConfig  `json:"vendors"`
+	AllowLegitimateInterest bool               `json:"allowLegitimateInterest"`
+}
+
+// consentDroppedDestination is a destination an event was dropped for, since the user didn't consent to it
+type consentDroppedDestination struct {
+	destination backendconfig.DestinationT
+	reason      string
 }
 
 /*
 Filters and returns destinations based on the consents configured for the destination and the user consents present in the event.
 
-Supports legacy and generic consent management.
+Supports legacy and generic consent management, as well as IAB TCF v2.2 consent strings.
 For GCM based filtering, uses source and destination IDs to fetch the appropriate GCM data from the config.
 */
 func (proc *Handle) getConsentFilteredDestinations(event types.SingularEventT, sourceID string, destinations []backendconfig.DestinationT) []backendconfig.DestinationT {
-	// If the event does not have denied consent IDs, do not filter any destinations
+	filtered, _ := proc.filterDestinationsByConsent(event, sourceID, destinations)
+	return filtered
+}
+
+// filterDestinationsByConsent filters destinations like [Handle.getConsentFilteredDestinations],
+// returning the dropped destinations along with the reason they were dropped for.
+func (proc *Handle) filterDestinationsByConsent(event types.SingularEventT, sourceID string, destinations []backendconfig.DestinationT) ([]backendconfig.DestinationT, []consentDroppedDestination) {
 	consentManagementInfo, err := getConsentManagementInfo(event)
 	if err != nil {
 		// Log the error for debugging purposes
 		proc.logger.Errorw("failed to get consent management info", "error", err.Error())
 	}
+	tcString, _ := misc.MapLookup(event, "context", "consent", "tcString").(string)
 
-	if len(consentManagementInfo.DeniedConsentIDs) == 0 {
-		return destinations
+	// If the event has neither denied consent IDs nor a consent string, do not filter any destinations
+	if len(consentManagementInfo.DeniedConsentIDs) == 0 && tcString == "" {
+		return destinations, nil
 	}
 
-	return lo.Filter(destinations, func(dest backendconfig.DestinationT, _ int) bool {
-		// Generic consent management
-		if cmpData := proc.getGCMData(sourceID, dest.ID, consentManagementInfo.Provider); len(cmpData.Consents) > 0 {
+	// the consent string is decoded once, and only if a destination needs it
+	decodeTCString := sync.OnceValues(func() (*tcf.Consent, error) {
+		return tcf.Decode(tcString)
+	})
+
+	var dropped []consentDroppedDestination
+	filtered := lo.Filter(destinations, func(dest backendconfig.DestinationT, _ int) bool {
+		reason := ""
+		if tcString != "" {
+			reason = proc.getTCFDeniedReason(sourceID, dest.ID, decodeTCString)
+		}
+		if reason == "" && len(consentManagementInfo.DeniedConsentIDs) > 0 {
+			reason = proc.getDeniedConsentReason(consentManagementInfo, sourceID, dest.ID)
+		}
+		if reason != "" {
+			dropped = append(dropped, consentDroppedDestination{destination: dest, reason: reason})
+			return false
+		}
+		return true
+	})
+	return filtered, dropped
+}
+
+// getDeniedConsentReason returns the reason the destination lacks consent according to the denied consent ids of the event,
+// or an empty string if it doesn't
+func (proc *Handle) getDeniedConsentReason(consentManagementInfo ConsentManagementInfo, sourceID, destinationID string) string {
+	// Generic consent management
+	if cmpData := proc.getGCMData(sourceID, destinationID, consentManagementInfo.Provider); len(cmpData.Consents) > 0 {
 
-			finalResolutionStrategy := consentManagementInfo.ResolutionStrategy
+		finalResolutionStrategy := consentManagementInfo.ResolutionStrategy
 
-			// For custom provider, the resolution strategy is to be picked from the destination config
-			if consentManagementInfo.Provider == "custom" {
-				finalResolutionStrategy = cmpData.ResolutionStrategy
-			}
+		// For custom provider, the resolution strategy is to be picked from the destination config
+		if consentManagementInfo.Provider == "custom" {
+			finalResolutionStrategy = cmpData.ResolutionStrategy
+		}
 
-			switch finalResolutionStrategy {
-			// The user must consent to at least one of the configured consents in the destination
-			case "or":
-				return !lo.Every(consentManagementInfo.DeniedConsentIDs, cmpData.Consents)
+		var consented bool
+		switch finalResolutionStrategy {
+		// The user must consent to at least one of the configured consents in the destination
+		case "or":
+			consented = !lo.Every(consentManagementInfo.DeniedConsentIDs, cmpData.Consents)
 
-			// The user must consent to all of the configured consents in the destination
-			default: // "and"
-				return len(lo.Intersect(cmpData.Consents, consentManagementInfo.DeniedConsentIDs)) == 0
-			}
+		// The user must consent to all of the configured consents in the destination
+		default: // "and"
+			consented = len(lo.Intersect(cmpData.Consents, consentManagementInfo.DeniedConsentIDs)) == 0
 		}
+		return deniedConsentReason(consented, consentManagementInfo.Provider)
+	}
 
-		// Legacy consent management
-		if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "oneTrust" {
-			// If the destination has oneTrustCookieCategories, returns false if any of the oneTrustCategories are present in deniedCategories
-			if oneTrustCategories := proc.getOneTrustConsentData(dest.ID); len(oneTrustCategories) > 0 {
-				return len(lo.Intersect(oneTrustCategories, consentManagementInfo.DeniedConsentIDs)) == 0
-			}
+	// Legacy consent management
+	if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "oneTrust" {
+		// If the destination has oneTrustCookieCategories, returns false if any of the oneTrustCategories are present in deniedCategories
+		if oneTrustCategories := proc.getOneTrustConsentData(destinationID); len(oneTrustCategories) > 0 {
+			return deniedConsentReason(len(lo.Intersect(oneTrustCategories, consentManagementInfo.DeniedConsentIDs)) == 0, "oneTrust")
 		}
+	}
 
-		if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "ketch" {
-			// If the destination has ketchConsentPurposes, returns false if all ketchPurposes are present in deniedCategories
-			if ketchPurposes := proc.getKetchConsentData(dest.ID); len(ketchPurposes) > 0 {
-				return !lo.Every(consentManagementInfo.DeniedConsentIDs, ketchPurposes)
-			}
+	if consentManagementInfo.Provider == "" || consentManagementInfo.Provider == "ketch" {
+		// If the destination has ketchConsentPurposes, returns false if all ketchPurposes are present in deniedCategories
+		if ketchPurposes := proc.getKetchConsentData(destinationID); len(ketchPurposes) > 0 {
+			return deniedConsentReason(!lo.Every(consentManagementInfo.DeniedConsentIDs, ketchPurposes), "ketch")
 		}
+	}
 
-		return true
-	})
+	return ""
+}
+
+func deniedConsentReason(consented bool, provider string) string {
+	if consented {
+		return ""
+	}
+	return provider + consentDroppedReasonDeniedSuffix
+}
+
+// getTCFDeniedReason returns the reason the destination lacks consent according to the IAB TCF consent string of the event,
+// or an empty string if it doesn't or the destination doesn't require any purposes or vendors.
+//
+// With the "or" resolution strategy the user must consent to at least one of the purposes and at least one of the vendors
+// configured in the destination, otherwise to all of them.
+func (proc *Handle) getTCFDeniedReason(sourceID, destinationID string, decodeTCString func() (*tcf.Consent, error)) string {
+	iabData := proc.getGCMData(sourceID, destinationID, iabConsentProvider)
+	if len(iabData.Purposes) == 0 && len(iabData.Vendors) == 0 {
+		return ""
+	}
+	consent, err := decodeTCString()
+	if err != nil {
+		return consentDroppedReasonInvalidTCString
+	}
+
+	hasPurpose := func(purpose int) bool {
+		if consent.PurposeConsent(purpose) {
+			return true
+		}
+		return iabData.AllowLegitimateInterest && tcf.AllowsLegitimateInterest(purpose) && consent.PurposeLegitimateInterest(purpose)
+	}
+	hasVendor := func(vendor int) bool {
+		return consent.VendorConsent(vendor) || (iabData.AllowLegitimateInterest && consent.VendorLegitimateInterest(vendor))
+	}
+	resolve := lo.EveryBy[int]
+	if iabData.ResolutionStrategy == "or" {
+		resolve = lo.SomeBy[int]
+	}
+
+	if len(iabData.Purposes) > 0 && !resolve(iabData.Purposes, hasPurpose) {
+		return consentDroppedReasonTCFPurposes
+	}
+	if len(iabData.Vendors) > 0 && !resolve(iabData.Vendors, hasVendor) {
+		return consentDroppedReasonTCFVendors
+	}
+	return ""
+}
+
+// updateConsentMetricMaps records the destinations an event was dropped for, along with the reason they were dropped for
+func updateConsentMetricMaps(
+	connectionDetailsMap map[string]*reportingtypes.ConnectionDetails,
+	statusDetailsMap map[string]*reportingtypes.StatusDetail,
+	metadata *types.Metadata,
+	dropped []consentDroppedDestination,
+) {
+	for _, d := range dropped {
+		key := strings.Join([]string{
+			metadata.SourceID,
+			d.destination.ID,
+			metadata.SourceJobRunID,
+			metadata.EventName,
+			metadata.EventType,
+			d.reason,
+		}, MetricKeyDelimiter)
+		if _, ok := connectionDetailsMap[key]; !ok {
+			connectionDetailsMap[key] = &reportingtypes.ConnectionDetails{
+				SourceID:                metadata.SourceID,
+				SourceTaskRunID:         metadata.SourceTaskRunID,
+				SourceJobID:             metadata.SourceJobID,
+				SourceJobRunID:          metadata.SourceJobRunID,
+				SourceDefinitionID:      metadata.SourceDefinitionID,
+				SourceCategory:          metadata.SourceCategory,
+				DestinationID:           d.destination.ID,
+				DestinationDefinitionID: d.destination.DestinationDefinition.ID,
+			}
+			statusDetailsMap[key] = &reportingtypes.StatusDetail{
+				Status:         reportingtypes.ConsentDeniedStatus,
+				StatusCode:     reportingtypes.FilterEventCode,
+				SampleResponse: d.reason,
+				EventName:      metadata.EventName,
+				EventType:      metadata.EventType,
+				ErrorType:      d.reason,
+			}
+		}
+		statusDetailsMap[key].Count++
+	}
 }
 
 func (proc *Handle) getOneTrustConsentData(destinationID string) []string {
@@ -183,6 +343,17 @@ func getGenericConsentManagementData(dest *backendconfig.DestinationT) (ConsentP
 	}
 
 	for _, providerConfig := range consentManagementConfig {
+		if providerConfig.Provider == iabConsentProvider {
+			iabData, err := getIABConsentData(providerConfig)
+			if err != nil {
+				return genericConsentManagementData, fmt.Errorf("%v for destination ID: %s", err, dest.ID)
+			}
+			if len(iabData.Purposes) > 0 || len(iabData.Vendors) > 0 {
+				genericConsentManagementData[iabConsentProvider] = iabData
+			}
+			continue
+		}
+
 		consentsConfig := providerConfig.Consents
 
 		if len(consentsConfig) > 0 && providerConfig.Provider != "" {
@@ -205,6 +376,34 @@ func getGenericConsentManagementData(dest *backendconfig.DestinationT) (ConsentP
 	return genericConsentManagementData, nil
 }
 
+func getIABConsentData(providerConfig GenericConsentManagementProviderConfig) (GenericConsentManagementProviderData, error) {
+	data := GenericConsentManagementProviderData{
+		ResolutionStrategy:      providerConfig.ResolutionStrategy,
+		AllowLegitimateInterest: providerConfig.AllowLegitimateInterest,
+	}
+	for _, purposeConfig := range providerConfig.Purposes {
+		if purposeConfig.Purpose == "" {
+			continue
+		}
+		purpose, err := strconv.Atoi(purposeConfig.Purpose)
+		if err != nil || purpose < 1 || purpose > tcf.NumPurposes {
+			return data, fmt.Errorf("invalid iab purpose: %q", purposeConfig.Purpose)
+		}
+		data.Purposes = append(data.Purposes, purpose)
+	}
+	for _, vendorConfig := range providerConfig.Vendors {
+		if vendorConfig.Vendor == "" {
+			continue
+		}
+		vendor, err := strconv.Atoi(vendorConfig.Vendor)
+		if err != nil || vendor < 1 {
+			return data, fmt.Errorf("invalid iab vendor: %q", vendorConfig.Vendor)
+		}
+		data.Vendors = append(data.Vendors, vendor)
+	}
+	return data, nil
+}
+
 func getConsentManagementInfo(event types.SingularEventT) (ConsentManagementInfo, error) {
 	consentManagementInfo := ConsentManagementInfo{}
 	if consentManagement, ok := misc.MapLookup(event, "context", "consentManagement").(map[string]interface{}); ok {
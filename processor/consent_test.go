@@ -9,6 +9,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/processor/types"
+	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
 )
 
 type ConnectionInfo struct {
@@ -1787,6 +1788,47 @@ func TestGetGenericConsentManagementData(t *testing.T) {
 		},
 	}
 
+	testCases = append(testCases,
+		testCaseT{
+			description: "should return iab purposes and vendors when the iab provider is configured",
+			input: &backendconfig.DestinationT{
+				Config: map[string]interface{}{
+					"consentManagement": []interface{}{
+						map[string]interface{}{
+							"provider":                "iab",
+							"resolutionStrategy":      "or",
+							"allowLegitimateInterest": true,
+							"purposes":                []interface{}{map[string]interface{}{"purpose": "1"}, map[string]interface{}{"purpose": ""}, map[string]interface{}{"purpose": "7"}},
+							"vendors":                 []interface{}{map[string]interface{}{"vendor": "755"}},
+						},
+					},
+				},
+			},
+			expected: ConsentProviderMap{
+				"iab": {
+					ResolutionStrategy:      "or",
+					Purposes:                []int{1, 7},
+					Vendors:                 []int{755},
+					AllowLegitimateInterest: true,
+				},
+			},
+		},
+		testCaseT{
+			description: "should skip the iab provider when no purposes nor vendors are configured",
+			input: &backendconfig.DestinationT{
+				Config: map[string]interface{}{
+					"consentManagement": []interface{}{
+						map[string]interface{}{
+							"provider": "iab",
+							"purposes": []interface{}{map[string]interface{}{"purpose": ""}},
+						},
+					},
+				},
+			},
+			expected: defGenericConsentManagementData,
+		},
+	)
+
 	for _, testCase := range testCases {
 		t.Run(testCase.description, func(t *testing.T) {
 			actual, _ := getGenericConsentManagementData(testCase.input)
@@ -1794,3 +1836,147 @@ func TestGetGenericConsentManagementData(t *testing.T) {
 		})
 	}
 }
+
+func TestGetGenericConsentManagementDataInvalidIAB(t *testing.T) {
+	for _, iabConfig := range []map[string]interface{}{
+		{"provider": "iab", "purposes": []interface{}{map[string]interface{}{"purpose": "25"}}},
+		{"provider": "iab", "purposes": []interface{}{map[string]interface{}{"purpose": "one"}}},
+		{"provider": "iab", "vendors": []interface{}{map[string]interface{}{"vendor": "-1"}}},
+	} {
+		_, err := getGenericConsentManagementData(&backendconfig.DestinationT{
+			ID:     "destID",
+			Config: map[string]interface{}{"consentManagement": []interface{}{iabConfig}},
+		})
+		require.ErrorContains(t, err, "invalid iab")
+	}
+}
+
+func TestFilterDestinationsByTCFConsent(t *testing.T) {
+	const (
+		sourceID = "sourceID"
+		// purposes 1-4 consented, legitimate interest for purpose 7, vendors 755 and 793 consented
+		tcStringA = "CP99LpAP99LpAEsABAENBkFAAPAAAAIAAAYgGMgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAgAAAAAIAAAAA"
+		// purpose 1 consented, legitimate interest for purposes 3 and 7, vendor 793 consented, legitimate interest for vendor 755
+		tcStringB = "CP99LpAP99LpAEsABAENBkFAAIAAACIAAAYgGMgAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAIF5gAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAgAA"
+	)
+	iabDestination := func(id string, iabConfig map[string]interface{}) backendconfig.DestinationT {
+		iabConfig["provider"] = "iab"
+		return backendconfig.DestinationT{
+			ID:     id,
+			Config: map[string]interface{}{"consentManagement": []interface{}{iabConfig}},
+		}
+	}
+	purposes := func(ids ...string) []interface{} {
+		return lo.Map(ids, func(id string, _ int) interface{} { return map[string]interface{}{"purpose": id} })
+	}
+	vendors := func(ids ...string) []interface{} {
+		return lo.Map(ids, func(id string, _ int) interface{} { return map[string]interface{}{"vendor": id} })
+	}
+	destinations := []backendconfig.DestinationT{
+		iabDestination("purposes", map[string]interface{}{"purposes": purposes("1", "2")}),
+		iabDestination("purposes-li", map[string]interface{}{"purposes": purposes("1", "7"), "allowLegitimateInterest": true}),
+		iabDestination("purposes-li-not-allowed", map[string]interface{}{"purposes": purposes("3"), "allowLegitimateInterest": true}),
+		iabDestination("vendors-or", map[string]interface{}{"vendors": vendors("755", "1"), "resolutionStrategy": "or"}),
+		{
+			ID: "oneTrust",
+			Config: map[string]interface{}{
+				"oneTrustCookieCategories": []interface{}{map[string]interface{}{"oneTrustCookieCategory": "foo"}},
+			},
+		},
+		{ID: "no-consents"},
+	}
+
+	proc := &Handle{}
+	proc.logger = logger.NOP
+	proc.config.oneTrustConsentCategoriesMap = make(map[string][]string)
+	proc.config.ketchConsentCategoriesMap = make(map[string][]string)
+	proc.config.genericConsentManagementMap = SourceConsentMap{SourceID(sourceID): make(DestConsentMap)}
+	for _, dest := range destinations {
+		proc.config.oneTrustConsentCategoriesMap[dest.ID] = getOneTrustConsentCategories(&dest)
+		var err error
+		proc.config.genericConsentManagementMap[SourceID(sourceID)][DestinationID(dest.ID)], err = getGenericConsentManagementData(&dest)
+		require.NoError(t, err)
+	}
+
+	testCases := []struct {
+		description string
+		context     map[string]interface{}
+		expected    map[string]string // the reasons of dropped destinations
+	}{
+		{
+			description: "no consent string",
+			context:     map[string]interface{}{},
+			expected:    map[string]string{},
+		},
+		{
+			description: "consent string with all purposes and vendors",
+			context:     map[string]interface{}{"consent": map[string]interface{}{"tcString": tcStringA}},
+			expected:    map[string]string{},
+		},
+		{
+			description: "consent string lacking purposes and vendors",
+			context:     map[string]interface{}{"consent": map[string]interface{}{"tcString": tcStringB}},
+			expected: map[string]string{
+				"purposes":                consentDroppedReasonTCFPurposes,
+				"purposes-li-not-allowed": consentDroppedReasonTCFPurposes,
+				"vendors-or":              consentDroppedReasonTCFVendors,
+			},
+		},
+		{
+			description: "invalid consent string",
+			context:     map[string]interface{}{"consent": map[string]interface{}{"tcString": "invalid!"}},
+			expected: map[string]string{
+				"purposes":                consentDroppedReasonInvalidTCString,
+				"purposes-li":             consentDroppedReasonInvalidTCString,
+				"purposes-li-not-allowed": consentDroppedReasonInvalidTCString,
+				"vendors-or":              consentDroppedReasonInvalidTCString,
+			},
+		},
+		{
+			description: "consent string along with denied consent ids",
+			context: map[string]interface{}{
+				"consent":           map[string]interface{}{"tcString": tcStringA},
+				"consentManagement": map[string]interface{}{"deniedConsentIds": []interface{}{"foo"}},
+			},
+			expected: map[string]string{
+				"oneTrust": "oneTrust" + consentDroppedReasonDeniedSuffix,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			event := types.SingularEventT{"type": "track", "context": tc.context}
+			filtered, dropped := proc.filterDestinationsByConsent(event, sourceID, destinations)
+
+			reasons := lo.SliceToMap(dropped, func(d consentDroppedDestination) (string, string) { return d.destination.ID, d.reason })
+			require.Equal(t, tc.expected, reasons)
+			require.Len(t, filtered, len(destinations)-len(dropped))
+			for _, dest := range filtered {
+				require.NotContains(t, reasons, dest.ID)
+			}
+			require.Equal(t, filtered, proc.getConsentFilteredDestinations(event, sourceID, destinations))
+		})
+	}
+
+	t.Run("reporting", func(t *testing.T) {
+		event := types.SingularEventT{"type": "track", "context": map[string]interface{}{"consent": map[string]interface{}{"tcString": tcStringB}}}
+		_, dropped := proc.filterDestinationsByConsent(event, sourceID, destinations)
+		metadata := &types.Metadata{SourceID: sourceID, EventName: "Order Completed", EventType: "track"}
+
+		connectionDetailsMap := make(map[string]*reportingtypes.ConnectionDetails)
+		statusDetailsMap := make(map[string]*reportingtypes.StatusDetail)
+		updateConsentMetricMaps(connectionDetailsMap, statusDetailsMap, metadata, dropped)
+		updateConsentMetricMaps(connectionDetailsMap, statusDetailsMap, metadata, dropped)
+		require.Len(t, connectionDetailsMap, 3)
+		for k, cd := range connectionDetailsMap {
+			sd := statusDetailsMap[k]
+			require.Equal(t, sourceID, cd.SourceID)
+			require.Equal(t, reportingtypes.ConsentDeniedStatus, sd.Status)
+			require.Equal(t, reportingtypes.FilterEventCode, sd.StatusCode)
+			require.EqualValues(t, 2, sd.Count)
+			require.Equal(t, "Order Completed", sd.EventName)
+			require.Equal(t, sd.ErrorType, sd.SampleResponse)
+			require.Contains(t, []string{consentDroppedReasonTCFPurposes, consentDroppedReasonTCFVendors}, sd.ErrorType)
+		}
+	})
+}
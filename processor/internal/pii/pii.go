@@ -0,0 +1,348 @@
+// Package pii redacts personally identifiable information from events, according to rules configured per connection.
+//
+// Rules are read from the piiRedaction section of a connection's config, e.g.
+//
+//	{
+//	  "piiRedaction": {
+//	    "rules": [
+//	      {"name": "email", "paths": ["traits.email", "context.traits.email"], "action": "hash"},
+//	      {"name": "ip", "paths": ["context.ip", "request_ip"], "action": "truncate"},
+//	      {"name": "free text", "detect": ["email", "phone"], "action": "redact"}
+//	    ]
+//	  }
+//	}
+//
+// A rule having paths applies its action to the values found at them, a rule having detectors applies it to the PII
+// detected in string values, either everywhere in the event or, if it also has paths, only under them.
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+)
+
+// ConfigKey is the key of the redaction rules in a connection's config
+const ConfigKey = "piiRedaction"
+
+// Actions applied to PII
+const (
+	// ActionRedact replaces PII with [RedactedValue]
+	ActionRedact = "redact"
+	// ActionHash replaces PII with its hex encoded SHA-256 hash
+	ActionHash = "hash"
+	// ActionTruncate masks ip addresses to their network (/24 for IPv4, /48 for IPv6) and keeps the first characters of any other PII
+	ActionTruncate = "truncate"
+)
+
+// Detectors of PII in string values
+const (
+	DetectEmail = "email"
+	DetectPhone = "phone"
+	DetectIP    = "ip"
+)
+
+// RedactedValue replaces redacted PII
+const RedactedValue = "[REDACTED]"
+
+const defaultTruncateLength = 4
+
+// Config is the redaction configuration of a connection
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Rule is a redaction rule, see the package documentation
+type Rule struct {
+	Name   string   `json:"name"`
+	Paths  []string `json:"paths"`
+	Detect []string `json:"detect"`
+	Action string   `json:"action"`
+	// Length is the number of characters kept by [ActionTruncate], defaults to 4
+	Length int `json:"length"`
+}
+
+var (
+	emailRegexp = regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`)
+	phoneRegexp = regexp.MustCompile(`\+\d{7,15}\b|(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{3}\)|\b\d{3})[\s.\-]?\d{3}[\s.\-]\d{4}\b`)
+	// ip candidates are validated by parsing them, since the expressions also match e.g. times
+	ipRegexp = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b|(?:[0-9a-fA-F]{0,4}:){2,7}[0-9a-fA-F]{0,4}`)
+)
+
+type rule struct {
+	name      string
+	paths     [][]string
+	detectors []string
+	action    string
+	length    int
+}
+
+// Redactor applies a set of rules to events
+type Redactor struct {
+	rules []rule
+}
+
+// FromConnectionConfig creates a redactor from the rules of a connection's config.
+// It returns nil if the connection doesn't have any rules.
+func FromConnectionConfig(connectionConfig map[string]any) (*Redactor, error) {
+	raw, ok := connectionConfig[ConfigKey]
+	if !ok || raw == nil {
+		return nil, nil
+	}
+	data, err := jsonrs.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling pii redaction config: %w", err)
+	}
+	var conf Config
+	if err := jsonrs.Unmarshal(data, &conf); err != nil {
+		return nil, fmt.Errorf("unmarshalling pii redaction config: %w", err)
+	}
+	if len(conf.Rules) == 0 {
+		return nil, nil
+	}
+	return New(conf.Rules)
+}
+
+// New creates a redactor applying the rules in order
+func New(rules []Rule) (*Redactor, error) {
+	r := &Redactor{rules: make([]rule, 0, len(rules))}
+	for i, ru := range rules {
+		name := ru.Name
+		if name == "" {
+			name = "rule-" + strconv.Itoa(i+1)
+		}
+		switch ru.Action {
+		case ActionRedact, ActionHash, ActionTruncate:
+		default:
+			return nil, fmt.Errorf("pii rule %q: unknown action %q", name, ru.Action)
+		}
+		if len(ru.Paths) == 0 && len(ru.Detect) == 0 {
+			return nil, fmt.Errorf("pii rule %q: neither paths nor detectors are set", name)
+		}
+		for _, detector := range ru.Detect {
+			switch detector {
+			case DetectEmail, DetectPhone, DetectIP:
+			default:
+				return nil, fmt.Errorf("pii rule %q: unknown detector %q", name, detector)
+			}
+		}
+		if ru.Length < 0 {
+			return nil, fmt.Errorf("pii rule %q: negative length", name)
+		}
+		compiled := rule{name: name, detectors: ru.Detect, action: ru.Action, length: ru.Length}
+		if compiled.length == 0 {
+			compiled.length = defaultTruncateLength
+		}
+		for _, path := range ru.Paths {
+			segments := strings.Split(path, ".")
+			if slices.Contains(segments, "") {
+				return nil, fmt.Errorf("pii rule %q: invalid path %q", name, path)
+			}
+			compiled.paths = append(compiled.paths, segments)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+	return r, nil
+}
+
+// Applied is the number of values a rule was applied to
+type Applied struct {
+	Rule   string
+	Action string
+	Count  int
+}
+
+// Redact returns a copy of the event with the rules applied, along with the number of values each rule was applied to.
+// The event itself is left untouched, since it may be shared with other destinations.
+func (r *Redactor) Redact(event map[string]any) (map[string]any, []Applied) {
+	redacted, _ := deepCopy(event).(map[string]any)
+	applied := make([]Applied, len(r.rules))
+	for i, ru := range r.rules {
+		applied[i] = Applied{Rule: ru.name, Action: ru.action}
+		fn := func(v any) (any, bool) { return ru.applyToValue(v) }
+		if len(ru.detectors) > 0 {
+			fn = func(v any) (any, bool) { return ru.applyToDetected(v) }
+		}
+		if len(ru.paths) == 0 {
+			walk(redacted, fn, &applied[i].Count)
+			continue
+		}
+		for _, path := range ru.paths {
+			walkPath(redacted, path, fn, &applied[i].Count)
+		}
+	}
+	return redacted, applied
+}
+
+// applyToValue applies the action to a whole scalar value
+func (ru rule) applyToValue(v any) (any, bool) {
+	s, ok := stringOf(v)
+	if !ok || s == "" {
+		return v, false
+	}
+	return ru.transform(s), true
+}
+
+// applyToDetected applies the action to the PII detected in a string value
+func (ru rule) applyToDetected(v any) (any, bool) {
+	s, ok := v.(string)
+	if !ok || s == "" {
+		return v, false
+	}
+	var found bool
+	for _, detector := range ru.detectors {
+		switch detector {
+		case DetectEmail:
+			s = replaceAll(emailRegexp, s, nil, ru.transform, &found)
+		case DetectPhone:
+			s = replaceAll(phoneRegexp, s, nil, ru.transform, &found)
+		case DetectIP:
+			s = replaceAll(ipRegexp, s, isIP, ru.transform, &found)
+		}
+	}
+	return s, found
+}
+
+func replaceAll(re *regexp.Regexp, s string, valid func(string) bool, transform func(string) string, found *bool) string {
+	return re.ReplaceAllStringFunc(s, func(match string) string {
+		if valid != nil && !valid(match) {
+			return match
+		}
+		*found = true
+		return transform(match)
+	})
+}
+
+// isIP returns whether the candidate is an ip address. IPv6 addresses need at least three groups,
+// so that e.g. the separators of fully qualified names (Foo::Bar) aren't mistaken for abbreviated addresses.
+func isIP(s string) bool {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return false
+	}
+	if addr.Is4() {
+		return true
+	}
+	var groups int
+	for _, group := range strings.Split(s, ":") {
+		if group != "" {
+			groups++
+		}
+	}
+	return groups >= 3
+}
+
+func (ru rule) transform(s string) string {
+	switch ru.action {
+	case ActionHash:
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	case ActionTruncate:
+		if addr, err := netip.ParseAddr(s); err == nil {
+			bits := 48
+			if addr.Unmap().Is4() {
+				addr, bits = addr.Unmap(), 24
+			}
+			prefix, _ := addr.Prefix(bits)
+			return prefix.Addr().String()
+		}
+		if runes := []rune(s); len(runes) > ru.length {
+			return string(runes[:ru.length])
+		}
+		return s
+	default:
+		return RedactedValue
+	}
+}
+
+// stringOf returns the string representation of scalar values
+func stringOf(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case bool:
+		return strconv.FormatBool(v), true
+	default:
+		return "", false
+	}
+}
+
+// walk applies fn to all scalar values under v, replacing them in place
+func walk(v any, fn func(any) (any, bool), count *int) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = walk(child, fn, count)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = walk(child, fn, count)
+		}
+		return v
+	default:
+		nv, ok := fn(v)
+		if ok {
+			*count++
+		}
+		return nv
+	}
+}
+
+// walkPath applies fn to all scalar values under the path, a * segment matching any key or array element
+func walkPath(v any, path []string, fn func(any) (any, bool), count *int) any {
+	if len(path) == 0 {
+		return walk(v, fn, count)
+	}
+	switch v := v.(type) {
+	case map[string]any:
+		if path[0] == "*" {
+			for k, child := range v {
+				v[k] = walkPath(child, path[1:], fn, count)
+			}
+		} else if child, ok := v[path[0]]; ok {
+			v[path[0]] = walkPath(child, path[1:], fn, count)
+		}
+	case []any:
+		if path[0] == "*" {
+			for i, child := range v {
+				v[i] = walkPath(child, path[1:], fn, count)
+			}
+		} else if i, err := strconv.Atoi(path[0]); err == nil && i >= 0 && i < len(v) {
+			v[i] = walkPath(v[i], path[1:], fn, count)
+		}
+	}
+	return v
+}
+
+func deepCopy(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(v))
+		for k, child := range v {
+			c[k] = deepCopy(child)
+		}
+		return c
+	case []any:
+		c := make([]any, len(v))
+		for i, child := range v {
+			c[i] = deepCopy(child)
+		}
+		return c
+	default:
+		return v
+	}
+}
@@ -0,0 +1,133 @@
+package pii
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRedactor(t *testing.T) {
+	newEvent := func() map[string]any {
+		return map[string]any{
+			"messageId":  "a0b1c2d3-e4f5-4a6b-8c7d-9e0f1a2b3c4d",
+			"request_ip": "203.0.113.42",
+			"traits": map[string]any{
+				"email": "John.Doe@example.com",
+				"phone": float64(15551234567),
+				"name":  "John Doe",
+			},
+			"context": map[string]any{
+				"ip":        "2001:db8:85a3:1234::8a2e:370:7334",
+				"timestamp": "2024-01-01T10:30:00.000Z",
+			},
+			"properties": map[string]any{
+				"comment":  "call me at +1 555-123-4567 or mail john@example.com, sent from 198.51.100.7",
+				"language": "Foo::Bar",
+				"items": []any{
+					map[string]any{"owner": "jane@example.com"},
+					map[string]any{"owner": "joe@example.com"},
+				},
+			},
+		}
+	}
+
+	t.Run("paths", func(t *testing.T) {
+		r, err := New([]Rule{
+			{Name: "email", Paths: []string{"traits.email", "missing.path"}, Action: ActionHash},
+			{Name: "phone", Paths: []string{"traits.phone"}, Action: ActionRedact},
+			{Name: "ip", Paths: []string{"request_ip", "context.ip"}, Action: ActionTruncate},
+			{Name: "name", Paths: []string{"traits.name"}, Action: ActionTruncate, Length: 2},
+			{Name: "owners", Paths: []string{"properties.items.*.owner"}, Action: ActionRedact},
+		})
+		require.NoError(t, err)
+
+		event := newEvent()
+		redacted, applied := r.Redact(event)
+		require.Equal(t, newEvent(), event, "the event shouldn't be modified")
+		require.Equal(t, sha256Hex("John.Doe@example.com"), redacted["traits"].(map[string]any)["email"])
+		require.Equal(t, RedactedValue, redacted["traits"].(map[string]any)["phone"])
+		require.Equal(t, "Jo", redacted["traits"].(map[string]any)["name"])
+		require.Equal(t, "203.0.113.0", redacted["request_ip"])
+		require.Equal(t, "2001:db8:85a3::", redacted["context"].(map[string]any)["ip"])
+		require.Equal(t, []any{
+			map[string]any{"owner": RedactedValue},
+			map[string]any{"owner": RedactedValue},
+		}, redacted["properties"].(map[string]any)["items"])
+		require.Equal(t, []Applied{
+			{Rule: "email", Action: ActionHash, Count: 1},
+			{Rule: "phone", Action: ActionRedact, Count: 1},
+			{Rule: "ip", Action: ActionTruncate, Count: 2},
+			{Rule: "name", Action: ActionTruncate, Count: 1},
+			{Rule: "owners", Action: ActionRedact, Count: 2},
+		}, applied)
+	})
+
+	t.Run("detectors", func(t *testing.T) {
+		r, err := New([]Rule{
+			{Detect: []string{DetectEmail, DetectPhone, DetectIP}, Action: ActionRedact},
+		})
+		require.NoError(t, err)
+
+		redacted, applied := r.Redact(newEvent())
+		require.Equal(t, "call me at [REDACTED] or mail [REDACTED], sent from [REDACTED]", redacted["properties"].(map[string]any)["comment"])
+		require.Equal(t, RedactedValue, redacted["traits"].(map[string]any)["email"])
+		require.Equal(t, RedactedValue, redacted["request_ip"])
+		require.Equal(t, RedactedValue, redacted["context"].(map[string]any)["ip"])
+		require.Equal(t, float64(15551234567), redacted["traits"].(map[string]any)["phone"], "detectors only apply to strings")
+		require.Equal(t, "John Doe", redacted["traits"].(map[string]any)["name"])
+		require.Equal(t, "a0b1c2d3-e4f5-4a6b-8c7d-9e0f1a2b3c4d", redacted["messageId"])
+		require.Equal(t, "2024-01-01T10:30:00.000Z", redacted["context"].(map[string]any)["timestamp"])
+		require.Equal(t, "Foo::Bar", redacted["properties"].(map[string]any)["language"])
+		require.Equal(t, []Applied{{Rule: "rule-1", Action: ActionRedact, Count: 6}}, applied)
+	})
+
+	t.Run("detectors under paths", func(t *testing.T) {
+		r, err := New([]Rule{
+			{Name: "comment", Paths: []string{"properties.comment"}, Detect: []string{DetectIP}, Action: ActionTruncate},
+		})
+		require.NoError(t, err)
+
+		redacted, applied := r.Redact(newEvent())
+		require.Equal(t, "call me at +1 555-123-4567 or mail john@example.com, sent from 198.51.100.0", redacted["properties"].(map[string]any)["comment"])
+		require.Equal(t, "203.0.113.42", redacted["request_ip"])
+		require.Equal(t, []Applied{{Rule: "comment", Action: ActionTruncate, Count: 1}}, applied)
+	})
+}
+
+func TestFromConnectionConfig(t *testing.T) {
+	r, err := FromConnectionConfig(map[string]any{})
+	require.NoError(t, err)
+	require.Nil(t, r)
+
+	r, err = FromConnectionConfig(map[string]any{ConfigKey: map[string]any{"rules": []any{}}})
+	require.NoError(t, err)
+	require.Nil(t, r)
+
+	r, err = FromConnectionConfig(map[string]any{ConfigKey: map[string]any{"rules": []any{
+		map[string]any{"paths": []any{"traits.email"}, "action": "hash"},
+	}}})
+	require.NoError(t, err)
+	require.NotNil(t, r)
+
+	for _, tc := range []struct {
+		config map[string]any
+		err    string
+	}{
+		{config: map[string]any{"rules": "invalid"}, err: "unmarshalling pii redaction config"},
+		{config: map[string]any{"rules": []any{map[string]any{"paths": []any{"traits.email"}, "action": "encrypt"}}}, err: `pii rule "rule-1": unknown action "encrypt"`},
+		{config: map[string]any{"rules": []any{map[string]any{"name": "empty", "action": "hash"}}}, err: `pii rule "empty": neither paths nor detectors are set`},
+		{config: map[string]any{"rules": []any{map[string]any{"detect": []any{"ssn"}, "action": "hash"}}}, err: `pii rule "rule-1": unknown detector "ssn"`},
+		{config: map[string]any{"rules": []any{map[string]any{"paths": []any{"traits..email"}, "action": "hash"}}}, err: `pii rule "rule-1": invalid path "traits..email"`},
+		{config: map[string]any{"rules": []any{map[string]any{"paths": []any{"traits.email"}, "action": "truncate", "length": -1}}}, err: `pii rule "rule-1": negative length`},
+	} {
+		_, err := FromConnectionConfig(map[string]any{ConfigKey: tc.config})
+		require.ErrorContains(t, err, tc.err)
+	}
+}
@@ -0,0 +1,201 @@
+// Package tcf decodes IAB Transparency and Consent Framework (TCF) v2.2 consent strings.
+//
+// Only the core segment of a string is decoded, which carries the purposes and vendors the user consented to.
+// The publisher restrictions and the optional disclosed vendors and publisher purposes segments are ignored.
+// See https://github.com/InteractiveAdvertisingBureau/GDPR-Transparency-and-Consent-Framework for the format.
+package tcf
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Version is the version of the consent strings supported
+const Version = 2
+
+// NumPurposes is the number of purposes defined by the framework
+const NumPurposes = 24
+
+// NumSpecialFeatures is the number of special features defined by the framework
+const NumSpecialFeatures = 12
+
+var (
+	ErrEmpty              = errors.New("empty tcf consent string")
+	ErrInvalidEncoding    = errors.New("invalid tcf consent string encoding")
+	ErrTruncated          = errors.New("truncated tcf consent string")
+	ErrUnsupportedVersion = errors.New("unsupported tcf consent string version")
+)
+
+// Consent is the decoded core segment of a consent string
+type Consent struct {
+	Version             int
+	Created             time.Time
+	LastUpdated         time.Time
+	CMPID               int
+	CMPVersion          int
+	ConsentScreen       int
+	ConsentLanguage     string
+	VendorListVersion   int
+	PolicyVersion       int
+	IsServiceSpecific   bool
+	UseNonStandardTexts bool
+	PurposeOneTreatment bool
+	PublisherCC         string
+
+	specialFeatureOptIns      []bool
+	purposesConsent           []bool
+	purposesLITransparency    []bool
+	vendorConsents            []bool
+	vendorLegitimateInterests []bool
+}
+
+// Decode decodes a consent string
+func Decode(s string) (*Consent, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, ErrEmpty
+	}
+	core, _, _ := strings.Cut(s, ".")
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(core, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEncoding, err)
+	}
+	r := &bitReader{data: data}
+	c := &Consent{}
+	if c.Version = r.readInt(6); r.err == nil && c.Version != Version {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, c.Version)
+	}
+	c.Created = r.readTime()
+	c.LastUpdated = r.readTime()
+	c.CMPID = r.readInt(12)
+	c.CMPVersion = r.readInt(12)
+	c.ConsentScreen = r.readInt(6)
+	c.ConsentLanguage = r.readLetters()
+	c.VendorListVersion = r.readInt(12)
+	c.PolicyVersion = r.readInt(6)
+	c.IsServiceSpecific = r.readBool()
+	c.UseNonStandardTexts = r.readBool()
+	c.specialFeatureOptIns = r.readBitField(NumSpecialFeatures)
+	c.purposesConsent = r.readBitField(NumPurposes)
+	c.purposesLITransparency = r.readBitField(NumPurposes)
+	c.PurposeOneTreatment = r.readBool()
+	c.PublisherCC = r.readLetters()
+	c.vendorConsents = r.readVendors()
+	c.vendorLegitimateInterests = r.readVendors()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return c, nil
+}
+
+// SpecialFeatureOptIn returns whether the user opted in to the special feature
+func (c *Consent) SpecialFeatureOptIn(id int) bool {
+	return isSet(c.specialFeatureOptIns, id)
+}
+
+// PurposeConsent returns whether the user consented to the purpose
+func (c *Consent) PurposeConsent(id int) bool {
+	return isSet(c.purposesConsent, id)
+}
+
+// PurposeLegitimateInterest returns whether the legitimate interest for the purpose was established, i.e. the user didn't object to it
+func (c *Consent) PurposeLegitimateInterest(id int) bool {
+	return isSet(c.purposesLITransparency, id)
+}
+
+// VendorConsent returns whether the user consented to the vendor
+func (c *Consent) VendorConsent(id int) bool {
+	return isSet(c.vendorConsents, id)
+}
+
+// VendorLegitimateInterest returns whether the legitimate interest of the vendor was established, i.e. the user didn't object to it
+func (c *Consent) VendorLegitimateInterest(id int) bool {
+	return isSet(c.vendorLegitimateInterests, id)
+}
+
+// AllowsLegitimateInterest returns whether the purpose can be processed on the basis of legitimate interest.
+// Since TCF v2.2 only consent is a valid legal basis for purposes 1, 3, 4, 5 and 6.
+func AllowsLegitimateInterest(purpose int) bool {
+	switch purpose {
+	case 1, 3, 4, 5, 6:
+		return false
+	default:
+		return purpose > 0 && purpose <= NumPurposes
+	}
+}
+
+// isSet returns whether the 1-based id is set in the field
+func isSet(field []bool, id int) bool {
+	return id > 0 && id <= len(field) && field[id-1]
+}
+
+// bitReader reads big endian bit sequences, recording the first error encountered
+type bitReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *bitReader) readInt(bits int) int {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos+bits > len(r.data)*8 {
+		r.err = ErrTruncated
+		return 0
+	}
+	var v int
+	for i := 0; i < bits; i++ {
+		bit := (r.data[r.pos/8] >> (7 - r.pos%8)) & 1
+		v = v<<1 | int(bit)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) readBool() bool {
+	return r.readInt(1) == 1
+}
+
+// readTime reads a timestamp in deciseconds
+func (r *bitReader) readTime() time.Time {
+	return time.UnixMilli(int64(r.readInt(36)) * 100).UTC()
+}
+
+// readLetters reads a two letter code, each letter encoded as its offset from A
+func (r *bitReader) readLetters() string {
+	return string([]byte{byte('A' + r.readInt(6)), byte('A' + r.readInt(6))})
+}
+
+func (r *bitReader) readBitField(bits int) []bool {
+	field := make([]bool, bits)
+	for i := range field {
+		field[i] = r.readBool()
+	}
+	return field
+}
+
+// readVendors reads a vendor section, encoded either as a bit field or as a list of ranges
+func (r *bitReader) readVendors() []bool {
+	maxVendorID := r.readInt(16)
+	if !r.readBool() {
+		return r.readBitField(maxVendorID)
+	}
+	vendors := make([]bool, maxVendorID)
+	numEntries := r.readInt(12)
+	for i := 0; i < numEntries && r.err == nil; i++ {
+		isRange := r.readBool()
+		start := r.readInt(16)
+		end := start
+		if isRange {
+			end = r.readInt(16)
+		}
+		for id := max(start, 1); id <= min(end, maxVendorID); id++ {
+			vendors[id-1] = true
+		}
+	}
+	return vendors
+}
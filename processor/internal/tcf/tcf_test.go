@@ -0,0 +1,215 @@
+package tcf_test
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/processor/internal/tcf"
+)
+
+func TestDecode(t *testing.T) {
+	created := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("bit field vendors", func(t *testing.T) {
+		s := encode(t, consentString{
+			version:             2,
+			created:             created,
+			cmpID:               300,
+			language:            "EN",
+			policyVersion:       5,
+			specialFeatures:     []int{1},
+			purposes:            []int{1, 2, 7},
+			purposesLI:          []int{2, 7, 10},
+			publisherCC:         "DE",
+			vendors:             []int{1, 755},
+			vendorsLI:           []int{3},
+			purposeOneTreatment: true,
+		})
+		c, err := tcf.Decode(s + ".YAAAAAAAAAAA") // a disclosed vendors segment is ignored
+		require.NoError(t, err)
+
+		require.Equal(t, 2, c.Version)
+		require.Equal(t, created, c.Created)
+		require.Equal(t, created, c.LastUpdated)
+		require.Equal(t, 300, c.CMPID)
+		require.Equal(t, "EN", c.ConsentLanguage)
+		require.Equal(t, 5, c.PolicyVersion)
+		require.Equal(t, "DE", c.PublisherCC)
+		require.True(t, c.PurposeOneTreatment)
+
+		require.True(t, c.SpecialFeatureOptIn(1))
+		require.False(t, c.SpecialFeatureOptIn(2))
+		for _, p := range []int{1, 2, 7} {
+			require.True(t, c.PurposeConsent(p), "purpose %d", p)
+		}
+		for _, p := range []int{0, 3, 24, 25} {
+			require.False(t, c.PurposeConsent(p), "purpose %d", p)
+		}
+		require.True(t, c.PurposeLegitimateInterest(10))
+		require.False(t, c.PurposeLegitimateInterest(1))
+
+		require.True(t, c.VendorConsent(1))
+		require.True(t, c.VendorConsent(755))
+		require.False(t, c.VendorConsent(2))
+		require.False(t, c.VendorConsent(756))
+		require.True(t, c.VendorLegitimateInterest(3))
+		require.False(t, c.VendorLegitimateInterest(755))
+	})
+
+	t.Run("range vendors", func(t *testing.T) {
+		s := encode(t, consentString{
+			version:       2,
+			created:       created,
+			language:      "FR",
+			publisherCC:   "FR",
+			purposes:      []int{1},
+			vendors:       []int{5, 6, 7, 8, 100},
+			rangeEncoding: true,
+		})
+		c, err := tcf.Decode(s)
+		require.NoError(t, err)
+		for _, v := range []int{5, 6, 7, 8, 100} {
+			require.True(t, c.VendorConsent(v), "vendor %d", v)
+		}
+		for _, v := range []int{4, 9, 99, 101} {
+			require.False(t, c.VendorConsent(v), "vendor %d", v)
+		}
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		_, err := tcf.Decode(" ")
+		require.ErrorIs(t, err, tcf.ErrEmpty)
+
+		_, err = tcf.Decode("not base64!")
+		require.ErrorIs(t, err, tcf.ErrInvalidEncoding)
+
+		_, err = tcf.Decode(encode(t, consentString{version: 1, language: "EN", publisherCC: "EN"}))
+		require.ErrorIs(t, err, tcf.ErrUnsupportedVersion)
+
+		s := encode(t, consentString{version: 2, language: "EN", publisherCC: "EN", vendors: []int{1000}})
+		_, err = tcf.Decode(s[:len(s)-12])
+		require.ErrorIs(t, err, tcf.ErrTruncated)
+	})
+}
+
+func TestAllowsLegitimateInterest(t *testing.T) {
+	for _, p := range []int{1, 3, 4, 5, 6, 0, 25} {
+		require.False(t, tcf.AllowsLegitimateInterest(p), "purpose %d", p)
+	}
+	for _, p := range []int{2, 7, 10, 24} {
+		require.True(t, tcf.AllowsLegitimateInterest(p), "purpose %d", p)
+	}
+}
+
+type consentString struct {
+	version             int
+	created             time.Time
+	cmpID               int
+	language            string
+	policyVersion       int
+	specialFeatures     []int
+	purposes            []int
+	purposesLI          []int
+	purposeOneTreatment bool
+	publisherCC         string
+	vendors             []int
+	vendorsLI           []int
+	rangeEncoding       bool
+}
+
+// encode encodes the core segment of a consent string
+func encode(t *testing.T, cs consentString) string {
+	t.Helper()
+	w := &bitWriter{}
+	w.writeInt(cs.version, 6)
+	w.writeInt(int(cs.created.UnixMilli()/100), 36)
+	w.writeInt(int(cs.created.UnixMilli()/100), 36)
+	w.writeInt(cs.cmpID, 12)
+	w.writeInt(1, 12) // cmp version
+	w.writeInt(0, 6)  // consent screen
+	w.writeLetters(cs.language)
+	w.writeInt(100, 12) // vendor list version
+	w.writeInt(cs.policyVersion, 6)
+	w.writeBool(false) // is service specific
+	w.writeBool(false) // use non standard texts
+	w.writeBitField(cs.specialFeatures, tcf.NumSpecialFeatures)
+	w.writeBitField(cs.purposes, tcf.NumPurposes)
+	w.writeBitField(cs.purposesLI, tcf.NumPurposes)
+	w.writeBool(cs.purposeOneTreatment)
+	w.writeLetters(cs.publisherCC)
+	w.writeVendors(cs.vendors, cs.rangeEncoding)
+	w.writeVendors(cs.vendorsLI, false)
+	w.writeInt(0, 12) // publisher restrictions
+	return base64.RawURLEncoding.EncodeToString(w.bytes())
+}
+
+type bitWriter struct {
+	bits []bool
+}
+
+func (w *bitWriter) writeInt(v, n int) {
+	for i := n - 1; i >= 0; i-- {
+		w.bits = append(w.bits, v>>i&1 == 1)
+	}
+}
+
+func (w *bitWriter) writeBool(b bool) {
+	w.bits = append(w.bits, b)
+}
+
+func (w *bitWriter) writeLetters(s string) {
+	w.writeInt(int(s[0]-'A'), 6)
+	w.writeInt(int(s[1]-'A'), 6)
+}
+
+func (w *bitWriter) writeBitField(ids []int, n int) {
+	field := make([]bool, n)
+	for _, id := range ids {
+		field[id-1] = true
+	}
+	w.bits = append(w.bits, field...)
+}
+
+func (w *bitWriter) writeVendors(ids []int, rangeEncoding bool) {
+	maxID := 0
+	for _, id := range ids {
+		maxID = max(maxID, id)
+	}
+	w.writeInt(maxID, 16)
+	w.writeBool(rangeEncoding)
+	if !rangeEncoding {
+		w.writeBitField(ids, maxID)
+		return
+	}
+	// consecutive ids are encoded as ranges
+	type entry struct{ start, end int }
+	var entries []entry
+	for _, id := range ids {
+		if len(entries) > 0 && entries[len(entries)-1].end == id-1 {
+			entries[len(entries)-1].end = id
+			continue
+		}
+		entries = append(entries, entry{id, id})
+	}
+	w.writeInt(len(entries), 12)
+	for _, e := range entries {
+		w.writeBool(e.start != e.end)
+		w.writeInt(e.start, 16)
+		if e.start != e.end {
+			w.writeInt(e.end, 16)
+		}
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	data := make([]byte, (len(w.bits)+7)/8)
+	for i, bit := range w.bits {
+		if bit {
+			data[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return data
+}
@@ -30,8 +30,10 @@ import (
 	transformerclient "github.com/rudderlabs/rudder-server/internal/transformer-client"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
 	transformerutils "github.com/rudderlabs/rudder-server/processor/internal/transformer"
+	httpdestination "github.com/rudderlabs/rudder-server/processor/internal/transformer/destination_transformer/embedded/http"
 	"github.com/rudderlabs/rudder-server/processor/internal/transformer/destination_transformer/embedded/kafka"
 	"github.com/rudderlabs/rudder-server/processor/internal/transformer/destination_transformer/embedded/pubsub"
+	"github.com/rudderlabs/rudder-server/processor/internal/transformer/destination_transformer/embedded/webhook"
 	"github.com/rudderlabs/rudder-server/processor/types"
 	"github.com/rudderlabs/rudder-server/utils/httputil"
 	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
@@ -393,6 +395,8 @@ type transformer func(ctx context.Context, clientEvents []types.TransformerEvent
 var embeddedTransformerImpls = map[string]transformer{
 	"GOOGLEPUBSUB": pubsub.Transform,
 	"KAFKA":        kafka.Transform,
+	"WEBHOOK":      webhook.Transform,
+	"HTTP":         httpdestination.Transform,
 }
 
 func (c *Client) Transform(ctx context.Context, clientEvents []types.TransformerEvent) types.Response {
@@ -0,0 +1,280 @@
+package http
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/rudderlabs/rudder-go-kit/stringify"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	utils "github.com/rudderlabs/rudder-server/processor/internal/transformer/destination_transformer/embedded"
+	types "github.com/rudderlabs/rudder-server/processor/types"
+)
+
+const (
+	formatJSON = "JSON"
+	formatFORM = "FORM"
+	formatXML  = "XML"
+
+	authBasic  = "basicAuth"
+	authBearer = "bearerTokenAuth"
+	authAPIKey = "apiKeyAuth"
+
+	defaultXMLRootKey = "root"
+)
+
+var (
+	errMissingURL = errors.New("apiUrl is required")
+	errInvalidURL = errors.New("invalid apiUrl")
+
+	contentTypes = map[string]string{
+		formatJSON: "application/json",
+		formatFORM: "application/x-www-form-urlencoded",
+		formatXML:  "application/xml",
+	}
+
+	methodsWithBody = map[string]struct{}{
+		http.MethodPost:  {},
+		http.MethodPut:   {},
+		http.MethodPatch: {},
+	}
+)
+
+// config is the request configuration of an HTTP destination
+type config struct {
+	apiURL            string
+	method            string
+	format            string
+	xmlRootKey        string
+	headers           []utils.KeyValue
+	queryParams       []utils.KeyValue
+	propertiesMapping []utils.KeyValue
+	pathParams        []string
+	authHeaders       map[string]interface{}
+}
+
+func Transform(_ context.Context, events []types.TransformerEvent) types.Response {
+	response := types.Response{}
+	conf := getConfig(events[0].Destination)
+
+	for _, event := range events {
+		event.Metadata.SourceDefinitionType = "" // TODO: Currently, it's getting ignored during JSON marshalling Remove this once we start using it.
+
+		if event.Destination.ID != events[0].Destination.ID {
+			panic("all events must have the same destination")
+		}
+
+		endpoint, err := conf.endpoint(event.Message)
+		if err != nil {
+			response.FailedEvents = append(response.FailedEvents, types.TransformerResponse{
+				Error:      err.Error(),
+				Metadata:   event.Metadata,
+				StatusCode: http.StatusBadRequest,
+				StatTags:   utils.GetValidationErrorStatTags(event.Destination),
+			})
+			continue
+		}
+
+		anonymousID, _ := event.Message["anonymousId"].(string)
+		output := utils.NewRESTOutput(conf.method, endpoint, anonymousID)
+		output["headers"] = conf.requestHeaders(event.Message)
+		output["params"] = resolveAll(conf.queryParams, event.Message)
+		if _, ok := methodsWithBody[conf.method]; ok {
+			body := output["body"].(map[string]interface{})
+			payload := conf.payload(event.Message)
+			switch conf.format {
+			case formatFORM:
+				body[formatFORM] = toForm(payload)
+			case formatXML:
+				body[formatXML] = map[string]interface{}{"payload": toXML(conf.xmlRootKey, payload)}
+			default:
+				body[formatJSON] = payload
+			}
+		}
+
+		response.Events = append(response.Events, types.TransformerResponse{
+			Output:     output,
+			StatusCode: http.StatusOK,
+			Metadata:   event.Metadata,
+		})
+	}
+
+	return response
+}
+
+func getConfig(destination backendconfig.DestinationT) config {
+	getString := func(key string) string {
+		s, _ := destination.Config[key].(string)
+		return strings.TrimSpace(s)
+	}
+	conf := config{
+		apiURL:            getString("apiUrl"),
+		method:            strings.ToUpper(getString("method")),
+		format:            strings.ToUpper(getString("format")),
+		xmlRootKey:        getString("xmlRootKey"),
+		headers:           utils.GetKeyValuePairs(destination, "headers", "to", "from"),
+		queryParams:       utils.GetKeyValuePairs(destination, "queryParams", "to", "from"),
+		propertiesMapping: utils.GetKeyValuePairs(destination, "propertiesMapping", "to", "from"),
+		authHeaders:       map[string]interface{}{},
+	}
+	switch conf.method {
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	default:
+		conf.method = http.MethodPost
+	}
+	if _, ok := contentTypes[conf.format]; !ok {
+		conf.format = formatJSON
+	}
+	if conf.xmlRootKey == "" {
+		conf.xmlRootKey = defaultXMLRootKey
+	}
+	if pathParams, ok := destination.Config["pathParams"].([]interface{}); ok {
+		for _, p := range pathParams {
+			if m, ok := p.(map[string]interface{}); ok {
+				if path, ok := m["path"].(string); ok && strings.TrimSpace(path) != "" {
+					conf.pathParams = append(conf.pathParams, strings.TrimSpace(path))
+				}
+			}
+		}
+	}
+	switch getString("auth") {
+	case authBasic:
+		credentials := getString("username") + ":" + getString("password")
+		conf.authHeaders["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	case authBearer:
+		conf.authHeaders["Authorization"] = "Bearer " + getString("bearerToken")
+	case authAPIKey:
+		if name := getString("apiKeyName"); name != "" {
+			conf.authHeaders[name] = getString("apiKeyValue")
+		}
+	}
+	return conf
+}
+
+// endpoint renders the api url template and the path params for the event
+func (c config) endpoint(message types.SingularEventT) (string, error) {
+	if c.apiURL == "" {
+		return "", errMissingURL
+	}
+	endpoint := utils.RenderURLTemplate(c.apiURL, message)
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errInvalidURL
+	}
+	for _, path := range c.pathParams {
+		segment := stringify.Any(utils.ResolveValue(path, message))
+		if segment == "" {
+			continue
+		}
+		u = u.JoinPath(segment)
+	}
+	return u.String(), nil
+}
+
+// requestHeaders returns the content type and authentication headers, followed by the configured headers rendered for the event
+func (c config) requestHeaders(message types.SingularEventT) map[string]interface{} {
+	headers := map[string]interface{}{"Content-Type": contentTypes[c.format]}
+	for k, v := range c.authHeaders {
+		headers[k] = v
+	}
+	for k, v := range resolveAll(c.headers, message) {
+		headers[k] = v
+	}
+	return headers
+}
+
+// payload returns the properties mapped from the event, or the whole event if there are no mappings
+func (c config) payload(message types.SingularEventT) map[string]interface{} {
+	if len(c.propertiesMapping) == 0 {
+		return utils.GetMessageAsMap(message)
+	}
+	payload := map[string]interface{}{}
+	for _, mapping := range c.propertiesMapping {
+		value := utils.ResolveValue(mapping.Value, message)
+		if value == nil {
+			continue
+		}
+		setValueByPath(payload, mapping.Key, value)
+	}
+	return payload
+}
+
+// resolveAll resolves the values of the pairs for the event, skipping empty ones
+func resolveAll(pairs []utils.KeyValue, message types.SingularEventT) map[string]interface{} {
+	resolved := make(map[string]interface{}, len(pairs))
+	for _, pair := range pairs {
+		if value := stringify.Any(utils.ResolveValue(pair.Value, message)); value != "" {
+			resolved[pair.Key] = value
+		}
+	}
+	return resolved
+}
+
+// setValueByPath sets the value at a dot separated path, creating the intermediate objects
+func setValueByPath(target map[string]interface{}, path string, value interface{}) {
+	segments := strings.Split(strings.TrimPrefix(strings.TrimPrefix(path, "$"), "."), ".")
+	for _, segment := range segments[:len(segments)-1] {
+		child, ok := target[segment].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			target[segment] = child
+		}
+		target = child
+	}
+	target[segments[len(segments)-1]] = value
+}
+
+// toForm flattens the payload into the top level string values expected by the router for form bodies
+func toForm(payload map[string]interface{}) map[string]interface{} {
+	form := make(map[string]interface{})
+	for k, v := range utils.Flatten(payload) {
+		form[k] = stringify.Any(v)
+	}
+	return form
+}
+
+// toXML encodes the payload as an xml document with the given root element, sorting keys so that the output is stable
+func toXML(rootKey string, payload map[string]interface{}) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>`)
+	writeXMLElement(&sb, rootKey, payload)
+	return sb.String()
+}
+
+func writeXMLElement(sb *strings.Builder, name string, value interface{}) {
+	switch v := value.(type) {
+	case []interface{}:
+		// arrays are encoded as repeated elements
+		for _, item := range v {
+			writeXMLElement(sb, name, item)
+		}
+		return
+	case nil:
+		sb.WriteString("<" + name + "/>")
+		return
+	}
+	sb.WriteString("<" + name + ">")
+	if m, ok := value.(map[string]interface{}); ok {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeXMLElement(sb, k, m[k])
+		}
+	} else {
+		sb.WriteString(escapeXML(stringify.Any(value)))
+	}
+	sb.WriteString("</" + name + ">")
+}
+
+var xmlEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&apos;")
+
+func escapeXML(s string) string {
+	return xmlEscaper.Replace(s)
+}
@@ -0,0 +1,183 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+func TestTransform(t *testing.T) {
+	newDestination := func(config map[string]interface{}) backendconfig.DestinationT {
+		return backendconfig.DestinationT{
+			ID:          "destination-id-123",
+			WorkspaceID: "workspace-id-123",
+			DestinationDefinition: backendconfig.DestinationDefinitionT{
+				Name: "HTTP",
+			},
+			Config: config,
+		}
+	}
+	message := types.SingularEventT{
+		"type":        "track",
+		"event":       "Order Completed",
+		"userId":      "user-1",
+		"anonymousId": "anon-1",
+		"properties": map[string]interface{}{
+			"orderId":  "order-1",
+			"revenue":  10.5,
+			"products": []interface{}{map[string]interface{}{"sku": "sku-1"}},
+		},
+	}
+	transform := func(config map[string]interface{}) types.Response {
+		return Transform(context.Background(), []types.TransformerEvent{{
+			Message:     message,
+			Destination: newDestination(config),
+		}})
+	}
+	outputOf := func(t *testing.T, response types.Response) map[string]interface{} {
+		t.Helper()
+		require.Empty(t, response.FailedEvents)
+		require.Len(t, response.Events, 1)
+		require.Equal(t, http.StatusOK, response.Events[0].StatusCode)
+		return response.Events[0].Output
+	}
+
+	t.Run("posts the whole event as json by default", func(t *testing.T) {
+		output := outputOf(t, transform(map[string]interface{}{"apiUrl": "https://example.com/events"}))
+		require.Equal(t, map[string]interface{}{
+			"version":  "1",
+			"type":     "REST",
+			"method":   http.MethodPost,
+			"endpoint": "https://example.com/events",
+			"userId":   "anon-1",
+			"headers":  map[string]interface{}{"Content-Type": "application/json"},
+			"params":   map[string]interface{}{},
+			"body": map[string]interface{}{
+				"JSON":       map[string]interface{}(message),
+				"JSON_ARRAY": map[string]interface{}{},
+				"XML":        map[string]interface{}{},
+				"FORM":       map[string]interface{}{},
+			},
+			"files": map[string]interface{}{},
+		}, output)
+	})
+
+	t.Run("templates the url, path params, headers and query params", func(t *testing.T) {
+		output := outputOf(t, transform(map[string]interface{}{
+			"apiUrl": "https://example.com/users/{{ $.userId }}",
+			"method": "GET",
+			"pathParams": []interface{}{
+				map[string]interface{}{"path": "orders"},
+				map[string]interface{}{"path": "$.properties.orderId"},
+				map[string]interface{}{"path": "$.properties.missing"},
+			},
+			"headers": []interface{}{
+				map[string]interface{}{"to": "X-Event", "from": "$.event"},
+				map[string]interface{}{"to": "X-Trace", "from": "trace-{{ $.anonymousId }}"},
+			},
+			"queryParams": []interface{}{
+				map[string]interface{}{"to": "revenue", "from": "$.properties.revenue"},
+				map[string]interface{}{"to": "sku", "from": "$.properties.products[0].sku"},
+				map[string]interface{}{"to": "missing", "from": "$.properties.missing"},
+			},
+		}))
+		require.Equal(t, http.MethodGet, output["method"])
+		require.Equal(t, "https://example.com/users/user-1/orders/order-1", output["endpoint"])
+		require.Equal(t, map[string]interface{}{
+			"Content-Type": "application/json",
+			"X-Event":      "Order Completed",
+			"X-Trace":      "trace-anon-1",
+		}, output["headers"])
+		require.Equal(t, map[string]interface{}{"revenue": "10.5", "sku": "sku-1"}, output["params"])
+		require.Equal(t, map[string]interface{}{}, output["body"].(map[string]interface{})["JSON"], "GET requests have no body")
+	})
+
+	t.Run("maps properties into the body", func(t *testing.T) {
+		output := outputOf(t, transform(map[string]interface{}{
+			"apiUrl": "https://example.com/events",
+			"method": "patch",
+			"propertiesMapping": []interface{}{
+				map[string]interface{}{"from": "$.event", "to": "$.name"},
+				map[string]interface{}{"from": "$.properties.revenue", "to": "$.order.total"},
+				map[string]interface{}{"from": "web", "to": "$.channel"},
+				map[string]interface{}{"from": "$.properties.missing", "to": "$.missing"},
+			},
+		}))
+		require.Equal(t, http.MethodPatch, output["method"])
+		require.Equal(t, map[string]interface{}{
+			"name":    "Order Completed",
+			"order":   map[string]interface{}{"total": 10.5},
+			"channel": "web",
+		}, output["body"].(map[string]interface{})["JSON"])
+	})
+
+	t.Run("encodes form and xml bodies", func(t *testing.T) {
+		mapping := []interface{}{
+			map[string]interface{}{"from": "$.properties.orderId", "to": "$.order.id"},
+			map[string]interface{}{"from": "<b>&</b>", "to": "$.note"},
+		}
+		output := outputOf(t, transform(map[string]interface{}{
+			"apiUrl":            "https://example.com/events",
+			"format":            "FORM",
+			"propertiesMapping": mapping,
+		}))
+		require.Equal(t, map[string]interface{}{"Content-Type": "application/x-www-form-urlencoded"}, output["headers"])
+		require.Equal(t, map[string]interface{}{"order.id": "order-1", "note": "<b>&</b>"}, output["body"].(map[string]interface{})["FORM"])
+
+		output = outputOf(t, transform(map[string]interface{}{
+			"apiUrl":            "https://example.com/events",
+			"format":            "XML",
+			"xmlRootKey":        "event",
+			"propertiesMapping": mapping,
+		}))
+		require.Equal(t, map[string]interface{}{"Content-Type": "application/xml"}, output["headers"])
+		require.Equal(t, map[string]interface{}{
+			"payload": `<?xml version="1.0" encoding="UTF-8"?><event><note>&lt;b&gt;&amp;&lt;/b&gt;</note><order><id>order-1</id></order></event>`,
+		}, output["body"].(map[string]interface{})["XML"])
+	})
+
+	t.Run("adds authentication headers", func(t *testing.T) {
+		output := outputOf(t, transform(map[string]interface{}{
+			"apiUrl": "https://example.com", "auth": "basicAuth", "username": "user", "password": "pass",
+		}))
+		require.Equal(t, "Basic dXNlcjpwYXNz", output["headers"].(map[string]interface{})["Authorization"])
+
+		output = outputOf(t, transform(map[string]interface{}{
+			"apiUrl": "https://example.com", "auth": "bearerTokenAuth", "bearerToken": "token",
+		}))
+		require.Equal(t, "Bearer token", output["headers"].(map[string]interface{})["Authorization"])
+
+		output = outputOf(t, transform(map[string]interface{}{
+			"apiUrl": "https://example.com", "auth": "apiKeyAuth", "apiKeyName": "X-API-Key", "apiKeyValue": "key",
+		}))
+		require.Equal(t, "key", output["headers"].(map[string]interface{})["X-API-Key"])
+	})
+
+	t.Run("fails events with an invalid api url", func(t *testing.T) {
+		statTags := map[string]string{
+			"destinationId":  "destination-id-123",
+			"workspaceId":    "workspace-id-123",
+			"destType":       "HTTP",
+			"module":         "destination",
+			"implementation": "native",
+			"errorCategory":  "dataValidation",
+			"errorType":      "configuration",
+			"feature":        "processor",
+		}
+		require.Equal(t, types.Response{
+			FailedEvents: []types.TransformerResponse{
+				{Error: "apiUrl is required", StatusCode: http.StatusBadRequest, StatTags: statTags},
+			},
+		}, transform(map[string]interface{}{}))
+		require.Equal(t, types.Response{
+			FailedEvents: []types.TransformerResponse{
+				{Error: "invalid apiUrl", StatusCode: http.StatusBadRequest, StatTags: statTags},
+			},
+		}, transform(map[string]interface{}{"apiUrl": "{{ $.properties.missing }}/events"}))
+	})
+}
@@ -0,0 +1,166 @@
+package utils
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rudderlabs/rudder-go-kit/stringify"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+)
+
+var templateRegexp = regexp.MustCompile(`{{\s*([^{}]*?)\s*}}`)
+
+// KeyValue is a key value pair configured in a destination, e.g. a header
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// GetKeyValuePairs returns the key value pairs of a list of mappings in the destination config, preserving their order.
+// Mappings with an empty key are skipped.
+func GetKeyValuePairs(destination backendconfig.DestinationT, key, keyField, valueField string) []KeyValue {
+	list, ok := destination.Config[key].([]interface{})
+	if !ok {
+		return nil
+	}
+	pairs := make([]KeyValue, 0, len(list))
+	for _, mapping := range list {
+		m, ok := mapping.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		k, _ := m[keyField].(string)
+		v, _ := m[valueField].(string)
+		if strings.TrimSpace(k) == "" {
+			continue
+		}
+		pairs = append(pairs, KeyValue{Key: k, Value: v})
+	}
+	return pairs
+}
+
+// GetValueByPath returns the value found in the message at a path like $.context.traits.email or products[0].sku.
+// The leading $ is optional.
+func GetValueByPath(message map[string]interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(path), "$"), ".")
+	if path == "" {
+		return message, true
+	}
+	var current interface{} = message
+	for _, segment := range strings.Split(strings.ReplaceAll(strings.ReplaceAll(path, "[", "."), "]", ""), ".") {
+		if segment == "" {
+			continue
+		}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			child, ok := v[segment]
+			if !ok {
+				return nil, false
+			}
+			current = child
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			current = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+// RenderTemplate replaces the {{ $.path }} placeholders of a template with the values found in the message.
+// Placeholders of missing values are replaced with an empty string.
+func RenderTemplate(template string, message map[string]interface{}) string {
+	return renderTemplate(template, message, nil)
+}
+
+// RenderURLTemplate renders a template like [RenderTemplate], escaping the values so that they can be used in any part of a url
+func RenderURLTemplate(template string, message map[string]interface{}) string {
+	return renderTemplate(template, message, func(s string) string {
+		return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+	})
+}
+
+func renderTemplate(template string, message map[string]interface{}, escape func(string) string) string {
+	if !strings.Contains(template, "{{") {
+		return template
+	}
+	return templateRegexp.ReplaceAllStringFunc(template, func(placeholder string) string {
+		path := templateRegexp.FindStringSubmatch(placeholder)[1]
+		value, _ := GetValueByPath(message, path)
+		if escape != nil {
+			return escape(stringify.Any(value))
+		}
+		return stringify.Any(value)
+	})
+}
+
+// ResolveValue resolves a configured value: a $.path is replaced with the value found in the message,
+// anything else is rendered as a template.
+func ResolveValue(value string, message map[string]interface{}) interface{} {
+	if trimmed := strings.TrimSpace(value); strings.HasPrefix(trimmed, "$.") && !strings.Contains(trimmed, "{{") {
+		v, _ := GetValueByPath(message, trimmed)
+		return v
+	}
+	return RenderTemplate(value, message)
+}
+
+// Flatten flattens nested objects and arrays into a single level map, joining keys with dots
+func Flatten(message map[string]interface{}) map[string]interface{} {
+	flattened := make(map[string]interface{})
+	flatten("", message, flattened)
+	return flattened
+}
+
+func flatten(prefix string, value interface{}, flattened map[string]interface{}) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		if len(v) == 0 && prefix != "" {
+			flattened[prefix] = v
+		}
+		for key, child := range v {
+			flatten(join(key), child, flattened)
+		}
+	case []interface{}:
+		if len(v) == 0 && prefix != "" {
+			flattened[prefix] = v
+		}
+		for i, child := range v {
+			flatten(join(strconv.Itoa(i)), child, flattened)
+		}
+	default:
+		flattened[prefix] = v
+	}
+}
+
+// NewRESTOutput returns the output of a REST request in the format expected by the router,
+// see [github.com/rudderlabs/rudder-server/processor/integrations.PostParametersT]
+func NewRESTOutput(method, endpoint, userID string) map[string]interface{} {
+	return map[string]interface{}{
+		"version":  "1",
+		"type":     "REST",
+		"method":   method,
+		"endpoint": endpoint,
+		"userId":   userID,
+		"headers":  map[string]interface{}{},
+		"params":   map[string]interface{}{},
+		"body": map[string]interface{}{
+			"JSON":       map[string]interface{}{},
+			"JSON_ARRAY": map[string]interface{}{},
+			"XML":        map[string]interface{}{},
+			"FORM":       map[string]interface{}{},
+		},
+		"files": map[string]interface{}{},
+	}
+}
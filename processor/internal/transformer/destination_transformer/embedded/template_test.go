@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplates(t *testing.T) {
+	message := map[string]interface{}{
+		"userId": "user 1",
+		"traits": map[string]interface{}{"age": 30.0, "tags": []interface{}{"a", "b"}},
+	}
+
+	t.Run("GetValueByPath", func(t *testing.T) {
+		v, ok := GetValueByPath(message, "$.traits.age")
+		require.True(t, ok)
+		require.Equal(t, 30.0, v)
+
+		v, ok = GetValueByPath(message, "traits.tags[1]")
+		require.True(t, ok)
+		require.Equal(t, "b", v)
+
+		_, ok = GetValueByPath(message, "$.traits.tags.5")
+		require.False(t, ok)
+		_, ok = GetValueByPath(message, "$.userId.nested")
+		require.False(t, ok)
+	})
+
+	t.Run("RenderTemplate", func(t *testing.T) {
+		require.Equal(t, "user 1 is 30, tags: [\"a\",\"b\"], missing: ", RenderTemplate("{{$.userId}} is {{ $.traits.age }}, tags: {{ $.traits.tags }}, missing: {{ $.missing }}", message))
+		require.Equal(t, "no placeholders", RenderTemplate("no placeholders", message))
+		require.Equal(t, "https://example.com/user%201?age=30", RenderURLTemplate("https://example.com/{{ $.userId }}?age={{ $.traits.age }}", message))
+	})
+
+	t.Run("ResolveValue", func(t *testing.T) {
+		require.Equal(t, 30.0, ResolveValue("$.traits.age", message))
+		require.Nil(t, ResolveValue("$.missing", message))
+		require.Equal(t, "constant", ResolveValue("constant", message))
+		require.Equal(t, "id-user 1", ResolveValue("id-{{ $.userId }}", message))
+	})
+
+	t.Run("Flatten", func(t *testing.T) {
+		require.Equal(t, map[string]interface{}{
+			"userId":        "user 1",
+			"traits.age":    30.0,
+			"traits.tags.0": "a",
+			"traits.tags.1": "b",
+		}, Flatten(message))
+	})
+}
@@ -0,0 +1,152 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rudderlabs/rudder-go-kit/stringify"
+	utils "github.com/rudderlabs/rudder-server/processor/internal/transformer/destination_transformer/embedded"
+	types "github.com/rudderlabs/rudder-server/processor/types"
+)
+
+const (
+	// headerKey is the key of the headers an event can set dynamically, e.g. through a user transformation
+	headerKey = "header"
+	// appendPathKey is the key of the path an event can append dynamically to the webhook url
+	appendPathKey = "appendPath"
+)
+
+// Capital "I" needed for mirroring/comparison; re-enable lint after mirroring ends.
+var errInvalidURL = errors.New("Invalid URL in destination config") //nolint:staticcheck
+
+var supportedMethods = map[string]struct{}{
+	http.MethodGet:    {},
+	http.MethodPost:   {},
+	http.MethodPut:    {},
+	http.MethodPatch:  {},
+	http.MethodDelete: {},
+}
+
+func Transform(_ context.Context, events []types.TransformerEvent) types.Response {
+	response := types.Response{}
+	destination := events[0].Destination
+	webhookURL, _ := destination.Config["webhookUrl"].(string)
+	method := getMethod(destination.Config["webhookMethod"])
+	headers := utils.GetKeyValuePairs(destination, "headers", "from", "to")
+
+	for _, event := range events {
+		event.Metadata.SourceDefinitionType = "" // TODO: Currently, it's getting ignored during JSON marshalling Remove this once we start using it.
+
+		if event.Destination.ID != destination.ID {
+			panic("all events must have the same destination")
+		}
+
+		endpoint, err := getEndpoint(webhookURL, event.Message)
+		if err != nil {
+			response.FailedEvents = append(response.FailedEvents, types.TransformerResponse{
+				Error:      err.Error(),
+				Metadata:   event.Metadata,
+				StatusCode: http.StatusBadRequest,
+				StatTags:   utils.GetValidationErrorStatTags(event.Destination),
+			})
+			continue
+		}
+
+		anonymousID, _ := event.Message["anonymousId"].(string)
+		output := utils.NewRESTOutput(method, endpoint, anonymousID)
+		output["headers"] = getHeaders(headers, event.Message)
+		if method == http.MethodGet {
+			output["params"] = utils.Flatten(getPropertyParams(event.Message))
+		} else {
+			output["body"].(map[string]interface{})["JSON"] = getPayload(event.Message)
+		}
+
+		response.Events = append(response.Events, types.TransformerResponse{
+			Output:     output,
+			StatusCode: http.StatusOK,
+			Metadata:   event.Metadata,
+		})
+	}
+
+	return response
+}
+
+// getMethod returns the configured method, defaulting to POST
+func getMethod(configured interface{}) string {
+	method, _ := configured.(string)
+	method = strings.ToUpper(strings.TrimSpace(method))
+	if _, ok := supportedMethods[method]; !ok {
+		return http.MethodPost
+	}
+	return method
+}
+
+// getEndpoint renders the webhook url template for the event and appends the event's dynamic path
+func getEndpoint(webhookURL string, message types.SingularEventT) (string, error) {
+	if strings.TrimSpace(webhookURL) == "" {
+		return "", errInvalidURL
+	}
+	endpoint := utils.RenderURLTemplate(webhookURL, message)
+	if appendPath, ok := message[appendPathKey].(string); ok {
+		endpoint += appendPath
+	}
+	if u, err := url.Parse(endpoint); err != nil || u.Scheme == "" || u.Host == "" {
+		return "", errInvalidURL
+	}
+	return endpoint, nil
+}
+
+// getHeaders returns the configured headers, with their values rendered for the event, followed by the event's dynamic headers
+func getHeaders(headers []utils.KeyValue, message types.SingularEventT) map[string]interface{} {
+	result := map[string]interface{}{"content-type": "application/json"}
+	for _, header := range headers {
+		if header.Value == "" {
+			continue
+		}
+		result[header.Key] = utils.RenderTemplate(header.Value, message)
+	}
+	if dynamic, ok := message[headerKey].(map[string]interface{}); ok {
+		for key, value := range dynamic {
+			if value == nil || value == "" {
+				continue
+			}
+			result[key] = stringify.Any(value)
+		}
+	}
+	return result
+}
+
+// getPropertyParams returns the properties sent as query parameters of GET requests: traits for identify events, properties otherwise
+func getPropertyParams(message types.SingularEventT) map[string]interface{} {
+	if message["type"] == "identify" {
+		if traits, ok := message["traits"].(map[string]interface{}); ok {
+			return traits
+		}
+		traits, _ := utils.GetValueByPath(message, "context.traits")
+		t, _ := traits.(map[string]interface{})
+		return t
+	}
+	properties, _ := message["properties"].(map[string]interface{})
+	return properties
+}
+
+// getPayload returns the message without the keys used for dynamically configuring the request.
+// The message itself is left untouched, since it is shared with the other destinations of the event.
+func getPayload(message types.SingularEventT) map[string]interface{} {
+	_, hasHeader := message[headerKey]
+	_, hasAppendPath := message[appendPathKey]
+	if !hasHeader && !hasAppendPath {
+		return utils.GetMessageAsMap(message)
+	}
+	payload := make(map[string]interface{}, len(message))
+	for k, v := range message {
+		if k == headerKey || k == appendPathKey {
+			continue
+		}
+		payload[k] = v
+	}
+	return payload
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+func TestTransform(t *testing.T) {
+	newDestination := func(config map[string]interface{}) backendconfig.DestinationT {
+		return backendconfig.DestinationT{
+			ID:          "destination-id-123",
+			WorkspaceID: "workspace-id-123",
+			DestinationDefinition: backendconfig.DestinationDefinitionT{
+				Name: "WEBHOOK",
+			},
+			Config: config,
+		}
+	}
+	emptyBody := func(json map[string]interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"JSON":       json,
+			"JSON_ARRAY": map[string]interface{}{},
+			"XML":        map[string]interface{}{},
+			"FORM":       map[string]interface{}{},
+		}
+	}
+
+	cases := []struct {
+		name     string
+		config   map[string]interface{}
+		message  types.SingularEventT
+		expected types.Response
+	}{
+		{
+			name:   "posts the event to the webhook url by default",
+			config: map[string]interface{}{"webhookUrl": "https://example.com/hook"},
+			message: types.SingularEventT{
+				"type":        "track",
+				"event":       "Order Completed",
+				"anonymousId": "anon-1",
+				"properties":  map[string]interface{}{"revenue": 10.5},
+			},
+			expected: types.Response{
+				Events: []types.TransformerResponse{
+					{
+						Output: map[string]interface{}{
+							"version":  "1",
+							"type":     "REST",
+							"method":   http.MethodPost,
+							"endpoint": "https://example.com/hook",
+							"userId":   "anon-1",
+							"headers":  map[string]interface{}{"content-type": "application/json"},
+							"params":   map[string]interface{}{},
+							"body": emptyBody(map[string]interface{}{
+								"type":        "track",
+								"event":       "Order Completed",
+								"anonymousId": "anon-1",
+								"properties":  map[string]interface{}{"revenue": 10.5},
+							}),
+							"files": map[string]interface{}{},
+						},
+						StatusCode: http.StatusOK,
+					},
+				},
+			},
+		},
+		{
+			name: "sends the flattened traits of identify events as query params of GET requests",
+			config: map[string]interface{}{
+				"webhookUrl":    "https://example.com/users/{{ $.userId }}?source={{$.context.library.name}}",
+				"webhookMethod": "GET",
+			},
+			message: types.SingularEventT{
+				"type":        "identify",
+				"userId":      "user 1",
+				"anonymousId": "anon-1",
+				"traits":      map[string]interface{}{"name": "John", "address": map[string]interface{}{"city": "Berlin"}},
+				"context":     map[string]interface{}{"library": map[string]interface{}{"name": "js&sdk"}},
+			},
+			expected: types.Response{
+				Events: []types.TransformerResponse{
+					{
+						Output: map[string]interface{}{
+							"version":  "1",
+							"type":     "REST",
+							"method":   http.MethodGet,
+							"endpoint": "https://example.com/users/user%201?source=js%26sdk",
+							"userId":   "anon-1",
+							"headers":  map[string]interface{}{"content-type": "application/json"},
+							"params":   map[string]interface{}{"name": "John", "address.city": "Berlin"},
+							"body":     emptyBody(map[string]interface{}{}),
+							"files":    map[string]interface{}{},
+						},
+						StatusCode: http.StatusOK,
+					},
+				},
+			},
+		},
+		{
+			name: "applies configured, templated and dynamic headers and the dynamic path",
+			config: map[string]interface{}{
+				"webhookUrl":    "https://example.com/hook",
+				"webhookMethod": "put",
+				"headers": []interface{}{
+					map[string]interface{}{"from": "x-api-key", "to": "secret"},
+					map[string]interface{}{"from": "x-user", "to": "user-{{ $.userId }}"},
+					map[string]interface{}{"from": "x-empty", "to": ""},
+				},
+			},
+			message: types.SingularEventT{
+				"type":       "track",
+				"userId":     "user-1",
+				"header":     map[string]interface{}{"x-dynamic": "dynamic", "x-number": 1.0},
+				"appendPath": "/orders",
+			},
+			expected: types.Response{
+				Events: []types.TransformerResponse{
+					{
+						Output: map[string]interface{}{
+							"version":  "1",
+							"type":     "REST",
+							"method":   http.MethodPut,
+							"endpoint": "https://example.com/hook/orders",
+							"userId":   "",
+							"headers": map[string]interface{}{
+								"content-type": "application/json",
+								"x-api-key":    "secret",
+								"x-user":       "user-user-1",
+								"x-dynamic":    "dynamic",
+								"x-number":     "1",
+							},
+							"params": map[string]interface{}{},
+							"body":   emptyBody(map[string]interface{}{"type": "track", "userId": "user-1"}),
+							"files":  map[string]interface{}{},
+						},
+						StatusCode: http.StatusOK,
+					},
+				},
+			},
+		},
+		{
+			name:    "fails events when the webhook url is missing",
+			config:  map[string]interface{}{},
+			message: types.SingularEventT{"type": "track"},
+			expected: types.Response{
+				FailedEvents: []types.TransformerResponse{
+					{
+						Error:      "Invalid URL in destination config",
+						StatusCode: http.StatusBadRequest,
+						StatTags: map[string]string{
+							"destinationId":  "destination-id-123",
+							"workspaceId":    "workspace-id-123",
+							"destType":       "WEBHOOK",
+							"module":         "destination",
+							"implementation": "native",
+							"errorCategory":  "dataValidation",
+							"errorType":      "configuration",
+							"feature":        "processor",
+						},
+					},
+				},
+			},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			events := []types.TransformerEvent{{
+				Message:     tc.message,
+				Destination: newDestination(tc.config),
+			}}
+			response := Transform(context.Background(), events)
+			require.Equal(t, tc.expected, response)
+		})
+	}
+
+	t.Run("leaves the message untouched", func(t *testing.T) {
+		message := types.SingularEventT{"type": "track", "header": map[string]interface{}{"x": "y"}}
+		events := []types.TransformerEvent{{
+			Message:     message,
+			Destination: newDestination(map[string]interface{}{"webhookUrl": "https://example.com"}),
+		}}
+		_ = Transform(context.Background(), events)
+		require.Equal(t, types.SingularEventT{"type": "track", "header": map[string]interface{}{"x": "y"}}, message)
+	})
+
+	t.Run("panics on events of different destinations", func(t *testing.T) {
+		other := newDestination(nil)
+		other.ID = "other"
+		require.Panics(t, func() {
+			Transform(context.Background(), []types.TransformerEvent{
+				{Message: types.SingularEventT{}, Destination: newDestination(nil)},
+				{Message: types.SingularEventT{}, Destination: other},
+			})
+		})
+	})
+}
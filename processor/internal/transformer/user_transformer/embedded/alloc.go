@@ -0,0 +1,495 @@
+package embedded
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/syntax"
+)
+
+// Approximate sizes of the values which aren't sized by their contents
+const (
+	refSize   = 16 // a reference to a value, e.g. an element of a list
+	entrySize = 64 // an entry of a dict
+)
+
+const allocatorKey = "allocator"
+
+var errMemoryLimitExceeded = errors.New("transformation exceeded its memory limit")
+
+// allocator bounds the memory allocated by a transformation while running on a thread.
+// The size of every value created by an operation which can allocate memory is estimated and charged before the operation runs,
+// failing it once the budget of the thread is exhausted.
+type allocator struct {
+	remaining int
+}
+
+// allocationBuiltins are the builtins that the code of transformations is rewritten to call, see [rewriteFile]
+var allocationBuiltins = starlark.StringDict{
+	binaryBuiltin:  starlark.NewBuiltin(binaryBuiltin, binaryOp),
+	inplaceBuiltin: starlark.NewBuiltin(inplaceBuiltin, inplaceOp),
+	callBuiltin:    starlark.NewBuiltin(callBuiltin, callFn),
+}
+
+func newAllocator(thread *starlark.Thread, budget int) {
+	thread.SetLocal(allocatorKey, &allocator{remaining: budget})
+}
+
+func allocatorOf(thread *starlark.Thread) (*allocator, error) {
+	a, ok := thread.Local(allocatorKey).(*allocator)
+	if !ok {
+		return nil, errors.New("thread doesn't have an allocator")
+	}
+	return a, nil
+}
+
+// charge takes size bytes from the budget, failing if it isn't enough
+func (a *allocator) charge(size int) error {
+	if size > a.remaining {
+		a.remaining = 0
+		return errMemoryLimitExceeded
+	}
+	a.remaining -= size
+	return nil
+}
+
+// binaryOp implements _rudder_binary(op, x, y), charging for the result of x op y before computing it
+func binaryOp(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	op, x, y, err := unpackOperation(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	a, err := allocatorOf(thread)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.charge(a.binarySize(op, x, y)); err != nil {
+		return nil, err
+	}
+	return starlark.Binary(op, x, y)
+}
+
+// inplaceOp implements _rudder_inplace(op, x, y), the augmented assignment x op= y, which updates lists and dicts in place
+func inplaceOp(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	op, x, y, err := unpackOperation(b, args, kwargs)
+	if err != nil {
+		return nil, err
+	}
+	a, err := allocatorOf(thread)
+	if err != nil {
+		return nil, err
+	}
+	switch x := x.(type) {
+	case *starlark.List:
+		if op == syntax.PLUS {
+			if y, ok := y.(starlark.Iterable); ok {
+				elems := elements(y) // copied first, for extending a list with itself
+				if err := a.charge(mulSize(len(elems), refSize)); err != nil {
+					return nil, err
+				}
+				for _, elem := range elems {
+					if err := x.Append(elem); err != nil {
+						return nil, err
+					}
+				}
+				return x, nil
+			}
+		}
+	case *starlark.Dict:
+		if op == syntax.PIPE {
+			if y, ok := y.(*starlark.Dict); ok {
+				if err := a.charge(mulSize(y.Len(), entrySize)); err != nil {
+					return nil, err
+				}
+				for _, item := range y.Items() {
+					if err := x.SetKey(item[0], item[1]); err != nil {
+						return nil, err
+					}
+				}
+				return x, nil
+			}
+		}
+	}
+	if err := a.charge(a.binarySize(op, x, y)); err != nil {
+		return nil, err
+	}
+	return starlark.Binary(op, x, y)
+}
+
+// callFn implements _rudder_call(fn, *args, **kwargs), charging for the result of builtin functions before calling them
+func callFn(thread *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) == 0 {
+		return nil, errors.New(b.Name() + ": missing function")
+	}
+	a, err := allocatorOf(thread)
+	if err != nil {
+		return nil, err
+	}
+	fn, args := args[0], args[1:]
+	if builtin, ok := fn.(*starlark.Builtin); ok {
+		if err := a.charge(a.callSize(builtin, args, kwargs)); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.Call(thread, fn, args, kwargs)
+}
+
+func unpackOperation(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (op syntax.Token, x, y starlark.Value, err error) {
+	var opName string
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 3, &opName, &x, &y); err != nil {
+		return 0, nil, nil, err
+	}
+	for op := range accountedOps {
+		if op.String() == opName {
+			return op, x, y, nil
+		}
+	}
+	return 0, nil, nil, errors.New(b.Name() + ": unknown operator " + opName)
+}
+
+// binarySize returns the approximate size of the result of x op y
+func (a *allocator) binarySize(op syntax.Token, x, y starlark.Value) int {
+	switch op {
+	case syntax.PLUS:
+		if isSized(x) {
+			return addSize(sizeOf(x), sizeOf(y))
+		}
+		if isInt(x) && isInt(y) {
+			return addSize(max(sizeOf(x), sizeOf(y)), 8)
+		}
+	case syntax.STAR:
+		if n, ok := repetitions(y); ok && isSized(x) {
+			return mulSize(sizeOf(x), n)
+		}
+		if n, ok := repetitions(x); ok && isSized(y) {
+			return mulSize(sizeOf(y), n)
+		}
+		if isInt(x) && isInt(y) {
+			return addSize(sizeOf(x), sizeOf(y))
+		}
+	case syntax.PERCENT:
+		if format, ok := x.(starlark.String); ok {
+			return addSize(len(format), mulSize(strings.Count(string(format), "%"), a.reprSize(y)))
+		}
+	case syntax.PIPE:
+		if _, ok := x.(*starlark.Dict); ok {
+			return addSize(sizeOf(x), sizeOf(y))
+		}
+	case syntax.LTLT:
+		if n, ok := repetitions(y); ok && isInt(x) && n <= 512 { // larger shifts are rejected by the interpreter
+			return addSize(sizeOf(x), n/8+1)
+		}
+	}
+	return 0
+}
+
+// callSize returns the approximate size of the result of calling a builtin function or method
+func (a *allocator) callSize(b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) int {
+	arg := func(i int) starlark.Value {
+		if i < len(args) {
+			return args[i]
+		}
+		return starlark.None
+	}
+	kwarg := func(name string) starlark.Value {
+		for _, kv := range kwargs {
+			if k, _ := starlark.AsString(kv[0]); k == name {
+				return kv[1]
+			}
+		}
+		return starlark.None
+	}
+	switch recv := b.Receiver().(type) {
+	case nil:
+		switch b.Name() {
+		case "list", "tuple", "sorted", "reversed":
+			return mulSize(count(arg(0)), refSize)
+		case "dict":
+			return mulSize(addSize(count(arg(0)), len(kwargs)), entrySize)
+		case "enumerate":
+			return mulSize(count(arg(0)), 3*refSize)
+		case "zip":
+			size := 0
+			for _, arg := range args {
+				size = addSize(size, mulSize(count(arg), 2*refSize))
+			}
+			return size
+		case "str", "repr", "print":
+			if _, ok := arg(0).(starlark.String); ok && b.Name() == "str" {
+				return 0
+			}
+			size := 0
+			for _, arg := range args {
+				size = addSize(size, a.reprSize(arg))
+			}
+			return size
+		case "bytes":
+			if s, ok := arg(0).(starlark.String); ok {
+				return len(s)
+			}
+			return count(arg(0))
+		case "json.encode":
+			return a.reprSize(arg(0))
+		case "json.decode":
+			if s, ok := arg(0).(starlark.String); ok {
+				return mulSize(len(s), 4)
+			}
+		case "json.indent":
+			s, _ := starlark.AsString(arg(0))
+			prefix, _ := starlark.AsString(kwarg("prefix"))
+			indent, ok := starlark.AsString(kwarg("indent"))
+			if !ok {
+				indent = "\t"
+			}
+			return indentSize(s, prefix, indent)
+		}
+	case starlark.String:
+		s := string(recv)
+		switch b.Name() {
+		case "join":
+			return joinSize(s, arg(0))
+		case "replace":
+			old, _ := starlark.AsString(arg(0))
+			replacement, _ := starlark.AsString(arg(1))
+			n := strings.Count(s, old)
+			if limit, ok := repetitions(arg(2)); ok && limit < n {
+				n = limit
+			}
+			return addSize(len(s), mulSize(n, max(len(replacement)-len(old), 0)))
+		case "format":
+			var largest int
+			for _, arg := range args {
+				largest = max(largest, a.reprSize(arg))
+			}
+			for _, kv := range kwargs {
+				largest = max(largest, a.reprSize(kv[1]))
+			}
+			return addSize(len(s), mulSize(strings.Count(s, "{"), largest))
+		case "split", "rsplit":
+			parts := len(s)/2 + 1
+			if sep, ok := starlark.AsString(arg(0)); ok && sep != "" {
+				parts = strings.Count(s, sep) + 1
+			}
+			return mulSize(parts, 2*refSize)
+		case "splitlines":
+			return mulSize(strings.Count(s, "\n")+1, 2*refSize)
+		case "partition", "rpartition":
+			return 6 * refSize
+		case "capitalize", "lower", "title", "upper": // case mappings can change the encoded length of runes
+			return mulSize(len(s), 2)
+		}
+	case *starlark.List:
+		switch b.Name() {
+		case "append", "insert":
+			return refSize
+		case "extend":
+			return mulSize(count(arg(0)), refSize)
+		}
+	case *starlark.Dict:
+		switch b.Name() {
+		case "setdefault":
+			return entrySize
+		case "update":
+			return mulSize(addSize(count(arg(0)), len(kwargs)), entrySize)
+		case "items":
+			return mulSize(recv.Len(), 4*refSize)
+		case "keys", "values":
+			return mulSize(recv.Len(), refSize)
+		}
+	}
+	return 0
+}
+
+// reprSize returns the approximate size of the string representation of a value, stopping once it exceeds the remaining budget
+func (a *allocator) reprSize(v starlark.Value) int {
+	size := 0
+	var walk func(v starlark.Value) bool
+	walk = func(v starlark.Value) bool {
+		switch v := v.(type) {
+		case starlark.String:
+			size = addSize(size, len(v)+2)
+		case starlark.Bytes:
+			size = addSize(size, len(v)+3)
+		case starlark.Int:
+			size = addSize(size, mulSize(sizeOf(v), 3)) // at most 3 digits per byte
+		case *starlark.List, starlark.Tuple:
+			seq := v.(starlark.Indexable)
+			size = addSize(size, 2)
+			for i := 0; i < seq.Len() && size <= a.remaining; i++ {
+				size = addSize(size, 2)
+				walk(seq.Index(i))
+			}
+		case *starlark.Dict:
+			size = addSize(size, 2)
+			for _, item := range v.Items() {
+				if size > a.remaining {
+					break
+				}
+				size = addSize(size, 4)
+				walk(item[0])
+				walk(item[1])
+			}
+		default:
+			size = addSize(size, 32)
+		}
+		return size <= a.remaining
+	}
+	walk(v)
+	return size
+}
+
+// sizeOf returns the approximate size of a value, not including the values it references
+func sizeOf(v starlark.Value) int {
+	switch v := v.(type) {
+	case starlark.String:
+		return len(v)
+	case starlark.Bytes:
+		return len(v)
+	case starlark.Int:
+		if _, ok := v.Int64(); ok {
+			return 8
+		}
+		return v.BigInt().BitLen()/8 + 8
+	case *starlark.List:
+		return mulSize(v.Len(), refSize)
+	case starlark.Tuple:
+		return mulSize(v.Len(), refSize)
+	case *starlark.Dict:
+		return mulSize(v.Len(), entrySize)
+	}
+	return 0
+}
+
+// isSized returns whether a value is a string, bytes, a list or a tuple, whose size grows when they are concatenated or repeated
+func isSized(v starlark.Value) bool {
+	switch v.(type) {
+	case starlark.String, starlark.Bytes, *starlark.List, starlark.Tuple:
+		return true
+	}
+	return false
+}
+
+func isInt(v starlark.Value) bool {
+	_, ok := v.(starlark.Int)
+	return ok
+}
+
+// repetitions returns the value of a non-negative int, zero for negative ones, or false if the value isn't an int
+func repetitions(v starlark.Value) (int, bool) {
+	i, ok := v.(starlark.Int)
+	if !ok {
+		return 0, false
+	}
+	n, ok := i.Int64()
+	if !ok || n > math.MaxInt32 {
+		return math.MaxInt32, true
+	}
+	return max(int(n), 0), true
+}
+
+// count returns the number of elements of an iterable, iterating over it if its length isn't known
+func count(v starlark.Value) int {
+	if n := starlark.Len(v); n >= 0 {
+		return n
+	}
+	iterable, ok := v.(starlark.Iterable)
+	if !ok {
+		return 0
+	}
+	iter := iterable.Iterate()
+	defer iter.Done()
+	n := 0
+	var elem starlark.Value
+	for iter.Next(&elem) {
+		n++
+	}
+	return n
+}
+
+// elements returns the elements of an iterable
+func elements(v starlark.Iterable) []starlark.Value {
+	iter := v.Iterate()
+	defer iter.Done()
+	var elems []starlark.Value
+	var elem starlark.Value
+	for iter.Next(&elem) {
+		elems = append(elems, elem)
+	}
+	return elems
+}
+
+// joinSize returns the size of the string joining the strings of an iterable with a separator
+func joinSize(sep string, v starlark.Value) int {
+	iterable, ok := v.(starlark.Iterable)
+	if !ok {
+		return 0
+	}
+	iter := iterable.Iterate()
+	defer iter.Done()
+	size, n := 0, 0
+	var elem starlark.Value
+	for iter.Next(&elem) {
+		if s, ok := elem.(starlark.String); ok {
+			size = addSize(size, len(s))
+		}
+		n++
+	}
+	return addSize(size, mulSize(max(n-1, 0), len(sep)))
+}
+
+// indentSize returns the approximate size of an indented json document, each value starting a new line
+func indentSize(s, prefix, indent string) int {
+	newline := func(depth int) int {
+		return addSize(1+len(prefix), mulSize(max(depth, 0), len(indent)))
+	}
+	size, depth := len(s), 0
+	var inString, escaped bool
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '[', '{':
+			depth++
+			size = addSize(size, newline(depth))
+		case ']', '}':
+			depth--
+			size = addSize(size, newline(depth))
+		case ',':
+			size = addSize(size, newline(depth))
+		}
+	}
+	return size
+}
+
+// addSize adds two sizes, saturating instead of overflowing
+func addSize(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// mulSize multiplies two non-negative sizes, saturating instead of overflowing
+func mulSize(a, b int) int {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	if a > math.MaxInt/b {
+		return math.MaxInt
+	}
+	return a * b
+}
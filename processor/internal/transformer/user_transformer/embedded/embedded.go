@@ -0,0 +1,401 @@
+// Package embedded runs user transformations in process, using a sandboxed Starlark interpreter,
+// as an alternative to sending them to the transformer service.
+//
+// A transformation is embedded if its config flags it as such and provides its code, e.g.
+//
+//	{"embedded": true, "language": "starlark", "code": "def transformEvent(event, metadata):\n    ..."}
+//
+// The code must define a transformEvent(event, metadata) function, returning either the transformed event,
+// a list of events, or None for filtering the event out, same as JavaScript transformations.
+// The json and math modules are available to transformations, which can't access the filesystem or the network.
+//
+// Transformations are limited by the number of execution steps, the time they can take and the memory they can allocate for each event,
+// along with the size of the events they return. For accounting the memory they allocate, their code is rewritten so that
+// operations which can create large values, e.g. string concatenation or calls to builtins, are charged before running, see [rewriteFile].
+package embedded
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	starlarkmath "go.starlark.net/lib/math"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkjson"
+	"go.starlark.net/syntax"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/types"
+	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
+)
+
+// Keys of the transformation config
+const (
+	ConfigKeyEmbedded = "embedded"
+	ConfigKeyLanguage = "language"
+	ConfigKeyCode     = "code"
+)
+
+// LanguageStarlark is the only language supported by embedded transformations
+const LanguageStarlark = "starlark"
+
+const transformFunction = "transformEvent"
+
+// IsEmbedded returns whether the transformation should run embedded
+func IsEmbedded(transformation backendconfig.TransformationT) bool {
+	embedded, _ := transformation.Config[ConfigKeyEmbedded].(bool)
+	language, _ := transformation.Config[ConfigKeyLanguage].(string)
+	return embedded && strings.EqualFold(language, LanguageStarlark)
+}
+
+// Transformer runs embedded transformations
+type Transformer struct {
+	config struct {
+		maxExecutionSteps config.ValueLoader[int]
+		timeout           config.ValueLoader[time.Duration]
+		maxOutputBytes    config.ValueLoader[int]
+		maxAllocatedBytes config.ValueLoader[int]
+	}
+	log      logger.Logger
+	stat     stats.Stats
+	programs *lru.Cache[string, *program] // compiled transformations, keyed by their version id
+}
+
+// program is a compiled transformation, err being set if the transformation cannot be compiled
+type program struct {
+	transformEvent starlark.Callable
+	err            error
+}
+
+// New creates a transformer running embedded transformations
+func New(conf *config.Config, log logger.Logger, stat stats.Stats) *Transformer {
+	t := &Transformer{
+		log:  log.Child("embedded"),
+		stat: stat,
+	}
+	t.config.maxExecutionSteps = conf.GetReloadableIntVar(1_000_000, 1, "Processor.UserTransformer.Embedded.maxExecutionSteps")
+	t.config.timeout = conf.GetReloadableDurationVar(1, time.Second, "Processor.UserTransformer.Embedded.timeout")
+	t.config.maxOutputBytes = conf.GetReloadableIntVar(4, 1<<20, "Processor.UserTransformer.Embedded.maxOutputBytes")
+	t.config.maxAllocatedBytes = conf.GetReloadableIntVar(64, 1<<20, "Processor.UserTransformer.Embedded.maxAllocatedBytes")
+	programs, err := lru.New[string, *program](conf.GetInt("Processor.UserTransformer.Embedded.cacheSize", 1000))
+	if err != nil {
+		panic(fmt.Errorf("creating embedded transformations cache: %w", err))
+	}
+	t.programs = programs
+	return t
+}
+
+// Transform runs the embedded transformation of the events' destination, returning the same response as the transformer service would
+func (t *Transformer) Transform(ctx context.Context, clientEvents []types.TransformerEvent) types.Response {
+	if len(clientEvents) == 0 {
+		return types.Response{}
+	}
+	start := time.Now()
+	transformation := clientEvents[0].Destination.Transformations[0]
+	prog := t.program(transformation)
+
+	var response types.Response
+	for i := range clientEvents {
+		metadata := clientEvents[i].Metadata
+		if prog.err != nil {
+			response.FailedEvents = append(response.FailedEvents, types.TransformerResponse{
+				StatusCode: http.StatusBadRequest,
+				Error:      prog.err.Error(),
+				Metadata:   metadata,
+			})
+			continue
+		}
+		outputs, err := t.transformEvent(ctx, transformation, prog, &clientEvents[i])
+		switch {
+		case err != nil:
+			response.FailedEvents = append(response.FailedEvents, types.TransformerResponse{
+				StatusCode: http.StatusBadRequest,
+				Error:      err.Error(),
+				Metadata:   metadata,
+			})
+		case len(outputs) == 0:
+			response.FailedEvents = append(response.FailedEvents, types.TransformerResponse{
+				StatusCode: reportingtypes.FilterEventCode,
+				Metadata:   metadata,
+			})
+		default:
+			for _, output := range outputs {
+				response.Events = append(response.Events, types.TransformerResponse{
+					Output:     output,
+					StatusCode: http.StatusOK,
+					Metadata:   metadata,
+				})
+			}
+		}
+	}
+
+	tags := stats.Tags{
+		"workspaceId":      clientEvents[0].Metadata.WorkspaceID,
+		"sourceId":         clientEvents[0].Metadata.SourceID,
+		"destinationId":    clientEvents[0].Destination.ID,
+		"transformationId": transformation.ID,
+	}
+	t.stat.NewTaggedStat("embedded_user_transform_time", stats.TimerType, tags).Since(start)
+	t.stat.NewTaggedStat("embedded_user_transform_events", stats.CountType, tags).Count(len(clientEvents))
+	return response
+}
+
+// program returns the compiled transformation, compiling it if it isn't cached
+func (t *Transformer) program(transformation backendconfig.TransformationT) *program {
+	if prog, ok := t.programs.Get(transformation.VersionID); ok {
+		return prog
+	}
+	prog := t.compile(transformation)
+	if prog.err != nil {
+		t.log.Warnn("compiling embedded transformation",
+			logger.NewStringField("transformationId", transformation.ID),
+			logger.NewStringField("transformationVersionId", transformation.VersionID),
+			logger.NewErrorField(prog.err),
+		)
+	}
+	t.programs.Add(transformation.VersionID, prog)
+	return prog
+}
+
+func (t *Transformer) compile(transformation backendconfig.TransformationT) *program {
+	code, _ := transformation.Config[ConfigKeyCode].(string)
+	if code == "" {
+		return &program{err: errors.New("embedded transformation doesn't have any code")}
+	}
+	f, err := (&syntax.FileOptions{}).Parse(transformation.VersionID+".star", code, 0)
+	if err != nil {
+		return &program{err: fmt.Errorf("compiling embedded transformation: %w", err)}
+	}
+	if err := rewriteFile(f); err != nil {
+		return &program{err: fmt.Errorf("compiling embedded transformation: %w", err)}
+	}
+	predeclared := starlark.StringDict{
+		"json": starlarkjson.Module,
+		"math": starlarkmath.Module,
+	}
+	for name, builtin := range allocationBuiltins {
+		predeclared[name] = builtin
+	}
+	prog, err := starlark.FileProgram(f, predeclared.Has)
+	if err != nil {
+		return &program{err: fmt.Errorf("compiling embedded transformation: %w", err)}
+	}
+	globals, err := prog.Init(t.newThread(transformation.ID), predeclared)
+	globals.Freeze()
+	if err != nil {
+		return &program{err: fmt.Errorf("compiling embedded transformation: %w", err)}
+	}
+	fn, ok := globals[transformFunction].(starlark.Callable)
+	if !ok {
+		return &program{err: fmt.Errorf("embedded transformation doesn't define a %s function", transformFunction)}
+	}
+	return &program{transformEvent: fn}
+}
+
+func (t *Transformer) newThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: func(*starlark.Thread, string) {}, // transformations cannot log
+	}
+	thread.SetMaxExecutionSteps(uint64(t.config.maxExecutionSteps.Load()))
+	newAllocator(thread, t.config.maxAllocatedBytes.Load())
+	return thread
+}
+
+// transformEvent calls the transformation's transformEvent function for an event, returning the events it produced
+func (t *Transformer) transformEvent(ctx context.Context, transformation backendconfig.TransformationT, prog *program, clientEvent *types.TransformerEvent) ([]map[string]any, error) {
+	metadata := clientEvent.Metadata
+	// same as for the transformer service, transformations see the original source id of replayed events
+	if metadata.OriginalSourceID != "" {
+		metadata.OriginalSourceID, metadata.SourceID = metadata.SourceID, metadata.OriginalSourceID
+	}
+	metadataValue, err := metadataToStarlark(metadata)
+	if err != nil {
+		return nil, err
+	}
+	event, err := toStarlark(map[string]any(clientEvent.Message))
+	if err != nil {
+		return nil, fmt.Errorf("converting event: %w", err)
+	}
+
+	thread := t.newThread(transformation.ID)
+	timer := time.AfterFunc(t.config.timeout.Load(), func() { thread.Cancel("timeout") })
+	defer timer.Stop()
+	stop := context.AfterFunc(ctx, func() { thread.Cancel("context cancelled") })
+	defer stop()
+
+	result, err := starlark.Call(thread, prog.transformEvent, starlark.Tuple{event, metadataValue}, nil)
+	if err != nil {
+		var evalErr *starlark.EvalError
+		if errors.As(err, &evalErr) {
+			return nil, fmt.Errorf("%s", evalErr.Backtrace())
+		}
+		return nil, err
+	}
+
+	budget := t.config.maxOutputBytes.Load()
+	var outputs []map[string]any
+	appendOutput := func(v starlark.Value) error {
+		if v == starlark.None {
+			return nil
+		}
+		if _, ok := v.(*starlark.Dict); !ok {
+			return fmt.Errorf("%s returned a %s instead of an event", transformFunction, v.Type())
+		}
+		output, err := fromStarlark(v, &budget)
+		if err != nil {
+			return err
+		}
+		outputs = append(outputs, output.(map[string]any))
+		return nil
+	}
+	switch result := result.(type) {
+	case *starlark.List:
+		for i := 0; i < result.Len(); i++ {
+			if err := appendOutput(result.Index(i)); err != nil {
+				return nil, err
+			}
+		}
+	case starlark.Tuple:
+		for _, v := range result {
+			if err := appendOutput(v); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		if err := appendOutput(result); err != nil {
+			return nil, err
+		}
+	}
+	return outputs, nil
+}
+
+func metadataToStarlark(metadata types.Metadata) (starlark.Value, error) {
+	data, err := jsonrs.Marshal(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("marshalling metadata: %w", err)
+	}
+	var m map[string]any
+	if err := jsonrs.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("unmarshalling metadata: %w", err)
+	}
+	v, err := toStarlark(m)
+	if err != nil {
+		return nil, fmt.Errorf("converting metadata: %w", err)
+	}
+	v.Freeze()
+	return v, nil
+}
+
+// toStarlark converts a json value to its Starlark counterpart, integral numbers becoming ints
+func toStarlark(v any) (starlark.Value, error) {
+	switch v := v.(type) {
+	case nil:
+		return starlark.None, nil
+	case bool:
+		return starlark.Bool(v), nil
+	case string:
+		return starlark.String(v), nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return starlark.MakeInt64(int64(v)), nil
+		}
+		return starlark.Float(v), nil
+	case int:
+		return starlark.MakeInt(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case map[string]any:
+		d := starlark.NewDict(len(v))
+		for k, child := range v {
+			sv, err := toStarlark(child)
+			if err != nil {
+				return nil, err
+			}
+			if err := d.SetKey(starlark.String(k), sv); err != nil {
+				return nil, err
+			}
+		}
+		return d, nil
+	case []any:
+		elems := make([]starlark.Value, len(v))
+		for i, child := range v {
+			sv, err := toStarlark(child)
+			if err != nil {
+				return nil, err
+			}
+			elems[i] = sv
+		}
+		return starlark.NewList(elems), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", v)
+	}
+}
+
+// fromStarlark converts a Starlark value to its json counterpart, failing if its approximate size exceeds the remaining budget
+func fromStarlark(v starlark.Value, budget *int) (any, error) {
+	*budget -= 8
+	if *budget < 0 {
+		return nil, errOutputTooLarge
+	}
+	switch v := v.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case starlark.Bool:
+		return bool(v), nil
+	case starlark.String:
+		if *budget -= len(v); *budget < 0 {
+			return nil, errOutputTooLarge
+		}
+		return string(v), nil
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		f, _ := new(big.Float).SetInt(v.BigInt()).Float64()
+		return f, nil
+	case starlark.Float:
+		return float64(v), nil
+	case *starlark.Dict:
+		m := make(map[string]any, v.Len())
+		for _, item := range v.Items() {
+			k, ok := item[0].(starlark.String)
+			if !ok {
+				return nil, fmt.Errorf("unsupported %s key in dict, only strings are supported", item[0].Type())
+			}
+			if *budget -= len(k); *budget < 0 {
+				return nil, errOutputTooLarge
+			}
+			child, err := fromStarlark(item[1], budget)
+			if err != nil {
+				return nil, err
+			}
+			m[string(k)] = child
+		}
+		return m, nil
+	case starlark.Indexable: // lists and tuples
+		s := make([]any, v.Len())
+		for i := range s {
+			child, err := fromStarlark(v.Index(i), budget)
+			if err != nil {
+				return nil, err
+			}
+			s[i] = child
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s in output", v.Type())
+	}
+}
+
+var errOutputTooLarge = errors.New("output of the transformation is too large")
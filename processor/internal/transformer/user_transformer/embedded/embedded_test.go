@@ -0,0 +1,200 @@
+package embedded
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/types"
+	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
+)
+
+func newEvents(code string, messages ...types.SingularEventT) []types.TransformerEvent {
+	destination := backendconfig.DestinationT{
+		ID: "destination-id",
+		Transformations: []backendconfig.TransformationT{{
+			ID:        "transformation-id",
+			VersionID: code, // a version per code, so that the cache doesn't mix them up
+			Config: map[string]any{
+				ConfigKeyEmbedded: true,
+				ConfigKeyLanguage: LanguageStarlark,
+				ConfigKeyCode:     code,
+			},
+		}},
+	}
+	events := make([]types.TransformerEvent, len(messages))
+	for i, message := range messages {
+		events[i] = types.TransformerEvent{
+			Message:     message,
+			Metadata:    types.Metadata{MessageID: message["messageId"].(string), SourceID: "source-id", WorkspaceID: "workspace-id"},
+			Destination: destination,
+		}
+	}
+	return events
+}
+
+func TestIsEmbedded(t *testing.T) {
+	require.True(t, IsEmbedded(backendconfig.TransformationT{Config: map[string]any{"embedded": true, "language": "Starlark"}}))
+	require.False(t, IsEmbedded(backendconfig.TransformationT{Config: map[string]any{"embedded": false, "language": "starlark"}}))
+	require.False(t, IsEmbedded(backendconfig.TransformationT{Config: map[string]any{"embedded": true, "language": "javascript"}}))
+	require.False(t, IsEmbedded(backendconfig.TransformationT{}))
+}
+
+func TestTransform(t *testing.T) {
+	tr := New(config.New(), logger.NOP, stats.NOP)
+
+	t.Run("success, one to many and filtering", func(t *testing.T) {
+		code := `
+def transformEvent(event, metadata):
+    if event.get("event") == "drop":
+        return None
+    if event.get("event") == "split":
+        return [dict(event, part=1), dict(event, part=2)]
+    event["properties"]["total"] = event["properties"]["price"] * event["properties"]["quantity"]
+    event["properties"]["sourceId"] = metadata["sourceId"]
+    event["properties"]["encoded"] = json.encode({"a": 1})
+    return event
+`
+		events := newEvents(code,
+			types.SingularEventT{"messageId": "1", "event": "buy", "properties": map[string]any{"price": 2.5, "quantity": float64(4)}},
+			types.SingularEventT{"messageId": "2", "event": "drop"},
+			types.SingularEventT{"messageId": "3", "event": "split"},
+		)
+		response := tr.Transform(context.Background(), events)
+
+		require.Len(t, response.Events, 3)
+		require.Equal(t, map[string]any{
+			"messageId": "1",
+			"event":     "buy",
+			"properties": map[string]any{
+				"price":    2.5,
+				"quantity": int64(4),
+				"total":    float64(10),
+				"sourceId": "source-id",
+				"encoded":  `{"a":1}`,
+			},
+		}, response.Events[0].Output)
+		require.Equal(t, http.StatusOK, response.Events[0].StatusCode)
+		require.Equal(t, events[0].Metadata, response.Events[0].Metadata)
+		require.Equal(t, map[string]any{"messageId": "3", "event": "split", "part": int64(1)}, response.Events[1].Output)
+		require.Equal(t, map[string]any{"messageId": "3", "event": "split", "part": int64(2)}, response.Events[2].Output)
+		require.Equal(t, "3", response.Events[2].Metadata.MessageID)
+
+		require.Len(t, response.FailedEvents, 1)
+		require.Equal(t, reportingtypes.FilterEventCode, response.FailedEvents[0].StatusCode)
+		require.Equal(t, "2", response.FailedEvents[0].Metadata.MessageID)
+		require.Equal(t, types.SingularEventT{"messageId": "1", "event": "buy", "properties": map[string]any{"price": 2.5, "quantity": float64(4)}}, events[0].Message,
+			"input events shouldn't be modified")
+	})
+
+	t.Run("replayed events see their original source", func(t *testing.T) {
+		events := newEvents(`
+def transformEvent(event, metadata):
+    event["sourceId"] = metadata["sourceId"]
+    return event
+`, types.SingularEventT{"messageId": "1"})
+		events[0].Metadata.OriginalSourceID = "original-source-id"
+		response := tr.Transform(context.Background(), events)
+		require.Len(t, response.Events, 1)
+		require.Equal(t, "original-source-id", response.Events[0].Output["sourceId"])
+		require.Equal(t, "source-id", response.Events[0].Metadata.SourceID)
+	})
+
+	t.Run("operations keep their semantics when accounting for memory", func(t *testing.T) {
+		events := newEvents(`
+def append(l, *values, sep=","):
+    l += values
+    return sep.join([str(v) for v in l])
+
+def transformEvent(event, metadata):
+    items = ["a"]
+    alias = items
+    items += ["b"]
+    counts = {"x": 1}
+    counts["x"] += 2
+    counts |= {"y": 1}
+    label = "%s-%d" % ("n", counts["x"])
+    label *= 2
+    event["properties"] = {
+        "aliased": alias,
+        "joined": append(items, "c", *["d"], **{"sep": "|"}),
+        "counts": counts,
+        "label": label,
+        "squares": {k: v * v for k, v in {"a": 2}.items()},
+        "shifted": (lambda x, by=1 + 1: x << by)(1),
+    }
+    return event
+`, types.SingularEventT{"messageId": "1"})
+		response := tr.Transform(context.Background(), events)
+		require.Empty(t, response.FailedEvents)
+		require.Len(t, response.Events, 1)
+		require.Equal(t, map[string]any{
+			"aliased": []any{"a", "b", "c", "d"},
+			"joined":  "a|b|c|d",
+			"counts":  map[string]any{"x": int64(3), "y": int64(1)},
+			"label":   "n-3n-3",
+			"squares": map[string]any{"a": int64(4)},
+			"shifted": int64(4),
+		}, response.Events[0].Output["properties"])
+	})
+
+	for _, tc := range []struct {
+		name string
+		code string
+		err  string
+	}{
+		{name: "runtime error", code: "def transformEvent(event, metadata):\n    return event[\"missing\"]", err: `key "missing" not in dict`},
+		{name: "invalid result", code: "def transformEvent(event, metadata):\n    return 1", err: "transformEvent returned a int instead of an event"},
+		{name: "syntax error", code: "def transformEvent(event, metadata)\n    return event", err: "compiling embedded transformation"},
+		{name: "missing function", code: "def transform(event, metadata):\n    return event", err: "embedded transformation doesn't define a transformEvent function"},
+		{name: "no code", code: "", err: "embedded transformation doesn't have any code"},
+		{name: "metadata is read only", code: "def transformEvent(event, metadata):\n    metadata[\"sourceId\"] = \"x\"\n    return event", err: "frozen"},
+		{name: "too many steps", code: "def transformEvent(event, metadata):\n    for i in range(10000000):\n        pass\n    return event", err: "too many steps"},
+		{name: "reserved identifier", code: "def transformEvent(event, metadata):\n    _rudder_call = 1\n    return event", err: "identifiers starting with _rudder_ are reserved"},
+		{name: "memory limit, repetition", code: "def transformEvent(event, metadata):\n    event[\"large\"] = \"x\" * 100000000\n    return event", err: "transformation exceeded its memory limit"},
+		{name: "memory limit, concatenation", code: "def transformEvent(event, metadata):\n    s = \"x\"\n    for i in range(40):\n        s += s\n    return event", err: "transformation exceeded its memory limit"},
+		{name: "memory limit, list growth", code: "def transformEvent(event, metadata):\n    l = [1]\n    for i in range(40):\n        l.extend(l)\n    return event", err: "transformation exceeded its memory limit"},
+		{name: "memory limit, aliased values", code: "def transformEvent(event, metadata):\n    l = [\"x\" * 1000000] * 100\n    event[\"large\"] = len(\",\".join(l))\n    return event", err: "transformation exceeded its memory limit"},
+		{name: "memory limit, while compiling", code: "large = list(range(100000000))\ndef transformEvent(event, metadata):\n    return event", err: "transformation exceeded its memory limit"},
+		{name: "output too large", code: "def transformEvent(event, metadata):\n    event[\"large\"] = \"x\" * 5000000\n    return event", err: "output of the transformation is too large"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			response := tr.Transform(context.Background(), newEvents(tc.code, types.SingularEventT{"messageId": "1"}, types.SingularEventT{"messageId": "2"}))
+			require.Empty(t, response.Events)
+			require.Len(t, response.FailedEvents, 2)
+			for _, failed := range response.FailedEvents {
+				require.Equal(t, http.StatusBadRequest, failed.StatusCode)
+				require.Contains(t, failed.Error, tc.err)
+			}
+		})
+	}
+
+	t.Run("timeout", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("Processor.UserTransformer.Embedded.maxExecutionSteps", 1_000_000_000)
+		conf.Set("Processor.UserTransformer.Embedded.timeout", "10ms")
+		tr := New(conf, logger.NOP, stats.NOP)
+		response := tr.Transform(context.Background(), newEvents("def transformEvent(event, metadata):\n    for i in range(1000000000):\n        pass\n    return event", types.SingularEventT{"messageId": "1"}))
+		require.Len(t, response.FailedEvents, 1)
+		require.Contains(t, response.FailedEvents[0].Error, "timeout")
+	})
+}
+
+func BenchmarkTransform(b *testing.B) {
+	tr := New(config.New(), logger.NOP, stats.NOP)
+	events := newEvents(`
+def transformEvent(event, metadata):
+    event["context"]["enriched"] = True
+    return event
+`, types.SingularEventT{"messageId": "1", "event": "buy", "context": map[string]any{"ip": "1.1.1.1"}, "properties": map[string]any{"price": 2.5}})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Transform(context.Background(), events)
+	}
+}
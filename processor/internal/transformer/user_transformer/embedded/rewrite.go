@@ -0,0 +1,280 @@
+package embedded
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go.starlark.net/syntax"
+)
+
+// Names of the builtins which the code of transformations is rewritten to call, so that the memory their operations allocate is accounted for
+const (
+	reservedPrefix = "_rudder_"
+	binaryBuiltin  = reservedPrefix + "binary"
+	inplaceBuiltin = reservedPrefix + "inplace"
+	callBuiltin    = reservedPrefix + "call"
+)
+
+// accountedOps are the binary operators whose results can be larger than their operands
+var accountedOps = map[syntax.Token]bool{
+	syntax.PLUS:    true,
+	syntax.STAR:    true,
+	syntax.PERCENT: true,
+	syntax.PIPE:    true,
+	syntax.LTLT:    true,
+}
+
+// augmentedOps maps augmented assignment operators to their binary counterparts
+var augmentedOps = map[syntax.Token]syntax.Token{
+	syntax.PLUS_EQ:    syntax.PLUS,
+	syntax.STAR_EQ:    syntax.STAR,
+	syntax.PERCENT_EQ: syntax.PERCENT,
+	syntax.PIPE_EQ:    syntax.PIPE,
+	syntax.LTLT_EQ:    syntax.LTLT,
+}
+
+// rewriteFile rewrites the code of a transformation, so that all function calls and binary operations which can allocate memory go through the allocation builtins, e.g.
+//
+//	a = b + c        ->  a = _rudder_binary("+", b, c)
+//	a += b           ->  a = _rudder_inplace("+", a, b)
+//	s.join(l)        ->  _rudder_call(s.join, l)
+//
+// Identifiers starting with the reserved prefix are rejected, so that transformations cannot shadow the builtins.
+func rewriteFile(f *syntax.File) error {
+	var err error
+	syntax.Walk(f, func(n syntax.Node) bool {
+		if id, ok := n.(*syntax.Ident); ok && strings.HasPrefix(id.Name, reservedPrefix) {
+			err = fmt.Errorf("%s: identifiers starting with %s are reserved", id.NamePos, reservedPrefix)
+		}
+		return err == nil
+	})
+	if err != nil {
+		return err
+	}
+	r := &rewriter{}
+	f.Stmts = r.stmts(f.Stmts)
+	return nil
+}
+
+type rewriter struct {
+	temps int // number of temporary variables introduced so far
+}
+
+func (r *rewriter) stmts(stmts []syntax.Stmt) []syntax.Stmt {
+	if stmts == nil {
+		return nil
+	}
+	rewritten := make([]syntax.Stmt, 0, len(stmts))
+	for _, stmt := range stmts {
+		rewritten = append(rewritten, r.stmt(stmt)...)
+	}
+	return rewritten
+}
+
+func (r *rewriter) stmt(stmt syntax.Stmt) []syntax.Stmt {
+	switch stmt := stmt.(type) {
+	case *syntax.AssignStmt:
+		if op, ok := augmentedOps[stmt.Op]; ok {
+			return r.augmented(stmt, op)
+		}
+		stmt.LHS = r.target(stmt.LHS)
+		stmt.RHS = r.expr(stmt.RHS)
+	case *syntax.DefStmt:
+		r.params(stmt.Params)
+		stmt.Body = r.stmts(stmt.Body)
+	case *syntax.ExprStmt:
+		stmt.X = r.expr(stmt.X)
+	case *syntax.ForStmt:
+		stmt.Vars = r.target(stmt.Vars)
+		stmt.X = r.expr(stmt.X)
+		stmt.Body = r.stmts(stmt.Body)
+	case *syntax.WhileStmt:
+		stmt.Cond = r.expr(stmt.Cond)
+		stmt.Body = r.stmts(stmt.Body)
+	case *syntax.IfStmt:
+		stmt.Cond = r.expr(stmt.Cond)
+		stmt.True = r.stmts(stmt.True)
+		stmt.False = r.stmts(stmt.False)
+	case *syntax.ReturnStmt:
+		if stmt.Result != nil {
+			stmt.Result = r.expr(stmt.Result)
+		}
+	}
+	return []syntax.Stmt{stmt}
+}
+
+// augmented rewrites an augmented assignment to a plain one, evaluating the operands of its target only once, same as the interpreter does, e.g.
+//
+//	a[k()] += b  ->  _rudder_t1 = a; _rudder_t2 = k(); _rudder_t1[_rudder_t2] = _rudder_inplace("+", _rudder_t1[_rudder_t2], b)
+func (r *rewriter) augmented(stmt *syntax.AssignStmt, op syntax.Token) []syntax.Stmt {
+	var (
+		stmts  []syntax.Stmt
+		target func() syntax.Expr // returns a new node for the target, which cannot be shared between the assignment and the operation
+	)
+	switch lhs := stmt.LHS.(type) {
+	case *syntax.IndexExpr:
+		x := r.temp(&stmts, lhs.X)
+		y := r.temp(&stmts, lhs.Y)
+		target = func() syntax.Expr {
+			return &syntax.IndexExpr{X: x(), Lbrack: lhs.Lbrack, Y: y(), Rbrack: lhs.Rbrack}
+		}
+	case *syntax.DotExpr:
+		x := r.temp(&stmts, lhs.X)
+		target = func() syntax.Expr {
+			return &syntax.DotExpr{X: x(), Dot: lhs.Dot, NamePos: lhs.NamePos, Name: ident(lhs.Name.Name, lhs.Name.NamePos)}
+		}
+	default: // identifiers, the only other target of augmented assignments
+		target = func() syntax.Expr {
+			return copyIdent(lhs)
+		}
+	}
+	return append(stmts, &syntax.AssignStmt{
+		OpPos: stmt.OpPos,
+		Op:    syntax.EQ,
+		LHS:   target(),
+		RHS:   call(inplaceBuiltin, stmt.OpPos, opLiteral(op, stmt.OpPos), target(), r.expr(stmt.RHS)),
+	})
+}
+
+// temp appends an assignment of the expression to a new temporary variable, returning a function creating identifiers referencing it
+func (r *rewriter) temp(stmts *[]syntax.Stmt, x syntax.Expr) func() syntax.Expr {
+	r.temps++
+	name := reservedPrefix + "t" + strconv.Itoa(r.temps)
+	pos, _ := x.Span()
+	*stmts = append(*stmts, &syntax.AssignStmt{OpPos: pos, Op: syntax.EQ, LHS: ident(name, pos), RHS: r.expr(x)})
+	return func() syntax.Expr {
+		return ident(name, pos)
+	}
+}
+
+// target rewrites the expressions nested in the target of an assignment, keeping the target itself as is
+func (r *rewriter) target(x syntax.Expr) syntax.Expr {
+	switch x := x.(type) {
+	case *syntax.ParenExpr:
+		x.X = r.target(x.X)
+	case *syntax.TupleExpr:
+		for i := range x.List {
+			x.List[i] = r.target(x.List[i])
+		}
+	case *syntax.ListExpr:
+		for i := range x.List {
+			x.List[i] = r.target(x.List[i])
+		}
+	case *syntax.IndexExpr:
+		x.X = r.expr(x.X)
+		x.Y = r.expr(x.Y)
+	case *syntax.DotExpr:
+		x.X = r.expr(x.X)
+	}
+	return x
+}
+
+// params rewrites the default values of parameters
+func (r *rewriter) params(params []syntax.Expr) {
+	for _, param := range params {
+		if param, ok := param.(*syntax.BinaryExpr); ok && param.Op == syntax.EQ {
+			param.Y = r.expr(param.Y)
+		}
+	}
+}
+
+func (r *rewriter) expr(x syntax.Expr) syntax.Expr {
+	switch x := x.(type) {
+	case *syntax.BinaryExpr:
+		x.X = r.expr(x.X)
+		x.Y = r.expr(x.Y)
+		if accountedOps[x.Op] {
+			return call(binaryBuiltin, x.OpPos, opLiteral(x.Op, x.OpPos), x.X, x.Y)
+		}
+	case *syntax.CallExpr:
+		x.Fn = r.expr(x.Fn)
+		for i, arg := range x.Args {
+			if named, ok := arg.(*syntax.BinaryExpr); ok && named.Op == syntax.EQ { // named argument
+				named.Y = r.expr(named.Y)
+				continue
+			}
+			x.Args[i] = r.expr(arg) // including *args and **kwargs, which are unary expressions
+		}
+		return &syntax.CallExpr{
+			Fn:     ident(callBuiltin, x.Lparen),
+			Lparen: x.Lparen,
+			Args:   append([]syntax.Expr{x.Fn}, x.Args...),
+			Rparen: x.Rparen,
+		}
+	case *syntax.ParenExpr:
+		x.X = r.expr(x.X)
+	case *syntax.DotExpr:
+		x.X = r.expr(x.X)
+	case *syntax.IndexExpr:
+		x.X = r.expr(x.X)
+		x.Y = r.expr(x.Y)
+	case *syntax.SliceExpr:
+		x.X = r.expr(x.X)
+		x.Lo = r.optionalExpr(x.Lo)
+		x.Hi = r.optionalExpr(x.Hi)
+		x.Step = r.optionalExpr(x.Step)
+	case *syntax.UnaryExpr:
+		x.X = r.optionalExpr(x.X)
+	case *syntax.CondExpr:
+		x.Cond = r.expr(x.Cond)
+		x.True = r.expr(x.True)
+		x.False = r.expr(x.False)
+	case *syntax.ListExpr:
+		for i := range x.List {
+			x.List[i] = r.expr(x.List[i])
+		}
+	case *syntax.TupleExpr:
+		for i := range x.List {
+			x.List[i] = r.expr(x.List[i])
+		}
+	case *syntax.DictExpr:
+		for i := range x.List {
+			x.List[i] = r.expr(x.List[i])
+		}
+	case *syntax.DictEntry:
+		x.Key = r.expr(x.Key)
+		x.Value = r.expr(x.Value)
+	case *syntax.LambdaExpr:
+		r.params(x.Params)
+		x.Body = r.expr(x.Body)
+	case *syntax.Comprehension:
+		for _, clause := range x.Clauses {
+			switch clause := clause.(type) {
+			case *syntax.ForClause:
+				clause.Vars = r.target(clause.Vars)
+				clause.X = r.expr(clause.X)
+			case *syntax.IfClause:
+				clause.Cond = r.expr(clause.Cond)
+			}
+		}
+		x.Body = r.expr(x.Body)
+	}
+	return x
+}
+
+func (r *rewriter) optionalExpr(x syntax.Expr) syntax.Expr {
+	if x == nil {
+		return nil
+	}
+	return r.expr(x)
+}
+
+func call(fn string, pos syntax.Position, args ...syntax.Expr) *syntax.CallExpr {
+	return &syntax.CallExpr{Fn: ident(fn, pos), Lparen: pos, Args: args, Rparen: pos}
+}
+
+func ident(name string, pos syntax.Position) *syntax.Ident {
+	return &syntax.Ident{NamePos: pos, Name: name}
+}
+
+func copyIdent(x syntax.Expr) syntax.Expr {
+	if id, ok := x.(*syntax.Ident); ok {
+		return ident(id.Name, id.NamePos)
+	}
+	return x
+}
+
+func opLiteral(op syntax.Token, pos syntax.Position) *syntax.Literal {
+	return &syntax.Literal{Token: syntax.STRING, TokenPos: pos, Raw: strconv.Quote(op.String()), Value: op.String()}
+}
@@ -23,6 +23,7 @@ import (
 	transformerclient "github.com/rudderlabs/rudder-server/internal/transformer-client"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
 	transformerutils "github.com/rudderlabs/rudder-server/processor/internal/transformer"
+	"github.com/rudderlabs/rudder-server/processor/internal/transformer/user_transformer/embedded"
 	"github.com/rudderlabs/rudder-server/processor/types"
 	"github.com/rudderlabs/rudder-server/utils/httputil"
 	reportingtypes "github.com/rudderlabs/rudder-server/utils/types"
@@ -52,6 +53,10 @@ func New(conf *config.Config, log logger.Logger, stat stats.Stats, opts ...Opt)
 	handle.config.maxRetryBackoffInterval = conf.GetReloadableDurationVar(30, time.Second, "Processor.UserTransformer.maxRetryBackoffInterval", "Processor.maxRetryBackoffInterval")
 	handle.config.collectInstanceLevelStats = conf.GetBool("Processor.collectInstanceLevelStats", false)
 	handle.config.batchSize = conf.GetReloadableIntVar(200, 1, "Processor.UserTransformer.batchSize", "Processor.userTransformBatchSize")
+	handle.config.embeddedEnabled = conf.GetReloadableBoolVar(true, "Processor.UserTransformer.Embedded.enabled")
+	handle.config.embeddedMirroring = conf.GetReloadableBoolVar(false, "Processor.UserTransformer.Embedded.mirroring")
+	handle.embedded = embedded.New(conf, handle.log, stat)
+	handle.embeddedMirroringLimiter = make(chan struct{}, conf.GetInt("Processor.UserTransformer.Embedded.mirroringConcurrency", 10))
 
 	for _, opt := range opts {
 		opt(handle)
@@ -75,17 +80,92 @@ type Client struct {
 		timeoutDuration            time.Duration
 		collectInstanceLevelStats  bool
 		batchSize                  config.ValueLoader[int]
+		embeddedEnabled            config.ValueLoader[bool]
+		embeddedMirroring          config.ValueLoader[bool]
 	}
 	conf   *config.Config
 	log    logger.Logger
 	stat   stats.Stats
 	client transformerclient.Client
+
+	embedded                 *embedded.Transformer
+	embeddedMirroringLimiter chan struct{} // limits the number of concurrent mirroring requests of embedded transformations
 }
 
+// Transform runs the user transformation of the events' destination.
+// Transformations flagged as embedded run in process, unless this is a mirroring client, see [embedded.IsEmbedded].
 func (u *Client) Transform(ctx context.Context, clientEvents []types.TransformerEvent) types.Response {
 	if len(clientEvents) == 0 {
 		return types.Response{}
 	}
+	if !u.config.forMirroring && u.config.embeddedEnabled.Load() &&
+		len(clientEvents[0].Destination.Transformations) > 0 && embedded.IsEmbedded(clientEvents[0].Destination.Transformations[0]) {
+		return u.transformEmbedded(ctx, clientEvents)
+	}
+	return u.transform(ctx, clientEvents)
+}
+
+// transformEmbedded runs an embedded transformation. If mirroring is enabled, the events are also sent to the transformer service
+// in the background and the responses get compared, the embedded transformation's response being the one returned.
+func (u *Client) transformEmbedded(ctx context.Context, clientEvents []types.TransformerEvent) types.Response {
+	response := u.embedded.Transform(ctx, clientEvents)
+	if !u.config.embeddedMirroring.Load() {
+		return response
+	}
+	var (
+		workspaceID      = clientEvents[0].Metadata.WorkspaceID
+		sourceID         = clientEvents[0].Metadata.SourceID
+		destinationID    = clientEvents[0].Destination.ID
+		transformationID = clientEvents[0].Destination.Transformations[0].ID
+	)
+	mirroringStat := func(result string) stats.Measurement {
+		return u.stat.NewTaggedStat("embedded_user_transform_mirroring_responses_count", stats.CountType, stats.Tags{
+			"workspaceId":      workspaceID,
+			"sourceId":         sourceID,
+			"destinationId":    destinationID,
+			"transformationId": transformationID,
+			"result":           result,
+		})
+	}
+	select {
+	case u.embeddedMirroringLimiter <- struct{}{}:
+	default:
+		mirroringStat("skipped").Increment()
+		return response
+	}
+
+	// copying the events and the response, since they may be modified by the processor once returned,
+	// with the copy of the response also having the same number types as the transformer service's
+	mirroredEvents, embeddedResponse := deepCopy(clientEvents), deepCopy(response)
+	go func() {
+		defer func() { <-u.embeddedMirroringLimiter }()
+		remoteResponse := u.transform(context.WithoutCancel(ctx), mirroredEvents)
+		diff, equal := remoteResponse.Equal(&embeddedResponse)
+		if equal {
+			mirroringStat("equal").Increment()
+			return
+		}
+		mirroringStat("different").Increment()
+		u.log.Warnn("Embedded user transformation response differs from the transformer's",
+			obskit.WorkspaceID(workspaceID),
+			obskit.SourceID(sourceID),
+			obskit.DestinationID(destinationID),
+			logger.NewStringField("transformationID", transformationID),
+			logger.NewStringField("diff", diff),
+		)
+	}()
+	return response
+}
+
+func deepCopy[T any](src T) T {
+	var dst T
+	if data, err := jsonrs.Marshal(src); err == nil {
+		_ = jsonrs.Unmarshal(data, &dst)
+	}
+	return dst
+}
+
+func (u *Client) transform(ctx context.Context, clientEvents []types.TransformerEvent) types.Response {
 	batchSize := u.config.batchSize.Load()
 	var transformationID string
 	if len(clientEvents[0].Destination.Transformations) > 0 {
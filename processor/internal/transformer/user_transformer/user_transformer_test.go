@@ -700,6 +700,92 @@ func TestUserTransformer(t *testing.T) {
 	}
 }
 
+func TestEmbeddedUserTransformer(t *testing.T) {
+	var requests atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		(&fakeTransformer{t: t}).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	destination := backendconfigtest.NewDestinationBuilder("WEBHOOK").
+		WithUserTransformation("transformation-id", "version-id").Build()
+	destination.Transformations[0].Config = map[string]any{
+		"embedded": true,
+		"language": "starlark",
+		"code": `
+def transformEvent(event, metadata):
+    event.pop("forceStatusCode")
+    event["echo-key-1"] = event["src-key-1"]
+    return event
+`,
+	}
+	newEvents := func() []types.TransformerEvent {
+		return []types.TransformerEvent{{
+			Message:     types.SingularEventT{"src-key-1": "msg-1", "forceStatusCode": float64(http.StatusOK)},
+			Metadata:    types.Metadata{MessageID: "msg-1", SourceID: "source-id", WorkspaceID: "workspace-id", DestinationID: destination.ID},
+			Destination: destination,
+		}}
+	}
+	expectedResponse := types.Response{Events: []types.TransformerResponse{{
+		Output:     map[string]any{"src-key-1": "msg-1", "echo-key-1": "msg-1"},
+		Metadata:   newEvents()[0].Metadata,
+		StatusCode: http.StatusOK,
+	}}}
+
+	newConf := func() *config.Config {
+		conf := config.New()
+		conf.Set("USER_TRANSFORM_URL", srv.URL)
+		conf.Set("USER_TRANSFORM_MIRROR_URL", srv.URL)
+		conf.Set("Processor.maxRetry", 1)
+		return conf
+	}
+
+	t.Run("embedded transformations run in process", func(t *testing.T) {
+		tr := user_transformer.New(newConf(), logger.NOP, stats.NOP, user_transformer.WithClient(srv.Client()))
+		require.Equal(t, expectedResponse, tr.Transform(context.Background(), newEvents()))
+		require.Zero(t, requests.Load())
+	})
+
+	t.Run("embedded transformations can be disabled", func(t *testing.T) {
+		conf := newConf()
+		conf.Set("Processor.UserTransformer.Embedded.enabled", false)
+		tr := user_transformer.New(conf, logger.NOP, stats.NOP, user_transformer.WithClient(srv.Client()))
+		response := tr.Transform(context.Background(), newEvents())
+		require.Len(t, response.Events, 1)
+		require.EqualValues(t, 1, requests.Swap(0))
+	})
+
+	t.Run("mirroring clients always use the transformer", func(t *testing.T) {
+		tr := user_transformer.New(newConf(), logger.NOP, stats.NOP, user_transformer.WithClient(srv.Client()), user_transformer.ForMirroring())
+		response := tr.Transform(context.Background(), newEvents())
+		require.Len(t, response.Events, 1)
+		require.EqualValues(t, 1, requests.Swap(0))
+	})
+
+	t.Run("mirroring", func(t *testing.T) {
+		statsStore, err := memstats.New()
+		require.NoError(t, err)
+		conf := newConf()
+		conf.Set("Processor.UserTransformer.Embedded.mirroring", true)
+		tr := user_transformer.New(conf, logger.NOP, statsStore, user_transformer.WithClient(srv.Client()))
+		require.Equal(t, expectedResponse, tr.Transform(context.Background(), newEvents()))
+
+		tags := stats.Tags{
+			"workspaceId":      "workspace-id",
+			"sourceId":         "source-id",
+			"destinationId":    destination.ID,
+			"transformationId": "transformation-id",
+			"result":           "equal",
+		}
+		require.Eventually(t, func() bool {
+			m := statsStore.Get("embedded_user_transform_mirroring_responses_count", tags)
+			return m != nil && m.LastValue() == 1
+		}, 5*time.Second, 10*time.Millisecond)
+		require.EqualValues(t, 1, requests.Swap(0))
+	})
+}
+
 func TestLongRunningTransformation(t *testing.T) {
 	fileName := t.TempDir() + "out.log"
 	f, err := os.Create(fileName)
@@ -2,6 +2,7 @@ package processor
 
 import (
 	"context"
+	"database/sql"
 	"sync"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -174,6 +175,13 @@ func WithAdaptiveLimit(adaptiveLimitFunction func(int64) int64) Opts {
 	}
 }
 
+// WithDBHandle sets the jobsdb database handle to be shared with the deduplication service
+func WithDBHandle(dbHandle *sql.DB) Opts {
+	return func(l *LifecycleManager) {
+		l.Handle.dbHandle = dbHandle
+	}
+}
+
 func WithStats(stats stats.Stats) Opts {
 	return func(l *LifecycleManager) {
 		l.Handle.statsFactory = stats
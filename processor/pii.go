@@ -0,0 +1,42 @@
+package processor
+
+import (
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/internal/pii"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+// piiRedactionConfig is the pii redaction configuration of a connection, err being set if its rules are invalid
+type piiRedactionConfig struct {
+	redactor *pii.Redactor
+	err      error
+}
+
+// getPIIRedactor returns the redactor of a connection, nil if the connection doesn't have any pii redaction rules.
+// An error is returned if the connection's rules are invalid, in which case its events must not be sent to the destination.
+func (proc *Handle) getPIIRedactor(sourceID, destinationID string) (*pii.Redactor, error) {
+	proc.config.configSubscriberLock.RLock()
+	defer proc.config.configSubscriberLock.RUnlock()
+	c := proc.config.piiRedactionMap[connection{sourceID: sourceID, destinationID: destinationID}]
+	return c.redactor, c.err
+}
+
+// redactPII applies the connection's pii redaction rules to the events' messages, which are replaced by redacted copies
+func (proc *Handle) redactPII(redactor *pii.Redactor, sourceID, workspaceID string, destination *backendconfig.DestinationT, events []types.TransformerEvent) {
+	counts := make(map[pii.Applied]int)
+	for i := range events {
+		redacted, applied := redactor.Redact(events[i].Message)
+		events[i].Message = redacted
+		for _, a := range applied {
+			counts[pii.Applied{Rule: a.Rule, Action: a.Action}] += a.Count
+		}
+	}
+	for a, count := range counts {
+		tags := buildStatTags(sourceID, workspaceID, destination, PIIRedaction)
+		tags["rule"] = a.Rule
+		tags["action"] = a.Action
+		proc.statsFactory.NewTaggedStat("proc_pii_redacted_values", stats.CountType, tags).Count(count)
+	}
+}
@@ -0,0 +1,67 @@
+package processor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/processor/internal/pii"
+	"github.com/rudderlabs/rudder-server/processor/types"
+)
+
+func TestRedactPII(t *testing.T) {
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	proc := &Handle{statsFactory: statsStore}
+	proc.config.piiRedactionMap = make(map[connection]piiRedactionConfig)
+	for _, conn := range []backendconfig.Connection{
+		{SourceID: "source-1", DestinationID: "dest-1", Config: map[string]any{
+			"piiRedaction": map[string]any{"rules": []any{
+				map[string]any{"name": "email", "paths": []any{"traits.email"}, "action": "hash"},
+				map[string]any{"name": "ip", "paths": []any{"context.ip"}, "action": "truncate"},
+			}},
+		}},
+		{SourceID: "source-1", DestinationID: "dest-2", Config: map[string]any{
+			"piiRedaction": map[string]any{"rules": []any{
+				map[string]any{"paths": []any{"traits.email"}, "action": "encrypt"},
+			}},
+		}},
+	} {
+		redactor, err := pii.FromConnectionConfig(conn.Config)
+		proc.config.piiRedactionMap[connection{sourceID: conn.SourceID, destinationID: conn.DestinationID}] = piiRedactionConfig{redactor: redactor, err: err}
+	}
+
+	redactor, err := proc.getPIIRedactor("source-1", "dest-3")
+	require.NoError(t, err)
+	require.Nil(t, redactor, "connections without rules shouldn't have a redactor")
+
+	_, err = proc.getPIIRedactor("source-1", "dest-2")
+	require.ErrorContains(t, err, `unknown action "encrypt"`)
+
+	redactor, err = proc.getPIIRedactor("source-1", "dest-1")
+	require.NoError(t, err)
+	require.NotNil(t, redactor)
+
+	shared := types.SingularEventT{
+		"traits":  map[string]any{"email": "john@example.com"},
+		"context": map[string]any{"ip": "203.0.113.42"},
+	}
+	destination := &backendconfig.DestinationT{ID: "dest-1", DestinationDefinition: backendconfig.DestinationDefinitionT{Name: "WEBHOOK"}}
+	events := []types.TransformerEvent{{Message: shared}, {Message: types.SingularEventT{"traits": map[string]any{}}}}
+	proc.redactPII(redactor, "source-1", "workspace-1", destination, events)
+
+	require.Equal(t, types.SingularEventT{
+		"traits":  map[string]any{"email": "855f96e983f1f8e8be944692b6f719fd54329826cb62e98015efee8e2e071dd4"},
+		"context": map[string]any{"ip": "203.0.113.0"},
+	}, events[0].Message)
+	require.Equal(t, types.SingularEventT{"traits": map[string]any{}}, events[1].Message)
+	require.Equal(t, "john@example.com", shared["traits"].(map[string]any)["email"], "messages shared with other destinations shouldn't be modified")
+
+	tags := buildStatTags("source-1", "workspace-1", destination, PIIRedaction)
+	tags["rule"], tags["action"] = "email", "hash"
+	require.EqualValues(t, 1, statsStore.Get("proc_pii_redacted_values", tags).LastValue())
+	tags["rule"], tags["action"] = "ip", "truncate"
+	require.EqualValues(t, 1, statsStore.Get("proc_pii_redacted_values", tags).LastValue())
+}
@@ -3,6 +3,7 @@ package processor
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -38,6 +39,7 @@ import (
 	"github.com/rudderlabs/rudder-server/processor/delayed"
 	"github.com/rudderlabs/rudder-server/processor/eventfilter"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/processor/internal/pii"
 	"github.com/rudderlabs/rudder-server/processor/isolation"
 	"github.com/rudderlabs/rudder-server/processor/stash"
 	"github.com/rudderlabs/rudder-server/processor/transformer"
@@ -78,6 +80,7 @@ const (
 	UserTransformation    = "USER_TRANSFORMATION"
 	DestTransformation    = "DEST_TRANSFORMATION"
 	EventFilter           = "EVENT_FILTER"
+	PIIRedaction          = "PII_REDACTION"
 	sourceCategoryWebhook = "webhook"
 )
 
@@ -114,6 +117,7 @@ type Handle struct {
 	logger                     logger.Logger
 	enrichers                  []enricher.PipelineEnricher
 	dedup                      dedup.Dedup
+	dedupKeyBuilder            *dedup.KeyBuilder
 	reporting                  reportingtypes.Reporting
 	reportingEnabled           bool
 	backgroundWait             func() error
@@ -160,6 +164,7 @@ type Handle struct {
 		workspaceLibrariesMap                     map[string]backendconfig.LibrariesT
 		oneTrustConsentCategoriesMap              map[string][]string
 		connectionConfigMap                       map[connection]backendconfig.Connection
+		piiRedactionMap                           map[connection]piiRedactionConfig
 		ketchConsentCategoriesMap                 map[string][]string
 		genericConsentManagementMap               SourceConsentMap
 		batchDestinations                         []string
@@ -192,6 +197,7 @@ type Handle struct {
 
 	adaptiveLimit func(int64) int64
 	storePlocker  kitsync.PartitionLocker
+	dbHandle      *sql.DB // jobsdb database handle, shared with the postgres deduplication backend
 
 	sourceObservers      []sourceObserver
 	trackedUsersReporter trackedUsersReporter
@@ -571,11 +577,12 @@ func (proc *Handle) Setup(
 
 	if proc.config.enableDedup {
 		var err error
-		proc.dedup, err = dedup.New(proc.conf, proc.statsFactory)
+		proc.dedup, err = dedup.New(proc.conf, proc.statsFactory, proc.dbHandle)
 		if err != nil {
 			return err
 		}
 	}
+	proc.dedupKeyBuilder = dedup.NewKeyBuilder(proc.conf)
 	proc.sourceObservers = []sourceObserver{delayed.NewEventStats(proc.statsFactory, proc.conf)}
 	ctx, cancel := context.WithCancel(context.Background())
 	g, ctx := errgroup.WithContext(ctx)
@@ -807,10 +814,22 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 			credentialsMap               = make(map[string][]types.Credential)
 			nonEventStreamSources        = make(map[string]bool)
 			connectionConfigMap          = make(map[connection]backendconfig.Connection)
+			piiRedactionMap              = make(map[connection]piiRedactionConfig)
 		)
 		for workspaceID, wConfig := range config {
 			for _, conn := range wConfig.Connections {
 				connectionConfigMap[connection{sourceID: conn.SourceID, destinationID: conn.DestinationID}] = conn
+				redactor, err := pii.FromConnectionConfig(conn.Config)
+				if err != nil {
+					proc.logger.Errorn("invalid pii redaction rules, events of the connection will be filtered",
+						logger.NewStringField("sourceId", conn.SourceID),
+						logger.NewStringField("destinationId", conn.DestinationID),
+						obskit.Error(err),
+					)
+				}
+				if redactor != nil || err != nil {
+					piiRedactionMap[connection{sourceID: conn.SourceID, destinationID: conn.DestinationID}] = piiRedactionConfig{redactor: redactor, err: err}
+				}
 			}
 			for i := range wConfig.Sources {
 				source := &wConfig.Sources[i]
@@ -847,6 +866,7 @@ func (proc *Handle) backendConfigSubscriber(ctx context.Context) {
 		}
 		proc.config.configSubscriberLock.Lock()
 		proc.config.connectionConfigMap = connectionConfigMap
+		proc.config.piiRedactionMap = piiRedactionMap
 		proc.config.oneTrustConsentCategoriesMap = oneTrustConsentCategoriesMap
 		proc.config.ketchConsentCategoriesMap = ketchConsentCategoriesMap
 		proc.config.genericConsentManagementMap = genericConsentManagementMap
@@ -1648,6 +1668,7 @@ func getDiffMetrics(
 
 type dupStatKey struct {
 	sourceID string
+	mode     string
 }
 
 func (proc *Handle) eventAuditEnabled(workspaceID string) bool {
@@ -1665,6 +1686,8 @@ type preTransformationMessage struct {
 	statusDetailsMap             map[string]map[string]*reportingtypes.StatusDetail
 	enricherConnectionDetailsMap map[string]*reportingtypes.ConnectionDetails
 	enricherStatusDetailsMap     map[string]map[string]*reportingtypes.StatusDetail
+	consentConnectionDetailsMap  map[string]*reportingtypes.ConnectionDetails
+	consentStatusDetailsMap      map[string]*reportingtypes.StatusDetail
 	reportMetrics                []*reportingtypes.PUReportedMetric
 	destFilterStatusDetailMap    map[string]map[string]*reportingtypes.StatusDetail
 	inCountMetadataMap           map[string]MetricMetadata
@@ -1734,6 +1757,8 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 	destFilterStatusDetailMap := make(map[string]map[string]*reportingtypes.StatusDetail)
 	enricherConnectionDetailsMap := make(map[string]*reportingtypes.ConnectionDetails)
 	enricherStatusDetailsMap := make(map[string]map[string]*reportingtypes.StatusDetail)
+	consentConnectionDetailsMap := make(map[string]*reportingtypes.ConnectionDetails)
+	consentStatusDetailsMap := make(map[string]*reportingtypes.StatusDetail)
 	// map of jobID to destinationID: for messages that needs to be delivered to a specific destinations only
 	jobIDToSpecificDestMapOnly := make(map[int64]string)
 
@@ -1752,6 +1777,7 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 		userId        string
 		eventParams   types.EventParams
 		dedupKey      dedup.BatchKey
+		dedupMode     string
 		requestIP     string
 		recievedAt    time.Time
 		parameters    json.RawMessage
@@ -1834,6 +1860,27 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 		}
 
 		for _, singularEvent := range gatewayBatchEvent.Batch {
+			if enricher.IsDroppedBotEvent(singularEvent) {
+				if proc.isReportingEnabled() {
+					// Pass nil for countMetadataMap and countMap as we don't want to capture diff metrics for bot enricher
+					proc.updateMetricMaps(
+						nil,
+						nil,
+						enricherConnectionDetailsMap,
+						enricherStatusDetailsMap,
+						&types.TransformerResponse{
+							Metadata: *proc.makeCommonMetadataFromSingularEvent(singularEvent, batchEvent.UserID, batchEvent.JobID, receivedAt, source, eventParams),
+						},
+						reportingtypes.BotDroppedStatus,
+						reportingtypes.GATEWAY,
+						func() json.RawMessage {
+							return nil
+						},
+						nil,
+					)
+				}
+				continue
+			}
 			messageId := stringify.Any(singularEvent["messageId"])
 			payloadFunc := ro.Memoize(func() json.RawMessage {
 				payloadBytes, err := jsonrs.Marshal(singularEvent)
@@ -1842,10 +1889,12 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 				}
 				return payloadBytes
 			})
-			dedupBatchKey := dedup.BatchKey{
-				Index: dedupBatchKeysIdx,
-				Key:   messageId + eventParams.SourceJobRunId,
+			// jobs addressed to a specific destination are deduplicated separately per destination, e.g. copies of the same job restored for several destinations
+			dedupScope := eventParams.SourceJobRunId
+			if eventParams.DestinationID != "" {
+				dedupScope += ":" + eventParams.DestinationID
 			}
+			dedupBatchKey, dedupMode := proc.dedupKeyBuilder.Key(dedupBatchKeysIdx, eventParams.SourceId, dedupScope, messageId, singularEvent)
 			dedupBatchKeysIdx++
 			jobsWithMetaData = append(jobsWithMetaData, jobWithMetaData{
 				jobID:         batchEvent.JobID,
@@ -1855,6 +1904,7 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 				messageID:     messageId,
 				eventParams:   eventParams,
 				dedupKey:      dedupBatchKey,
+				dedupMode:     dedupMode,
 				requestIP:     requestIP,
 				recievedAt:    receivedAt,
 				parameters:    parameters,
@@ -1887,7 +1937,7 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 		if proc.config.enableDedup {
 			if !allowedBatchKeys[event.dedupKey] {
 				proc.logger.Debugn("Dropping event with duplicate key %s", logger.NewStringField("key", event.dedupKey.Key))
-				sourceDupStats[dupStatKey{sourceID: event.eventParams.SourceId}] += 1
+				sourceDupStats[dupStatKey{sourceID: event.eventParams.SourceId, mode: event.dedupMode}] += 1
 				continue
 			}
 			dedupKeys[event.dedupKey.Key] = struct{}{}
@@ -1973,13 +2023,7 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 				nil,
 			)
 
-			if event.eventParams.IsBot {
-				botStatus := reportingtypes.BotDetectedStatus
-				// TODO: remove the empty check after ingestion service is released with BotAction field
-				if event.eventParams.BotAction == "flag" || event.eventParams.BotAction == "" {
-					botStatus = reportingtypes.BotFlaggedStatus
-				}
-
+			if botStatus := botReportingStatus(event.singularEvent, event.eventParams); botStatus != "" {
 				// Pass nil for countMetadataMap and countMap as we don't want to capture diff metrics for bot enricher
 				proc.updateMetricMaps(
 					nil,
@@ -2001,7 +2045,11 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 		// Event will be dropped if no valid destination is present
 		// if empty destinationID is passed in this fn all the destinations for the source are validated
 		// else only passed destinationID will be validated
-		if !proc.isDestinationAvailable(event.singularEvent, sourceId, event.eventParams.DestinationID) {
+		destinationAvailable, consentDroppedDestinations := proc.checkDestinationAvailability(event.singularEvent, sourceId, event.eventParams.DestinationID)
+		if proc.isReportingEnabled() {
+			updateConsentMetricMaps(consentConnectionDetailsMap, consentStatusDetailsMap, commonMetadataFromSingularEvent, consentDroppedDestinations)
+		}
+		if !destinationAvailable {
 			continue
 		}
 
@@ -2051,6 +2099,8 @@ func (proc *Handle) preprocessStage(partition string, subJobs subJob) (*preTrans
 		statusDetailsMap:             statusDetailsMap,
 		enricherConnectionDetailsMap: enricherConnectionDetailsMap,
 		enricherStatusDetailsMap:     enricherStatusDetailsMap,
+		consentConnectionDetailsMap:  consentConnectionDetailsMap,
+		consentStatusDetailsMap:      consentStatusDetailsMap,
 		reportMetrics:                reportMetrics,
 		destFilterStatusDetailMap:    destFilterStatusDetailMap,
 		inCountMetadataMap:           inCountMetadataMap,
@@ -2168,6 +2218,15 @@ func (proc *Handle) pretransformStage(partition string, preTrans *preTransformat
 			}
 		}
 
+		reportingtypes.AssertSameKeys(preTrans.consentConnectionDetailsMap, preTrans.consentStatusDetailsMap)
+		for k, cd := range preTrans.consentConnectionDetailsMap {
+			preTrans.reportMetrics = append(preTrans.reportMetrics, &reportingtypes.PUReportedMetric{
+				ConnectionDetails: *cd,
+				PUDetails:         *reportingtypes.CreatePUDetails(reportingtypes.GATEWAY, reportingtypes.DESTINATION_FILTER, true, false),
+				StatusDetail:      preTrans.consentStatusDetailsMap[k],
+			})
+		}
+
 		// empty failedCountMap because no failures,
 		// events are just dropped at this point if no destination is found to route the events
 		diffMetrics := getDiffMetrics(
@@ -2235,13 +2294,16 @@ func (proc *Handle) pretransformStage(partition string, preTrans *preTransformat
 				enabledDestinationsList := proc.getConsentFilteredDestinations(
 					singularEvent,
 					sourceId,
-					lo.Filter(proc.getEnabledDestinations(sourceId, *destType), func(item backendconfig.DestinationT, index int) bool {
-						destId := preTrans.jobIDToSpecificDestMapOnly[event.Metadata.JobID]
-						if destId != "" {
-							return destId == item.ID
-						}
-						return destId == ""
-					}),
+					getBotFilteredDestinations(
+						singularEvent,
+						lo.Filter(proc.getEnabledDestinations(sourceId, *destType), func(item backendconfig.DestinationT, index int) bool {
+							destId := preTrans.jobIDToSpecificDestMapOnly[event.Metadata.JobID]
+							if destId != "" {
+								return destId == item.ID
+							}
+							return destId == ""
+						}),
+					),
 				)
 
 				// Adding a singular event multiple times if there are multiple destinations of same type
@@ -3195,6 +3257,12 @@ func (proc *Handle) userTransformAndFilter(
 	if transformAtOverrideFound {
 		transformAt = proc.conf.GetString("Processor."+destination.DestinationDefinition.Name+".transformAt", "processor")
 	}
+	// Redacting PII configured for the connection, before events reach the destination transformer
+	piiRedactor, piiRedactionErr := proc.getPIIRedactor(sourceID, destID)
+	if piiRedactor != nil {
+		proc.redactPII(piiRedactor, sourceID, workspaceID, destination, eventsToTransform)
+	}
+
 	// Filtering events based on the supported message types - START
 	s := time.Now()
 	eventFilterInCount := len(eventsToTransform)
@@ -3214,6 +3282,9 @@ func (proc *Handle) userTransformAndFilter(
 				)
 				return true, "cancelled jobRunId"
 			}
+			if piiRedactionErr != nil {
+				return true, "invalid pii redaction rules"
+			}
 			return false, ""
 		},
 	)
@@ -3787,6 +3858,7 @@ func (proc *Handle) updateSourceStats(sourceStats map[dupStatKey]int, bucket str
 	for dupStat, count := range sourceStats {
 		tags := map[string]string{
 			"source": dupStat.sourceID,
+			"mode":   dupStat.mode,
 		}
 		sourceStatsD := proc.statsFactory.NewTaggedStat(bucket, stats.CountType, tags)
 		sourceStatsD.Count(count)
@@ -3824,34 +3896,51 @@ func (*Handle) getLimiterPriority(partition string) kitsync.LimiterPriorityValue
 //
 // event will be dropped if no destination is found
 func (proc *Handle) isDestinationAvailable(event types.SingularEventT, sourceId, destinationID string) bool {
+	available, _ := proc.checkDestinationAvailability(event, sourceId, destinationID)
+	return available
+}
+
+// checkDestinationAvailability checks if event has eligible destinations to send to, like [Handle.isDestinationAvailable],
+// also returning the destinations the event was dropped for since the user didn't consent to them
+func (proc *Handle) checkDestinationAvailability(event types.SingularEventT, sourceId, destinationID string) (bool, []consentDroppedDestination) {
 	enabledDestTypes := integrations.FilterClientIntegrations(
 		event,
 		proc.getBackendEnabledDestinationTypes(sourceId),
 	)
 	if len(enabledDestTypes) == 0 {
 		proc.logger.Debug("No enabled destination types")
-		return false
+		return false, nil
 	}
 
-	if enabledDestinationsList := lo.Filter(proc.getConsentFilteredDestinations(
+	isRequestedDestination := func(dest backendconfig.DestinationT) bool {
+		return len(destinationID) == 0 || dest.ID == destinationID
+	}
+	consentFilteredDestinations, consentDroppedDestinations := proc.filterDestinationsByConsent(
 		event,
 		sourceId,
-		lo.Flatten(
-			lo.Map(
-				enabledDestTypes,
-				func(destType string, _ int) []backendconfig.DestinationT {
-					return proc.getEnabledDestinations(sourceId, destType)
-				},
+		getBotFilteredDestinations(
+			event,
+			lo.Flatten(
+				lo.Map(
+					enabledDestTypes,
+					func(destType string, _ int) []backendconfig.DestinationT {
+						return proc.getEnabledDestinations(sourceId, destType)
+					},
+				),
 			),
 		),
-	), func(dest backendconfig.DestinationT, index int) bool {
-		return len(destinationID) == 0 || dest.ID == destinationID
+	)
+	consentDroppedDestinations = lo.Filter(consentDroppedDestinations, func(d consentDroppedDestination, _ int) bool {
+		return isRequestedDestination(d.destination)
+	})
+	if enabledDestinationsList := lo.Filter(consentFilteredDestinations, func(dest backendconfig.DestinationT, index int) bool {
+		return isRequestedDestination(dest)
 	}); len(enabledDestinationsList) == 0 {
 		proc.logger.Debug("No destination to route this event to")
-		return false
+		return false, consentDroppedDestinations
 	}
 
-	return true
+	return true, consentDroppedDestinations
 }
 
 // pipelineDelayStats reports the delay of the pipeline as a range:
@@ -2712,6 +2712,72 @@ var _ = Describe("Processor", Ordered, func() {
 			processor.dedup = c.MockDedup
 			handlePendingGatewayJobs(processor)
 		})
+
+		It("should deduplicate copies of the same event addressed to different destinations separately", func() {
+			GinkgoT().Setenv("RUDDER_TMPDIR", GinkgoT().TempDir())
+			event := mockEventData{
+				id:                "some-id",
+				jobid:             1010,
+				originalTimestamp: "2000-01-02T01:23:45",
+				sentAt:            "2000-01-02 01:23",
+				integrations:      map[string]bool{"All": true},
+			}
+			// copies of the same job, as restored from an archive for destinations A and C
+			unprocessedJobsList := lo.Map([]string{DestinationIDEnabledA, DestinationIDEnabledC, DestinationIDEnabledA}, func(destinationID string, i int) *jobsdb.JobT {
+				return &jobsdb.JobT{
+					UUID:         uuid.New(),
+					JobID:        int64(1010 + i),
+					CreatedAt:    time.Date(2020, 0o4, 28, 23, 26, 0o0, 0o0, time.UTC),
+					ExpireAt:     time.Date(2020, 0o4, 28, 23, 26, 0o0, 0o0, time.UTC),
+					CustomVal:    gatewayCustomVal[0],
+					EventPayload: createBatchPayload(WriteKeyEnabled, "2001-01-02T02:23:45.000Z", []mockEventData{event}, createMessagePayloadWithSameMessageId),
+					EventCount:   1,
+					Parameters:   []byte(fmt.Sprintf(`{"source_id":%q,"destination_id":%q}`, SourceIDEnabled, destinationID)),
+				}
+			})
+
+			mockTransformerClients := transformer.NewSimpleClients()
+			mockTransformerClients.WithDynamicDestinationTransform(func(_ context.Context, clientEvents []types.TransformerEvent) types.Response {
+				return types.Response{Events: lo.Map(clientEvents, func(event types.TransformerEvent, _ int) types.TransformerResponse {
+					return types.TransformerResponse{Output: event.Message, Metadata: event.Metadata, StatusCode: 200}
+				})}
+			})
+			c.mockGatewayJobsDB.EXPECT().GetUnprocessed(gomock.Any(), gomock.Any()).Return(jobsdb.JobsResult{Jobs: unprocessedJobsList}, nil).Times(1)
+
+			c.mockRouterJobsDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil).Times(1)
+			var routerJobs []*jobsdb.JobT
+			callStoreRouter := c.mockRouterJobsDB.EXPECT().StoreInTx(gomock.Any(), gomock.Any(), gomock.Any()).Times(1).
+				Do(func(ctx context.Context, tx jobsdb.StoreSafeTx, jobs []*jobsdb.JobT) {
+					routerJobs = jobs
+				})
+
+			c.mockArchivalDB.EXPECT().WithStoreSafeTx(gomock.Any(), gomock.Any()).AnyTimes().Do(func(ctx context.Context, f func(tx jobsdb.StoreSafeTx) error) {
+				_ = f(jobsdb.EmptyStoreSafeTx())
+			}).Return(nil)
+			c.mockArchivalDB.EXPECT().StoreInTx(gomock.Any(), gomock.Any(), gomock.Any()).AnyTimes()
+
+			c.mockGatewayJobsDB.EXPECT().WithUpdateSafeTx(gomock.Any(), gomock.Any()).Do(func(ctx context.Context, f func(tx jobsdb.UpdateSafeTx) error) {
+				_ = f(jobsdb.EmptyUpdateSafeTx())
+			}).Return(nil).Times(1)
+			c.mockGatewayJobsDB.EXPECT().UpdateJobStatusInTx(gomock.Any(), gomock.Any(), gomock.Len(len(unprocessedJobsList)), gatewayCustomVal, nil).Times(1).After(callStoreRouter)
+			processor := prepareHandle(NewHandle(config.Default, mockTransformerClients))
+
+			Setup(processor, c, true, false) // using the default deduplication backend
+			defer processor.dedup.Close()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			Expect(processor.config.asyncInit.WaitContext(ctx)).To(BeNil())
+
+			handlePendingGatewayJobs(processor)
+			// the last copy is a duplicate of the first one
+			Expect(lo.Map(routerJobs, func(job *jobsdb.JobT, _ int) string { return job.CustomVal })).To(ConsistOf(
+				"enabled-destination-a-definition-name",
+				"WEBHOOK",
+			))
+		})
 	})
 
 	Context("transformations", func() {
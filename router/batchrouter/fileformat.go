@@ -0,0 +1,481 @@
+package batchrouter
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/tidwall/gjson"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/common"
+	"github.com/xitongsys/parquet-go/reader"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/warehouse/encoding"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+// File formats supported for object storage uploads, configured through the fileFormat destination config option
+const (
+	fileFormatJSON    = "json"
+	fileFormatParquet = "parquet"
+	fileFormatCSV     = "csv"
+)
+
+// objectStorageFileFormat returns the file format configured for an object storage destination, defaulting to newline-delimited json
+func objectStorageFileFormat(destConfig map[string]interface{}) string {
+	format, _ := destConfig["fileFormat"].(string)
+	switch format := strings.ToLower(format); format {
+	case fileFormatParquet, fileFormatCSV:
+		return format
+	default:
+		return fileFormatJSON
+	}
+}
+
+// fileExtension returns the extension of files written using the given format
+func fileExtension(format string) string {
+	switch format {
+	case fileFormatParquet:
+		return "parquet"
+	case fileFormatCSV:
+		return "csv.gz"
+	default:
+		return "json.gz"
+	}
+}
+
+// fileFormatFromKey returns the file format of an uploaded object based on its extension
+func fileFormatFromKey(key string) string {
+	switch {
+	case strings.HasSuffix(key, "."+fileExtension(fileFormatParquet)):
+		return fileFormatParquet
+	case strings.HasSuffix(key, "."+fileExtension(fileFormatCSV)):
+		return fileFormatCSV
+	default:
+		return fileFormatJSON
+	}
+}
+
+// errInvalidPayload is returned by batch file writers for event payloads which cannot be written using the file's format
+var errInvalidPayload = errors.New("invalid event payload")
+
+// batchFileWriter writes event payloads to a local file before it gets uploaded
+type batchFileWriter interface {
+	// Write writes a single event payload to the file. Payloads that cannot be written using the file's format are rejected with [errInvalidPayload]
+	Write(payload []byte) error
+	// Close flushes any pending data and closes the file
+	Close() error
+}
+
+// newBatchFileWriter creates a new writer for the given file format.
+// Columnar formats need to know all events for inferring their schema, thus events are spooled to a temporary file until the writer is closed.
+func newBatchFileWriter(format, filePath string, encodingFactory *encoding.Factory) (batchFileWriter, error) {
+	switch format {
+	case fileFormatParquet, fileFormatCSV:
+		spoolFile, err := os.Create(filePath + ".rows")
+		if err != nil {
+			return nil, err
+		}
+		return &columnarFileWriter{
+			format:          format,
+			filePath:        filePath,
+			encodingFactory: encodingFactory,
+			spoolFile:       spoolFile,
+			spool:           bufio.NewWriter(spoolFile),
+			columns:         make(map[string]*columnarColumn),
+			parquetNames:    make(map[string]string),
+		}, nil
+	default:
+		gzWriter, err := misc.CreateGZ(filePath)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonFileWriter{gzWriter: gzWriter}, nil
+	}
+}
+
+// jsonFileWriter writes gzipped newline-delimited json files
+type jsonFileWriter struct {
+	gzWriter misc.GZipWriter
+}
+
+func (w *jsonFileWriter) Write(payload []byte) error {
+	return w.gzWriter.WriteGZ(string(payload) + "\n")
+}
+
+func (w *jsonFileWriter) Close() error {
+	return w.gzWriter.CloseGZ()
+}
+
+// columnarFileWriter writes parquet or gzipped csv files, having one column for every top-level property found in the events.
+// Nested objects and arrays are written as json strings.
+// Column types are inferred using the warehouse data types, and parquet files are written using the warehouse parquet load file writer.
+type columnarFileWriter struct {
+	format          string
+	filePath        string
+	encodingFactory *encoding.Factory
+
+	spoolFile *os.File
+	spool     *bufio.Writer // compacted event payloads, one per line
+	rows      int
+
+	columns      map[string]*columnarColumn // keyed by property name
+	parquetNames map[string]string          // property names keyed by the internal name of their parquet column, for detecting collisions
+}
+
+type columnarColumn struct {
+	name     string // name of the column in the file
+	dataType string // warehouse data type of the column, empty if only null values have been seen
+}
+
+// Warehouse data types inferred for columnar files
+const (
+	dataTypeInt     = "int"
+	dataTypeFloat   = "float"
+	dataTypeBoolean = "boolean"
+	dataTypeString  = "string"
+)
+
+func (w *columnarFileWriter) Write(payload []byte) error {
+	var row map[string]interface{}
+	if err := jsonrs.Unmarshal(payload, &row); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidPayload, err)
+	}
+	if row == nil {
+		return fmt.Errorf("%w: not a json object", errInvalidPayload)
+	}
+	// validating new columns before changing any state, so that rejected payloads leave no trace
+	newColumns := make(map[string]string)
+	for property := range row {
+		if _, ok := w.columns[property]; ok || w.format != fileFormatParquet {
+			continue
+		}
+		parquetName := common.StringToVariableName(parquetColumnName(property))
+		if other, ok := w.parquetNames[parquetName]; ok {
+			return fmt.Errorf("%w: property %q collides with property %q in parquet schema", errInvalidPayload, property, other)
+		}
+		if other, ok := newColumns[parquetName]; ok {
+			return fmt.Errorf("%w: property %q collides with property %q in parquet schema", errInvalidPayload, property, other)
+		}
+		newColumns[parquetName] = property
+	}
+	var line bytes.Buffer
+	if err := json.Compact(&line, payload); err != nil {
+		return fmt.Errorf("%w: %w", errInvalidPayload, err)
+	}
+	line.WriteByte('\n')
+	if _, err := w.spool.Write(line.Bytes()); err != nil {
+		return fmt.Errorf("spooling event payload: %w", err)
+	}
+	w.rows++
+
+	for parquetName, property := range newColumns {
+		w.parquetNames[parquetName] = property
+	}
+	for property, value := range row {
+		column, ok := w.columns[property]
+		if !ok {
+			column = &columnarColumn{name: property}
+			if w.format == fileFormatParquet {
+				column.name = parquetColumnName(property)
+			}
+			w.columns[property] = column
+		}
+		column.dataType = mergeDataTypes(column.dataType, inferDataType(value))
+	}
+	return nil
+}
+
+func (w *columnarFileWriter) Close() error {
+	defer func() { _ = os.Remove(w.spoolFile.Name()) }()
+	if err := w.spool.Flush(); err != nil {
+		_ = w.spoolFile.Close()
+		return fmt.Errorf("flushing spool file: %w", err)
+	}
+	if err := w.spoolFile.Close(); err != nil {
+		return fmt.Errorf("closing spool file: %w", err)
+	}
+	if w.rows == 0 {
+		f, err := os.Create(w.filePath)
+		if err != nil {
+			return err
+		}
+		return f.Close()
+	}
+	// ordering properties by column name, the same way the warehouse parquet writer orders its schema
+	properties := lo.Keys(w.columns)
+	slices.SortFunc(properties, func(a, b string) int { return strings.Compare(w.columns[a].name, w.columns[b].name) })
+	if w.format == fileFormatParquet {
+		return w.writeParquet(properties)
+	}
+	return w.writeCSV(properties)
+}
+
+// readSpool calls fn for every spooled event
+func (w *columnarFileWriter) readSpool(fn func(row map[string]interface{}) error) error {
+	f, err := os.Open(w.spoolFile.Name())
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading spool file: %w", err)
+		}
+		var row map[string]interface{}
+		if err := jsonrs.Unmarshal(line, &row); err != nil {
+			return fmt.Errorf("unmarshalling spooled event: %w", err)
+		}
+		if err := fn(row); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *columnarFileWriter) writeCSV(properties []string) error {
+	f, err := os.Create(w.filePath)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	gzWriter := gzip.NewWriter(f)
+	csvWriter := csv.NewWriter(gzWriter)
+	if err := csvWriter.Write(properties); err != nil {
+		return fmt.Errorf("writing csv header: %w", err)
+	}
+	record := make([]string, len(properties))
+	if err := w.readSpool(func(row map[string]interface{}) error {
+		for i, property := range properties {
+			record[i] = csvValue(row[property])
+		}
+		if err := csvWriter.Write(record); err != nil {
+			return fmt.Errorf("writing csv record: %w", err)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("flushing csv writer: %w", err)
+	}
+	if err := gzWriter.Close(); err != nil {
+		return fmt.Errorf("closing gzip writer: %w", err)
+	}
+	return f.Close()
+}
+
+func (w *columnarFileWriter) writeParquet(properties []string) error {
+	schema := make(map[string]string, len(properties))
+	for _, property := range properties {
+		column := w.columns[property]
+		schema[column.name] = lo.Ternary(column.dataType == "", dataTypeString, column.dataType)
+	}
+	loadFileWriter, err := w.encodingFactory.NewLoadFileWriter(warehouseutils.LoadFileTypeParquet, w.filePath, schema, warehouseutils.S3Datalake)
+	if err != nil {
+		return fmt.Errorf("creating parquet writer: %w", err)
+	}
+	if err := w.readSpool(func(row map[string]interface{}) error {
+		loader := w.encodingFactory.NewEventLoader(loadFileWriter, warehouseutils.LoadFileTypeParquet, warehouseutils.S3Datalake)
+		for _, property := range properties {
+			column := w.columns[property]
+			dataType := schema[column.name]
+			loader.AddColumn(column.name, dataType, parquetValue(row[property], dataType))
+		}
+		if err := loader.Write(); err != nil {
+			return fmt.Errorf("writing parquet record: %w", err)
+		}
+		return nil
+	}); err != nil {
+		_ = loadFileWriter.Close()
+		return err
+	}
+	if err := loadFileWriter.Close(); err != nil {
+		return fmt.Errorf("closing parquet writer: %w", err)
+	}
+	return nil
+}
+
+// inferDataType returns the warehouse data type of a json value, or an empty string for null values.
+// Nested objects and arrays are strings.
+func inferDataType(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case bool:
+		return dataTypeBoolean
+	case float64:
+		if v != math.Trunc(v) || math.Abs(v) > math.MaxInt64 {
+			return dataTypeFloat
+		}
+		return dataTypeInt
+	default:
+		return dataTypeString
+	}
+}
+
+// mergeDataTypes returns the data type of a column having values of both data types. Columns with mixed values fall back to strings.
+func mergeDataTypes(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	case (a == dataTypeInt && b == dataTypeFloat) || (a == dataTypeFloat && b == dataTypeInt):
+		return dataTypeFloat
+	default:
+		return dataTypeString
+	}
+}
+
+// parquetValue converts a json value to the go type expected by the warehouse parquet loader for the given data type
+func parquetValue(v interface{}, dataType string) interface{} {
+	if v == nil {
+		return nil
+	}
+	switch dataType {
+	case dataTypeBoolean:
+		return v.(bool)
+	case dataTypeInt:
+		return int(v.(float64))
+	case dataTypeFloat:
+		return v.(float64)
+	default:
+		return csvValue(v)
+	}
+}
+
+func csvValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		b, _ := jsonrs.Marshal(v)
+		return string(b)
+	}
+}
+
+// parquetColumnName replaces characters having a special meaning in parquet schema tags.
+// Different properties can end up having the same parquet column, see [columnarFileWriter.Write].
+func parquetColumnName(column string) string {
+	return strings.NewReplacer(",", "_", "=", "_").Replace(column)
+}
+
+// uploadedMessageIDs reads the messageIds of all events contained in a previously uploaded file
+func uploadedMessageIDs(filePath, format string) ([]string, error) {
+	switch format {
+	case fileFormatParquet:
+		return parquetMessageIDs(filePath)
+	case fileFormatCSV:
+		return csvMessageIDs(filePath)
+	default:
+		return jsonMessageIDs(filePath)
+	}
+}
+
+func jsonMessageIDs(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gzReader.Close() }()
+
+	var messageIDs []string
+	sc := bufio.NewScanner(gzReader)
+	for sc.Scan() {
+		messageIDs = append(messageIDs, gjson.GetBytes(sc.Bytes(), "messageId").String())
+	}
+	return messageIDs, nil
+}
+
+func csvMessageIDs(filePath string) ([]string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	gzReader, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = gzReader.Close() }()
+
+	csvReader := csv.NewReader(gzReader)
+	header, err := csvReader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+	idx := slices.Index(header, "messageId")
+	if idx < 0 {
+		return nil, nil
+	}
+	var messageIDs []string
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			return messageIDs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading csv record: %w", err)
+		}
+		messageIDs = append(messageIDs, record[idx])
+	}
+}
+
+func parquetMessageIDs(filePath string) ([]string, error) {
+	f, err := local.NewLocalFileReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	pr, err := reader.NewParquetReader(f, nil, 1)
+	if err != nil {
+		return nil, fmt.Errorf("creating parquet reader: %w", err)
+	}
+	defer pr.ReadStop()
+
+	path := common.PathToStr([]string{pr.SchemaHandler.GetRootExName(), "messageId"})
+	if _, err := pr.SchemaHandler.ConvertToInPathStr(path); err != nil {
+		return nil, nil // no messageId column
+	}
+	values, _, _, err := pr.ReadColumnByPath(path, pr.GetNumRows())
+	if err != nil {
+		return nil, fmt.Errorf("reading messageId column: %w", err)
+	}
+	return lo.FilterMap(values, func(v interface{}, _ int) (string, bool) {
+		s, ok := v.(string)
+		return s, ok
+	}), nil
+}
@@ -0,0 +1,190 @@
+package batchrouter
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/xitongsys/parquet-go-source/local"
+	"github.com/xitongsys/parquet-go/reader"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/filemanager/mock_filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	"github.com/rudderlabs/rudder-server/warehouse/encoding"
+)
+
+func TestObjectStorageFileFormat(t *testing.T) {
+	require.Equal(t, fileFormatJSON, objectStorageFileFormat(map[string]interface{}{}))
+	require.Equal(t, fileFormatJSON, objectStorageFileFormat(map[string]interface{}{"fileFormat": "unknown"}))
+	require.Equal(t, fileFormatParquet, objectStorageFileFormat(map[string]interface{}{"fileFormat": "PARQUET"}))
+	require.Equal(t, fileFormatCSV, objectStorageFileFormat(map[string]interface{}{"fileFormat": "csv"}))
+
+	for _, format := range []string{fileFormatJSON, fileFormatParquet, fileFormatCSV} {
+		require.Equal(t, format, fileFormatFromKey("rudder-logs/source/2024-01-01/1704067200.source.uuid."+fileExtension(format)))
+	}
+}
+
+func TestBatchFileWriter(t *testing.T) {
+	payloads := [][]byte{
+		[]byte(`{"messageId":"m-1","type":"track","event":"Order Completed","sentAt":"2024-01-01T00:00:00.000Z","properties":{"revenue":10},"count":1,"price":1.5,"enabled":true}`),
+		[]byte(`{"messageId":"m-2","type":"identify","traits":{"name":"John"},"count":2,"price":2,"enabled":false,"mixed":"a"}`),
+		[]byte(`{"messageId":"m-3","type":"page","mixed":1}`),
+	}
+	write := func(t *testing.T, format string) string {
+		filePath := filepath.Join(t.TempDir(), "file."+fileExtension(format))
+		w, err := newBatchFileWriter(format, filePath, encoding.NewFactory(config.New()))
+		require.NoError(t, err)
+		for _, payload := range payloads {
+			require.NoError(t, w.Write(payload))
+		}
+		require.NoError(t, w.Close())
+		return filePath
+	}
+
+	t.Run("json", func(t *testing.T) {
+		filePath := write(t, fileFormatJSON)
+		messageIDs, err := uploadedMessageIDs(filePath, fileFormatJSON)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m-1", "m-2", "m-3"}, messageIDs)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		filePath := write(t, fileFormatCSV)
+
+		f, err := os.Open(filePath)
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+		gzReader, err := gzip.NewReader(f)
+		require.NoError(t, err)
+		records, err := csv.NewReader(gzReader).ReadAll()
+		require.NoError(t, err)
+		require.Equal(t, [][]string{
+			{"count", "enabled", "event", "messageId", "mixed", "price", "properties", "sentAt", "traits", "type"},
+			{"1", "true", "Order Completed", "m-1", "", "1.5", `{"revenue":10}`, "2024-01-01T00:00:00.000Z", "", "track"},
+			{"2", "false", "", "m-2", "a", "2", "", "", `{"name":"John"}`, "identify"},
+			{"", "", "", "m-3", "1", "", "", "", "", "page"},
+		}, records)
+
+		messageIDs, err := uploadedMessageIDs(filePath, fileFormatCSV)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m-1", "m-2", "m-3"}, messageIDs)
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		filePath := write(t, fileFormatParquet)
+
+		f, err := local.NewLocalFileReader(filePath)
+		require.NoError(t, err)
+		defer func() { _ = f.Close() }()
+		pr, err := reader.NewParquetReader(f, nil, 1)
+		require.NoError(t, err)
+		defer pr.ReadStop()
+		require.EqualValues(t, 3, pr.GetNumRows())
+
+		columnTypes := make(map[string]string)
+		for i, element := range pr.SchemaHandler.SchemaElements[1:] {
+			columnTypes[pr.SchemaHandler.Infos[i+1].ExName] = element.GetType().String()
+		}
+		require.Equal(t, map[string]string{
+			"count":      "INT64",
+			"enabled":    "BOOLEAN",
+			"event":      "BYTE_ARRAY",
+			"messageId":  "BYTE_ARRAY",
+			"mixed":      "BYTE_ARRAY",
+			"price":      "DOUBLE",
+			"properties": "BYTE_ARRAY",
+			"sentAt":     "BYTE_ARRAY",
+			"traits":     "BYTE_ARRAY",
+			"type":       "BYTE_ARRAY",
+		}, columnTypes)
+
+		messageIDs, err := uploadedMessageIDs(filePath, fileFormatParquet)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m-1", "m-2", "m-3"}, messageIDs)
+	})
+
+	t.Run("invalid payloads are rejected by columnar formats", func(t *testing.T) {
+		for _, format := range []string{fileFormatParquet, fileFormatCSV} {
+			filePath := filepath.Join(t.TempDir(), "file."+fileExtension(format))
+			w, err := newBatchFileWriter(format, filePath, encoding.NewFactory(config.New()))
+			require.NoError(t, err)
+			for _, payload := range []string{`not json`, `[1,2]`, `null`, `"string"`} {
+				require.ErrorIs(t, w.Write([]byte(payload)), errInvalidPayload, payload)
+			}
+			require.NoError(t, w.Write(payloads[0]))
+			require.NoError(t, w.Close())
+			messageIDs, err := uploadedMessageIDs(filePath, format)
+			require.NoError(t, err)
+			require.Equal(t, []string{"m-1"}, messageIDs)
+			require.NoFileExists(t, filePath+".rows", "spool file should be removed")
+		}
+	})
+
+	t.Run("colliding parquet columns are rejected", func(t *testing.T) {
+		filePath := filepath.Join(t.TempDir(), "file.parquet")
+		w, err := newBatchFileWriter(fileFormatParquet, filePath, encoding.NewFactory(config.New()))
+		require.NoError(t, err)
+		require.NoError(t, w.Write([]byte(`{"messageId":"m-1","a,b":1}`)))
+		require.ErrorIs(t, w.Write([]byte(`{"messageId":"m-2","a_b":2}`)), errInvalidPayload, "a,b and a_b have the same column name")
+		require.ErrorIs(t, w.Write([]byte(`{"messageId":"m-3","MessageId":"x"}`)), errInvalidPayload, "messageId and MessageId have the same internal name")
+		require.ErrorIs(t, w.Write([]byte(`{"messageId":"m-4","c-d":1,"c45d":2}`)), errInvalidPayload, "c-d and c45d have the same internal name")
+		require.NoError(t, w.Write([]byte(`{"messageId":"m-5","a,b":3}`)))
+		require.NoError(t, w.Close())
+
+		messageIDs, err := uploadedMessageIDs(filePath, fileFormatParquet)
+		require.NoError(t, err)
+		require.Equal(t, []string{"m-1", "m-5"}, messageIDs, "rejected payloads shouldn't be written")
+	})
+}
+
+func TestUploadRejectedJobs(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockFileManager := mock_filemanager.NewMockFileManager(mockCtrl)
+	mockFileManager.EXPECT().
+		Upload(gomock.Any(), gomock.Any(), "rudder-logs", "source-1", "dt=2024-01-01").
+		Return(filemanager.UploadedFile{Location: "local", ObjectName: "file"}, nil)
+	jobsDB := mocksJobsDB.NewMockJobsDB(mockCtrl)
+	jobsDB.EXPECT().JournalMarkStart(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+
+	c := config.New()
+	brt := &Handle{
+		logger:             logger.NOP,
+		fileManagerFactory: func(*filemanager.Settings) (filemanager.FileManager, error) { return mockFileManager, nil },
+		encodingFactory:    encoding.NewFactory(c),
+		datePrefixOverride: config.GetReloadableStringVar("", "BatchRouter.datePrefixOverride"),
+		customDatePrefix:   config.GetReloadableStringVar("", "BatchRouter.customDatePrefix"),
+		dateFormatProvider: &storageDateFormatProvider{dateFormatsCache: make(map[string]string)},
+		conf:               c,
+		now:                timeutil.Now,
+		jobsDB:             jobsDB,
+	}
+	result := brt.upload("S3", &BatchedJobs{
+		Jobs: []*jobsdb.JobT{
+			{JobID: 1, EventPayload: []byte(`{"messageId":"m-1","receivedAt":"2024-01-01T05:10:00Z"}`)},
+			{JobID: 2, EventPayload: []byte(`["not","an","object"]`)},
+		},
+		Connection: &Connection{
+			Source:      backendconfig.SourceT{ID: "source-1"},
+			Destination: backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{"fileFormat": fileFormatParquet}},
+		},
+		PartitionPath: "dt=2024-01-01",
+	}, false)
+	defer misc.RemoveFilePaths(result.LocalFilePaths...)
+	require.NoError(t, result.Error)
+	require.Equal(t, 1, result.TotalEvents)
+	require.NotNil(t, result.Rejected)
+	require.Len(t, result.Rejected.Jobs, 1)
+	require.EqualValues(t, 2, result.Rejected.Jobs[0].JobID)
+	require.ErrorIs(t, result.Rejected.Error, errInvalidPayload)
+}
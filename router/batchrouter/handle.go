@@ -46,6 +46,7 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/types"
 	"github.com/rudderlabs/rudder-server/utils/workerpool"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	"github.com/rudderlabs/rudder-server/warehouse/encoding"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
@@ -63,6 +64,7 @@ type Handle struct {
 	reporting             types.Reporting
 	backendConfig         backendconfig.BackendConfig
 	fileManagerFactory    filemanager.Factory
+	encodingFactory       *encoding.Factory
 	transientSources      transientsource.Service
 	rsourcesService       rsources.JobService
 	warehouseClient       *client.Warehouse
@@ -255,22 +257,27 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 	if err != nil {
 		panic(err)
 	}
-	gzipFilePath := filepath.Join(
+	fileFormat := fileFormatJSON // warehouse staging files are always newline-delimited json
+	if !isWarehouse {
+		fileFormat = objectStorageFileFormat(batchJobs.Connection.Destination.Config)
+	}
+	localFilePath := filepath.Join(
 		tmpDirPath,
 		localTmpDirName,
 		fmt.Sprintf(
-			"%v.%v.%v.json.gz",
+			"%v.%v.%v.%v",
 			time.Now().Unix(),
 			batchJobs.Connection.Source.ID,
 			uuid,
+			fileExtension(fileFormat),
 		),
 	)
 
-	err = os.MkdirAll(filepath.Dir(gzipFilePath), os.ModePerm)
+	err = os.MkdirAll(filepath.Dir(localFilePath), os.ModePerm)
 	if err != nil {
 		panic(err)
 	}
-	gzWriter, err := misc.CreateGZ(gzipFilePath)
+	fileWriter, err := newBatchFileWriter(fileFormat, localFilePath, brt.encodingFactory)
 	if err != nil {
 		panic(err)
 	}
@@ -283,6 +290,10 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 	brt.configSubscriberMu.RUnlock()
 	var totalBytes int
 	bytesPerTable := make(map[string]int64)
+	var (
+		rejectedJobs []*jobsdb.JobT // jobs whose payloads couldn't be written using the file format
+		rejectedErrs []error
+	)
 
 	for _, job := range batchJobs.Jobs {
 		// do not add to staging file if the event is a rudder_identity_merge_rules record
@@ -308,32 +319,49 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		var ok bool
 		interruptedEventsMap, isDestInterrupted := brt.uploadedRawDataJobsCache[batchJobs.Connection.Destination.ID]
 		if isDestInterrupted {
-			if _, ok = interruptedEventsMap[eventID]; !ok {
-				eventsFound = true
-				line := string(job.EventPayload) + "\n"
-				totalBytes += len(line)
-				if isWarehouse {
-					tableName := gjson.GetBytes(job.EventPayload, "metadata.table").String()
-					bytesPerTable[tableName] += int64(len(line))
-				}
-				_ = gzWriter.WriteGZ(line)
+			if _, ok = interruptedEventsMap[eventID]; ok {
+				continue
 			}
-		} else {
-			eventsFound = true
-			line := string(job.EventPayload) + "\n"
-			totalBytes += len(line)
-			if isWarehouse {
-				tableName := gjson.GetBytes(job.EventPayload, "metadata.table").String()
-				bytesPerTable[tableName] += int64(len(line))
+		}
+		if err := fileWriter.Write(job.EventPayload); err != nil {
+			if errors.Is(err, errInvalidPayload) {
+				rejectedJobs = append(rejectedJobs, job)
+				rejectedErrs = append(rejectedErrs, err)
+				continue
 			}
-			_ = gzWriter.WriteGZ(line)
+			_ = fileWriter.Close()
+			brt.logger.Errorf("BRT: Error writing %s file for upload to %s: %v", fileFormat, provider, err)
+			return UploadResult{
+				Error:          err,
+				LocalFilePaths: []string{localFilePath},
+			}
+		}
+		eventsFound = true
+		lineBytes := len(job.EventPayload) + 1
+		totalBytes += lineBytes
+		if isWarehouse {
+			tableName := gjson.GetBytes(job.EventPayload, "metadata.table").String()
+			bytesPerTable[tableName] += int64(lineBytes)
+		}
+	}
+	var rejected *RejectedJobs
+	if len(rejectedJobs) > 0 {
+		brt.logger.Warnf("BRT: %d events could not be written to %s file for upload to %s", len(rejectedJobs), fileFormat, provider)
+		rejected = &RejectedJobs{Jobs: rejectedJobs, Error: fmt.Errorf("writing %s file: %w", fileFormat, errors.Join(rejectedErrs...))}
+	}
+	if err := fileWriter.Close(); err != nil {
+		brt.logger.Errorf("BRT: Error writing %s file for upload to %s: %v", fileFormat, provider, err)
+		return UploadResult{
+			Error:          err,
+			LocalFilePaths: []string{localFilePath},
+			Rejected:       rejected,
 		}
 	}
-	_ = gzWriter.CloseGZ()
 	if !eventsFound {
 		brt.logger.Infof("BRT: No events in this batch for upload to %s. Events are either de-deuplicated or skipped", provider)
 		return UploadResult{
-			LocalFilePaths: []string{gzipFilePath},
+			LocalFilePaths: []string{localFilePath},
+			Rejected:       rejected,
 		}
 	}
 	// assumes events from warehouse have receivedAt in metadata
@@ -361,7 +389,7 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		lastEventAt = gjson.GetBytes(batchJobs.Jobs[len(batchJobs.Jobs)-1].EventPayload, "receivedAt").String()
 	}
 
-	brt.logger.Debugf("BRT: Logged to local file: %v", gzipFilePath)
+	brt.logger.Debugf("BRT: Logged to local file: %v", localFilePath)
 	useRudderStorage := isWarehouse && misc.IsConfiguredToUseRudderObjectStorage(batchJobs.Connection.Destination.Config)
 	uploader, err := brt.fileManagerFactory(&filemanager.Settings{
 		Provider: provider,
@@ -376,11 +404,12 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 	if err != nil {
 		return UploadResult{
 			Error:          err,
-			LocalFilePaths: []string{gzipFilePath},
+			LocalFilePaths: []string{localFilePath},
+			Rejected:       rejected,
 		}
 	}
 
-	outputFile, err := os.Open(gzipFilePath)
+	outputFile, err := os.Open(localFilePath)
 	if err != nil {
 		panic(err)
 	}
@@ -393,42 +422,15 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		folderName = config.GetString("DESTINATION_BUCKET_FOLDER_NAME", "rudder-logs")
 	}
 
-	var datePrefixLayout string
-	if brt.datePrefixOverride.Load() != "" {
-		datePrefixLayout = brt.datePrefixOverride.Load()
+	keyPrefixes := []string{folderName, batchJobs.Connection.Source.ID}
+	if batchJobs.PartitionPath != "" {
+		// partitioned layouts replace the date folder
+		keyPrefixes = append(keyPrefixes, batchJobs.PartitionPath)
 	} else {
-		dateFormat, _ := brt.dateFormatProvider.GetFormat(brt.logger, uploader, batchJobs.Connection, folderName)
-		datePrefixLayout = dateFormat
-	}
-
-	workspaceID := batchJobs.Connection.Destination.WorkspaceID
-	customTimezone := brt.conf.GetString("BatchRouter.customTimezone."+workspaceID, "")
-
-	now := brt.now()
-	if customTimezone != "" {
-		loc, err := time.LoadLocation(customTimezone)
-		if err != nil {
-			brt.logger.Errorn(
-				"Error loading custom timezone",
-				obskit.Error(err),
-				obskit.WorkspaceID(workspaceID),
-				logger.NewStringField("customTimezone", customTimezone),
-			)
-		}
-		now = now.In(loc)
-	}
-
-	brt.logger.Debugf("BRT: Date prefix layout is %s", datePrefixLayout)
-	switch datePrefixLayout {
-	case "MM-DD-YYYY": // used to be earlier default
-		datePrefixLayout = now.Format("01-02-2006")
-	default:
-		datePrefixLayout = now.Format("2006-01-02")
+		keyPrefixes = append(keyPrefixes, brt.customDatePrefix.Load()+brt.datePrefix(uploader, batchJobs.Connection, folderName))
 	}
 
-	keyPrefixes := []string{folderName, batchJobs.Connection.Source.ID, brt.customDatePrefix.Load() + datePrefixLayout}
-
-	_, fileName := filepath.Split(gzipFilePath)
+	_, fileName := filepath.Split(localFilePath)
 	var (
 		opID      int64
 		opPayload stdjson.RawMessage
@@ -462,7 +464,8 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		return UploadResult{
 			Error:          err,
 			JournalOpID:    opID,
-			LocalFilePaths: []string{gzipFilePath},
+			LocalFilePaths: []string{localFilePath},
+			Rejected:       rejected,
 		}
 	}
 
@@ -470,17 +473,73 @@ func (brt *Handle) upload(provider string, batchJobs *BatchedJobs, isWarehouse b
 		Config:           batchJobs.Connection.Destination.Config,
 		Key:              uploadOutput.ObjectName,
 		FileLocation:     uploadOutput.Location,
-		LocalFilePaths:   []string{gzipFilePath},
+		LocalFilePaths:   []string{localFilePath},
 		JournalOpID:      opID,
 		FirstEventAt:     firstEventAt,
 		LastEventAt:      lastEventAt,
-		TotalEvents:      len(batchJobs.Jobs) - dedupedIDMergeRuleJobs,
+		TotalEvents:      len(batchJobs.Jobs) - dedupedIDMergeRuleJobs - len(rejectedJobs),
 		TotalBytes:       totalBytes,
 		BytesPerTable:    bytesPerTable,
 		UseRudderStorage: useRudderStorage,
+		Rejected:         rejected,
 	}
 }
 
+// abortRejectedJobs aborts the jobs which were left out of an upload file and removes them from the batch,
+// so that the status of the remaining jobs can be updated according to the upload's outcome
+func (brt *Handle) abortRejectedJobs(batchJobs *BatchedJobs, output UploadResult, isWarehouse bool) {
+	if output.Rejected == nil {
+		return
+	}
+	brt.updateJobStatus(&BatchedJobs{
+		Jobs:       output.Rejected.Jobs,
+		Connection: batchJobs.Connection,
+		JobState:   jobsdb.Aborted.State,
+	}, isWarehouse, output.Rejected.Error, false)
+	rejected := lo.SliceToMap(output.Rejected.Jobs, func(job *jobsdb.JobT) (int64, struct{}) { return job.JobID, struct{}{} })
+	batchJobs.Jobs = lo.Filter(batchJobs.Jobs, func(job *jobsdb.JobT, _ int) bool {
+		_, ok := rejected[job.JobID]
+		return !ok
+	})
+}
+
+// datePrefix returns the date folder of object storage uploads, formatted according to the date format already in use by the destination
+func (brt *Handle) datePrefix(uploader filemanager.FileManager, connection *Connection, folderName string) string {
+	var datePrefixLayout string
+	if brt.datePrefixOverride.Load() != "" {
+		datePrefixLayout = brt.datePrefixOverride.Load()
+	} else {
+		dateFormat, _ := brt.dateFormatProvider.GetFormat(brt.logger, uploader, connection, folderName)
+		datePrefixLayout = dateFormat
+	}
+
+	workspaceID := connection.Destination.WorkspaceID
+	customTimezone := brt.conf.GetString("BatchRouter.customTimezone."+workspaceID, "")
+
+	now := brt.now()
+	if customTimezone != "" {
+		loc, err := time.LoadLocation(customTimezone)
+		if err != nil {
+			brt.logger.Errorn(
+				"Error loading custom timezone",
+				obskit.Error(err),
+				obskit.WorkspaceID(workspaceID),
+				logger.NewStringField("customTimezone", customTimezone),
+			)
+		}
+		now = now.In(loc)
+	}
+
+	brt.logger.Debugf("BRT: Date prefix layout is %s", datePrefixLayout)
+	switch datePrefixLayout {
+	case "MM-DD-YYYY": // used to be earlier default
+		datePrefixLayout = now.Format("01-02-2006")
+	default:
+		datePrefixLayout = now.Format("2006-01-02")
+	}
+	return datePrefixLayout
+}
+
 // pingWarehouse notifies the warehouse about a new data upload (staging files)
 func (brt *Handle) pingWarehouse(batchJobs *BatchedJobs, output UploadResult) (err error) {
 	schemaMap := brt.generateSchemaMap(batchJobs)
@@ -875,40 +934,6 @@ func (brt *Handle) skipFetchingJobs(partition string) bool {
 	return false
 }
 
-// splitBatchJobsOnTimeWindow splits the batchJobs based on a timeWindow if the destination requires so, otherwise a single entry is returned using the zero value as key
-func (brt *Handle) splitBatchJobsOnTimeWindow(batchJobs BatchedJobs) map[time.Time]*BatchedJobs {
-	splitBatches := map[time.Time]*BatchedJobs{}
-	if !slices.Contains(warehouseutils.TimeWindowDestinations, brt.destType) {
-		// return only one batchJob if the destination type is not time window destinations
-		splitBatches[time.Time{}] = &batchJobs
-		return splitBatches
-	}
-
-	// split batchJobs based on timeWindow
-	for _, job := range batchJobs.Jobs {
-		// ignore error as receivedAt will always be in the expected format
-		receivedAtStr := gjson.Get(string(job.EventPayload), "metadata.receivedAt").String()
-		receivedAt, err := time.Parse(time.RFC3339, receivedAtStr)
-		if err != nil {
-			brt.logger.Errorf("Invalid value '%s' for receivedAt : %v ", receivedAtStr, err)
-			panic(err)
-		}
-		timeWindow := warehouseutils.GetTimeWindow(receivedAt)
-
-		// create batchJob for timeWindow if it does not exist
-		if _, ok := splitBatches[timeWindow]; !ok {
-			splitBatches[timeWindow] = &BatchedJobs{
-				Jobs:       make([]*jobsdb.JobT, 0),
-				Connection: batchJobs.Connection,
-				TimeWindow: timeWindow,
-			}
-		}
-
-		splitBatches[timeWindow].Jobs = append(splitBatches[timeWindow].Jobs, job)
-	}
-	return splitBatches
-}
-
 func (brt *Handle) retryLimitReached(status *jobsdb.JobStatusT) bool {
 	firstAttemptedAtTime := getFirstAttemptAtFromErrorResponse(status.ErrorResponse)
 
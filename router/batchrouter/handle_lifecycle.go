@@ -1,8 +1,6 @@
 package batchrouter
 
 import (
-	"bufio"
-	"compress/gzip"
 	"context"
 	"fmt"
 	"net/http"
@@ -16,7 +14,6 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/google/uuid"
-	"github.com/tidwall/gjson"
 
 	"github.com/rudderlabs/rudder-go-kit/bytesize"
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -41,6 +38,7 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/misc"
 	"github.com/rudderlabs/rudder-server/utils/types"
 	"github.com/rudderlabs/rudder-server/warehouse/client"
+	"github.com/rudderlabs/rudder-server/warehouse/encoding"
 	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
 )
 
@@ -96,6 +94,7 @@ func (brt *Handle) Setup(
 		panic(fmt.Errorf("resolving isolation strategy for mode %q: %w", isolationMode, err))
 	}
 	brt.conf = conf
+	brt.encodingFactory = encoding.NewFactory(conf)
 	brt.maxEventsInABatch = config.GetIntVar(10000, 1, "BatchRouter."+brt.destType+"."+"maxEventsInABatch", "BatchRouter.maxEventsInABatch")
 	brt.maxPayloadSizeInBytes = config.GetIntVar(10000, 1, "BatchRouter."+brt.destType+"."+"maxPayloadSizeInBytes", "BatchRouter.maxPayloadSizeInBytes")
 	brt.reportingEnabled = config.GetBoolVar(types.DefaultReportingEnabled, "Reporting.enabled")
@@ -346,27 +345,18 @@ func (brt *Handle) crashRecover() {
 
 			_ = jsonFile.Close()
 			defer func() { _ = os.Remove(jsonPath) }()
-			rawf, err := os.Open(jsonPath)
-			if err != nil {
-				panic(err)
-			}
-			reader, err := gzip.NewReader(rawf)
+
+			brt.logger.Debug("BRT: Setting go map cache for incomplete journal entry to recover from...")
+			messageIDs, err := uploadedMessageIDs(jsonPath, fileFormatFromKey(object.Key))
 			if err != nil {
 				panic(err)
 			}
-
-			sc := bufio.NewScanner(reader)
-
-			brt.logger.Debug("BRT: Setting go map cache for incomplete journal entry to recover from...")
-			for sc.Scan() {
-				lineBytes := sc.Bytes()
-				eventID := gjson.GetBytes(lineBytes, "messageId").String()
+			for _, eventID := range messageIDs {
 				if _, ok := brt.uploadedRawDataJobsCache[object.DestinationID]; !ok {
 					brt.uploadedRawDataJobsCache[object.DestinationID] = make(map[string]bool)
 				}
 				brt.uploadedRawDataJobsCache[object.DestinationID][eventID] = true
 			}
-			_ = reader.Close()
 			brt.jobsDB.JournalDeleteEntry(entry.OpID)
 		}
 	}
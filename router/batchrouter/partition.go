@@ -0,0 +1,183 @@
+package batchrouter
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+const (
+	// partitionKeyDate is the name of the partition key holding the date of the event, formatted as YYYY-MM-DD
+	partitionKeyDate = "dt"
+	// partitionKeyHour is the name of the partition key holding the hour of the event, formatted as HH
+	partitionKeyHour = "hr"
+	// defaultTimePartitionPath is the json path used by time partition keys, unless a different one is provided
+	defaultTimePartitionPath = "receivedAt"
+	// hiveDefaultPartition is the value used by hive for null or empty partition values
+	hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+)
+
+// partitionKey is a single key of a hive-style partitioned layout, e.g. event_type=track
+type partitionKey struct {
+	name string // name of the partition, used as the folder's prefix
+	path string // json path of the event property holding the partition's value
+}
+
+// batchPartition identifies a subset of a batch that needs to be uploaded separately
+type batchPartition struct {
+	timeWindow time.Time
+	path       string
+}
+
+// parsePartitionKeys parses partition keys having either the form name=path or just path, in which case the path is also used as the name.
+// The dt and hr keys are time partitions, using the event's receivedAt unless a different path is provided.
+func parsePartitionKeys(keys []string) ([]partitionKey, error) {
+	partitionKeys := make([]partitionKey, 0, len(keys))
+	for _, key := range keys {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		name, path, found := strings.Cut(key, "=")
+		name, path = strings.TrimSpace(name), strings.TrimSpace(path)
+		if !found {
+			path = name
+			if name == partitionKeyDate || name == partitionKeyHour {
+				path = defaultTimePartitionPath
+			}
+		}
+		if name == "" || path == "" {
+			return nil, fmt.Errorf("invalid partition key %q", key)
+		}
+		if slices.ContainsFunc(partitionKeys, func(pk partitionKey) bool { return pk.name == name }) {
+			return nil, fmt.Errorf("duplicate partition key %q", name)
+		}
+		partitionKeys = append(partitionKeys, partitionKey{name: name, path: path})
+	}
+	return partitionKeys, nil
+}
+
+// partitionKeys returns the partition keys configured for an object storage destination.
+// Keys are read from the partitionKeys destination config option, falling back to BatchRouter.<destType>.<destID>.partitionKeys and BatchRouter.<destType>.partitionKeys.
+func (brt *Handle) partitionKeys(destination backendconfig.DestinationT) []partitionKey {
+	var keys []string
+	switch v := destination.Config["partitionKeys"].(type) {
+	case string:
+		keys = strings.Split(v, ",")
+	case []interface{}:
+		for _, key := range v {
+			if s, ok := key.(string); ok {
+				keys = append(keys, s)
+			}
+		}
+	default:
+		keys = brt.conf.GetStringSliceVar(nil, "BatchRouter."+brt.destType+"."+destination.ID+".partitionKeys", "BatchRouter."+brt.destType+".partitionKeys")
+	}
+	partitionKeys, err := parsePartitionKeys(keys)
+	if err != nil {
+		brt.logger.Errorf("BRT: Ignoring partition keys of destination %s: %v", destination.ID, err)
+		return nil
+	}
+	return partitionKeys
+}
+
+// partitionPath returns the hive-style path of the partition an event belongs to, e.g. event_type=track/dt=2024-01-01/hr=05
+func partitionPath(partitionKeys []partitionKey, payload []byte, loc *time.Location) string {
+	segments := make([]string, len(partitionKeys))
+	for i, pk := range partitionKeys {
+		value := gjson.GetBytes(payload, pk.path).String()
+		if value != "" && (pk.name == partitionKeyDate || pk.name == partitionKeyHour) {
+			if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+				layout := "2006-01-02"
+				if pk.name == partitionKeyHour {
+					layout = "15"
+				}
+				value = t.In(loc).Format(layout)
+			}
+		}
+		segments[i] = pk.name + "=" + escapePartitionValue(value)
+	}
+	return strings.Join(segments, "/")
+}
+
+// escapePartitionValue escapes characters not allowed in hive partition values, using the same percent-encoding as hive
+func escapePartitionValue(value string) string {
+	if value == "" {
+		return hiveDefaultPartition
+	}
+	var sb strings.Builder
+	for _, c := range []byte(value) {
+		if c < 0x20 || c == 0x7F || strings.IndexByte("\"#%'*/:=?\\{[]^", c) >= 0 {
+			fmt.Fprintf(&sb, "%%%02X", c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// splitBatchJobs splits the batchJobs into the partitions they need to be uploaded to.
+// Warehouse destinations requiring so are split based on time windows, whereas object storage destinations are split based on their configured partition keys.
+// If no split is required, a single batch is returned having a zero value partition.
+func (brt *Handle) splitBatchJobs(batchJobs BatchedJobs) []*BatchedJobs {
+	switch {
+	case slices.Contains(warehouseutils.TimeWindowDestinations, brt.destType):
+		return splitBatchJobsBy(batchJobs, func(job *jobsdb.JobT) batchPartition {
+			// ignore error as receivedAt will always be in the expected format
+			receivedAtStr := gjson.GetBytes(job.EventPayload, "metadata.receivedAt").String()
+			receivedAt, err := time.Parse(time.RFC3339, receivedAtStr)
+			if err != nil {
+				brt.logger.Errorf("Invalid value '%s' for receivedAt : %v ", receivedAtStr, err)
+				panic(err)
+			}
+			return batchPartition{timeWindow: warehouseutils.GetTimeWindow(receivedAt)}
+		})
+	case IsObjectStorageDestination(brt.destType):
+		partitionKeys := brt.partitionKeys(batchJobs.Connection.Destination)
+		if len(partitionKeys) == 0 {
+			break
+		}
+		loc := time.UTC
+		workspaceID := batchJobs.Connection.Destination.WorkspaceID
+		if customTimezone := brt.conf.GetString("BatchRouter.customTimezone."+workspaceID, ""); customTimezone != "" {
+			if l, err := time.LoadLocation(customTimezone); err == nil {
+				loc = l
+			}
+		}
+		return splitBatchJobsBy(batchJobs, func(job *jobsdb.JobT) batchPartition {
+			return batchPartition{path: partitionPath(partitionKeys, job.EventPayload, loc)}
+		})
+	}
+	return []*BatchedJobs{&batchJobs}
+}
+
+// splitBatchJobsBy splits the batchJobs based on the partition returned by partitionOf for every job.
+// Batches are returned in the order their first job was encountered and jobs retain their relative order within every batch.
+func splitBatchJobsBy(batchJobs BatchedJobs, partitionOf func(job *jobsdb.JobT) batchPartition) []*BatchedJobs {
+	var splitBatches []*BatchedJobs
+	batchesByPartition := map[batchPartition]*BatchedJobs{}
+	for _, job := range batchJobs.Jobs {
+		partition := partitionOf(job)
+		batch, ok := batchesByPartition[partition]
+		if !ok {
+			batch = &BatchedJobs{
+				Jobs:          make([]*jobsdb.JobT, 0),
+				Connection:    batchJobs.Connection,
+				TimeWindow:    partition.timeWindow,
+				PartitionPath: partition.path,
+				JobState:      batchJobs.JobState,
+			}
+			batchesByPartition[partition] = batch
+			splitBatches = append(splitBatches, batch)
+		}
+		batch.Jobs = append(batch.Jobs, job)
+	}
+	return splitBatches
+}
@@ -0,0 +1,172 @@
+package batchrouter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/filemanager"
+	"github.com/rudderlabs/rudder-go-kit/filemanager/mock_filemanager"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/jobsdb"
+	mocksJobsDB "github.com/rudderlabs/rudder-server/mocks/jobsdb"
+	"github.com/rudderlabs/rudder-server/utils/timeutil"
+	warehouseutils "github.com/rudderlabs/rudder-server/warehouse/utils"
+)
+
+func TestParsePartitionKeys(t *testing.T) {
+	t.Run("valid keys", func(t *testing.T) {
+		keys, err := parsePartitionKeys([]string{"event_type=type", " event ", "country=context.location.country", "dt", "hr=timestamp", ""})
+		require.NoError(t, err)
+		require.Equal(t, []partitionKey{
+			{name: "event_type", path: "type"},
+			{name: "event", path: "event"},
+			{name: "country", path: "context.location.country"},
+			{name: "dt", path: "receivedAt"},
+			{name: "hr", path: "timestamp"},
+		}, keys)
+	})
+
+	t.Run("invalid keys", func(t *testing.T) {
+		_, err := parsePartitionKeys([]string{"event_type="})
+		require.Error(t, err)
+		_, err = parsePartitionKeys([]string{"=type"})
+		require.Error(t, err)
+		_, err = parsePartitionKeys([]string{"event", "event=properties.event"})
+		require.Error(t, err)
+	})
+}
+
+func TestPartitionPath(t *testing.T) {
+	keys, err := parsePartitionKeys([]string{"event_type=type", "event", "plan=properties.plan", "dt", "hr"})
+	require.NoError(t, err)
+	payload := []byte(`{"type":"track","event":"Order Completed/Refunded","properties":{"plan":null},"receivedAt":"2024-01-01T23:30:00.123Z"}`)
+
+	require.Equal(t,
+		"event_type=track/event=Order Completed%2FRefunded/plan=__HIVE_DEFAULT_PARTITION__/dt=2024-01-01/hr=23",
+		partitionPath(keys, payload, time.UTC),
+	)
+
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	require.Equal(t,
+		"event_type=track/event=Order Completed%2FRefunded/plan=__HIVE_DEFAULT_PARTITION__/dt=2024-01-02/hr=05",
+		partitionPath(keys, payload, loc),
+		"time partitions should honour the provided location",
+	)
+}
+
+func TestSplitBatchJobs(t *testing.T) {
+	newJob := func(id int64, payload string) *jobsdb.JobT {
+		return &jobsdb.JobT{JobID: id, EventPayload: []byte(payload)}
+	}
+	jobIDs := func(batch *BatchedJobs) []int64 {
+		return lo.Map(batch.Jobs, func(job *jobsdb.JobT, _ int) int64 { return job.JobID })
+	}
+
+	t.Run("object storage without partition keys", func(t *testing.T) {
+		brt := &Handle{destType: "S3", conf: config.New(), logger: logger.NOP}
+		batchJobs := BatchedJobs{
+			Jobs:       []*jobsdb.JobT{newJob(1, `{"type":"track"}`), newJob(2, `{"type":"identify"}`)},
+			Connection: &Connection{Destination: backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{}}},
+		}
+		splitBatches := brt.splitBatchJobs(batchJobs)
+		require.Len(t, splitBatches, 1)
+		require.Empty(t, splitBatches[0].PartitionPath)
+		require.Equal(t, []int64{1, 2}, jobIDs(splitBatches[0]))
+	})
+
+	t.Run("object storage with partition keys in destination config", func(t *testing.T) {
+		brt := &Handle{destType: "S3", conf: config.New(), logger: logger.NOP}
+		batchJobs := BatchedJobs{
+			Jobs: []*jobsdb.JobT{
+				newJob(1, `{"type":"track","receivedAt":"2024-01-01T05:10:00Z"}`),
+				newJob(2, `{"type":"identify","receivedAt":"2024-01-01T05:20:00Z"}`),
+				newJob(3, `{"type":"track","receivedAt":"2024-01-01T05:30:00Z"}`),
+				newJob(4, `{"type":"track","receivedAt":"2024-01-01T06:00:00Z"}`),
+			},
+			Connection: &Connection{Destination: backendconfig.DestinationT{
+				ID:     "dest-1",
+				Config: map[string]interface{}{"partitionKeys": []interface{}{"event_type=type", "dt", "hr"}},
+			}},
+		}
+		splitBatches := brt.splitBatchJobs(batchJobs)
+		require.Len(t, splitBatches, 3)
+		require.Equal(t, "event_type=track/dt=2024-01-01/hr=05", splitBatches[0].PartitionPath)
+		require.Equal(t, []int64{1, 3}, jobIDs(splitBatches[0]))
+		require.Equal(t, "event_type=identify/dt=2024-01-01/hr=05", splitBatches[1].PartitionPath)
+		require.Equal(t, []int64{2}, jobIDs(splitBatches[1]))
+		require.Equal(t, "event_type=track/dt=2024-01-01/hr=06", splitBatches[2].PartitionPath)
+		require.Equal(t, []int64{4}, jobIDs(splitBatches[2]))
+		for _, batch := range splitBatches {
+			require.Equal(t, batchJobs.Connection, batch.Connection)
+			require.True(t, batch.TimeWindow.IsZero())
+		}
+	})
+
+	t.Run("object storage with partition keys in server config", func(t *testing.T) {
+		c := config.New()
+		c.Set("BatchRouter.GCS.partitionKeys", []string{"event"})
+		brt := &Handle{destType: "GCS", conf: c, logger: logger.NOP}
+		batchJobs := BatchedJobs{
+			Jobs:       []*jobsdb.JobT{newJob(1, `{"event":"Signed Up"}`), newJob(2, `{}`)},
+			Connection: &Connection{Destination: backendconfig.DestinationT{ID: "dest-1", Config: map[string]interface{}{}}},
+		}
+		splitBatches := brt.splitBatchJobs(batchJobs)
+		require.Len(t, splitBatches, 2)
+		require.Equal(t, "event=Signed Up", splitBatches[0].PartitionPath)
+		require.Equal(t, "event=__HIVE_DEFAULT_PARTITION__", splitBatches[1].PartitionPath)
+	})
+
+	t.Run("time window destinations", func(t *testing.T) {
+		brt := &Handle{destType: warehouseutils.S3Datalake, conf: config.New(), logger: logger.NOP}
+		batchJobs := BatchedJobs{
+			Jobs: []*jobsdb.JobT{
+				newJob(1, `{"metadata":{"receivedAt":"2024-01-01T05:10:00Z"}}`),
+				newJob(2, `{"metadata":{"receivedAt":"2024-01-01T06:20:00Z"}}`),
+				newJob(3, `{"metadata":{"receivedAt":"2024-01-01T05:30:00Z"}}`),
+			},
+			Connection: &Connection{},
+		}
+		splitBatches := brt.splitBatchJobs(batchJobs)
+		require.Len(t, splitBatches, 2)
+		require.Equal(t, time.Date(2024, 1, 1, 5, 0, 0, 0, time.UTC), splitBatches[0].TimeWindow)
+		require.Equal(t, []int64{1, 3}, jobIDs(splitBatches[0]))
+		require.Equal(t, time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC), splitBatches[1].TimeWindow)
+		require.Equal(t, []int64{2}, jobIDs(splitBatches[1]))
+		require.Empty(t, splitBatches[0].PartitionPath)
+	})
+}
+
+func TestUploadPartitionedLayout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	mockFileManager := mock_filemanager.NewMockFileManager(mockCtrl)
+	mockFileManager.EXPECT().
+		Upload(gomock.Any(), gomock.Any(), "rudder-logs", "source-1", "event_type=track/dt=2024-01-01").
+		Return(filemanager.UploadedFile{Location: "local", ObjectName: "file"}, nil)
+	jobsDB := mocksJobsDB.NewMockJobsDB(mockCtrl)
+	jobsDB.EXPECT().JournalMarkStart(gomock.Any(), gomock.Any()).Times(1).Return(int64(1), nil)
+
+	brt := &Handle{
+		logger:             logger.NOP,
+		fileManagerFactory: func(*filemanager.Settings) (filemanager.FileManager, error) { return mockFileManager, nil },
+		datePrefixOverride: config.GetReloadableStringVar("", "BatchRouter.datePrefixOverride"),
+		customDatePrefix:   config.GetReloadableStringVar("", "BatchRouter.customDatePrefix"),
+		dateFormatProvider: &storageDateFormatProvider{dateFormatsCache: make(map[string]string)},
+		conf:               config.New(),
+		now:                timeutil.Now,
+		jobsDB:             jobsDB,
+	}
+	result := brt.upload("S3", &BatchedJobs{
+		Jobs:          []*jobsdb.JobT{{EventPayload: []byte(`{"type":"track","receivedAt":"2024-01-01T05:10:00Z"}`)}},
+		Connection:    &Connection{Source: backendconfig.SourceT{ID: "source-1"}, Destination: backendconfig.DestinationT{ID: "dest-1"}},
+		PartitionPath: "event_type=track/dt=2024-01-01",
+	}, false)
+	require.NoError(t, result.Error)
+	require.Equal(t, "file", result.Key)
+}
@@ -182,16 +182,21 @@ func (pw *PartitionWorker) processAndUploadBatch(sourceID, destID string, jobs [
 	defer pw.brt.limiter.upload.Begin("")()
 
 	// Helper function for standard object storage upload process
-	processObjectStorageUpload := func(destType string, isWarehouse bool) {
-		output := pw.brt.upload(destType, &batchedJobs, isWarehouse)
-		pw.brt.recordDeliveryStatus(*batchedJobs.Connection, output, isWarehouse)
-		pw.brt.updateJobStatus(&batchedJobs, isWarehouse, output.Error, false)
+	processObjectStorageUpload := func(destType string, batchJob *BatchedJobs, isWarehouse bool) {
+		output := pw.brt.upload(destType, batchJob, isWarehouse)
+		pw.brt.abortRejectedJobs(batchJob, output, isWarehouse)
+		if len(batchJob.Jobs) == 0 { // all jobs got rejected
+			misc.RemoveFilePaths(output.LocalFilePaths...)
+			return
+		}
+		pw.brt.recordDeliveryStatus(*batchJob.Connection, output, isWarehouse)
+		pw.brt.updateJobStatus(batchJob, isWarehouse, output.Error, false)
 		misc.RemoveFilePaths(output.LocalFilePaths...)
 		if output.JournalOpID > 0 {
 			pw.brt.jobsDB.JournalDeleteEntry(output.JournalOpID)
 		}
 		if output.Error == nil {
-			pw.brt.recordUploadStats(*batchedJobs.Connection, output)
+			pw.brt.recordUploadStats(*batchJob.Connection, output)
 			pw.cb.Success()
 		} else {
 			pw.cb.Failure()
@@ -200,11 +205,13 @@ func (pw *PartitionWorker) processAndUploadBatch(sourceID, destID string, jobs [
 
 	switch {
 	case IsObjectStorageDestination(pw.brt.destType):
-		processObjectStorageUpload(pw.brt.destType, false)
+		for _, batchJob := range pw.brt.splitBatchJobs(batchedJobs) {
+			processObjectStorageUpload(pw.brt.destType, batchJob, false)
+		}
 	case IsWarehouseDestination(pw.brt.destType):
 		useRudderStorage := misc.IsConfiguredToUseRudderObjectStorage(batchedJobs.Connection.Destination.Config)
 		objectStorageType := warehouseutils.ObjectStorageType(pw.brt.destType, batchedJobs.Connection.Destination.Config, useRudderStorage)
-		splitBatchJobs := pw.brt.splitBatchJobsOnTimeWindow(batchedJobs)
+		splitBatchJobs := pw.brt.splitBatchJobs(batchedJobs)
 		for _, batchJob := range splitBatchJobs {
 			output := pw.brt.upload(objectStorageType, batchJob, true)
 			notifyWarehouseErr := false
@@ -46,6 +46,13 @@ type UploadResult struct {
 	TotalBytes       int
 	BytesPerTable    map[string]int64
 	UseRudderStorage bool
+	Rejected         *RejectedJobs // jobs left out of the uploaded file, since their payloads couldn't be written using the file format
+}
+
+// RejectedJobs are jobs whose payloads couldn't be written to an upload file, along with the reason
+type RejectedJobs struct {
+	Jobs  []*jobsdb.JobT
+	Error error
 }
 
 type ErrorResponse struct {
@@ -57,10 +64,11 @@ type WarningResponse struct {
 }
 
 type BatchedJobs struct {
-	Jobs       []*jobsdb.JobT
-	Connection *Connection
-	TimeWindow time.Time
-	JobState   string // ENUM waiting, executing, succeeded, waiting_retry, filtered, failed, aborted, migrating, migrated, wont_migrate
+	Jobs          []*jobsdb.JobT
+	Connection    *Connection
+	TimeWindow    time.Time
+	PartitionPath string // hive-style partition path of object storage uploads, e.g. event_type=track/dt=2024-01-01
+	JobState      string // ENUM waiting, executing, succeeded, waiting_retry, filtered, failed, aborted, migrating, migrated, wont_migrate
 }
 
 type getReportMetricsParams struct {
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/samber/lo"
 	"github.com/sony/gobreaker"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -42,6 +43,12 @@ type DestinationManager interface {
 	BackendConfigInitialized() <-chan struct{}
 }
 
+// BatchDestinationManager is implemented by destination managers which can send multiple messages to a destination with a single call
+type BatchDestinationManager interface {
+	DestinationManager
+	SendDataBatch(messages []json.RawMessage, destID string) ([]int, []string)
+}
+
 // CustomManagerT handles this module
 type CustomManagerT struct {
 	destType    string
@@ -76,7 +83,7 @@ func Init() {
 }
 
 func loadConfig() {
-	ObjectStreamDestinations = []string{"KINESIS", "KAFKA", "AZURE_EVENT_HUB", "FIREHOSE", "EVENTBRIDGE", "GOOGLEPUBSUB", "CONFLUENT_CLOUD", "PERSONALIZE", "GOOGLESHEETS", "BQSTREAM", "LAMBDA", "GOOGLE_CLOUD_FUNCTION", "WUNDERKIND"}
+	ObjectStreamDestinations = []string{"KINESIS", "KAFKA", "AZURE_EVENT_HUB", "FIREHOSE", "EVENTBRIDGE", "GOOGLEPUBSUB", "CONFLUENT_CLOUD", "PERSONALIZE", "GOOGLESHEETS", "BQSTREAM", "LAMBDA", "GOOGLE_CLOUD_FUNCTION", "WUNDERKIND", "PULSAR", "NATS_JETSTREAM"}
 	KVStoreDestinations = []string{"REDIS"}
 	Destinations = append(ObjectStreamDestinations, KVStoreDestinations...)
 	disableEgress = config.GetBoolVar(false, "disableEgress")
@@ -163,11 +170,77 @@ func (customManager *CustomManagerT) SendData(jsonData json.RawMessage, destID s
 		return 200, `200: outgoing disabled`
 	}
 
+	customDestination, clientLock, respStatusCode, respBody := customManager.getClient(destID)
+	if customDestination == nil {
+		return respStatusCode, respBody
+	}
+
+	respStatusCode, respBody = customManager.send(jsonData, customDestination.client, customDestination.config)
+
+	if respStatusCode == CLIENT_EXPIRED_CODE {
+		if customDestination, respStatusCode, respBody = customManager.refreshExpiredClient(destID, clientLock); customDestination == nil {
+			return respStatusCode, respBody
+		}
+		respStatusCode, respBody = customManager.send(jsonData, customDestination.client, customDestination.config)
+	}
+
+	return respStatusCode, respBody
+}
+
+// SendDataBatch sends multiple messages to a destination, returning a status code and a response body for every message, in the same order as the messages.
+// Messages are sent with a single call if the destination's producer is a [common.BatchProducer], otherwise they are sent one by one.
+func (customManager *CustomManagerT) SendDataBatch(messages []json.RawMessage, destID string) ([]int, []string) {
+	respStatusCodes := make([]int, len(messages))
+	respBodies := make([]string, len(messages))
+	fail := func(indexes []int, respStatusCode int, respBody string) ([]int, []string) {
+		for _, i := range indexes {
+			respStatusCodes[i], respBodies[i] = respStatusCode, respBody
+		}
+		return respStatusCodes, respBodies
+	}
+	if disableEgress {
+		return fail(lo.Range(len(messages)), 200, `200: outgoing disabled`)
+	}
+
+	customDestination, clientLock, respStatusCode, respBody := customManager.getClient(destID)
+	if customDestination == nil {
+		return fail(lo.Range(len(messages)), respStatusCode, respBody)
+	}
+
+	customManager.sendBatch(messages, lo.Range(len(messages)), customDestination, respStatusCodes, respBodies)
+
+	if expired := lo.Filter(lo.Range(len(messages)), func(i, _ int) bool { return respStatusCodes[i] == CLIENT_EXPIRED_CODE }); len(expired) > 0 {
+		if customDestination, respStatusCode, respBody = customManager.refreshExpiredClient(destID, clientLock); customDestination == nil {
+			return fail(expired, respStatusCode, respBody)
+		}
+		customManager.sendBatch(messages, expired, customDestination, respStatusCodes, respBodies)
+	}
+
+	return respStatusCodes, respBodies
+}
+
+// sendBatch sends the messages having the provided indexes, populating their status codes and response bodies
+func (customManager *CustomManagerT) sendBatch(messages []json.RawMessage, indexes []int, customDestination *clientHolder, respStatusCodes []int, respBodies []string) {
+	if batchProducer, ok := customDestination.client.(common.BatchProducer); ok && customManager.managerType == STREAM {
+		results := batchProducer.ProduceBatch(lo.Map(indexes, func(i, _ int) json.RawMessage { return messages[i] }), customDestination.config)
+		for j, i := range indexes {
+			respStatusCodes[i], respBodies[i] = results[j].StatusCode, results[j].ResponseMessage
+		}
+		return
+	}
+	for _, i := range indexes {
+		respStatusCodes[i], respBodies[i] = customManager.send(messages[i], customDestination.client, customDestination.config)
+	}
+}
+
+// getClient returns the client of a destination along with its lock, creating the client if it doesn't exist yet.
+// If the client is not available, a nil client is returned along with the status code and response body describing the failure.
+func (customManager *CustomManagerT) getClient(destID string) (*clientHolder, *sync.RWMutex, int, string) {
 	customManager.stateMu.RLock()
 	clientLock, ok := customManager.clientMu[destID]
 	customManager.stateMu.RUnlock()
 	if !ok {
-		return 500, fmt.Sprintf("[CDM %s] Unexpected state: Lock missing for %s. Config might not have been updated. Please wait for a min before sending events.", customManager.destType, destID)
+		return nil, nil, 500, fmt.Sprintf("[CDM %s] Unexpected state: Lock missing for %s. Config might not have been updated. Please wait for a min before sending events.", customManager.destType, destID)
 	}
 
 	clientLock.RLock()
@@ -182,29 +255,27 @@ func (customManager *CustomManagerT) SendData(jsonData json.RawMessage, destID s
 		}
 		clientLock.Unlock()
 		if err != nil {
-			return 400, fmt.Sprintf("[CDM %s] Unable to create client for %s %s", customManager.destType, destID, err.Error())
+			return nil, nil, 400, fmt.Sprintf("[CDM %s] Unable to create client for %s %s", customManager.destType, destID, err.Error())
 		}
 		clientLock.RLock()
 		customDestination = customManager.client[destID]
 	}
 	clientLock.RUnlock()
+	return customDestination, clientLock, 0, ""
+}
 
-	respStatusCode, respBody := customManager.send(jsonData, customDestination.client, customDestination.config)
-
-	if respStatusCode == CLIENT_EXPIRED_CODE {
-		clientLock.Lock()
-		err := customManager.refreshClient(destID)
-		clientLock.Unlock()
-		if err != nil {
-			return 400, fmt.Sprintf("[CDM %s] Unable to refresh client for %s %s", customManager.destType, destID, err.Error())
-		}
-		clientLock.RLock()
-		customDestination = customManager.client[destID]
-		clientLock.RUnlock()
-		respStatusCode, respBody = customManager.send(jsonData, customDestination.client, customDestination.config)
+// refreshExpiredClient replaces the expired client of a destination with a new one.
+// If the client cannot be refreshed, a nil client is returned along with the status code and response body describing the failure.
+func (customManager *CustomManagerT) refreshExpiredClient(destID string, clientLock *sync.RWMutex) (*clientHolder, int, string) {
+	clientLock.Lock()
+	err := customManager.refreshClient(destID)
+	clientLock.Unlock()
+	if err != nil {
+		return nil, 400, fmt.Sprintf("[CDM %s] Unable to refresh client for %s %s", customManager.destType, destID, err.Error())
 	}
-
-	return respStatusCode, respBody
+	clientLock.RLock()
+	defer clientLock.RUnlock()
+	return customManager.client[destID], 0, ""
 }
 
 func (customManager *CustomManagerT) close(destID string) {
@@ -28,6 +28,7 @@ import (
 	mock_kvstoremanager "github.com/rudderlabs/rudder-server/mocks/services/kvstoremanager"
 	mock_streammanager "github.com/rudderlabs/rudder-server/mocks/services/streammanager/common"
 	kvredis "github.com/rudderlabs/rudder-server/services/kvstoremanager/redis"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
 	"github.com/rudderlabs/rudder-server/services/streammanager/kafka"
 )
 
@@ -156,6 +157,59 @@ func TestSendDataWithStreamDestination(t *testing.T) {
 	customManager.SendData(event, someDestination.ID)
 }
 
+func TestSendDataBatchWithStreamDestination(t *testing.T) {
+	initCustomerManager()
+
+	customManager := New("LAMBDA", Opts{}).(*CustomManagerT)
+	someDestination := backendconfig.DestinationT{
+		ID: "someDestinationID2",
+		DestinationDefinition: backendconfig.DestinationDefinitionT{
+			Name: "LAMBDA",
+		},
+		Config: map[string]interface{}{
+			"region": "someRegion",
+		},
+	}
+	require.NoError(t, customManager.onNewDestination(someDestination))
+	messages := []json.RawMessage{[]byte(`{"id":1}`), []byte(`{"id":2}`), []byte(`{"id":3}`)}
+
+	t.Run("batch producer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockProducer := mock_streammanager.NewMockBatchProducer(ctrl)
+		customManager.client[someDestination.ID].client = mockProducer
+		mockProducer.EXPECT().ProduceBatch(messages, someDestination.Config).Return([]common.ProduceResult{
+			{StatusCode: 200, RespStatus: "Success", ResponseMessage: "delivered"},
+			{StatusCode: 429, RespStatus: "Throttled", ResponseMessage: "throttled"},
+			{StatusCode: 200, RespStatus: "Success", ResponseMessage: "delivered"},
+		}).Times(1)
+
+		statusCodes, respBodies := customManager.SendDataBatch(messages, someDestination.ID)
+		require.Equal(t, []int{200, 429, 200}, statusCodes)
+		require.Equal(t, []string{"delivered", "throttled", "delivered"}, respBodies)
+	})
+
+	t.Run("stream producer", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		mockProducer := mock_streammanager.NewMockStreamProducer(ctrl)
+		customManager.client[someDestination.ID].client = mockProducer
+		for i, message := range messages {
+			mockProducer.EXPECT().Produce(message, someDestination.Config).Return(200+i, "", strconv.Itoa(i)).Times(1)
+		}
+
+		statusCodes, respBodies := customManager.SendDataBatch(messages, someDestination.ID)
+		require.Equal(t, []int{200, 201, 202}, statusCodes)
+		require.Equal(t, []string{"0", "1", "2"}, respBodies)
+	})
+
+	t.Run("unknown destination", func(t *testing.T) {
+		statusCodes, respBodies := customManager.SendDataBatch(messages, "unknown")
+		require.Equal(t, []int{500, 500, 500}, statusCodes)
+		for _, respBody := range respBodies {
+			require.Contains(t, respBody, "Lock missing for unknown")
+		}
+	})
+}
+
 type transformedResponseJSON struct {
 	Message map[string]interface{} `json:"message"`
 	UserId  string                 `json:"userId"`
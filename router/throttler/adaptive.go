@@ -81,6 +81,10 @@ func (t *adaptiveThrottler) ResponseCodeReceived(code int) {
 	t.algorithm.ResponseCodeReceived(code)
 }
 
+func (t *adaptiveThrottler) ResponseLatencyReceived(latency time.Duration) {
+	t.algorithm.ResponseLatencyReceived(latency)
+}
+
 func (t *adaptiveThrottler) Shutdown() {
 	t.algorithm.Shutdown()
 }
@@ -66,6 +66,9 @@ func (a *Adaptive) ResponseCodeReceived(code int) {
 	a.decreaseLimitCounter.ResponseCodeReceived(code)
 }
 
+// ResponseLatencyReceived is a no-op, since this algorithm only reacts to response codes
+func (a *Adaptive) ResponseLatencyReceived(time.Duration) {}
+
 func (a *Adaptive) Shutdown() {
 	a.cancel()
 	a.wg.Wait()
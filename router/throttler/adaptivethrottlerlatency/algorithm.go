@@ -0,0 +1,149 @@
+package adaptivethrottlerlatency
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+)
+
+// maxSamples is the maximum number of latency samples kept for every window, further samples are reservoir sampled
+const maxSamples = 10000
+
+// Adaptive is an AIMD (additive increase, multiplicative decrease) controller for the limit factor, driven by the destination's response latency.
+// At the end of every window the configured latency percentile is calculated:
+//   - if it is above the target latency, the limit factor is decreased by decreasePercentage of its current value
+//   - otherwise the limit factor is increased by increasePercentage
+//
+// Windows with fewer than minSamples latency samples don't change the limit factor.
+type Adaptive struct {
+	window             config.ValueLoader[time.Duration]
+	targetLatency      config.ValueLoader[time.Duration]
+	percentile         config.ValueLoader[float64]
+	increasePercentage config.ValueLoader[int64]
+	decreasePercentage config.ValueLoader[int64]
+	minSamples         config.ValueLoader[int]
+
+	limitFactorMu sync.RWMutex
+	limitFactor   float64
+
+	samplesMu    sync.Mutex
+	samples      []time.Duration
+	samplesCount int // number of samples received in the current window, including the ones not kept
+
+	stats struct {
+		limitFactor stats.Measurement
+		latency     stats.Measurement
+	}
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(destName, destID string, conf *config.Config, stat stats.Stats, window config.ValueLoader[time.Duration]) *Adaptive {
+	keys := func(key string) []string {
+		return []string{
+			"Router.throttler.adaptive.latency." + destName + "." + destID + "." + key,
+			"Router.throttler.adaptive.latency." + destName + "." + key,
+			"Router.throttler.adaptive.latency." + key,
+		}
+	}
+	a := &Adaptive{
+		window:             window,
+		targetLatency:      conf.GetReloadableDurationVar(1, time.Second, keys("targetLatency")...),
+		percentile:         conf.GetReloadableFloat64Var(95, keys("percentile")...),
+		increasePercentage: conf.GetReloadableInt64Var(10, 1, keys("increasePercentage")...),
+		decreasePercentage: conf.GetReloadableInt64Var(30, 1, keys("decreasePercentage")...),
+		minSamples:         conf.GetReloadableIntVar(10, 1, keys("minSamples")...),
+		limitFactor:        1,
+	}
+	if stat == nil {
+		stat = stats.NOP
+	}
+	tags := stats.Tags{"destinationId": destID, "destType": destName}
+	a.stats.limitFactor = stat.NewTaggedStat("adaptive_throttler_latency_limit_factor", stats.GaugeType, tags)
+	a.stats.latency = stat.NewTaggedStat("adaptive_throttler_latency_percentile_seconds", stats.GaugeType, tags)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.wg.Add(1)
+	go a.run(ctx)
+	return a
+}
+
+func (a *Adaptive) LimitFactor() float64 {
+	a.limitFactorMu.RLock()
+	defer a.limitFactorMu.RUnlock()
+	return a.limitFactor
+}
+
+// ResponseCodeReceived is a no-op, since this algorithm only reacts to response latencies
+func (a *Adaptive) ResponseCodeReceived(int) {}
+
+func (a *Adaptive) ResponseLatencyReceived(latency time.Duration) {
+	a.samplesMu.Lock()
+	defer a.samplesMu.Unlock()
+	a.samplesCount++
+	if len(a.samples) < maxSamples {
+		a.samples = append(a.samples, latency)
+		return
+	}
+	if i := rand.IntN(a.samplesCount); i < maxSamples {
+		a.samples[i] = latency
+	}
+}
+
+func (a *Adaptive) Shutdown() {
+	a.cancel()
+	a.wg.Wait()
+}
+
+func (a *Adaptive) run(ctx context.Context) {
+	defer a.wg.Done()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.window.Load()):
+			a.adjust()
+		}
+	}
+}
+
+// adjust updates the limit factor based on the latency samples of the last window
+func (a *Adaptive) adjust() {
+	a.samplesMu.Lock()
+	samples := a.samples
+	a.samples = nil
+	a.samplesCount = 0
+	a.samplesMu.Unlock()
+
+	if len(samples) == 0 || len(samples) < a.minSamples.Load() {
+		return
+	}
+	latency := percentile(samples, a.percentile.Load())
+	a.stats.latency.Gauge(latency.Seconds())
+
+	a.limitFactorMu.Lock()
+	defer a.limitFactorMu.Unlock()
+	if latency > a.targetLatency.Load() {
+		a.limitFactor -= a.limitFactor * float64(a.decreasePercentage.Load()) / 100
+	} else {
+		a.limitFactor += float64(a.increasePercentage.Load()) / 100
+	}
+	a.limitFactor = min(max(a.limitFactor, 0), 1)
+	a.stats.limitFactor.Gauge(a.limitFactor)
+}
+
+// percentile returns the p-th percentile of the samples using the nearest-rank method
+func percentile(samples []time.Duration, p float64) time.Duration {
+	slices.Sort(samples)
+	p = min(max(p, 0), 100)
+	rank := int(math.Ceil(float64(len(samples))*p/100)) - 1
+	return samples[min(max(rank, 0), len(samples)-1)]
+}
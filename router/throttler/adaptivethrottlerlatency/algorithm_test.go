@@ -0,0 +1,96 @@
+package adaptivethrottlerlatency
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+)
+
+const float64EqualityThreshold = 1e-9
+
+func TestAdaptiveRateLimit(t *testing.T) {
+	cfg := config.New()
+	cfg.Set("Router.throttler.adaptive.latency.targetLatency", "100ms")
+	cfg.Set("Router.throttler.adaptive.latency.minSamples", 5)
+	statsStore, err := memstats.New()
+	require.NoError(t, err)
+	al := New("dest", "destID", cfg, statsStore, config.SingleValueLoader(time.Hour))
+	defer al.Shutdown()
+
+	// windows are ended explicitly, for avoiding samples being split across windows
+	sendWindow := func(latencies ...time.Duration) {
+		for _, latency := range latencies {
+			al.ResponseLatencyReceived(latency)
+		}
+		al.adjust()
+	}
+
+	t.Run("p95 latency above target decreases the limit factor multiplicatively", func(t *testing.T) {
+		sendWindow(time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond, time.Second)
+		require.True(t, floatCheck(al.LimitFactor(), 0.7)) // reduces by 30% of the current factor
+
+		sendWindow(time.Second, time.Second, time.Second, time.Second, time.Second)
+		require.True(t, floatCheck(al.LimitFactor(), 0.49))
+
+		tags := stats.Tags{"destinationId": "destID", "destType": "dest"}
+		require.EqualValues(t, 1, statsStore.Get("adaptive_throttler_latency_percentile_seconds", tags).LastValue())
+		require.InDelta(t, 0.49, statsStore.Get("adaptive_throttler_latency_limit_factor", tags).LastValue(), float64EqualityThreshold)
+	})
+
+	t.Run("not enough samples leave the limit factor unchanged", func(t *testing.T) {
+		sendWindow(time.Millisecond, time.Millisecond)
+		require.True(t, floatCheck(al.LimitFactor(), 0.49))
+	})
+
+	t.Run("p95 latency below target increases the limit factor additively", func(t *testing.T) {
+		sendWindow(time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond)
+		require.True(t, floatCheck(al.LimitFactor(), 0.59)) // increases by 10%
+
+		for range 10 {
+			sendWindow(time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond, time.Millisecond)
+		}
+		require.True(t, floatCheck(al.LimitFactor(), 1), "limit factor should not exceed 1")
+	})
+
+	t.Run("windows are evaluated periodically", func(t *testing.T) {
+		cfg := config.New()
+		cfg.Set("Router.throttler.adaptive.latency.dest.destID.targetLatency", "10ms")
+		cfg.Set("Router.throttler.adaptive.latency.minSamples", 1)
+		al := New("dest", "destID", cfg, stats.NOP, config.SingleValueLoader(100*time.Millisecond))
+		defer al.Shutdown()
+		require.Eventually(t, func() bool {
+			al.ResponseLatencyReceived(time.Second)
+			return al.LimitFactor() < 1
+		}, 2*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("response codes are ignored", func(t *testing.T) {
+		for range 10 {
+			al.ResponseCodeReceived(429)
+		}
+		al.adjust()
+		require.True(t, floatCheck(al.LimitFactor(), 1))
+	})
+}
+
+func TestPercentile(t *testing.T) {
+	samples := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		samples = append(samples, time.Duration(i)*time.Millisecond)
+	}
+	require.Equal(t, 95*time.Millisecond, percentile(samples, 95))
+	require.Equal(t, 50*time.Millisecond, percentile(samples, 50))
+	require.Equal(t, 100*time.Millisecond, percentile(samples, 100))
+	require.Equal(t, time.Millisecond, percentile(samples, 0))
+	require.Equal(t, time.Second, percentile([]time.Duration{time.Second}, 95))
+}
+
+func floatCheck(a, b float64) bool {
+	return math.Abs(a-b) < float64EqualityThreshold
+}
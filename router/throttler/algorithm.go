@@ -4,23 +4,34 @@ import (
 	"time"
 
 	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
 
 	"github.com/rudderlabs/rudder-server/router/throttler/adaptivethrottlercounter"
+	"github.com/rudderlabs/rudder-server/router/throttler/adaptivethrottlerlatency"
+)
+
+const (
+	adaptiveAlgoTypeCounter = "counter"
+	adaptiveAlgoTypeLatency = "latency"
 )
 
 type adaptiveAlgorithm interface {
 	// ResponseCodeReceived is called when a response is received from the destination
 	ResponseCodeReceived(code int)
+	// ResponseLatencyReceived is called with the time it took for the destination to respond
+	ResponseLatencyReceived(latency time.Duration)
 	// Shutdown is called when the throttler is shutting down
 	Shutdown()
 	// limitFactor returns a factor that is used to multiply the limit, a number between 0 and 1
 	LimitFactor() float64
 }
 
-func newAdaptiveAlgorithm(destination string, config *config.Config, window config.ValueLoader[time.Duration]) adaptiveAlgorithm {
-	name := config.GetString("Router.throttler.adaptive.algorithm", "")
+func newAdaptiveAlgorithm(destName, destID string, config *config.Config, stat stats.Stats, window config.ValueLoader[time.Duration]) adaptiveAlgorithm {
+	name := config.GetString("Router.throttler.adaptive.algorithm", adaptiveAlgoTypeCounter)
 	switch name {
+	case adaptiveAlgoTypeLatency:
+		return adaptivethrottlerlatency.New(destName, destID, config, stat, window)
 	default:
-		return adaptivethrottlercounter.New(destination, config, window)
+		return adaptivethrottlercounter.New(destName, config, window)
 	}
 }
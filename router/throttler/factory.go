@@ -80,7 +80,7 @@ func (f *factory) Get(destName, destID string) Throttler {
 		}
 		at = &adaptiveThrottler{
 			limiter:                f.adaptiveLimiter,
-			algorithm:              newAdaptiveAlgorithm(destName, f.config, adaptiveConf.window),
+			algorithm:              newAdaptiveAlgorithm(destName, destID, f.config, f.Stats, adaptiveConf.window),
 			config:                 adaptiveConf,
 			limitFactorMeasurement: limitFactorMeasurement,
 		}
@@ -175,6 +175,8 @@ func (t *noOpThrottler) CheckLimitReached(ctx context.Context, key string, cost
 
 func (t *noOpThrottler) ResponseCodeReceived(code int) {}
 
+func (t *noOpThrottler) ResponseLatencyReceived(latency time.Duration) {}
+
 func (t *noOpThrottler) Shutdown() {}
 
 func (t *noOpThrottler) getLimit() int64 {
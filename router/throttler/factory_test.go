@@ -10,6 +10,9 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	"github.com/rudderlabs/rudder-go-kit/stats/memstats"
+
+	"github.com/rudderlabs/rudder-server/router/throttler/adaptivethrottlercounter"
+	"github.com/rudderlabs/rudder-server/router/throttler/adaptivethrottlerlatency"
 )
 
 func TestFactory(t *testing.T) {
@@ -113,6 +116,22 @@ func TestFactory(t *testing.T) {
 		ta := f.Get("destName", "destID")
 		require.EqualValues(t, adaptiveDefaultMaxLimit, ta.getLimit())
 	})
+
+	t.Run("adaptive algorithm selection", func(t *testing.T) {
+		for algorithm, expected := range map[string]adaptiveAlgorithm{
+			"":                      &adaptivethrottlercounter.Adaptive{},
+			adaptiveAlgoTypeCounter: &adaptivethrottlercounter.Adaptive{},
+			adaptiveAlgoTypeLatency: &adaptivethrottlerlatency.Adaptive{},
+		} {
+			conf := config.New()
+			conf.Set("Router.throttler.adaptive.algorithm", algorithm)
+			f, err := NewFactory(conf, nil)
+			require.NoError(t, err)
+			ta := f.Get("destName", "destID").(*switchingThrottler).adaptive.(*adaptiveThrottler)
+			require.IsType(t, expected, ta.algorithm, "algorithm %q", algorithm)
+			f.Shutdown()
+		}
+	})
 }
 
 func floatCheck(a, b int64) bool {
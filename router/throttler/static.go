@@ -58,6 +58,10 @@ func (t *staticThrottler) ResponseCodeReceived(code int) {
 	// no-op
 }
 
+func (t *staticThrottler) ResponseLatencyReceived(latency time.Duration) {
+	// no-op
+}
+
 func (t *staticThrottler) Shutdown() {
 	// no-op
 }
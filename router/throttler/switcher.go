@@ -22,6 +22,11 @@ func (t *switchingThrottler) ResponseCodeReceived(code int) {
 	t.adaptive.ResponseCodeReceived(code)
 }
 
+func (t *switchingThrottler) ResponseLatencyReceived(latency time.Duration) {
+	t.static.ResponseLatencyReceived(latency)
+	t.adaptive.ResponseLatencyReceived(latency)
+}
+
 func (t *switchingThrottler) Shutdown() {
 	t.static.Shutdown()
 	t.adaptive.Shutdown()
@@ -14,6 +14,7 @@ const (
 type Throttler interface {
 	CheckLimitReached(ctx context.Context, key string, cost int64) (limited bool, retErr error)
 	ResponseCodeReceived(code int)
+	ResponseLatencyReceived(latency time.Duration)
 	Shutdown()
 	getLimit() int64
 	getTimeWindow() time.Duration
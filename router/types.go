@@ -84,4 +84,5 @@ type reloadableConfig struct {
 	skipRtAbortAlertForDelivery       config.ValueLoader[bool] // represents if transformation(router or batch) should be alerted via router-aborted-count alert def
 	oauthV2Enabled                    config.ValueLoader[bool]
 	oauthV2ExpirationTimeDiff         config.ValueLoader[time.Duration]
+	streamBatchSize                   config.ValueLoader[int] // maximum number of jobs sent with a single call to custom destinations supporting batching
 }
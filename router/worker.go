@@ -20,6 +20,7 @@ import (
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/jobsdb"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	customDestinationManager "github.com/rudderlabs/rudder-server/router/customdestinationmanager"
 	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/transformer"
 	"github.com/rudderlabs/rudder-server/router/types"
@@ -470,8 +471,9 @@ func (w *worker) processDestinationJobs() {
 	})
 
 	dontBatchDirectives := make(map[int64]bool)
+	var customDestinationResponses map[int]customDestinationResponse // responses of destination jobs already sent to a custom destination in a batch, keyed by their index
 
-	for _, destinationJob := range w.destinationJobs {
+	for i, destinationJob := range w.destinationJobs {
 		var respStatusCodes map[int64]int
 		var respBodys map[int64]string
 
@@ -506,10 +508,15 @@ func (w *worker) processDestinationJobs() {
 							panic(fmt.Errorf("different destinations are grouped together"))
 						}
 					}
-					attemptedRequests++
+					response, ok := customDestinationResponses[i]
+					if !ok {
+						attemptedRequests++
+						customDestinationResponses = w.sendToCustomDestination(i, failedJobOrderKeys)
+						response = customDestinationResponses[i]
+					}
+					startedAt = response.startedAt
 					attemptedJobs += len(destinationJob.JobMetadataArray)
-					respStatusCode, respBody := w.rt.customDestinationManager.SendData(destinationJob.Message, destinationID)
-					respStatusCodes, respBodys = w.prepareResponsesForJobs(&destinationJob, respStatusCode, respBody)
+					respStatusCodes, respBodys = w.prepareResponsesForJobs(&destinationJob, response.statusCode, response.body)
 					errorAt = routerutils.ERROR_AT_CUST
 				} else {
 					result, err := getIterableStruct(destinationJob.Message, transformAt)
@@ -611,6 +618,7 @@ func (w *worker) processDestinationJobs() {
 				}
 				ch <- struct{}{}
 				timeTaken := time.Since(startedAt)
+				w.rt.throttlerFactory.Get(w.rt.destType, destinationID).ResponseLatencyReceived(timeTaken) // send response latency to throttler
 
 				w.deliveryTimeStat.SendTiming(timeTaken)
 				deliveryLatencyStat.Since(startedAt)
@@ -929,12 +937,75 @@ func (w *worker) prepareResponsesForJobs(destinationJob *types.DestinationJobT,
 	return respStatusCodes, respBodys
 }
 
+// customDestinationResponse is the response of a custom destination for a destination job
+type customDestinationResponse struct {
+	statusCode int
+	body       string
+	startedAt  time.Time // time the request carrying the destination job was started
+}
+
+// sendToCustomDestination sends the destination job at index i to the custom destination.
+// If the custom destination manager supports batching, the destination jobs following it are sent in the same batch, up to streamBatchSize jobs,
+// as long as they belong to the same destination, can be sent to it and, if event ordering is guaranteed, don't share any user with other jobs in the batch.
+// The responses of all destination jobs sent are returned, keyed by their index.
+func (w *worker) sendToCustomDestination(i int, failedJobOrderKeys map[eventorder.BarrierKey]struct{}) map[int]customDestinationResponse {
+	startedAt := time.Now()
+	destinationJob := &w.destinationJobs[i]
+	destinationID := destinationJob.JobMetadataArray[0].DestinationID
+	batchManager, ok := w.rt.customDestinationManager.(customDestinationManager.BatchDestinationManager)
+	batchSize := w.rt.reloadableConfig.streamBatchSize.Load()
+	if !ok || batchSize <= 1 {
+		statusCode, body := w.rt.customDestinationManager.SendData(destinationJob.Message, destinationID)
+		return map[int]customDestinationResponse{i: {statusCode: statusCode, body: body, startedAt: startedAt}}
+	}
+
+	indexes := []int{i}
+	userIDs := make(map[string]struct{})
+	addUserIDs := func(destinationJob *types.DestinationJobT) bool {
+		if !w.eventOrderingRequired(destinationJob) {
+			return true
+		}
+		for _, jobMetadata := range destinationJob.JobMetadataArray {
+			if _, ok := userIDs[jobMetadata.UserID]; ok {
+				return false
+			}
+		}
+		for _, jobMetadata := range destinationJob.JobMetadataArray {
+			userIDs[jobMetadata.UserID] = struct{}{}
+		}
+		return true
+	}
+	addUserIDs(destinationJob)
+	for j := i + 1; j < len(w.destinationJobs) && len(indexes) < batchSize; j++ {
+		next := &w.destinationJobs[j]
+		if (next.StatusCode != 200 && next.StatusCode != 0) ||
+			next.JobMetadataArray[0].DestinationID != destinationID ||
+			!w.canSendJobToDestination(failedJobOrderKeys, next) ||
+			!addUserIDs(next) {
+			break
+		}
+		indexes = append(indexes, j)
+	}
+
+	statusCodes, bodies := batchManager.SendDataBatch(lo.Map(indexes, func(j, _ int) json.RawMessage { return w.destinationJobs[j].Message }), destinationID)
+	responses := make(map[int]customDestinationResponse, len(indexes))
+	for k, j := range indexes {
+		responses[j] = customDestinationResponse{statusCode: statusCodes[k], body: bodies[k], startedAt: startedAt}
+	}
+	return responses
+}
+
+// eventOrderingRequired returns true if the order of events needs to be guaranteed for the destination job
+func (w *worker) eventOrderingRequired(destinationJob *types.DestinationJobT) bool {
+	return w.rt.guaranteeUserEventOrder &&
+		!w.rt.eventOrderingDisabledForWorkspace(destinationJob.JobMetadataArray[0].WorkspaceID) &&
+		!w.rt.eventOrderingDisabledForDestination(destinationJob.JobMetadataArray[0].DestinationID)
+}
+
 func (w *worker) canSendJobToDestination(failedJobOrderKeys map[eventorder.BarrierKey]struct{}, destinationJob *types.DestinationJobT) bool {
 	destinationID := destinationJob.JobMetadataArray[0].DestinationID
 	workspaceID := destinationJob.JobMetadataArray[0].WorkspaceID
-	if !w.rt.guaranteeUserEventOrder ||
-		w.rt.eventOrderingDisabledForWorkspace(workspaceID) ||
-		w.rt.eventOrderingDisabledForDestination(destinationID) {
+	if !w.eventOrderingRequired(destinationJob) {
 		// if guaranteeUserEventOrder is false, letting the next jobs pass
 		return true
 	}
@@ -15,6 +15,7 @@ import (
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/enterprise/reporting"
 	"github.com/rudderlabs/rudder-server/processor/integrations"
+	"github.com/rudderlabs/rudder-server/router/internal/eventorder"
 	"github.com/rudderlabs/rudder-server/router/internal/partition"
 	"github.com/rudderlabs/rudder-server/router/throttler"
 	"github.com/rudderlabs/rudder-server/router/transformer"
@@ -740,3 +741,97 @@ func TestTransformForNonOAuthDestination(t *testing.T) {
 
 	worker.transform(routerJobs)
 }
+
+// mockBatchDestinationManager records the messages sent to it, responding with the status code found in every message
+type mockBatchDestinationManager struct {
+	calls [][]string
+}
+
+func (m *mockBatchDestinationManager) SendData(jsonData json.RawMessage, _ string) (int, string) {
+	m.calls = append(m.calls, []string{string(jsonData)})
+	return 200, string(jsonData)
+}
+
+func (m *mockBatchDestinationManager) SendDataBatch(messages []json.RawMessage, _ string) ([]int, []string) {
+	m.calls = append(m.calls, lo.Map(messages, func(message json.RawMessage, _ int) string { return string(message) }))
+	return lo.Map(messages, func(json.RawMessage, int) int { return 200 }), lo.Map(messages, func(message json.RawMessage, _ int) string { return string(message) })
+}
+
+func (*mockBatchDestinationManager) BackendConfigInitialized() <-chan struct{} {
+	return nil
+}
+
+func TestSendToCustomDestination(t *testing.T) {
+	destinationJob := func(userID, destinationID string, statusCode int) types.DestinationJobT {
+		return types.DestinationJobT{
+			Message:          json.RawMessage(userID),
+			StatusCode:       statusCode,
+			JobMetadataArray: []types.JobMetadataT{{UserID: userID, DestinationID: destinationID, WorkspaceID: "w1"}},
+		}
+	}
+	newWorker := func(batchSize int, guaranteeUserEventOrder bool) (*worker, *mockBatchDestinationManager) {
+		manager := &mockBatchDestinationManager{}
+		return &worker{
+			barrier: eventorder.NewBarrier(),
+			rt: &Handle{
+				customDestinationManager:            manager,
+				guaranteeUserEventOrder:             guaranteeUserEventOrder,
+				eventOrderingDisabledForWorkspace:   func(string) bool { return false },
+				eventOrderingDisabledForDestination: func(string) bool { return false },
+				reloadableConfig: &reloadableConfig{
+					streamBatchSize: config.SingleValueLoader(batchSize),
+				},
+			},
+			destinationJobs: []types.DestinationJobT{
+				destinationJob("u1", "d1", 200),
+				destinationJob("u2", "d1", 200),
+				destinationJob("u1", "d1", 200),
+				destinationJob("u3", "d1", 0),
+				destinationJob("u4", "d1", 200),
+				destinationJob("u5", "d1", 200),
+				destinationJob("u6", "d1", 500),
+				destinationJob("u7", "d2", 200),
+			},
+		}, manager
+	}
+
+	t.Run("batching disabled", func(t *testing.T) {
+		w, manager := newWorker(1, true)
+		responses := w.sendToCustomDestination(0, map[eventorder.BarrierKey]struct{}{})
+		require.Len(t, responses, 1)
+		require.Equal(t, 200, responses[0].statusCode)
+		require.Equal(t, [][]string{{"u1"}}, manager.calls)
+	})
+
+	t.Run("batches stop at jobs of users already in the batch", func(t *testing.T) {
+		w, manager := newWorker(10, true)
+		responses := w.sendToCustomDestination(0, map[eventorder.BarrierKey]struct{}{})
+		require.ElementsMatch(t, []int{0, 1}, lo.Keys(responses))
+		require.Equal(t, "u2", responses[1].body)
+		require.Equal(t, [][]string{{"u1", "u2"}}, manager.calls)
+	})
+
+	t.Run("batches stop at jobs of failed users", func(t *testing.T) {
+		w, manager := newWorker(10, true)
+		failedJobOrderKeys := map[eventorder.BarrierKey]struct{}{{UserID: "u5", DestinationID: "d1", WorkspaceID: "w1"}: {}}
+		responses := w.sendToCustomDestination(2, failedJobOrderKeys)
+		require.ElementsMatch(t, []int{2, 3, 4}, lo.Keys(responses))
+		require.Equal(t, [][]string{{"u1", "u3", "u4"}}, manager.calls)
+	})
+
+	t.Run("batches stop at failed jobs and other destinations", func(t *testing.T) {
+		w, manager := newWorker(10, true)
+		responses := w.sendToCustomDestination(2, map[eventorder.BarrierKey]struct{}{})
+		require.ElementsMatch(t, []int{2, 3, 4, 5}, lo.Keys(responses))
+		responses = w.sendToCustomDestination(7, map[eventorder.BarrierKey]struct{}{})
+		require.ElementsMatch(t, []int{7}, lo.Keys(responses))
+		require.Equal(t, [][]string{{"u1", "u3", "u4", "u5"}, {"u7"}}, manager.calls)
+	})
+
+	t.Run("batch size and users without ordering", func(t *testing.T) {
+		w, manager := newWorker(3, false)
+		responses := w.sendToCustomDestination(0, map[eventorder.BarrierKey]struct{}{})
+		require.ElementsMatch(t, []int{0, 1, 2}, lo.Keys(responses))
+		require.Equal(t, [][]string{{"u1", "u2", "u1"}}, manager.calls)
+	})
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/rudderlabs/rudder-server/app"
 	"github.com/rudderlabs/rudder-server/app/apphandlers"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/gateway/validator"
 	"github.com/rudderlabs/rudder-server/info"
 	"github.com/rudderlabs/rudder-server/router/customdestinationmanager"
 	"github.com/rudderlabs/rudder-server/rruntime"
@@ -61,6 +62,7 @@ type Runner struct {
 	logger                    logger.Logger
 	appHandler                apphandlers.AppHandler
 	gracefulShutdownTimeout   time.Duration
+	gatewayValidators         []validator.PayloadValidator
 }
 
 // New creates and initializes a new Runner
@@ -75,6 +77,11 @@ func New(releaseInfo ReleaseInfo) *Runner {
 	}
 }
 
+// RegisterGatewayValidators registers additional validators for the events received by the gateway, it needs to be called before Run
+func (r *Runner) RegisterGatewayValidators(validators ...validator.PayloadValidator) {
+	r.gatewayValidators = append(r.gatewayValidators, validators...)
+}
+
 // Run runs the application and returns the exit code
 func (r *Runner) Run(ctx context.Context, args []string) int {
 	// Start stats
@@ -135,6 +142,7 @@ func (r *Runner) Run(ctx context.Context, args []string) int {
 	}
 
 	options.EnterpriseToken = r.releaseInfo.EnterpriseToken
+	options.GatewayValidators = r.gatewayValidators
 
 	r.application = app.New(options)
 
@@ -10,7 +10,6 @@ import (
 
 	"github.com/dgraph-io/badger/v4"
 	"github.com/dgraph-io/badger/v4/options"
-	"github.com/samber/lo"
 
 	"github.com/rudderlabs/rudder-go-kit/bytesize"
 	"github.com/rudderlabs/rudder-go-kit/config"
@@ -18,7 +17,7 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/stats"
 
 	"github.com/rudderlabs/rudder-server/rruntime"
-	"github.com/rudderlabs/rudder-server/services/dedup/types"
+	"github.com/rudderlabs/rudder-server/services/dedup/internal/keystore"
 	"github.com/rudderlabs/rudder-server/utils/misc"
 )
 
@@ -53,7 +52,7 @@ func DefaultPath() string {
 	return fmt.Sprintf(`%v%v`, tmpDirPath, badgerPathName)
 }
 
-func NewBadgerDB(conf *config.Config, stat stats.Stats, path string) *Dedup {
+func NewBadgerDB(conf *config.Config, stat stats.Stats, path string) *keystore.Dedup {
 	dedupWindow := conf.GetReloadableDurationVar(3600, time.Second, "Dedup.dedupWindow", "Dedup.dedupWindowInS")
 	log := logger.NewLogger().Child("Dedup")
 	badgerOpts := badger.
@@ -94,13 +93,13 @@ func NewBadgerDB(conf *config.Config, stat stats.Stats, path string) *Dedup {
 	db.stats.vlogSize = stat.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": "dedup", "type": "vlog"})
 	db.stats.totSize = stat.NewTaggedStat("badger_db_size", stats.GaugeType, stats.Tags{"name": "dedup", "type": "total"})
 
-	return &Dedup{
-		badgerDB:    db,
-		uncommitted: make(map[string]struct{}),
-	}
+	return keystore.New("badger db", db)
 }
 
 func (d *BadgerDB) Get(keys []string) (map[string]bool, error) {
+	if err := d.init(); err != nil {
+		return nil, fmt.Errorf("initializing badger db: %w", err)
+	}
 	defer d.stats.getTimer.RecordDuration()()
 	results := make(map[string]bool, len(keys))
 	err := d.badgerDB.View(func(txn *badger.Txn) error {
@@ -118,12 +117,19 @@ func (d *BadgerDB) Get(keys []string) (map[string]bool, error) {
 	return results, err
 }
 
-func (d *BadgerDB) Set(keys []string) error {
+func (d *BadgerDB) Set(keys map[string]time.Duration) error {
+	if err := d.init(); err != nil {
+		return fmt.Errorf("initializing badger db: %w", err)
+	}
 	defer d.stats.setTimer.RecordDuration()()
 	wb := d.badgerDB.NewWriteBatch()
 	defer wb.Cancel()
-	for _, key := range keys {
-		e := badger.NewEntry([]byte(key), nil).WithTTL(d.window.Load())
+	window := d.window.Load()
+	for key, ttl := range keys {
+		if ttl <= 0 {
+			ttl = window
+		}
+		e := badger.NewEntry([]byte(key), nil).WithTTL(ttl)
 		if err := wb.SetEntry(e); err != nil {
 			return err
 		}
@@ -131,6 +137,31 @@ func (d *BadgerDB) Set(keys []string) error {
 	return wb.Flush()
 }
 
+func (d *BadgerDB) Refresh(keys map[string]time.Duration) error {
+	if err := d.init(); err != nil {
+		return fmt.Errorf("initializing badger db: %w", err)
+	}
+	defer d.stats.setTimer.RecordDuration()()
+	window := d.window.Load()
+	return d.badgerDB.Update(func(txn *badger.Txn) error {
+		for key, ttl := range keys {
+			if _, err := txn.Get([]byte(key)); err != nil {
+				if errors.Is(err, badger.ErrKeyNotFound) {
+					continue
+				}
+				return err
+			}
+			if ttl <= 0 {
+				ttl = window
+			}
+			if err := txn.SetEntry(badger.NewEntry([]byte(key), nil).WithTTL(ttl)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (d *BadgerDB) Close() {
 	d.cancel()
 	d.wg.Wait()
@@ -211,87 +242,6 @@ func (d *BadgerDB) gcLoop() {
 	}
 }
 
-type Dedup struct {
-	badgerDB      *BadgerDB
-	uncommittedMu sync.RWMutex
-	uncommitted   map[string]struct{}
-}
-
-func (d *Dedup) Allowed(batchKeys ...types.BatchKey) (map[types.BatchKey]bool, error) {
-	if err := d.badgerDB.init(); err != nil {
-		return nil, fmt.Errorf("initializing badger db: %w", err)
-	}
-	result := make(map[types.BatchKey]bool, len(batchKeys))  // keys encountered for the first time
-	seenInBatch := make(map[string]struct{}, len(batchKeys)) // keys already seen in the batch while iterating
-
-	// figure out which keys need to be checked against the DB
-	batchKeysToCheck := make([]types.BatchKey, 0, len(batchKeys)) // keys to check in the DB
-	d.uncommittedMu.RLock()
-	for _, batchKey := range batchKeys {
-		// if the key is already seen in the batch, skip it
-		if _, seen := seenInBatch[batchKey.Key]; seen {
-			continue
-		}
-		// if the key is already in the uncommitted list , skip it
-		if _, uncommitted := d.uncommitted[batchKey.Key]; uncommitted {
-			seenInBatch[batchKey.Key] = struct{}{}
-			continue
-		}
-		seenInBatch[batchKey.Key] = struct{}{}
-		batchKeysToCheck = append(batchKeysToCheck, batchKey)
-	}
-	d.uncommittedMu.RUnlock()
-
-	if len(batchKeysToCheck) > 0 {
-		seenInDB, err := d.badgerDB.Get(lo.Map(batchKeysToCheck, func(bk types.BatchKey, _ int) string { return bk.Key }))
-		if err != nil {
-			return nil, fmt.Errorf("getting keys from badger db: %w", err)
-		}
-		d.uncommittedMu.Lock()
-		defer d.uncommittedMu.Unlock()
-		for _, batchKey := range batchKeysToCheck {
-			if !seenInDB[batchKey.Key] {
-				if _, race := d.uncommitted[batchKey.Key]; !race { // if another goroutine managed to set this key, we should skip it
-					result[batchKey] = true
-					d.uncommitted[batchKey.Key] = struct{}{} // mark this key as uncommitted
-				}
-			}
-		}
-	}
-	return result, nil
-}
-
-func (d *Dedup) Commit(keys []string) error {
-	if err := d.badgerDB.init(); err != nil {
-		return fmt.Errorf("initializing badger db: %w", err)
-	}
-	kvs := make([]types.BatchKey, len(keys))
-	d.uncommittedMu.RLock()
-	for i, key := range keys {
-		if _, ok := d.uncommitted[key]; !ok {
-			d.uncommittedMu.RUnlock()
-			return fmt.Errorf("key %v has not been previously set", key)
-		}
-		kvs[i] = types.BatchKey{Key: key}
-	}
-	d.uncommittedMu.RUnlock()
-
-	if err := d.badgerDB.Set(keys); err != nil {
-		return fmt.Errorf("setting keys in badger db: %w", err)
-	}
-
-	d.uncommittedMu.Lock()
-	defer d.uncommittedMu.Unlock()
-	for _, kv := range kvs {
-		delete(d.uncommitted, kv.Key)
-	}
-	return nil
-}
-
-func (d *Dedup) Close() {
-	d.badgerDB.Close()
-}
-
 type loggerForBadger struct {
 	logger.Logger
 }
@@ -3,12 +3,24 @@
 package dedup
 
 import (
+	"database/sql"
+	"fmt"
+
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/stats"
 	"github.com/rudderlabs/rudder-server/services/dedup/badger"
+	"github.com/rudderlabs/rudder-server/services/dedup/postgres"
+	"github.com/rudderlabs/rudder-server/services/dedup/redis"
 	"github.com/rudderlabs/rudder-server/services/dedup/types"
 )
 
+// Supported deduplication backends, configured through Dedup.mode
+const (
+	ModeBadger   = "badger"
+	ModePostgres = "postgres"
+	ModeRedis    = "redis"
+)
+
 type BatchKey = types.BatchKey
 
 // SingleKey creates a BatchKey with index 0
@@ -16,9 +28,28 @@ func SingleKey(key string) BatchKey {
 	return types.BatchKey{Key: key}
 }
 
-// New creates a new deduplication service. The service needs to be closed after use.
-func New(conf *config.Config, stats stats.Stats) (Dedup, error) {
-	return badger.NewBadgerDB(conf, stats, badger.DefaultPath()), nil
+// New creates a new deduplication service, using the backend configured through Dedup.mode (badger by default).
+// The postgres backend uses the provided jobsdb database handle, or opens its own connection pool if it is nil.
+// The service needs to be closed after use.
+func New(conf *config.Config, stats stats.Stats, db *sql.DB) (Dedup, error) {
+	switch mode := conf.GetString("Dedup.mode", ModeBadger); mode {
+	case ModeBadger:
+		return badger.NewBadgerDB(conf, stats, badger.DefaultPath()), nil
+	case ModePostgres:
+		d, err := postgres.NewPostgresDB(conf, stats, db)
+		if err != nil {
+			return nil, fmt.Errorf("creating postgres dedup: %w", err)
+		}
+		return d, nil
+	case ModeRedis:
+		d, err := redis.NewRedisDB(conf, stats)
+		if err != nil {
+			return nil, fmt.Errorf("creating redis dedup: %w", err)
+		}
+		return d, nil
+	default:
+		return nil, fmt.Errorf("unsupported dedup mode: %s", mode)
+	}
 }
 
 // Dedup is the interface for deduplication service
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/ory/dockertest/v3"
 	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
@@ -17,6 +18,8 @@ import (
 	"github.com/rudderlabs/rudder-go-kit/config"
 	"github.com/rudderlabs/rudder-go-kit/logger"
 	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/postgres"
+	"github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/redis"
 	"github.com/rudderlabs/rudder-go-kit/testhelper/rand"
 
 	"github.com/rudderlabs/rudder-server/services/dedup"
@@ -24,20 +27,70 @@ import (
 	"github.com/rudderlabs/rudder-server/utils/misc"
 )
 
+type newDedupFunc func(t *testing.T, conf *config.Config) dedup.Dedup
+
+// forEachBackend runs the provided test function against every supported deduplication backend
+func forEachBackend(t *testing.T, fn func(t *testing.T, newDedup newDedupFunc)) {
+	t.Run(dedup.ModeBadger, func(t *testing.T) {
+		fn(t, func(t *testing.T, conf *config.Config) dedup.Dedup {
+			t.Setenv("RUDDER_TMPDIR", t.TempDir())
+			conf.Set("Dedup.mode", dedup.ModeBadger)
+			d, err := dedup.New(conf, stats.NOP, nil)
+			require.NoError(t, err)
+			return d
+		})
+	})
+
+	t.Run(dedup.ModePostgres, func(t *testing.T) {
+		pool, err := dockertest.NewPool("")
+		require.NoError(t, err)
+		postgresContainer, err := postgres.Setup(pool, t)
+		require.NoError(t, err)
+		fn(t, func(t *testing.T, conf *config.Config) dedup.Dedup {
+			conf.Set("Dedup.mode", dedup.ModePostgres)
+			d, err := dedup.New(conf, stats.NOP, postgresContainer.DB)
+			require.NoError(t, err)
+			return d
+		})
+	})
+
+	t.Run(dedup.ModeRedis, func(t *testing.T) {
+		pool, err := dockertest.NewPool("")
+		require.NoError(t, err)
+		redisContainer, err := redis.Setup(context.Background(), pool, t)
+		require.NoError(t, err)
+		fn(t, func(t *testing.T, conf *config.Config) dedup.Dedup {
+			conf.Set("Dedup.mode", dedup.ModeRedis)
+			conf.Set("Dedup.Redis.addr", redisContainer.Addr)
+			d, err := dedup.New(conf, stats.NOP, nil)
+			require.NoError(t, err)
+			return d
+		})
+	})
+}
+
+func Test_Dedup_UnsupportedMode(t *testing.T) {
+	conf := config.New()
+	conf.Set("Dedup.mode", "unknown")
+	_, err := dedup.New(conf, stats.NOP, nil)
+	require.Error(t, err)
+}
+
 func Test_Dedup(t *testing.T) {
 	config.Reset()
 	logger.Reset()
 	misc.Init()
 
-	dbPath := t.TempDir()
-	conf := config.New()
-	t.Setenv("RUDDER_TMPDIR", dbPath)
-
-	d, err := dedup.New(conf, stats.Default)
-	require.Nil(t, err)
-	defer d.Close()
+	forEachBackend(t, func(t *testing.T, newDedup newDedupFunc) {
+		d := newDedup(t, config.New())
+		defer d.Close()
+		testDedup(t, d)
+	})
+}
 
-	t.Run("key a not present in cache and badger db", func(t *testing.T) {
+// testDedup is the conformance suite that every deduplication backend must pass
+func testDedup(t *testing.T, d dedup.Dedup) {
+	t.Run("key a not present in cache and store", func(t *testing.T) {
 		key := dedup.SingleKey("a")
 		found, err := d.Allowed(key)
 		require.NoError(t, err)
@@ -114,16 +167,16 @@ func Test_Dedup_Window(t *testing.T) {
 	logger.Reset()
 	misc.Init()
 
-	dbPath := os.TempDir() + "/dedup_test"
-	conf := config.New()
-	defer func() { _ = os.RemoveAll(dbPath) }()
-	_ = os.RemoveAll(dbPath)
-	conf.Set("Dedup.dedupWindow", "1s")
-	t.Setenv("RUDDER_TMPDIR", dbPath)
-	d, err := dedup.New(conf, stats.Default)
-	require.Nil(t, err)
-	defer d.Close()
+	forEachBackend(t, func(t *testing.T, newDedup newDedupFunc) {
+		conf := config.New()
+		conf.Set("Dedup.dedupWindow", "1s")
+		d := newDedup(t, conf)
+		defer d.Close()
+		testDedupWindow(t, d)
+	})
+}
 
+func testDedupWindow(t *testing.T, d dedup.Dedup) {
 	k := dedup.SingleKey("to be deleted")
 	found, err := d.Allowed(k)
 	require.Nil(t, err)
@@ -143,6 +196,65 @@ func Test_Dedup_Window(t *testing.T) {
 	}, 2*time.Second, 100*time.Millisecond)
 }
 
+func Test_Dedup_KeyTTL(t *testing.T) {
+	config.Reset()
+	logger.Reset()
+	misc.Init()
+
+	forEachBackend(t, func(t *testing.T, newDedup newDedupFunc) {
+		d := newDedup(t, config.New())
+		defer d.Close()
+
+		k := dedup.BatchKey{Key: "short lived", TTL: time.Second}
+		found, err := d.Allowed(k)
+		require.NoError(t, err)
+		require.True(t, found[k])
+		require.NoError(t, d.Commit([]string{k.Key}))
+
+		found, err = d.Allowed(k)
+		require.NoError(t, err)
+		require.False(t, found[k])
+
+		require.Eventually(t, func() bool {
+			found, err = d.Allowed(k)
+			require.NoError(t, err)
+			return found[k]
+		}, 3*time.Second, 100*time.Millisecond, "key should expire according to its own ttl instead of the dedup window")
+	})
+}
+
+func Test_Dedup_SlidingWindow(t *testing.T) {
+	config.Reset()
+	logger.Reset()
+	misc.Init()
+
+	forEachBackend(t, func(t *testing.T, newDedup newDedupFunc) {
+		d := newDedup(t, config.New())
+		defer d.Close()
+
+		k := dedup.BatchKey{Key: "sliding", TTL: 2 * time.Second, Sliding: true}
+		found, err := d.Allowed(k)
+		require.NoError(t, err)
+		require.True(t, found[k])
+		require.NoError(t, d.Commit([]string{k.Key}))
+
+		// duplicates keep restarting the window, so the key outlives its original ttl
+		deadline := time.Now().Add(4 * time.Second)
+		for time.Now().Before(deadline) {
+			found, err = d.Allowed(k)
+			require.NoError(t, err)
+			require.False(t, found[k], "key should not expire while duplicates are encountered")
+			time.Sleep(500 * time.Millisecond)
+		}
+
+		require.Eventually(t, func() bool {
+			found, err = d.Allowed(dedup.BatchKey{Key: k.Key, TTL: k.TTL}) // not refreshing the key while waiting for it to expire
+			require.NoError(t, err)
+			return found[dedup.BatchKey{Key: k.Key, TTL: k.TTL}]
+		}, 5*time.Second, 100*time.Millisecond, "key should expire once no duplicates are encountered")
+	})
+}
+
 func Test_Dedup_ErrTxnTooBig(t *testing.T) {
 	config.Reset()
 	logger.Reset()
@@ -152,7 +264,7 @@ func Test_Dedup_ErrTxnTooBig(t *testing.T) {
 	defer func() { _ = os.RemoveAll(dbPath) }()
 	conf := config.New()
 	t.Setenv("RUDDER_TMPDIR", dbPath)
-	d, err := dedup.New(conf, stats.Default)
+	d, err := dedup.New(conf, stats.Default, nil)
 	require.Nil(t, err)
 	defer d.Close()
 
@@ -172,18 +284,14 @@ func Test_Dedup_Race(t *testing.T) {
 	logger.Reset()
 	misc.Init()
 
-	config.Reset()
-	logger.Reset()
-	misc.Init()
-
-	dbPath := t.TempDir()
-	conf := config.New()
-	t.Setenv("RUDDER_TMPDIR", dbPath)
-
-	d, err := dedup.New(conf, stats.Default)
-	require.Nil(t, err)
-	defer d.Close()
+	forEachBackend(t, func(t *testing.T, newDedup newDedupFunc) {
+		d := newDedup(t, config.New())
+		defer d.Close()
+		testDedupRace(t, d)
+	})
+}
 
+func testDedupRace(t *testing.T, d dedup.Dedup) {
 	// warm up by committing some keys
 	keys := lo.RepeatBy(10000, func(i int) string { return "warmup" + strconv.Itoa(i) })
 	allowed, err := d.Allowed(lo.Map(keys, func(k string, i int) dedup.BatchKey { return dedup.BatchKey{Index: i, Key: k} })...)
@@ -221,7 +329,7 @@ func Benchmark_Dedup(b *testing.B) {
 	_ = os.MkdirAll(dbPath, 0o750)
 	conf := config.New()
 	b.Setenv("RUDDER_TMPDIR", dbPath)
-	d, err := dedup.New(conf, stats.Default)
+	d, err := dedup.New(conf, stats.Default, nil)
 	require.NoError(b, err)
 	b.ResetTimer()
 	b.Run("no duplicates", func(b *testing.B) {
@@ -0,0 +1,127 @@
+package keystore
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/rudderlabs/rudder-server/services/dedup/types"
+)
+
+// Store is a persistent store of committed deduplication keys
+type Store interface {
+	// Get returns a map containing all keys which are present in the store.
+	// Keys that are not present are not included in the map.
+	Get(keys []string) (map[string]bool, error)
+
+	// Set persists the provided keys in the store, along with their time to live.
+	// A zero time to live means that the store's default deduplication window applies.
+	Set(keys map[string]time.Duration) error
+
+	// Refresh resets the time to live of the provided keys which are present in the store, keys which are not present are ignored.
+	// A zero time to live means that the store's default deduplication window applies.
+	Refresh(keys map[string]time.Duration) error
+
+	// Close closes the store
+	Close()
+}
+
+// Dedup implements the deduplication contract on top of a [Store], keeping track of keys which have been allowed but not committed yet
+type Dedup struct {
+	name          string
+	store         Store
+	uncommittedMu sync.RWMutex
+	uncommitted   map[string]time.Duration // uncommitted keys along with their time to live
+}
+
+// New creates a new deduplication service backed by the provided store. The name is used for annotating errors.
+func New(name string, store Store) *Dedup {
+	return &Dedup{
+		name:        name,
+		store:       store,
+		uncommitted: make(map[string]time.Duration),
+	}
+}
+
+func (d *Dedup) Allowed(batchKeys ...types.BatchKey) (map[types.BatchKey]bool, error) {
+	result := make(map[types.BatchKey]bool, len(batchKeys))  // keys encountered for the first time
+	seenInBatch := make(map[string]struct{}, len(batchKeys)) // keys already seen in the batch while iterating
+
+	// figure out which keys need to be checked against the store
+	batchKeysToCheck := make([]types.BatchKey, 0, len(batchKeys)) // keys to check in the store
+	d.uncommittedMu.RLock()
+	for _, batchKey := range batchKeys {
+		// if the key is already seen in the batch, skip it
+		if _, seen := seenInBatch[batchKey.Key]; seen {
+			continue
+		}
+		// if the key is already in the uncommitted list , skip it
+		if _, uncommitted := d.uncommitted[batchKey.Key]; uncommitted {
+			seenInBatch[batchKey.Key] = struct{}{}
+			continue
+		}
+		seenInBatch[batchKey.Key] = struct{}{}
+		batchKeysToCheck = append(batchKeysToCheck, batchKey)
+	}
+	d.uncommittedMu.RUnlock()
+
+	if len(batchKeysToCheck) > 0 {
+		seenInStore, err := d.store.Get(lo.Map(batchKeysToCheck, func(bk types.BatchKey, _ int) string { return bk.Key }))
+		if err != nil {
+			return nil, fmt.Errorf("getting keys from %s: %w", d.name, err)
+		}
+		refresh := make(map[string]time.Duration) // duplicates of sliding keys, whose ttl restarts
+		for _, batchKey := range batchKeysToCheck {
+			if batchKey.Sliding && seenInStore[batchKey.Key] {
+				refresh[batchKey.Key] = batchKey.TTL
+			}
+		}
+		if len(refresh) > 0 {
+			if err := d.store.Refresh(refresh); err != nil {
+				return nil, fmt.Errorf("refreshing keys in %s: %w", d.name, err)
+			}
+		}
+		d.uncommittedMu.Lock()
+		defer d.uncommittedMu.Unlock()
+		for _, batchKey := range batchKeysToCheck {
+			if !seenInStore[batchKey.Key] {
+				if _, race := d.uncommitted[batchKey.Key]; !race { // if another goroutine managed to set this key, we should skip it
+					result[batchKey] = true
+					d.uncommitted[batchKey.Key] = batchKey.TTL // mark this key as uncommitted
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+func (d *Dedup) Commit(keys []string) error {
+	kvs := make(map[string]time.Duration, len(keys))
+	d.uncommittedMu.RLock()
+	for _, key := range keys {
+		ttl, ok := d.uncommitted[key]
+		if !ok {
+			d.uncommittedMu.RUnlock()
+			return fmt.Errorf("key %v has not been previously set", key)
+		}
+		kvs[key] = ttl
+	}
+	d.uncommittedMu.RUnlock()
+
+	if err := d.store.Set(kvs); err != nil {
+		return fmt.Errorf("setting keys in %s: %w", d.name, err)
+	}
+
+	d.uncommittedMu.Lock()
+	defer d.uncommittedMu.Unlock()
+	for _, key := range keys {
+		delete(d.uncommitted, key)
+	}
+	return nil
+}
+
+func (d *Dedup) Close() {
+	d.store.Close()
+}
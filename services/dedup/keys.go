@@ -0,0 +1,143 @@
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// Key modes, configured per source through Dedup.<sourceID>.keyMode (falling back to Dedup.keyMode)
+const (
+	// KeyModeMessageID deduplicates events having the same messageId, for the whole deduplication window
+	KeyModeMessageID = "messageId"
+	// KeyModeContentHash deduplicates events having the same values for a configurable set of JSON paths
+	KeyModeContentHash = "contentHash"
+	// KeyModeSlidingWindow deduplicates events having the same messageId, with keys expiring once no duplicates have been seen for a per-source window
+	KeyModeSlidingWindow = "slidingWindow"
+)
+
+var defaultContentHashPaths = []string{"type", "event", "userId", "anonymousId", "properties", "traits"}
+
+// KeyBuilder builds deduplication keys for events according to the key mode configured for their source
+type KeyBuilder struct {
+	conf *config.Config
+
+	sourcesMu sync.RWMutex
+	sources   map[string]*sourceKeyConfig
+}
+
+type sourceKeyConfig struct {
+	mode   config.ValueLoader[string]
+	paths  config.ValueLoader[[]string]
+	window config.ValueLoader[time.Duration]
+}
+
+// NewKeyBuilder creates a new key builder
+func NewKeyBuilder(conf *config.Config) *KeyBuilder {
+	return &KeyBuilder{
+		conf:    conf,
+		sources: make(map[string]*sourceKeyConfig),
+	}
+}
+
+// Key returns the deduplication key for an event, along with the key mode that was used for building it.
+//
+// In [KeyModeSlidingWindow] the key expires after the source's window (Dedup.<sourceID>.window), which restarts whenever a duplicate is encountered, whereas in [KeyModeContentHash]
+// the source's window is only applied if it is explicitly configured. In any other case the global deduplication window applies.
+func (kb *KeyBuilder) Key(index int, sourceID, sourceJobRunID, messageID string, event map[string]interface{}) (BatchKey, string) {
+	sc := kb.sourceConfig(sourceID)
+	switch mode := sc.mode.Load(); mode {
+	case KeyModeContentHash:
+		key := BatchKey{
+			Index: index,
+			Key:   "ch:" + sourceID + ":" + contentHash(event, sc.paths.Load()) + sourceJobRunID,
+		}
+		if kb.conf.IsSet("Dedup."+sourceID+".window") || kb.conf.IsSet("Dedup.window") {
+			key.TTL = sc.window.Load()
+		}
+		return key, mode
+	case KeyModeSlidingWindow:
+		return BatchKey{
+			Index:   index,
+			Key:     messageID + sourceJobRunID,
+			TTL:     sc.window.Load(),
+			Sliding: true,
+		}, mode
+	default:
+		return BatchKey{
+			Index: index,
+			Key:   messageID + sourceJobRunID,
+		}, KeyModeMessageID
+	}
+}
+
+func (kb *KeyBuilder) sourceConfig(sourceID string) *sourceKeyConfig {
+	kb.sourcesMu.RLock()
+	sc, ok := kb.sources[sourceID]
+	kb.sourcesMu.RUnlock()
+	if ok {
+		return sc
+	}
+	kb.sourcesMu.Lock()
+	defer kb.sourcesMu.Unlock()
+	if sc, ok := kb.sources[sourceID]; ok {
+		return sc
+	}
+	sc = &sourceKeyConfig{
+		mode:   kb.conf.GetReloadableStringVar(KeyModeMessageID, "Dedup."+sourceID+".keyMode", "Dedup.keyMode"),
+		paths:  kb.conf.GetReloadableStringSliceVar(defaultContentHashPaths, "Dedup."+sourceID+".contentHash.paths", "Dedup.contentHash.paths"),
+		window: kb.conf.GetReloadableDurationVar(10, time.Minute, "Dedup."+sourceID+".window", "Dedup.window"),
+	}
+	kb.sources[sourceID] = sc
+	return sc
+}
+
+// contentHash returns a hex-encoded sha256 hash of the values found in the event for the provided JSON paths
+func contentHash(event map[string]interface{}, paths []string) string {
+	h := sha256.New()
+	for _, path := range paths {
+		_, _ = h.Write([]byte(path))
+		_, _ = h.Write([]byte{0})
+		writeValue(h, misc.MapLookup(event, strings.Split(path, ".")...))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeValue writes a canonical representation of a JSON value to the hash, sorting object keys so that key order doesn't affect the result
+func writeValue(h hash.Hash, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		_, _ = h.Write([]byte{'{'})
+		for _, k := range keys {
+			_, _ = fmt.Fprintf(h, "%q:", k)
+			writeValue(h, v[k])
+			_, _ = h.Write([]byte{','})
+		}
+		_, _ = h.Write([]byte{'}'})
+	case []interface{}:
+		_, _ = h.Write([]byte{'['})
+		for _, e := range v {
+			writeValue(h, e)
+			_, _ = h.Write([]byte{','})
+		}
+		_, _ = h.Write([]byte{']'})
+	case string:
+		_, _ = fmt.Fprintf(h, "%q", v)
+	default:
+		_, _ = fmt.Fprintf(h, "%v", v)
+	}
+}
@@ -0,0 +1,93 @@
+package dedup_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+
+	"github.com/rudderlabs/rudder-server/services/dedup"
+)
+
+func TestKeyBuilder(t *testing.T) {
+	event := func(messageID string) map[string]interface{} {
+		return map[string]interface{}{
+			"messageId":   messageID,
+			"type":        "track",
+			"event":       "Order Completed",
+			"anonymousId": "anon-1",
+			"properties": map[string]interface{}{
+				"orderId": "o-1",
+				"total":   10.5,
+				"items":   []interface{}{"a", "b"},
+			},
+		}
+	}
+
+	t.Run("messageId mode by default", func(t *testing.T) {
+		kb := dedup.NewKeyBuilder(config.New())
+		key, mode := kb.Key(3, "source-1", "jobrun-1", "message-1", event("message-1"))
+		require.Equal(t, dedup.KeyModeMessageID, mode)
+		require.Equal(t, dedup.BatchKey{Index: 3, Key: "message-1jobrun-1"}, key)
+	})
+
+	t.Run("content hash mode ignores messageId and key order", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("Dedup.source-1.keyMode", dedup.KeyModeContentHash)
+		kb := dedup.NewKeyBuilder(conf)
+
+		key1, mode := kb.Key(0, "source-1", "", "message-1", event("message-1"))
+		require.Equal(t, dedup.KeyModeContentHash, mode)
+		require.Zero(t, key1.TTL)
+
+		reordered := event("message-2")
+		reordered["properties"] = map[string]interface{}{
+			"items":   []interface{}{"a", "b"},
+			"total":   10.5,
+			"orderId": "o-1",
+		}
+		key2, _ := kb.Key(1, "source-1", "", "message-2", reordered)
+		require.Equal(t, key1.Key, key2.Key)
+
+		changed := event("message-3")
+		changed["event"] = "Order Refunded"
+		key3, _ := kb.Key(2, "source-1", "", "message-3", changed)
+		require.NotEqual(t, key1.Key, key3.Key)
+
+		otherSource, mode := kb.Key(0, "source-2", "", "message-1", event("message-1"))
+		require.Equal(t, dedup.KeyModeMessageID, mode, "other sources should keep using the default mode")
+		require.Equal(t, "message-1", otherSource.Key)
+	})
+
+	t.Run("content hash mode with custom paths and window", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("Dedup.keyMode", dedup.KeyModeContentHash)
+		conf.Set("Dedup.contentHash.paths", []string{"properties.orderId"})
+		conf.Set("Dedup.source-1.window", "30s")
+		kb := dedup.NewKeyBuilder(conf)
+
+		key1, _ := kb.Key(0, "source-1", "", "message-1", event("message-1"))
+		require.Equal(t, 30*time.Second, key1.TTL)
+
+		changed := event("message-2")
+		changed["event"] = "Order Refunded"
+		key2, _ := kb.Key(1, "source-1", "", "message-2", changed)
+		require.Equal(t, key1.Key, key2.Key, "only the configured paths should be part of the hash")
+	})
+
+	t.Run("sliding window mode", func(t *testing.T) {
+		conf := config.New()
+		conf.Set("Dedup.source-1.keyMode", dedup.KeyModeSlidingWindow)
+		kb := dedup.NewKeyBuilder(conf)
+
+		key, mode := kb.Key(0, "source-1", "", "message-1", event("message-1"))
+		require.Equal(t, dedup.KeyModeSlidingWindow, mode)
+		require.Equal(t, dedup.BatchKey{Key: "message-1", TTL: 10 * time.Minute, Sliding: true}, key)
+
+		conf.Set("Dedup.source-1.window", "1h")
+		key, _ = kb.Key(0, "source-1", "", "message-1", event("message-1"))
+		require.Equal(t, time.Hour, key.TTL)
+	})
+}
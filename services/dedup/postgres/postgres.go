@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+	"github.com/rudderlabs/rudder-go-kit/stats/collectors"
+
+	"github.com/rudderlabs/rudder-server/rruntime"
+	"github.com/rudderlabs/rudder-server/services/dedup/internal/keystore"
+	migrator "github.com/rudderlabs/rudder-server/services/sql-migrator"
+	"github.com/rudderlabs/rudder-server/utils/misc"
+)
+
+// PostgresDB is a deduplication key store backed by a postgres table, using the same database as jobsdb.
+// Expired keys are pruned periodically in the background.
+type PostgresDB struct {
+	logger        logger.Logger
+	db            *sql.DB
+	ownDB         bool // whether the database handle was opened by the store, and needs to be closed along with it
+	window        config.ValueLoader[time.Duration]
+	pruneInterval config.ValueLoader[time.Duration]
+	timeout       config.ValueLoader[time.Duration] // timeout of getting and setting keys
+
+	wg     sync.WaitGroup
+	bgCtx  context.Context
+	cancel context.CancelFunc
+	stats  struct {
+		getTimer    stats.Timer
+		setTimer    stats.Timer
+		prunedCount stats.Counter
+	}
+}
+
+// NewPostgresDB creates a new postgres-backed deduplication service, running any pending migrations for the keys table.
+// The service uses the provided jobsdb database handle, which it doesn't close. If the handle is nil, the service opens its own connection pool instead.
+func NewPostgresDB(conf *config.Config, stat stats.Stats, db *sql.DB) (*keystore.Dedup, error) {
+	ownDB := db == nil
+	if ownDB {
+		var err error
+		db, err = sql.Open("postgres", misc.GetConnectionString(conf, "dedup"))
+		if err != nil {
+			return nil, fmt.Errorf("opening postgres connection: %w", err)
+		}
+		db.SetMaxOpenConns(conf.GetIntVar(10, 1, "Dedup.Postgres.maxOpenConns"))
+		db.SetMaxIdleConns(conf.GetIntVar(5, 1, "Dedup.Postgres.maxIdleConns"))
+		if err := stat.RegisterCollector(collectors.NewDatabaseSQLStats("dedup", db)); err != nil {
+			_ = db.Close()
+			return nil, fmt.Errorf("registering collector: %w", err)
+		}
+	}
+	closeDB := func() {
+		if ownDB {
+			_ = db.Close()
+		}
+	}
+	m := &migrator.Migrator{
+		Handle:                     db,
+		MigrationsTable:            "dedup_migrations",
+		ShouldForceSetLowerVersion: conf.GetBool("SQLMigrator.forceSetLowerVersion", true),
+	}
+	if err := m.Migrate("dedup"); err != nil {
+		closeDB()
+		return nil, fmt.Errorf("running dedup migrations: %w", err)
+	}
+
+	bgCtx, cancel := context.WithCancel(context.Background())
+	p := &PostgresDB{
+		logger:        logger.NewLogger().Child("Dedup").Child("postgres"),
+		db:            db,
+		ownDB:         ownDB,
+		window:        conf.GetReloadableDurationVar(3600, time.Second, "Dedup.dedupWindow", "Dedup.dedupWindowInS"),
+		pruneInterval: conf.GetReloadableDurationVar(5, time.Minute, "Dedup.Postgres.pruneInterval"),
+		timeout:       conf.GetReloadableDurationVar(10, time.Second, "Dedup.Postgres.timeout"),
+		bgCtx:         bgCtx,
+		cancel:        cancel,
+	}
+	p.stats.getTimer = stat.NewTaggedStat("dedup_get_duration_seconds", stats.TimerType, stats.Tags{"mode": "postgres"})
+	p.stats.setTimer = stat.NewTaggedStat("dedup_set_duration_seconds", stats.TimerType, stats.Tags{"mode": "postgres"})
+	p.stats.prunedCount = stat.NewTaggedStat("dedup_pruned_keys_count", stats.CountType, stats.Tags{"mode": "postgres"})
+
+	p.wg.Add(1)
+	rruntime.Go(func() {
+		defer p.wg.Done()
+		p.pruneLoop()
+	})
+	return keystore.New("postgres", p), nil
+}
+
+func (p *PostgresDB) Get(keys []string) (map[string]bool, error) {
+	defer p.stats.getTimer.RecordDuration()()
+	ctx, cancel := context.WithTimeout(p.bgCtx, p.timeout.Load())
+	defer cancel()
+	rows, err := p.db.QueryContext(ctx, `SELECT key FROM dedup_keys WHERE key = ANY($1) AND expires_at > NOW()`, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("querying keys: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	results := make(map[string]bool, len(keys))
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("scanning key: %w", err)
+		}
+		results[key] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterating keys: %w", err)
+	}
+	return results, nil
+}
+
+func (p *PostgresDB) Set(keys map[string]time.Duration) error {
+	defer p.stats.setTimer.RecordDuration()()
+	window := p.window.Load()
+	ks := make([]string, 0, len(keys))
+	ttls := make([]int64, 0, len(keys))
+	for key, ttl := range keys {
+		if ttl <= 0 {
+			ttl = window
+		}
+		ks = append(ks, key)
+		ttls = append(ttls, ttl.Milliseconds())
+	}
+	ctx, cancel := context.WithTimeout(p.bgCtx, p.timeout.Load())
+	defer cancel()
+	if _, err := p.db.ExecContext(ctx,
+		`INSERT INTO dedup_keys (key, expires_at)
+		SELECT k, NOW() + ttl * INTERVAL '1 millisecond' FROM unnest($1::text[], $2::bigint[]) AS t(k, ttl)
+		ON CONFLICT (key) DO UPDATE SET expires_at = EXCLUDED.expires_at`,
+		pq.Array(ks), pq.Array(ttls),
+	); err != nil {
+		return fmt.Errorf("inserting keys: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresDB) Refresh(keys map[string]time.Duration) error {
+	defer p.stats.setTimer.RecordDuration()()
+	window := p.window.Load()
+	ks := make([]string, 0, len(keys))
+	ttls := make([]int64, 0, len(keys))
+	for key, ttl := range keys {
+		if ttl <= 0 {
+			ttl = window
+		}
+		ks = append(ks, key)
+		ttls = append(ttls, ttl.Milliseconds())
+	}
+	ctx, cancel := context.WithTimeout(p.bgCtx, p.timeout.Load())
+	defer cancel()
+	if _, err := p.db.ExecContext(ctx,
+		`UPDATE dedup_keys SET expires_at = NOW() + t.ttl * INTERVAL '1 millisecond'
+		FROM unnest($1::text[], $2::bigint[]) AS t(k, ttl)
+		WHERE dedup_keys.key = t.k AND dedup_keys.expires_at > NOW()`,
+		pq.Array(ks), pq.Array(ttls),
+	); err != nil {
+		return fmt.Errorf("refreshing keys: %w", err)
+	}
+	return nil
+}
+
+func (p *PostgresDB) Close() {
+	p.cancel()
+	p.wg.Wait()
+	if p.ownDB {
+		_ = p.db.Close()
+	}
+}
+
+// pruneLoop periodically deletes expired keys from the keys table
+func (p *PostgresDB) pruneLoop() {
+	for {
+		select {
+		case <-p.bgCtx.Done():
+			return
+		case <-time.After(p.pruneInterval.Load()):
+		}
+		res, err := p.db.ExecContext(p.bgCtx, `DELETE FROM dedup_keys WHERE expires_at <= NOW()`)
+		if err != nil {
+			if p.bgCtx.Err() == nil {
+				p.logger.Errorn("Error while pruning expired dedup keys", logger.NewErrorField(err))
+			}
+			continue
+		}
+		if affected, err := res.RowsAffected(); err == nil {
+			p.stats.prunedCount.Count(int(affected))
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/stats"
+
+	"github.com/rudderlabs/rudder-server/services/dedup/internal/keystore"
+)
+
+// RedisDB is a deduplication key store backed by redis.
+// Keys are stored using SETNX semantics and expire after the deduplication window.
+type RedisDB struct {
+	client    *redis.Client
+	keyPrefix string
+	window    config.ValueLoader[time.Duration]
+	timeout   config.ValueLoader[time.Duration]
+	stats     struct {
+		getTimer stats.Timer
+		setTimer stats.Timer
+	}
+}
+
+// NewRedisDB creates a new redis-backed deduplication service
+func NewRedisDB(conf *config.Config, stat stats.Stats) (*keystore.Dedup, error) {
+	if !conf.IsSet("Dedup.Redis.addr") {
+		return nil, fmt.Errorf("redis address is not configured, please set Dedup.Redis.addr")
+	}
+	client := redis.NewClient(&redis.Options{
+		Addr:     conf.GetString("Dedup.Redis.addr", "localhost:6379"),
+		Username: conf.GetString("Dedup.Redis.username", ""),
+		Password: conf.GetString("Dedup.Redis.password", ""),
+		DB:       conf.GetInt("Dedup.Redis.db", 0),
+	})
+	r := &RedisDB{
+		client:    client,
+		keyPrefix: conf.GetString("Dedup.Redis.keyPrefix", "dedup:"),
+		window:    conf.GetReloadableDurationVar(3600, time.Second, "Dedup.dedupWindow", "Dedup.dedupWindowInS"),
+		timeout:   conf.GetReloadableDurationVar(10, time.Second, "Dedup.Redis.timeout"),
+	}
+	r.stats.getTimer = stat.NewTaggedStat("dedup_get_duration_seconds", stats.TimerType, stats.Tags{"mode": "redis"})
+	r.stats.setTimer = stat.NewTaggedStat("dedup_set_duration_seconds", stats.TimerType, stats.Tags{"mode": "redis"})
+	return keystore.New("redis", r), nil
+}
+
+func (r *RedisDB) Get(keys []string) (map[string]bool, error) {
+	defer r.stats.getTimer.RecordDuration()()
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout.Load())
+	defer cancel()
+	cmds := make([]*redis.IntCmd, len(keys))
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Exists(ctx, r.keyPrefix+key)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("checking keys: %w", err)
+	}
+	results := make(map[string]bool, len(keys))
+	for i, cmd := range cmds {
+		if cmd.Val() > 0 {
+			results[keys[i]] = true
+		}
+	}
+	return results, nil
+}
+
+func (r *RedisDB) Set(keys map[string]time.Duration) error {
+	defer r.stats.setTimer.RecordDuration()()
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout.Load())
+	defer cancel()
+	window := r.window.Load()
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, ttl := range keys {
+			if ttl <= 0 {
+				ttl = window
+			}
+			pipe.SetNX(ctx, r.keyPrefix+key, "", ttl)
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("setting keys: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisDB) Refresh(keys map[string]time.Duration) error {
+	defer r.stats.setTimer.RecordDuration()()
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout.Load())
+	defer cancel()
+	window := r.window.Load()
+	if _, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for key, ttl := range keys {
+			if ttl <= 0 {
+				ttl = window
+			}
+			pipe.Expire(ctx, r.keyPrefix+key, ttl) // only applies to existing keys
+		}
+		return nil
+	}); err != nil {
+		return fmt.Errorf("refreshing keys: %w", err)
+	}
+	return nil
+}
+
+func (r *RedisDB) Close() {
+	_ = r.client.Close()
+}
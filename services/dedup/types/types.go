@@ -1,9 +1,15 @@
 package types
 
+import "time"
+
 // BatchKey represents a key in a batch
 type BatchKey struct {
 	// Index is the index of the key in the batch (used for discriminating between keys with the same value)
 	Index int
 	// Key is the value of the key
 	Key string
+	// TTL is the duration after which the key expires once committed (optional, defaults to the configured deduplication window)
+	TTL time.Duration
+	// Sliding refreshes the TTL of the key whenever a duplicate of it is encountered, so that it expires once no duplicates have been seen for the TTL
+	Sliding bool
 }
@@ -0,0 +1,50 @@
+package geolocation
+
+import (
+	"errors"
+	"fmt"
+)
+
+type chainFetcher struct {
+	fetchers []GeoFetcher
+}
+
+// NewChainFetcher creates a fetcher locating ips using all the provided fetchers, merging their results.
+// Fetchers are ordered by precedence: a fetcher only provides the information missing from the results of the previous ones,
+// with the location dependent information (city, postal code, continent, subdivisions, country and location) coming entirely from a single fetcher.
+// If some of the fetchers fail, the merged results of the rest are returned along with the error.
+func NewChainFetcher(fetchers ...GeoFetcher) GeoFetcher {
+	return &chainFetcher{fetchers: fetchers}
+}
+
+func (c *chainFetcher) Locate(ip string) (GeoInfo, error) {
+	var (
+		info GeoInfo
+		errs []error
+	)
+	for _, f := range c.fetchers {
+		result, err := f.Locate(ip)
+		if err != nil {
+			if errors.Is(err, ErrInvalidIP) {
+				return GeoInfo{}, err
+			}
+			errs = append(errs, err)
+			continue
+		}
+		info.merge(result)
+	}
+	return info, errors.Join(errs...)
+}
+
+func (c *chainFetcher) Close() error {
+	var errs []error
+	for _, f := range c.fetchers {
+		if err := f.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("closing the chained fetchers: %w", err)
+	}
+	return nil
+}
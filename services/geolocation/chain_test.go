@@ -0,0 +1,94 @@
+package geolocation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/services/geolocation"
+)
+
+type failingFetcher struct {
+	err error
+}
+
+func (f *failingFetcher) Locate(string) (geolocation.GeoInfo, error) {
+	return geolocation.GeoInfo{}, f.err
+}
+
+func (f *failingFetcher) Close() error {
+	return f.err
+}
+
+func TestChainFetcher(t *testing.T) {
+	// the asn and dbip databases are generated from the corresponding *_input.json files
+	city, err := geolocation.NewMaxmindDBReader("./testdata/city_test.mmdb")
+	require.NoError(t, err)
+	dbip, err := geolocation.NewMaxmindDBReader("./testdata/dbip_city_test.mmdb")
+	require.NoError(t, err)
+	asn, err := geolocation.NewMaxmindDBReader("./testdata/asn_test.mmdb")
+	require.NoError(t, err)
+	overrides, err := geolocation.NewCSVOverrideReader(writeFile(t, "overrides.csv", "network,city,organization\n2.125.160.0/24,Abingdon,Example Corp\n"))
+	require.NoError(t, err)
+
+	f := geolocation.NewChainFetcher(city, dbip, asn)
+
+	t.Run("results are merged in order of precedence", func(t *testing.T) {
+		info, err := f.Locate("2.125.160.216")
+		require.NoError(t, err)
+		require.Equal(t, "Boxford", info.City.Names["en"], "the city of the first fetcher should be used")
+		require.Equal(t, "OX1", info.Postal.Code)
+		require.EqualValues(t, 5607, info.ASNumber)
+		require.Equal(t, "Sky UK Limited", info.ASOrganization)
+
+		info, err = f.Locate("1.1.1.1")
+		require.NoError(t, err)
+		require.Equal(t, "Sydney", info.City.Names["en"], "missing information should be provided by the next fetchers")
+		require.Equal(t, "AU", info.Country.ISOCode)
+		require.Equal(t, "Australia/Sydney", info.Location.Timezone)
+		require.EqualValues(t, 13335, info.ASNumber)
+
+		info, err = f.Locate("2a02:ff40::1")
+		require.NoError(t, err)
+		require.Equal(t, "Europe", info.Continent.Names["en"])
+		require.EqualValues(t, 64512, info.ASNumber)
+		require.Equal(t, "Example IPv6 Network", info.ASOrganization)
+
+		info, err = geolocation.NewChainFetcher(overrides, city, asn).Locate("2.125.160.216")
+		require.NoError(t, err)
+		require.Equal(t, "Abingdon", info.City.Names["en"])
+		require.Equal(t, "Example Corp", info.Organization)
+		require.Empty(t, info.Postal.Code, "location dependent fields should not be mixed with the ones of another fetcher")
+		require.EqualValues(t, 5607, info.ASNumber, "fields which don't depend on the location should be provided by the next fetchers")
+	})
+
+	t.Run("location dependent fields are taken from a single fetcher", func(t *testing.T) {
+		overrides, err := geolocation.NewCSVOverrideReader(writeFile(t, "overrides.csv", "network,timezone\n2.125.160.0/24,Europe/Dublin\n"))
+		require.NoError(t, err)
+
+		info, err := geolocation.NewChainFetcher(overrides, city).Locate("2.125.160.216")
+		require.NoError(t, err)
+		require.Equal(t, "Europe/Dublin", info.Location.Timezone)
+		require.Nil(t, info.Location.Latitude, "coordinates should not be mixed with the location of another fetcher")
+		require.Nil(t, info.Location.Longitude)
+		require.Empty(t, info.City.Names)
+		require.Empty(t, info.Country.ISOCode)
+	})
+
+	t.Run("invalid ips", func(t *testing.T) {
+		_, err := f.Locate("invalid-ip")
+		require.ErrorIs(t, err, geolocation.ErrInvalidIP)
+	})
+
+	t.Run("partial results are returned when some fetchers fail", func(t *testing.T) {
+		failing := &failingFetcher{err: errors.New("lookup failed")}
+		info, err := geolocation.NewChainFetcher(failing, asn).Locate("1.1.1.1")
+		require.ErrorIs(t, err, failing.err)
+		require.EqualValues(t, 13335, info.ASNumber)
+
+		require.ErrorIs(t, geolocation.NewChainFetcher(failing).Close(), failing.err)
+	})
+
+	require.NoError(t, f.Close())
+}
@@ -0,0 +1,147 @@
+package geolocation
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// csvColumns are the columns supported by csv override files, only the network column is required
+var csvColumns = []string{"network", "country", "region", "city", "postal", "timezone", "latitude", "longitude", "asn", "organization"}
+
+type override struct {
+	prefix netip.Prefix
+	info   GeoInfo
+}
+
+type csvOverrideReader struct {
+	overrides []override // sorted by descending prefix length, so that the most specific network matches first
+}
+
+// NewCSVOverrideReader creates a reader of a csv file overriding the geolocation of ip ranges, e.g. for corporate networks.
+// The first line of the file is a header naming its columns, which can be any of:
+//
+//	network,country,region,city,postal,timezone,latitude,longitude,asn,organization
+//
+// where network is an ipv4 or ipv6 CIDR and country is an ISO code. When networks overlap, the most specific one is used.
+func NewCSVOverrideReader(path string) (*csvOverrideReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if _, ok := err.(*fs.PathError); ok {
+			return nil, ErrInvalidDatabase
+		}
+		return nil, fmt.Errorf("opening csv override file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+	r.Comment = '#'
+	header, err := r.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading csv header: %w", ErrInvalidDatabase, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(csvColumns, column) {
+			return nil, fmt.Errorf("%w: unsupported csv column %q", ErrInvalidDatabase, column)
+		}
+		columns[column] = i
+	}
+	if _, ok := columns["network"]; !ok {
+		return nil, fmt.Errorf("%w: missing network csv column", ErrInvalidDatabase)
+	}
+
+	reader := &csvOverrideReader{}
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading csv record: %w", ErrInvalidDatabase, err)
+		}
+		line, _ := r.FieldPos(0)
+		o, err := parseOverride(record, columns)
+		if err != nil {
+			return nil, fmt.Errorf("%w: line %d: %w", ErrInvalidDatabase, line, err)
+		}
+		reader.overrides = append(reader.overrides, o)
+	}
+	slices.SortStableFunc(reader.overrides, func(a, b override) int {
+		return b.prefix.Bits() - a.prefix.Bits()
+	})
+	return reader, nil
+}
+
+func parseOverride(record []string, columns map[string]int) (override, error) {
+	value := func(column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	prefix, err := netip.ParsePrefix(value("network"))
+	if err != nil {
+		return override{}, fmt.Errorf("parsing network: %w", err)
+	}
+	o := override{prefix: prefix.Masked()}
+	if country := value("country"); country != "" {
+		o.info.Country.ISOCode = strings.ToUpper(country)
+	}
+	if region := value("region"); region != "" {
+		o.info.Subdivisions = []Subdivision{{Names: map[string]string{"en": region}}}
+	}
+	if city := value("city"); city != "" {
+		o.info.City.Names = map[string]string{"en": city}
+	}
+	o.info.Postal.Code = value("postal")
+	o.info.Location.Timezone = value("timezone")
+	if latitude, longitude := value("latitude"), value("longitude"); latitude != "" && longitude != "" {
+		lat, err := strconv.ParseFloat(latitude, 64)
+		if err != nil {
+			return override{}, fmt.Errorf("parsing latitude: %w", err)
+		}
+		lon, err := strconv.ParseFloat(longitude, 64)
+		if err != nil {
+			return override{}, fmt.Errorf("parsing longitude: %w", err)
+		}
+		o.info.Location.Latitude, o.info.Location.Longitude = &lat, &lon
+	}
+	if asn := strings.TrimPrefix(strings.ToUpper(value("asn")), "AS"); asn != "" {
+		number, err := strconv.ParseUint(asn, 10, 32)
+		if err != nil {
+			return override{}, fmt.Errorf("parsing asn: %w", err)
+		}
+		o.info.ASNumber = uint(number)
+	}
+	o.info.Organization = value("organization")
+	return o, nil
+}
+
+func (r *csvOverrideReader) Locate(ip string) (GeoInfo, error) {
+	addr, err := netip.ParseAddr(normalizeIP(ip))
+	if err != nil {
+		return GeoInfo{}, ErrInvalidIP
+	}
+	addr = addr.Unmap()
+	for _, o := range r.overrides {
+		if o.prefix.Contains(addr) {
+			return o.info, nil
+		}
+	}
+	return GeoInfo{}, nil
+}
+
+func (r *csvOverrideReader) Close() error {
+	return nil
+}
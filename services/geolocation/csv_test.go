@@ -0,0 +1,90 @@
+package geolocation_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-server/services/geolocation"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestCSVOverrideReader(t *testing.T) {
+	t.Run("reader errors out when file is missing or invalid", func(t *testing.T) {
+		_, err := geolocation.NewCSVOverrideReader(filepath.Join(t.TempDir(), "missing.csv"))
+		require.ErrorIs(t, err, geolocation.ErrInvalidDatabase)
+
+		for _, content := range []string{
+			"",
+			"country,city\nGB,London\n",
+			"network,unknown\n10.0.0.0/8,value\n",
+			"network,city\n10.0.0.1,London\n",
+			"network,asn\n10.0.0.0/8,ASX\n",
+			"network,latitude,longitude\n10.0.0.0/8,north,west\n",
+		} {
+			_, err := geolocation.NewCSVOverrideReader(writeFile(t, "overrides.csv", content))
+			require.ErrorIs(t, err, geolocation.ErrInvalidDatabase, content)
+		}
+	})
+
+	f, err := geolocation.NewCSVOverrideReader(writeFile(t, "overrides.csv", `network, country, region, city, postal, timezone, latitude, longitude, asn, organization
+# corporate networks
+10.0.0.0/8,gb,England,London,EC1,Europe/London,51.5,-0.12,AS64500,Example Corp
+10.1.0.0/16,de,,Berlin,,Europe/Berlin,,,,Example Corp Berlin
+fd00:1234::/32,US,California,San Francisco,,,,,64501,Example Corp US
+`))
+	require.NoError(t, err)
+	defer func() { require.NoError(t, f.Close()) }()
+
+	t.Run("returns the most specific override", func(t *testing.T) {
+		info, err := f.Locate("10.2.3.4")
+		require.NoError(t, err)
+		require.Equal(t, "GB", info.Country.ISOCode)
+		require.Equal(t, "England", info.Subdivisions[0].Names["en"])
+		require.Equal(t, "London", info.City.Names["en"])
+		require.Equal(t, "EC1", info.Postal.Code)
+		require.Equal(t, "Europe/London", info.Location.Timezone)
+		require.Equal(t, 51.5, *info.Location.Latitude)
+		require.Equal(t, -0.12, *info.Location.Longitude)
+		require.EqualValues(t, 64500, info.ASNumber)
+		require.Equal(t, "Example Corp", info.Organization)
+
+		info, err = f.Locate("10.1.3.4")
+		require.NoError(t, err)
+		require.Equal(t, "DE", info.Country.ISOCode)
+		require.Equal(t, "Berlin", info.City.Names["en"])
+		require.Empty(t, info.Subdivisions)
+		require.Nil(t, info.Location.Latitude)
+		require.Zero(t, info.ASNumber)
+	})
+
+	t.Run("supports ipv6", func(t *testing.T) {
+		for _, ip := range []string{"fd00:1234::1", "[fd00:1234::1]", "fd00:1234::1%eth0"} {
+			info, err := f.Locate(ip)
+			require.NoError(t, err, ip)
+			require.Equal(t, "San Francisco", info.City.Names["en"], ip)
+			require.EqualValues(t, 64501, info.ASNumber, ip)
+		}
+
+		info, err := f.Locate("::ffff:10.2.3.4")
+		require.NoError(t, err)
+		require.Equal(t, "London", info.City.Names["en"], "ipv4-mapped ipv6 addresses should match ipv4 networks")
+	})
+
+	t.Run("returns empty lookup for ips without an override", func(t *testing.T) {
+		info, err := f.Locate("1.1.1.1")
+		require.NoError(t, err)
+		require.Empty(t, info)
+
+		_, err = f.Locate("invalid-ip")
+		require.ErrorIs(t, err, geolocation.ErrInvalidIP)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/fs"
 	"net"
+	"strings"
 
 	"github.com/oschwald/maxminddb-golang"
 )
@@ -18,6 +19,8 @@ type maxmindDBReader struct {
 	*maxminddb.Reader
 }
 
+// NewMaxmindDBReader creates a reader of an MMDB file, which can either be a MaxMind database
+// or any other database using the same format and layout, e.g. the MMDB files of IP2Location and DB-IP.
 func NewMaxmindDBReader(dbLoc string) (*maxmindDBReader, error) {
 	reader, err := maxminddb.Open(dbLoc)
 	if err != nil {
@@ -36,7 +39,7 @@ func NewMaxmindDBReader(dbLoc string) (*maxmindDBReader, error) {
 }
 
 func (f *maxmindDBReader) Locate(ip string) (GeoInfo, error) {
-	parsedIP := net.ParseIP(ip)
+	parsedIP := net.ParseIP(normalizeIP(ip))
 
 	if parsedIP == nil {
 		return GeoInfo{}, ErrInvalidIP
@@ -57,3 +60,13 @@ func (f *maxmindDBReader) Close() error {
 
 	return nil
 }
+
+// normalizeIP strips the brackets and zone of an ipv6 address, e.g. [fe80::1%eth0] becomes fe80::1
+func normalizeIP(ip string) string {
+	ip = strings.TrimSpace(ip)
+	ip = strings.TrimSuffix(strings.TrimPrefix(ip, "["), "]")
+	if i := strings.IndexByte(ip, '%'); i >= 0 {
+		ip = ip[:i]
+	}
+	return ip
+}
@@ -0,0 +1,104 @@
+package geolocation
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	obskit "github.com/rudderlabs/rudder-observability-kit/go/labels"
+)
+
+type reloadingFetcher struct {
+	path string
+	open func(path string) (GeoFetcher, error)
+	log  logger.Logger
+
+	fetcherMu sync.RWMutex
+	fetcher   GeoFetcher
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewReloadingFetcher creates a fetcher of a database file which is opened using the provided function.
+// The file is checked for modifications every interval and reopened whenever it changes on disk, e.g. when a newer database
+// gets downloaded, without interrupting lookups. The previous database is kept if the new one cannot be opened.
+func NewReloadingFetcher(path string, interval time.Duration, open func(path string) (GeoFetcher, error), log logger.Logger) (GeoFetcher, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, ErrInvalidDatabase
+	}
+	fetcher, err := open(path)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &reloadingFetcher{
+		path:    path,
+		open:    open,
+		log:     log.Withn(logger.NewStringField("path", path)),
+		fetcher: fetcher,
+		cancel:  cancel,
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.reload(ctx, info.ModTime(), interval)
+	}()
+	return r, nil
+}
+
+func (r *reloadingFetcher) reload(ctx context.Context, modTime time.Time, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		info, err := os.Stat(r.path)
+		if err != nil {
+			r.log.Warnn("checking geolocation database file", obskit.Error(err))
+			continue
+		}
+		if info.ModTime().Equal(modTime) {
+			continue
+		}
+		fetcher, err := r.open(r.path)
+		if err != nil {
+			r.log.Errorn("reloading geolocation database, keeping the previous one", obskit.Error(err))
+			continue
+		}
+		modTime = info.ModTime()
+
+		r.fetcherMu.Lock()
+		previous := r.fetcher
+		r.fetcher = fetcher
+		r.fetcherMu.Unlock()
+		if err := previous.Close(); err != nil {
+			r.log.Warnn("closing previous geolocation database", obskit.Error(err))
+		}
+		r.log.Infon("reloaded geolocation database")
+	}
+}
+
+func (r *reloadingFetcher) Locate(ip string) (GeoInfo, error) {
+	r.fetcherMu.RLock()
+	defer r.fetcherMu.RUnlock()
+	return r.fetcher.Locate(ip)
+}
+
+func (r *reloadingFetcher) Close() error {
+	r.cancel()
+	r.wg.Wait()
+	r.fetcherMu.Lock()
+	defer r.fetcherMu.Unlock()
+	if err := r.fetcher.Close(); err != nil {
+		return fmt.Errorf("closing the reloading fetcher: %w", err)
+	}
+	return nil
+}
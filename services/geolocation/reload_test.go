@@ -0,0 +1,62 @@
+package geolocation_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/services/geolocation"
+)
+
+func copyFile(t *testing.T, src, dst string, modTime time.Time) {
+	t.Helper()
+	data, err := os.ReadFile(src)
+	require.NoError(t, err)
+	tmp := dst + ".tmp"
+	require.NoError(t, os.WriteFile(tmp, data, 0o600))
+	require.NoError(t, os.Chtimes(tmp, modTime, modTime))
+	require.NoError(t, os.Rename(tmp, dst))
+}
+
+func TestReloadingFetcher(t *testing.T) {
+	openMMDB := func(path string) (geolocation.GeoFetcher, error) {
+		return geolocation.NewMaxmindDBReader(path)
+	}
+
+	t.Run("fetcher errors out when the file is missing or invalid", func(t *testing.T) {
+		_, err := geolocation.NewReloadingFetcher(filepath.Join(t.TempDir(), "missing.mmdb"), time.Second, openMMDB, logger.NOP)
+		require.ErrorIs(t, err, geolocation.ErrInvalidDatabase)
+
+		_, err = geolocation.NewReloadingFetcher("./testdata/corrupted_city_test.mmdb", time.Second, openMMDB, logger.NOP)
+		require.ErrorIs(t, err, geolocation.ErrInvalidDatabase)
+	})
+
+	t.Run("database is reloaded when the file changes", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "city.mmdb")
+		copyFile(t, "./testdata/city_test.mmdb", path, time.Now().Add(-time.Hour))
+
+		f, err := geolocation.NewReloadingFetcher(path, 10*time.Millisecond, openMMDB, logger.NOP)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, f.Close()) }()
+
+		info, err := f.Locate("1.1.1.1")
+		require.NoError(t, err)
+		require.Empty(t, info)
+
+		copyFile(t, "./testdata/corrupted_city_test.mmdb", path, time.Now().Add(-time.Minute))
+		time.Sleep(50 * time.Millisecond)
+		info, err = f.Locate("2.125.160.216")
+		require.NoError(t, err)
+		require.Equal(t, "Boxford", info.City.Names["en"], "the previous database should be kept if the new one is invalid")
+
+		copyFile(t, "./testdata/dbip_city_test.mmdb", path, time.Now())
+		require.Eventually(t, func() bool {
+			info, err := f.Locate("1.1.1.1")
+			return err == nil && info.City.Names["en"] == "Sydney"
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
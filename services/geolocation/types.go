@@ -8,6 +8,7 @@ type GeoFetcher interface {
 // The City struct corresponds to the data in the GeoIP2/GeoLite2 City
 // databases. Given we are using the native library to decode the information,
 // we have modified some fields in it to contain the pointer values.
+// The ASN fields correspond to the data in the GeoIP2/GeoLite2 ASN and ISP databases.
 type GeoInfo struct {
 	City         City          `maxminddb:"city"`
 	Postal       Postal        `maxminddb:"postal"`
@@ -15,6 +16,48 @@ type GeoInfo struct {
 	Subdivisions []Subdivision `maxminddb:"subdivisions"`
 	Country      Country       `maxminddb:"country"`
 	Location     Location      `maxminddb:"location"`
+
+	ASNumber       uint   `maxminddb:"autonomous_system_number"`
+	ASOrganization string `maxminddb:"autonomous_system_organization"`
+	ISP            string `maxminddb:"isp"`
+	Organization   string `maxminddb:"organization"`
+}
+
+// merge fills the missing information of the geo info with the one of the other geo info.
+// The location dependent fields, i.e. the city, postal code, continent, subdivisions, country and location, are taken as a whole
+// from the first geo info providing any of them, so that a city and a country never come from fetchers disagreeing on the ip's location.
+// The rest of the fields are filled one by one.
+func (g *GeoInfo) merge(other GeoInfo) {
+	if !g.hasLocation() {
+		g.City = other.City
+		g.Postal = other.Postal
+		g.Continent = other.Continent
+		g.Subdivisions = other.Subdivisions
+		g.Country = other.Country
+		g.Location = other.Location
+	}
+	if g.ASNumber == 0 {
+		g.ASNumber = other.ASNumber
+	}
+	if g.ASOrganization == "" {
+		g.ASOrganization = other.ASOrganization
+	}
+	if g.ISP == "" {
+		g.ISP = other.ISP
+	}
+	if g.Organization == "" {
+		g.Organization = other.Organization
+	}
+}
+
+// hasLocation returns true if any of the location dependent fields of the geo info is set
+func (g *GeoInfo) hasLocation() bool {
+	return len(g.City.Names) > 0 ||
+		g.Postal.Code != "" ||
+		g.Continent.Code != "" || len(g.Continent.Names) > 0 ||
+		len(g.Subdivisions) > 0 ||
+		g.Country.ISOCode != "" || len(g.Country.Names) > 0 ||
+		g.Location.Timezone != "" || g.Location.Latitude != nil || g.Location.Longitude != nil
 }
 
 type City struct {
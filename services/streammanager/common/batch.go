@@ -0,0 +1,35 @@
+package common
+
+// RecordBatcher groups the records of a [BatchProducer] into batch requests, honouring the maximum number of records and bytes of a single request
+type RecordBatcher[T any] struct {
+	// MaxRecords is the maximum number of records of a request
+	MaxRecords int
+	// MaxBytes is the maximum size of the records of a request
+	MaxBytes int
+	// Send sends a request with the provided records, along with the indexes of the messages they originate from
+	Send func(records []T, indexes []int)
+
+	records []T
+	indexes []int
+	bytes   int
+}
+
+// Add adds the record of the message at index, having the provided size, sending the pending records first if the record doesn't fit in the same request
+func (b *RecordBatcher[T]) Add(index int, record T, size int) {
+	if len(b.records) > 0 && (len(b.records) == b.MaxRecords || b.bytes+size > b.MaxBytes) {
+		b.Flush()
+	}
+	b.records = append(b.records, record)
+	b.indexes = append(b.indexes, index)
+	b.bytes += size
+}
+
+// Flush sends the pending records, if any
+func (b *RecordBatcher[T]) Flush() {
+	if len(b.records) == 0 {
+		return
+	}
+	records, indexes := b.records, b.indexes
+	b.records, b.indexes, b.bytes = nil, nil, 0
+	b.Send(records, indexes)
+}
@@ -1,4 +1,4 @@
-//go:generate mockgen --build_flags=--mod=mod -destination=../../../mocks/services/streammanager/common/mock_streammanager.go -package mock_streammanager github.com/rudderlabs/rudder-server/services/streammanager/common StreamProducer
+//go:generate mockgen --build_flags=--mod=mod -destination=../../../mocks/services/streammanager/common/mock_streammanager.go -package mock_streammanager github.com/rudderlabs/rudder-server/services/streammanager/common StreamProducer,BatchProducer
 
 package common
 
@@ -18,6 +18,30 @@ type StreamProducer interface {
 	Produce(jsonData json.RawMessage, destConfig interface{}) (int, string, string)
 }
 
+// BatchProducer is an optional interface implemented by stream producers which can send multiple messages with a single request.
+type BatchProducer interface {
+	StreamProducer
+	// ProduceBatch sends all messages, returning a result for every message in the same order as the messages,
+	// so that a partial failure only affects the messages which failed.
+	ProduceBatch(messages []json.RawMessage, destConfig interface{}) []ProduceResult
+}
+
+// ProduceResult is the result of producing a single message
+type ProduceResult struct {
+	StatusCode      int
+	RespStatus      string
+	ResponseMessage string
+}
+
+// FailedResults returns the same failure result for all messages, e.g. when the whole batch request failed
+func FailedResults(count, statusCode int, respStatus, responseMessage string) []ProduceResult {
+	results := make([]ProduceResult, count)
+	for i := range results {
+		results[i] = ProduceResult{StatusCode: statusCode, RespStatus: respStatus, ResponseMessage: responseMessage}
+	}
+	return results
+}
+
 type Opts struct {
 	Timeout time.Duration
 }
@@ -45,6 +45,23 @@ func (s *SwitchingProducer) Produce(jsonData json.RawMessage, val interface{}) (
 	return s.producerV1.Produce(jsonData, val)
 }
 
+// ProduceBatch sends the messages in a batch if the active producer is a BatchProducer, otherwise they are produced one by one
+func (s *SwitchingProducer) ProduceBatch(messages []json.RawMessage, val interface{}) []ProduceResult {
+	producer := s.producerV1
+	if s.isV2Enabled.Load() && s.producerV2 != nil {
+		producer = s.producerV2
+	}
+	if batchProducer, ok := producer.(BatchProducer); ok {
+		return batchProducer.ProduceBatch(messages, val)
+	}
+	results := make([]ProduceResult, len(messages))
+	for i, message := range messages {
+		statusCode, respStatus, responseMessage := producer.Produce(message, val)
+		results[i] = ProduceResult{StatusCode: statusCode, RespStatus: respStatus, ResponseMessage: responseMessage}
+	}
+	return results
+}
+
 func (s *SwitchingProducer) Close() error {
 	var closeErrors []error
 	if s.producerV2 != nil {
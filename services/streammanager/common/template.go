@@ -0,0 +1,46 @@
+package common
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// templatePlaceholder matches placeholders like {{message.type}} in topic templates
+var templatePlaceholder = regexp.MustCompile(`{{\s*([^{}\s]+)\s*}}`)
+
+// ExpandTemplate replaces the placeholders of a template, e.g. events.{{type}}.{{event}}, with the values found at the corresponding json paths of the event.
+// Values are escaped using the provided function, if any. An error is returned if the event has no value for a placeholder.
+func ExpandTemplate(template string, event gjson.Result, escape func(string) string) (string, error) {
+	var err error
+	expanded := templatePlaceholder.ReplaceAllStringFunc(template, func(placeholder string) string {
+		path := templatePlaceholder.FindStringSubmatch(placeholder)[1]
+		value := strings.TrimSpace(event.Get(path).String())
+		if value == "" {
+			if err == nil {
+				err = fmt.Errorf("no value found for %q", path)
+			}
+			return ""
+		}
+		if escape != nil {
+			value = escape(value)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return expanded, nil
+}
+
+// OrderingKey returns the key used for ordering the messages of a transformed event, i.e. its userId, falling back to the user and anonymous ids of the message
+func OrderingKey(payload gjson.Result) string {
+	for _, path := range []string{"userId", "message.userId", "message.anonymousId"} {
+		if key := payload.Get(path).String(); key != "" {
+			return key
+		}
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package common_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	event := gjson.Parse(`{"type":"track","event":"Order Completed","context":{"library":{"name":"js"}},"properties":{"empty":""}}`)
+	escape := func(s string) string { return strings.ReplaceAll(s, " ", "_") }
+
+	expanded, err := common.ExpandTemplate("events.{{type}}.{{ event }}.{{context.library.name}}", event, escape)
+	require.NoError(t, err)
+	require.Equal(t, "events.track.Order_Completed.js", expanded)
+
+	expanded, err = common.ExpandTemplate("static-topic", event, nil)
+	require.NoError(t, err)
+	require.Equal(t, "static-topic", expanded)
+
+	_, err = common.ExpandTemplate("events.{{properties.empty}}", event, nil)
+	require.ErrorContains(t, err, `no value found for "properties.empty"`)
+	_, err = common.ExpandTemplate("events.{{missing}}", event, nil)
+	require.Error(t, err)
+}
+
+func TestOrderingKey(t *testing.T) {
+	require.Equal(t, "u1", common.OrderingKey(gjson.Parse(`{"userId":"u1","message":{"userId":"u2"}}`)))
+	require.Equal(t, "u2", common.OrderingKey(gjson.Parse(`{"message":{"userId":"u2","anonymousId":"a1"}}`)))
+	require.Equal(t, "a1", common.OrderingKey(gjson.Parse(`{"message":{"anonymousId":"a1"}}`)))
+	require.Empty(t, common.OrderingKey(gjson.Parse(`{}`)))
+}
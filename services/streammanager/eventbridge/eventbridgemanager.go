@@ -92,6 +92,70 @@ func (producer *EventBridgeProducerV1) Produce(jsonData json.RawMessage, _ inter
 	return 200, "Success", message
 }
 
+// ProduceBatch sends the messages to EventBridge using PutEvents requests, having up to 10 entries and 256 KB each.
+// Entries failing individually get their own result, without failing the rest of the entries of the request.
+func (producer *EventBridgeProducerV1) ProduceBatch(messages []json.RawMessage, _ interface{}) []common.ProduceResult {
+	client := producer.client
+	if client == nil {
+		return common.FailedResults(len(messages), 400, "Could not create producer for EventBridge", "Could not create producer for EventBridge")
+	}
+
+	results := make([]common.ProduceResult, len(messages))
+	batcher := common.RecordBatcher[*eventbridge.PutEventsRequestEntry]{
+		MaxRecords: maxEntriesPerRequest,
+		MaxBytes:   maxBytesPerRequest,
+		Send: func(entries []*eventbridge.PutEventsRequestEntry, indexes []int) {
+			requestInput := eventbridge.PutEventsInput{}
+			requestInput.SetEntries(entries)
+			if err := requestInput.Validate(); err != nil {
+				for _, i := range indexes {
+					results[i] = common.ProduceResult{StatusCode: 400, RespStatus: "InvalidInput", ResponseMessage: err.Error()}
+				}
+				return
+			}
+			putEventsOutput, err := client.PutEvents(&requestInput)
+			if err != nil {
+				statusCode, respStatus, responseMessage := common.ParseAWSError(err)
+				pkgLogger.Errorf("[EventBridge] error  :: %d : %s : %s", statusCode, respStatus, responseMessage)
+				for _, i := range indexes {
+					results[i] = common.ProduceResult{StatusCode: statusCode, RespStatus: respStatus, ResponseMessage: responseMessage}
+				}
+				return
+			}
+			for j, i := range indexes {
+				if j >= len(putEventsOutput.Entries) {
+					results[i] = common.ProduceResult{StatusCode: 400, RespStatus: "Failed to send event to eventbridge", ResponseMessage: "Failed to send event to eventbridge"}
+					continue
+				}
+				outputEntry := putEventsOutput.Entries[j]
+				if errorCode, errorMessage := outputEntry.ErrorCode, outputEntry.ErrorMessage; errorCode != nil && errorMessage != nil {
+					results[i] = common.ProduceResult{StatusCode: entryErrorStatusCode(*errorCode), RespStatus: *errorCode, ResponseMessage: *errorMessage}
+					continue
+				}
+				message := "Successfully sent event to eventbridge"
+				if eventID := outputEntry.EventId; eventID != nil {
+					message += fmt.Sprintf(",with eventID: %v", *eventID)
+				}
+				results[i] = common.ProduceResult{StatusCode: 200, RespStatus: "Success", ResponseMessage: message}
+			}
+		},
+	}
+	for i, message := range messages {
+		putRequestEntry := eventbridge.PutEventsRequestEntry{}
+		if err := jsonrs.Unmarshal(message, &putRequestEntry); err != nil {
+			results[i] = common.ProduceResult{StatusCode: 400, RespStatus: "[EventBridge] Failed to create eventbridge event", ResponseMessage: err.Error()}
+			continue
+		}
+		if failure := entrySizeFailure(len(message)); failure != nil {
+			results[i] = *failure
+			continue
+		}
+		batcher.Add(i, &putRequestEntry, len(message))
+	}
+	batcher.Flush()
+	return results
+}
+
 func (*EventBridgeProducerV1) Close() error {
 	// no-op
 	return nil
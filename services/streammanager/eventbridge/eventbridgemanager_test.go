@@ -1,6 +1,9 @@
 package eventbridge
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -8,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge"
 	"github.com/aws/aws-sdk-go-v2/service/eventbridge/types"
 	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
 
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
@@ -138,3 +142,83 @@ func TestProduceWithBadResponse(t *testing.T) {
 	assert.Equal(t, errorCode, statusMsg)
 	assert.NotEmpty(t, respMsg)
 }
+
+func TestProduceBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mock_eventbridge.NewMockEventBridgeClientV2(ctrl)
+	producer := &EventBridgeProducerV2{client: mockClient}
+	mockLogger := mock_logger.NewMockLogger(ctrl)
+	pkgLogger = mockLogger
+
+	sampleEventJson, _ := jsonrs.Marshal(sampleEvent)
+
+	t.Run("partial failure", func(t *testing.T) {
+		messages := []json.RawMessage{sampleEventJson, []byte("invalid json"), sampleEventJson, sampleEventJson}
+		mockClient.EXPECT().PutEvents(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+			assert.Equal(t, []types.PutEventsRequestEntry{sampleEvent, sampleEvent, sampleEvent}, input.Entries)
+			return &eventbridge.PutEventsOutput{
+				FailedEntryCount: 2,
+				Entries: []types.PutEventsResultEntry{
+					{EventId: aws.String("1")},
+					{ErrorCode: aws.String("ThrottlingException"), ErrorMessage: aws.String("Rate exceeded")},
+					{ErrorCode: aws.String("MalformedDetail"), ErrorMessage: aws.String("Detail is malformed")},
+				},
+			}, nil
+		}).Times(1)
+
+		results := producer.ProduceBatch(messages, map[string]string{})
+		assert.Len(t, results, 4)
+		assert.Equal(t, common.ProduceResult{StatusCode: 200, RespStatus: "Success", ResponseMessage: "Successfully sent event to eventbridge,with eventID: 1"}, results[0])
+		assert.Equal(t, 400, results[1].StatusCode)
+		assert.Equal(t, "[EventBridge] Failed to create eventbridge event", results[1].RespStatus)
+		assert.Equal(t, common.ProduceResult{StatusCode: 429, RespStatus: "ThrottlingException", ResponseMessage: "Rate exceeded"}, results[2])
+		assert.Equal(t, common.ProduceResult{StatusCode: 400, RespStatus: "MalformedDetail", ResponseMessage: "Detail is malformed"}, results[3])
+	})
+
+	t.Run("request failure", func(t *testing.T) {
+		mockClient.EXPECT().PutEvents(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &smithy.GenericAPIError{
+			Code:    "someError",
+			Message: "someError",
+			Fault:   smithy.FaultServer,
+		}).Times(1)
+		mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+		results := producer.ProduceBatch([]json.RawMessage{sampleEventJson, sampleEventJson}, map[string]string{})
+		assert.Equal(t, []int{500, 500}, lo.Map(results, func(result common.ProduceResult, _ int) int { return result.StatusCode }))
+	})
+
+	t.Run("entries are split by count and size", func(t *testing.T) {
+		manyMessages := make([]json.RawMessage, maxEntriesPerRequest+1)
+		for i := range manyMessages {
+			manyMessages[i] = sampleEventJson
+		}
+		largeEvent := sampleEvent
+		largeEvent.Detail = aws.String(strings.Repeat("a", 100*1024))
+		largeEventJson, _ := jsonrs.Marshal(largeEvent)
+		largeMessages := []json.RawMessage{largeEventJson, largeEventJson, largeEventJson}
+
+		var requestSizes []int
+		mockClient.EXPECT().PutEvents(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *eventbridge.PutEventsInput, _ ...func(*eventbridge.Options)) (*eventbridge.PutEventsOutput, error) {
+			requestSizes = append(requestSizes, len(input.Entries))
+			return &eventbridge.PutEventsOutput{Entries: make([]types.PutEventsResultEntry, len(input.Entries))}, nil
+		}).Times(4)
+
+		for _, result := range producer.ProduceBatch(manyMessages, map[string]string{}) {
+			assert.Equal(t, 200, result.StatusCode)
+		}
+		for _, result := range producer.ProduceBatch(largeMessages, map[string]string{}) {
+			assert.Equal(t, 200, result.StatusCode)
+		}
+		assert.Equal(t, []int{maxEntriesPerRequest, 1, 2, 1}, requestSizes)
+	})
+
+	t.Run("entries too large fail individually", func(t *testing.T) {
+		largeEvent := sampleEvent
+		largeEvent.Detail = aws.String(strings.Repeat("a", maxBytesPerRequest))
+		largeEventJson, _ := jsonrs.Marshal(largeEvent)
+
+		results := producer.ProduceBatch([]json.RawMessage{largeEventJson}, map[string]string{})
+		assert.Equal(t, 400, results[0].StatusCode)
+		assert.Equal(t, "InvalidInput", results[0].RespStatus)
+	})
+}
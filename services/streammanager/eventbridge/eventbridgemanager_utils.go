@@ -1,9 +1,41 @@
 package eventbridge
 
-import "github.com/rudderlabs/rudder-go-kit/logger"
+import (
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
 
 var pkgLogger logger.Logger
 
+const (
+	// maxEntriesPerRequest is the maximum number of entries supported by a single PutEvents request
+	maxEntriesPerRequest = 10
+	// maxBytesPerRequest is the maximum size of the entries of a single PutEvents request
+	maxBytesPerRequest = int(256 * bytesize.KB)
+)
+
 func init() {
 	pkgLogger = logger.NewLogger().Child("streammanager").Child("eventbridge")
 }
+
+// entrySizeFailure fails entries too large for a PutEvents request.
+// The size of the message is used as the size of the entry, which is an upper bound of the size computed by EventBridge.
+func entrySizeFailure(size int) *common.ProduceResult {
+	if size > maxBytesPerRequest {
+		return &common.ProduceResult{StatusCode: 400, RespStatus: "InvalidInput", ResponseMessage: "[EventBridge] error :: event exceeds the maximum entry size"}
+	}
+	return nil
+}
+
+// entryErrorStatusCode returns the status code for the error code of an entry that failed in a PutEvents request
+func entryErrorStatusCode(errorCode string) int {
+	switch errorCode {
+	case "ThrottlingException":
+		return 429
+	case "InternalFailure", "InternalException":
+		return 500
+	default:
+		return 400
+	}
+}
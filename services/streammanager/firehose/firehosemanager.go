@@ -8,11 +8,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/firehose"
-	"github.com/tidwall/gjson"
 
 	"github.com/rudderlabs/rudder-go-kit/awsutil"
 	"github.com/rudderlabs/rudder-go-kit/config"
-	"github.com/rudderlabs/rudder-go-kit/jsonrs"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/services/streammanager/common"
 	"github.com/rudderlabs/rudder-server/utils/awsutils"
@@ -24,6 +22,7 @@ type FireHoseProducerV1 struct {
 
 type FireHoseClientV1 interface {
 	PutRecord(input *firehose.PutRecordInput) (*firehose.PutRecordOutput, error)
+	PutRecordBatch(input *firehose.PutRecordBatchInput) (*firehose.PutRecordBatchOutput, error)
 }
 
 // NewProducer creates a producer based on destination config
@@ -42,39 +41,20 @@ func NewProducerV1(destination *backendconfig.DestinationT, o common.Opts) (comm
 
 // Produce creates a producer and send data to Firehose.
 func (producer *FireHoseProducerV1) Produce(jsonData json.RawMessage, _ interface{}) (int, string, string) {
-	parsedJSON := gjson.ParseBytes(jsonData)
 	client := producer.client
 	if client == nil {
 		return 400, "Failure", "[FireHose] error :: Could not create producer"
 	}
-	data := parsedJSON.Get("message").Value()
-	if data == nil {
-		return 400, "Failure", "[FireHose] error :: message from payload not found"
-	}
-	value, err := jsonrs.Marshal(data)
-	if err != nil {
-		pkgLogger.Errorf("[FireHose] error  :: %v", err)
-		return 400, "Failure", "[FireHose] error  :: " + err.Error()
-	}
-
-	deliveryStreamMapTo := parsedJSON.Get("deliveryStreamMapTo").Value()
-	if deliveryStreamMapTo == nil {
-		return 400, "Failure", "[FireHose] error  :: Delivery Stream not found"
-	}
-
-	deliveryStreamMapToInputString, ok := deliveryStreamMapTo.(string)
-	if !ok {
-		return 400, "Failure", "[FireHose] error :: Could not parse delivery stream to string"
-	}
-	if deliveryStreamMapToInputString == "" {
-		return 400, "Failure", "[FireHose] error :: empty delivery stream"
+	deliveryStream, value, failure := recordOf(jsonData)
+	if failure != nil {
+		return failure.StatusCode, failure.RespStatus, failure.ResponseMessage
 	}
 
 	putInput := firehose.PutRecordInput{
-		DeliveryStreamName: aws.String(deliveryStreamMapToInputString),
+		DeliveryStreamName: aws.String(deliveryStream),
 		Record:             &firehose.Record{Data: value},
 	}
-	if err = putInput.Validate(); err != nil {
+	if err := putInput.Validate(); err != nil {
 		return 400, "InvalidInput", err.Error()
 	}
 	putOutput, errorRec := client.PutRecord(&putInput)
@@ -88,6 +68,68 @@ func (producer *FireHoseProducerV1) Produce(jsonData json.RawMessage, _ interfac
 	return 200, "Success", fmt.Sprintf("Message delivered with Record information %v", putOutput)
 }
 
+// ProduceBatch sends the messages to Firehose using PutRecordBatch requests per delivery stream, having up to 500 records and 4 MB each.
+// Records failing individually get their own result, without failing the rest of the records of the request.
+func (producer *FireHoseProducerV1) ProduceBatch(messages []json.RawMessage, _ interface{}) []common.ProduceResult {
+	client := producer.client
+	if client == nil {
+		return common.FailedResults(len(messages), 400, "Failure", "[FireHose] error :: Could not create producer")
+	}
+
+	results := make([]common.ProduceResult, len(messages))
+	batchers := streamBatchers[*firehose.Record]{
+		newBatcher: func(deliveryStream string) *common.RecordBatcher[*firehose.Record] {
+			return &common.RecordBatcher[*firehose.Record]{
+				MaxRecords: maxRecordsPerRequest,
+				MaxBytes:   maxBytesPerRequest,
+				Send: func(records []*firehose.Record, indexes []int) {
+					putInput := firehose.PutRecordBatchInput{
+						DeliveryStreamName: aws.String(deliveryStream),
+						Records:            records,
+					}
+					if err := putInput.Validate(); err != nil {
+						for _, i := range indexes {
+							results[i] = common.ProduceResult{StatusCode: 400, RespStatus: "InvalidInput", ResponseMessage: err.Error()}
+						}
+						return
+					}
+					putOutput, err := client.PutRecordBatch(&putInput)
+					if err != nil {
+						statusCode, respStatus, responseMessage := common.ParseAWSError(err)
+						pkgLogger.Errorf("[FireHose] error  :: %d : %s : %s", statusCode, respStatus, responseMessage)
+						for _, i := range indexes {
+							results[i] = common.ProduceResult{StatusCode: statusCode, RespStatus: respStatus, ResponseMessage: responseMessage}
+						}
+						return
+					}
+					for j, i := range indexes {
+						if j >= len(putOutput.RequestResponses) {
+							results[i] = common.ProduceResult{StatusCode: 500, RespStatus: "Failure", ResponseMessage: "[FireHose] error :: No result returned for record"}
+							continue
+						}
+						response := putOutput.RequestResponses[j]
+						if errorCode := aws.StringValue(response.ErrorCode); errorCode != "" {
+							results[i] = common.ProduceResult{StatusCode: recordErrorStatusCode(errorCode), RespStatus: errorCode, ResponseMessage: aws.StringValue(response.ErrorMessage)}
+							continue
+						}
+						results[i] = common.ProduceResult{StatusCode: 200, RespStatus: "Success", ResponseMessage: fmt.Sprintf("Message delivered with Record information %v", aws.StringValue(response.RecordId))}
+					}
+				},
+			}
+		},
+	}
+	for i, message := range messages {
+		deliveryStream, value, failure := batchRecordOf(message)
+		if failure != nil {
+			results[i] = *failure
+			continue
+		}
+		batchers.add(deliveryStream, i, &firehose.Record{Data: value}, len(value))
+	}
+	batchers.flush()
+	return results
+}
+
 func (*FireHoseProducerV1) Close() error {
 	// no-op
 	return nil
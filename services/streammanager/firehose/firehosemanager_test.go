@@ -1,7 +1,10 @@
 package firehose
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 	"time"
 
@@ -9,6 +12,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/firehose"
 	"github.com/aws/aws-sdk-go-v2/service/firehose/types"
 	"github.com/aws/smithy-go"
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 
@@ -154,3 +158,81 @@ func TestProduceWithServiceResponse(t *testing.T) {
 	assert.Equal(t, errorCode, statusMsg)
 	assert.NotEmpty(t, respMsg)
 }
+
+func TestProduceBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mock_firehose.NewMockFireHoseClientV2(ctrl)
+	producer := &FireHoseProducerV2{client: mockClient}
+	mockLogger := mock_logger.NewMockLogger(ctrl)
+	pkgLogger = mockLogger
+
+	message := func(data, deliveryStream string) json.RawMessage {
+		payload, _ := jsonrs.Marshal(map[string]string{"message": data, "deliveryStreamMapTo": deliveryStream})
+		return payload
+	}
+
+	t.Run("records are grouped per delivery stream", func(t *testing.T) {
+		messages := []json.RawMessage{message("data1", "stream1"), []byte("{}"), message("data2", "stream2"), message("data3", "stream1")}
+		mockClient.EXPECT().PutRecordBatch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *firehose.PutRecordBatchInput, _ ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error) {
+			assert.Equal(t, "stream1", aws.ToString(input.DeliveryStreamName))
+			assert.Len(t, input.Records, 2)
+			return &firehose.PutRecordBatchOutput{
+				FailedPutCount: aws.Int32(1),
+				RequestResponses: []types.PutRecordBatchResponseEntry{
+					{RecordId: aws.String("1")},
+					{ErrorCode: aws.String("ServiceUnavailableException"), ErrorMessage: aws.String("Slow down")},
+				},
+			}, nil
+		}).Times(1)
+		mockClient.EXPECT().PutRecordBatch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *firehose.PutRecordBatchInput, _ ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error) {
+			assert.Equal(t, "stream2", aws.ToString(input.DeliveryStreamName))
+			assert.Len(t, input.Records, 1)
+			return &firehose.PutRecordBatchOutput{RequestResponses: []types.PutRecordBatchResponseEntry{{RecordId: aws.String("2")}}}, nil
+		}).Times(1)
+
+		results := producer.ProduceBatch(messages, map[string]string{})
+		assert.Len(t, results, 4)
+		assert.Equal(t, common.ProduceResult{StatusCode: 200, RespStatus: "Success", ResponseMessage: "Message delivered with Record information 1"}, results[0])
+		assert.Equal(t, common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: "[FireHose] error :: message from payload not found"}, results[1])
+		assert.Equal(t, common.ProduceResult{StatusCode: 200, RespStatus: "Success", ResponseMessage: "Message delivered with Record information 2"}, results[2])
+		assert.Equal(t, common.ProduceResult{StatusCode: 429, RespStatus: "ServiceUnavailableException", ResponseMessage: "Slow down"}, results[3])
+	})
+
+	t.Run("request failure", func(t *testing.T) {
+		mockClient.EXPECT().PutRecordBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, errors.New("errorCode")).Times(1)
+		mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+		results := producer.ProduceBatch([]json.RawMessage{message("data1", "stream1"), message("data2", "stream1")}, map[string]string{})
+		assert.Equal(t, []int{500, 500}, lo.Map(results, func(result common.ProduceResult, _ int) int { return result.StatusCode }))
+	})
+
+	t.Run("records are split by count and size", func(t *testing.T) {
+		manyMessages := make([]json.RawMessage, maxRecordsPerRequest+1)
+		for i := range manyMessages {
+			manyMessages[i] = message("data", "stream")
+		}
+		largeMessages := make([]json.RawMessage, 5)
+		for i := range largeMessages {
+			largeMessages[i] = message(strings.Repeat("a", 900*1024), "stream")
+		}
+		var requestSizes []int
+		mockClient.EXPECT().PutRecordBatch(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *firehose.PutRecordBatchInput, _ ...func(*firehose.Options)) (*firehose.PutRecordBatchOutput, error) {
+			requestSizes = append(requestSizes, len(input.Records))
+			return &firehose.PutRecordBatchOutput{RequestResponses: make([]types.PutRecordBatchResponseEntry, len(input.Records))}, nil
+		}).Times(4)
+
+		for _, result := range producer.ProduceBatch(manyMessages, map[string]string{}) {
+			assert.Equal(t, 200, result.StatusCode)
+		}
+		for _, result := range producer.ProduceBatch(largeMessages, map[string]string{}) {
+			assert.Equal(t, 200, result.StatusCode)
+		}
+		assert.Equal(t, []int{maxRecordsPerRequest, 1, 4, 1}, requestSizes)
+	})
+
+	t.Run("records too large fail individually", func(t *testing.T) {
+		results := producer.ProduceBatch([]json.RawMessage{message(strings.Repeat("a", maxBytesPerRecord), "stream")}, map[string]string{})
+		assert.Equal(t, 400, results[0].StatusCode)
+		assert.Equal(t, "InvalidInput", results[0].RespStatus)
+	})
+}
@@ -1,9 +1,100 @@
 package firehose
 
-import "github.com/rudderlabs/rudder-go-kit/logger"
+import (
+	"encoding/json"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
 
 var pkgLogger logger.Logger
 
+const (
+	// maxRecordsPerRequest is the maximum number of records supported by a single PutRecordBatch request
+	maxRecordsPerRequest = 500
+	// maxBytesPerRequest is the maximum size of the records of a single PutRecordBatch request
+	maxBytesPerRequest = int(4 * bytesize.MB)
+	// maxBytesPerRecord is the maximum size of a single record
+	maxBytesPerRecord = int(1000 * bytesize.KB)
+)
+
 func init() {
 	pkgLogger = logger.NewLogger().Child("streammanager").Child("firehose")
 }
+
+// recordOf extracts the delivery stream and the data of the record to be sent to Firehose from a message
+func recordOf(jsonData json.RawMessage) (deliveryStream string, data []byte, failure *common.ProduceResult) {
+	parsedJSON := gjson.ParseBytes(jsonData)
+	message := parsedJSON.Get("message").Value()
+	if message == nil {
+		return "", nil, &common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: "[FireHose] error :: message from payload not found"}
+	}
+	data, err := jsonrs.Marshal(message)
+	if err != nil {
+		pkgLogger.Errorf("[FireHose] error  :: %v", err)
+		return "", nil, &common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: "[FireHose] error  :: " + err.Error()}
+	}
+
+	deliveryStreamMapTo := parsedJSON.Get("deliveryStreamMapTo").Value()
+	if deliveryStreamMapTo == nil {
+		return "", nil, &common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: "[FireHose] error  :: Delivery Stream not found"}
+	}
+	deliveryStream, ok := deliveryStreamMapTo.(string)
+	if !ok {
+		return "", nil, &common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: "[FireHose] error :: Could not parse delivery stream to string"}
+	}
+	if deliveryStream == "" {
+		return "", nil, &common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: "[FireHose] error :: empty delivery stream"}
+	}
+	return deliveryStream, data, nil
+}
+
+// batchRecordOf is like recordOf, additionally failing records too large for a PutRecordBatch request
+func batchRecordOf(jsonData json.RawMessage) (deliveryStream string, data []byte, failure *common.ProduceResult) {
+	deliveryStream, data, failure = recordOf(jsonData)
+	if failure != nil {
+		return "", nil, failure
+	}
+	if len(data) > maxBytesPerRecord {
+		return "", nil, &common.ProduceResult{StatusCode: 400, RespStatus: "InvalidInput", ResponseMessage: "[FireHose] error :: record exceeds the maximum record size"}
+	}
+	return deliveryStream, data, nil
+}
+
+// recordErrorStatusCode returns the status code for the error code of a record that failed in a PutRecordBatch request
+func recordErrorStatusCode(errorCode string) int {
+	if errorCode == "ServiceUnavailableException" {
+		return 429
+	}
+	return 500
+}
+
+// streamBatchers groups the records of a batch per delivery stream, since a PutRecordBatch request targets a single delivery stream
+type streamBatchers[T any] struct {
+	newBatcher func(deliveryStream string) *common.RecordBatcher[T]
+	batchers   map[string]*common.RecordBatcher[T]
+	streams    []string
+}
+
+func (s *streamBatchers[T]) add(deliveryStream string, index int, record T, size int) {
+	if s.batchers == nil {
+		s.batchers = make(map[string]*common.RecordBatcher[T])
+	}
+	batcher, ok := s.batchers[deliveryStream]
+	if !ok {
+		batcher = s.newBatcher(deliveryStream)
+		s.batchers[deliveryStream] = batcher
+		s.streams = append(s.streams, deliveryStream)
+	}
+	batcher.Add(index, record, size)
+}
+
+func (s *streamBatchers[T]) flush() {
+	for _, deliveryStream := range s.streams {
+		s.batchers[deliveryStream].Flush()
+	}
+}
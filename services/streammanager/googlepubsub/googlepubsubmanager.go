@@ -102,7 +102,6 @@ func NewProducer(destination *backendconfig.DestinationT, o common.Opts) (*Googl
 }
 
 func (producer *GooglePubSubProducer) Produce(jsonData json.RawMessage, _ interface{}) (statusCode int, respStatus, responseMessage string) {
-	parsedJSON := gjson.ParseBytes(jsonData)
 	pbs := producer.client
 	if pbs == nil {
 		respStatus = "Failure"
@@ -112,89 +111,99 @@ func (producer *GooglePubSubProducer) Produce(jsonData json.RawMessage, _ interf
 	ctx, cancel := context.WithTimeout(context.Background(), pbs.opts.Timeout)
 	defer cancel()
 
-	var data interface{}
-	if parsedJSON.Get("message").Value() != nil {
-		data = parsedJSON.Get("message").Value()
-	} else {
-		respStatus = "Failure"
-		responseMessage = "[GooglePubSub] error :: message from payload not found"
-		return 400, respStatus, responseMessage
+	result, failure := pbs.publish(ctx, jsonData)
+	if failure != nil {
+		return failure.StatusCode, failure.RespStatus, failure.ResponseMessage
+	}
+	produceResult := resultOf(ctx, result)
+	return produceResult.StatusCode, produceResult.RespStatus, produceResult.ResponseMessage
+}
+
+// ProduceBatch publishes all messages before waiting for their results, letting the Pub/Sub client bundle them in publish requests.
+// The producer's timeout applies to the batch as a whole.
+func (producer *GooglePubSubProducer) ProduceBatch(messages []json.RawMessage, _ interface{}) []common.ProduceResult {
+	pbs := producer.client
+	if pbs == nil {
+		return common.FailedResults(len(messages), 400, "Failure", "[GooglePubSub] error :: Could not create producer")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pbs.opts.Timeout)
+	defer cancel()
+
+	results := make([]common.ProduceResult, len(messages))
+	publishResults := make([]*pubsub.PublishResult, len(messages))
+	for i, message := range messages {
+		result, failure := pbs.publish(ctx, message)
+		if failure != nil {
+			results[i] = *failure
+			continue
+		}
+		publishResults[i] = result
+	}
+	for i, result := range publishResults {
+		if result != nil {
+			results[i] = resultOf(ctx, result)
+		}
+	}
+	return results
+}
+
+// publish publishes the message of the payload to its topic, returning a failure if the payload is invalid
+func (pbs *PubsubClient) publish(ctx context.Context, jsonData json.RawMessage) (*pubsub.PublishResult, *common.ProduceResult) {
+	parsedJSON := gjson.ParseBytes(jsonData)
+	failure := func(responseMessage string) *common.ProduceResult {
+		return &common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: responseMessage}
+	}
+
+	data := parsedJSON.Get("message").Value()
+	if data == nil {
+		return nil, failure("[GooglePubSub] error :: message from payload not found")
 	}
 	value, err := jsonrs.Marshal(data)
 	if err != nil {
-		respStatus = "Failure"
-		responseMessage = "[GooglePubSub] error  :: " + err.Error()
 		pkgLogger.Errorf("[GooglePubSub] error  :: %v", err)
-		statusCode := 400
-		return statusCode, respStatus, responseMessage
+		return nil, failure("[GooglePubSub] error  :: " + err.Error())
 	}
 
 	if parsedJSON.Get("topicId").Value() == nil {
-		respStatus = "Failure"
-		responseMessage = "[GooglePubSub] error  :: Topic Id not found"
-		return 400, respStatus, responseMessage
+		return nil, failure("[GooglePubSub] error  :: Topic Id not found")
 	}
 	topicIdString, ok := parsedJSON.Get("topicId").Value().(string)
 	if !ok {
-		respStatus = "Failure"
-		responseMessage = "[GooglePubSub] error :: Could not parse topic id to string"
+		responseMessage := "[GooglePubSub] error :: Could not parse topic id to string"
 		pkgLogger.Error(responseMessage)
-		statusCode := 400
-		return statusCode, respStatus, responseMessage
+		return nil, failure(responseMessage)
 	}
 	if topicIdString == "" {
-		respStatus = "Failure"
-		responseMessage = "[GooglePubSub] error :: empty topic id string"
-		return 400, respStatus, responseMessage
+		return nil, failure("[GooglePubSub] error :: empty topic id string")
 	}
 	topic := pbs.topicMap[topicIdString]
 	if topic == nil {
-		statusCode = 400
-		responseMessage = "[GooglePubSub] error :: Topic not found in project"
-		respStatus = "Failure"
-		return statusCode, respStatus, responseMessage
+		return nil, failure("[GooglePubSub] error :: Topic not found in project")
 	}
 
-	attributes := parsedJSON.Get("attributes").Map()
-	var result *pubsub.PublishResult
-
-	if len(attributes) != 0 {
-		attributesMap := make(map[string]string)
+	message := &pubsub.Message{Data: value}
+	if attributes := parsedJSON.Get("attributes").Map(); len(attributes) != 0 {
+		message.Attributes = make(map[string]string)
 		for k, v := range attributes {
-			attributesMap[k] = v.Str
+			message.Attributes[k] = v.Str
 		}
-		result = topic.Publish(
-			ctx,
-			&pubsub.Message{
-				Data:       value,
-				Attributes: attributesMap,
-			},
-		)
-	} else {
-		result = topic.Publish(
-			ctx,
-			&pubsub.Message{
-				Data: value,
-			},
-		)
 	}
+	return topic.Publish(ctx, message), nil
+}
 
+// resultOf waits for the result of a published message
+func resultOf(ctx context.Context, result *pubsub.PublishResult) common.ProduceResult {
 	serverID, err := result.Get(ctx)
-
 	if err != nil {
+		var statusCode int
 		if ctx.Err() != nil && errors.Is(err, context.DeadlineExceeded) {
 			statusCode = 504
 		} else {
 			statusCode = getError(err)
 		}
-		responseMessage = "[GooglePubSub] error :: Failed to publish:" + err.Error()
-		respStatus = "Failure"
-		return statusCode, respStatus, responseMessage
-	} else {
-		responseMessage = "Message publish with serverID" + serverID
+		return common.ProduceResult{StatusCode: statusCode, RespStatus: "Failure", ResponseMessage: "[GooglePubSub] error :: Failed to publish:" + err.Error()}
 	}
-	respStatus = "Success"
-	return 200, respStatus, responseMessage
+	return common.ProduceResult{StatusCode: 200, RespStatus: "Success", ResponseMessage: "Message publish with serverID" + serverID}
 }
 
 // Close closes a given producer
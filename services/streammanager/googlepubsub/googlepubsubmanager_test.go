@@ -2,6 +2,7 @@ package googlepubsub
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -63,6 +64,36 @@ func Test_Timeout(t *testing.T) {
 	}
 }
 
+func TestProduceBatch(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	pool.MaxWait = 2 * time.Minute
+
+	testConfig, err := SetupTestGooglePubSub(pool, t)
+	require.NoError(t, err)
+
+	config := map[string]interface{}{
+		"ProjectId": projectId,
+		"EventToTopicMap": []map[string]string{
+			{"to": topic},
+		},
+		"TestConfig": testConfig,
+	}
+	destination := backendconfig.DestinationT{Config: config}
+
+	producer, err := NewProducer(&destination, common.Opts{Timeout: 10 * time.Second})
+	require.NoError(t, err)
+	results := producer.ProduceBatch([]json.RawMessage{
+		[]byte(`{"topicId": "my-topic", "message": "{}"}`),
+		[]byte(`{"topicId": "unknown-topic", "message": "{}"}`),
+		[]byte(`{"topicId": "my-topic", "message": "{}", "attributes": {"key": "value"}}`),
+	}, nil)
+	require.Len(t, results, 3)
+	require.Equal(t, 200, results[0].StatusCode, results[0].ResponseMessage)
+	require.Equal(t, common.ProduceResult{StatusCode: 400, RespStatus: "Failure", ResponseMessage: "[GooglePubSub] error :: Topic not found in project"}, results[1])
+	require.Equal(t, 200, results[2].StatusCode, results[2].ResponseMessage)
+}
+
 func TestUnsupportedCredentials(t *testing.T) {
 	config := map[string]interface{}{
 		"ProjectId": projectId,
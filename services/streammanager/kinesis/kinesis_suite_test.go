@@ -1,6 +1,9 @@
 package kinesis
 
 import (
+	"context"
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 
@@ -8,9 +11,11 @@ import (
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/kinesis"
+	"github.com/aws/aws-sdk-go-v2/service/kinesis/types"
 	"github.com/aws/smithy-go"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/samber/lo"
 
 	"github.com/rudderlabs/rudder-go-kit/jsonrs"
 	"github.com/rudderlabs/rudder-go-kit/logger/mock_logger"
@@ -134,3 +139,100 @@ func TestProduceWithServiceResponse(t *testing.T) {
 	assert.Equal(t, errorCode, statusMsg)
 	assert.Contains(t, respMsg, errorCode)
 }
+
+func TestProduceBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockClient := mock_kinesis.NewMockKinesisClientV2(ctrl)
+	producer := &KinesisProducerV2{client: mockClient}
+	mockLogger := mock_logger.NewMockLogger(ctrl)
+	pkgLogger = mockLogger
+
+	message := func(data, userID string) json.RawMessage {
+		payload, _ := jsonrs.Marshal(map[string]string{"message": data, "userId": userID})
+		return payload
+	}
+	messages := []json.RawMessage{message("data1", "user1"), []byte("{}"), message("data2", "user2"), message("data3", "user3")}
+
+	t.Run("partial failure", func(t *testing.T) {
+		mockClient.EXPECT().PutRecords(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			assert.Equal(t, validDestinationConfigNotUseMessageID.Stream, aws.ToString(input.StreamName))
+			assert.Len(t, input.Records, 3, "invalid messages should not be sent")
+			assert.Equal(t, []string{"user1", "user2", "user3"}, lo.Map(input.Records, func(record types.PutRecordsRequestEntry, _ int) string { return aws.ToString(record.PartitionKey) }))
+			return &kinesis.PutRecordsOutput{
+				FailedRecordCount: aws.Int32(1),
+				Records: []types.PutRecordsResultEntry{
+					{SequenceNumber: aws.String("1"), ShardId: aws.String("shard")},
+					{ErrorCode: aws.String("ProvisionedThroughputExceededException"), ErrorMessage: aws.String("Rate exceeded")},
+					{SequenceNumber: aws.String("3"), ShardId: aws.String("shard")},
+				},
+			}, nil
+		}).Times(1)
+
+		results := producer.ProduceBatch(messages, validDestinationConfigNotUseMessageID)
+		assert.Len(t, results, 4)
+		assert.Equal(t, 200, results[0].StatusCode)
+		assert.Contains(t, results[0].ResponseMessage, "Message delivered at SequenceNumber: 1")
+		assert.Equal(t, common.ProduceResult{StatusCode: 400, RespStatus: "InvalidPayload", ResponseMessage: "Empty Payload"}, results[1])
+		assert.Equal(t, common.ProduceResult{StatusCode: 429, RespStatus: "ProvisionedThroughputExceededException", ResponseMessage: "Rate exceeded"}, results[2])
+		assert.Equal(t, 200, results[3].StatusCode)
+	})
+
+	t.Run("request failure", func(t *testing.T) {
+		mockClient.EXPECT().PutRecords(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, &smithy.GenericAPIError{
+			Code:    "someError",
+			Message: "someError",
+			Fault:   smithy.FaultServer,
+		}).Times(1)
+		mockLogger.EXPECT().Errorf(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(1)
+
+		results := producer.ProduceBatch(messages, validDestinationConfigNotUseMessageID)
+		assert.Equal(t, []int{500, 400, 500, 500}, lo.Map(results, func(result common.ProduceResult, _ int) int { return result.StatusCode }))
+	})
+
+	t.Run("records are split in multiple requests", func(t *testing.T) {
+		manyMessages := make([]json.RawMessage, maxRecordsPerRequest+1)
+		for i := range manyMessages {
+			manyMessages[i] = message("data", "user")
+		}
+		success := func(_ context.Context, input *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			return &kinesis.PutRecordsOutput{Records: make([]types.PutRecordsResultEntry, len(input.Records))}, nil
+		}
+		mockClient.EXPECT().PutRecords(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(success).Times(2)
+
+		results := producer.ProduceBatch(manyMessages, validDestinationConfigNotUseMessageID)
+		assert.Len(t, results, maxRecordsPerRequest+1)
+		for _, result := range results {
+			assert.Equal(t, 200, result.StatusCode)
+		}
+	})
+
+	t.Run("records are split by request size", func(t *testing.T) {
+		largeMessages := make([]json.RawMessage, 6)
+		for i := range largeMessages {
+			largeMessages[i] = message(strings.Repeat("a", 900*1024), "user")
+		}
+		var requestSizes []int
+		mockClient.EXPECT().PutRecords(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			requestSizes = append(requestSizes, len(input.Records))
+			return &kinesis.PutRecordsOutput{Records: make([]types.PutRecordsResultEntry, len(input.Records))}, nil
+		}).Times(2)
+
+		results := producer.ProduceBatch(largeMessages, validDestinationConfigNotUseMessageID)
+		assert.Equal(t, []int{5, 1}, requestSizes)
+		for _, result := range results {
+			assert.Equal(t, 200, result.StatusCode)
+		}
+	})
+
+	t.Run("records too large fail individually", func(t *testing.T) {
+		mockClient.EXPECT().PutRecords(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, input *kinesis.PutRecordsInput, _ ...func(*kinesis.Options)) (*kinesis.PutRecordsOutput, error) {
+			assert.Len(t, input.Records, 1)
+			return &kinesis.PutRecordsOutput{Records: make([]types.PutRecordsResultEntry, len(input.Records))}, nil
+		}).Times(1)
+
+		results := producer.ProduceBatch([]json.RawMessage{message(strings.Repeat("a", maxBytesPerRecord), "user"), message("data", "user")}, validDestinationConfigNotUseMessageID)
+		assert.Equal(t, 400, results[0].StatusCode)
+		assert.Equal(t, "InvalidInput", results[0].RespStatus)
+		assert.Equal(t, 200, results[1].StatusCode)
+	})
+}
@@ -8,11 +8,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/kinesis"
-	"github.com/tidwall/gjson"
 
 	"github.com/rudderlabs/rudder-go-kit/awsutil"
 	"github.com/rudderlabs/rudder-go-kit/config"
-	"github.com/rudderlabs/rudder-go-kit/jsonrs"
 	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
 	"github.com/rudderlabs/rudder-server/services/streammanager/common"
 	"github.com/rudderlabs/rudder-server/utils/awsutils"
@@ -24,6 +22,7 @@ type KinesisProducerV1 struct {
 
 type KinesisClientV1 interface {
 	PutRecord(input *kinesis.PutRecordInput) (*kinesis.PutRecordOutput, error)
+	PutRecords(input *kinesis.PutRecordsInput) (*kinesis.PutRecordsOutput, error)
 }
 
 // NewProducer creates a producer based on destination config
@@ -49,42 +48,17 @@ func (producer *KinesisProducerV1) Produce(jsonData json.RawMessage, destConfig
 		return 400, "Could not create producer for Kinesis", "Could not create producer for Kinesis"
 	}
 
-	config := Config{}
-
-	jsonConfig, err := jsonrs.Marshal(destConfig)
-	if err != nil {
-		outErr := fmt.Errorf("[KinesisManager] Error while Marshalling destination config %+v Error: %w", destConfig, err)
-		return 400, outErr.Error(), outErr.Error()
-	}
-	err = jsonrs.Unmarshal(jsonConfig, &config)
-	if err != nil {
-		outErr := fmt.Errorf("[KinesisManager] Error while Unmarshalling destination config: %w", err)
-		return 400, outErr.Error(), outErr.Error()
-	}
-
-	streamName := aws.String(config.Stream)
-	parsedJSON := gjson.ParseBytes(jsonData)
-	data := parsedJSON.Get("message").Value()
-	if data == nil {
-		return 400, "InvalidPayload", "Empty Payload"
-	}
-	value, err := jsonrs.Marshal(data)
+	config, err := parseConfig(destConfig)
 	if err != nil {
 		return 400, err.Error(), err.Error()
 	}
-
-	var partitionKey string
-
-	if config.UseMessageID {
-		partitionKey = parsedJSON.Get("message.messageId").String()
-	}
-
-	if partitionKey == "" {
-		partitionKey = parsedJSON.Get("userId").String()
+	value, partitionKey, failure := recordOf(jsonData, config)
+	if failure != nil {
+		return failure.StatusCode, failure.RespStatus, failure.ResponseMessage
 	}
 	putInput := kinesis.PutRecordInput{
 		Data:         value,
-		StreamName:   streamName,
+		StreamName:   aws.String(config.Stream),
 		PartitionKey: aws.String(partitionKey),
 	}
 	if err = putInput.Validate(); err != nil {
@@ -100,6 +74,69 @@ func (producer *KinesisProducerV1) Produce(jsonData json.RawMessage, destConfig
 	return 200, "Success", message
 }
 
+// ProduceBatch sends the messages to Kinesis using PutRecords requests, having up to 500 records and 5 MB each.
+// Records failing individually get their own result, without failing the rest of the records of the request.
+func (producer *KinesisProducerV1) ProduceBatch(messages []json.RawMessage, destConfig interface{}) []common.ProduceResult {
+	client := producer.client
+	if client == nil {
+		return common.FailedResults(len(messages), 400, "Could not create producer for Kinesis", "Could not create producer for Kinesis")
+	}
+	config, err := parseConfig(destConfig)
+	if err != nil {
+		return common.FailedResults(len(messages), 400, err.Error(), err.Error())
+	}
+
+	results := make([]common.ProduceResult, len(messages))
+	batcher := common.RecordBatcher[*kinesis.PutRecordsRequestEntry]{
+		MaxRecords: maxRecordsPerRequest,
+		MaxBytes:   maxBytesPerRequest,
+		Send: func(entries []*kinesis.PutRecordsRequestEntry, indexes []int) {
+			putInput := kinesis.PutRecordsInput{
+				Records:    entries,
+				StreamName: aws.String(config.Stream),
+			}
+			if err := putInput.Validate(); err != nil {
+				for _, i := range indexes {
+					results[i] = common.ProduceResult{StatusCode: 400, RespStatus: "InvalidInput", ResponseMessage: err.Error()}
+				}
+				return
+			}
+			putOutput, err := client.PutRecords(&putInput)
+			if err != nil {
+				statusCode, respStatus, responseMessage := common.ParseAWSError(err)
+				pkgLogger.Errorf("[Kinesis] error  :: %d : %s : %s", statusCode, respStatus, responseMessage)
+				for _, i := range indexes {
+					results[i] = common.ProduceResult{StatusCode: statusCode, RespStatus: respStatus, ResponseMessage: responseMessage}
+				}
+				return
+			}
+			for j, i := range indexes {
+				if j >= len(putOutput.Records) {
+					results[i] = common.ProduceResult{StatusCode: 500, RespStatus: "Failure", ResponseMessage: "No result returned for record"}
+					continue
+				}
+				record := putOutput.Records[j]
+				if errorCode := aws.StringValue(record.ErrorCode); errorCode != "" {
+					results[i] = common.ProduceResult{StatusCode: recordErrorStatusCode(errorCode), RespStatus: errorCode, ResponseMessage: aws.StringValue(record.ErrorMessage)}
+					continue
+				}
+				message := fmt.Sprintf("Message delivered at SequenceNumber: %v , shard Id: %v", aws.StringValue(record.SequenceNumber), aws.StringValue(record.ShardId))
+				results[i] = common.ProduceResult{StatusCode: 200, RespStatus: "Success", ResponseMessage: message}
+			}
+		},
+	}
+	for i, message := range messages {
+		value, partitionKey, size, failure := batchRecordOf(message, config)
+		if failure != nil {
+			results[i] = *failure
+			continue
+		}
+		batcher.Add(i, &kinesis.PutRecordsRequestEntry{Data: value, PartitionKey: aws.String(partitionKey)}, size)
+	}
+	batcher.Flush()
+	return results
+}
+
 func (*KinesisProducerV1) Close() error {
 	// no-op
 	return nil
@@ -1,9 +1,28 @@
 package kinesis
 
-import "github.com/rudderlabs/rudder-go-kit/logger"
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/bytesize"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
 
 var pkgLogger logger.Logger
 
+const (
+	// maxRecordsPerRequest is the maximum number of records supported by a single PutRecords request
+	maxRecordsPerRequest = 500
+	// maxBytesPerRequest is the maximum size of the records (data and partition keys) of a single PutRecords request
+	maxBytesPerRequest = int(5 * bytesize.MB)
+	// maxBytesPerRecord is the maximum size of a single record (data and partition key)
+	maxBytesPerRecord = int(1 * bytesize.MB)
+)
+
 // Config is the config that is required to send data to Kinesis
 type Config struct {
 	Stream       string
@@ -13,3 +32,59 @@ type Config struct {
 func init() {
 	pkgLogger = logger.NewLogger().Child("streammanager").Child("kinesis")
 }
+
+// parseConfig converts the destination config to a Config
+func parseConfig(destConfig interface{}) (Config, error) {
+	config := Config{}
+	jsonConfig, err := jsonrs.Marshal(destConfig)
+	if err != nil {
+		return config, fmt.Errorf("[KinesisManager] Error while Marshalling destination config %+v Error: %w", destConfig, err)
+	}
+	err = jsonrs.Unmarshal(jsonConfig, &config)
+	if err != nil {
+		return config, fmt.Errorf("[KinesisManager] Error while Unmarshalling destination config: %w", err)
+	}
+	return config, nil
+}
+
+// recordOf returns the data and partition key of the record for a message, or a failure result if the message is invalid
+func recordOf(jsonData json.RawMessage, config Config) (data []byte, partitionKey string, failure *common.ProduceResult) {
+	parsedJSON := gjson.ParseBytes(jsonData)
+	message := parsedJSON.Get("message").Value()
+	if message == nil {
+		return nil, "", &common.ProduceResult{StatusCode: 400, RespStatus: "InvalidPayload", ResponseMessage: "Empty Payload"}
+	}
+	data, err := jsonrs.Marshal(message)
+	if err != nil {
+		return nil, "", &common.ProduceResult{StatusCode: 400, RespStatus: err.Error(), ResponseMessage: err.Error()}
+	}
+	if config.UseMessageID {
+		partitionKey = parsedJSON.Get("message.messageId").String()
+	}
+	if partitionKey == "" {
+		partitionKey = parsedJSON.Get("userId").String()
+	}
+	return data, partitionKey, nil
+}
+
+// batchRecordOf is like recordOf, additionally returning the size of the record and failing records too large for a PutRecords request
+func batchRecordOf(jsonData json.RawMessage, config Config) (data []byte, partitionKey string, size int, failure *common.ProduceResult) {
+	data, partitionKey, failure = recordOf(jsonData, config)
+	if failure != nil {
+		return nil, "", 0, failure
+	}
+	size = len(data) + len(partitionKey)
+	if size > maxBytesPerRecord {
+		message := fmt.Sprintf("Record size %d exceeds the maximum record size of %d bytes", size, maxBytesPerRecord)
+		return nil, "", 0, &common.ProduceResult{StatusCode: 400, RespStatus: "InvalidInput", ResponseMessage: message}
+	}
+	return data, partitionKey, size, nil
+}
+
+// recordErrorStatusCode maps the error code of a record which failed in a PutRecords request to a status code
+func recordErrorStatusCode(errorCode string) int {
+	if errorCode == "ProvisionedThroughputExceededException" {
+		return 429
+	}
+	return 500
+}
@@ -0,0 +1,162 @@
+package natsjetstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
+
+// Authentication types supported by the destination
+const (
+	authenticationNone         = "none"
+	authenticationUserPassword = "userPassword"
+	authenticationToken        = "token"
+	authenticationJWT          = "jwt"
+)
+
+// OrderingKeyHeader is the header holding the key messages are ordered by, i.e. the event's userId
+const OrderingKeyHeader = "Rudder-Ordering-Key"
+
+var pkgLogger logger.Logger
+
+func init() {
+	pkgLogger = logger.NewLogger().Child("streammanager").Child("natsjetstream")
+}
+
+// Config is the config that is required to send data to NATS JetStream
+type Config struct {
+	URL string `json:"url"` // comma separated list of server urls
+	// Subject is the subject messages are published to, it may contain placeholders to be replaced with event fields, e.g. events.{{type}}
+	Subject            string `json:"subject"`
+	AuthenticationType string `json:"authenticationType"`
+	Username           string `json:"username"`
+	Password           string `json:"password"`
+	Token              string `json:"token"`
+	JWT                string `json:"jwt"`
+	NKeySeed           string `json:"nkeySeed"`
+}
+
+// Publisher publishes messages to JetStream streams
+type Publisher interface {
+	PublishMsg(ctx context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error)
+}
+
+// NATSJetStreamProducer publishes messages to the JetStream stream bound to their subject
+type NATSJetStreamProducer struct {
+	subject   string
+	opts      common.Opts
+	publisher Publisher
+	conn      *nats.Conn
+}
+
+// NewProducer creates a producer based on destination config
+func NewProducer(destination *backendconfig.DestinationT, o common.Opts) (*NATSJetStreamProducer, error) {
+	var config Config
+	jsonConfig, err := jsonrs.Marshal(destination.Config)
+	if err != nil {
+		return nil, fmt.Errorf("[NATSJetStream] Error while marshalling destination config: %w", err)
+	}
+	if err := jsonrs.Unmarshal(jsonConfig, &config); err != nil {
+		return nil, fmt.Errorf("[NATSJetStream] Error while unmarshalling destination config: %w", err)
+	}
+	if config.URL == "" {
+		return nil, errors.New("invalid configuration provided, missing url")
+	}
+	if config.Subject == "" {
+		return nil, errors.New("invalid configuration provided, missing subject")
+	}
+	options := []nats.Option{nats.Name("rudder-server"), nats.Timeout(o.Timeout)}
+	switch config.AuthenticationType {
+	case "", authenticationNone:
+	case authenticationUserPassword:
+		options = append(options, nats.UserInfo(config.Username, config.Password))
+	case authenticationToken:
+		options = append(options, nats.Token(config.Token))
+	case authenticationJWT:
+		options = append(options, nats.UserJWTAndSeed(config.JWT, config.NKeySeed))
+	default:
+		return nil, fmt.Errorf("invalid configuration provided, unsupported authentication type %q", config.AuthenticationType)
+	}
+	conn, err := nats.Connect(config.URL, options...)
+	if err != nil {
+		return nil, fmt.Errorf("[NATSJetStream] connecting to %s: %w", config.URL, err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("[NATSJetStream] creating jetstream context: %w", err)
+	}
+	return &NATSJetStreamProducer{subject: config.Subject, opts: o, publisher: js, conn: conn}, nil
+}
+
+// Produce publishes the message of the transformed event to the subject it maps to.
+// The event's messageId is used for deduplication by JetStream, whereas its userId is sent in the ordering key header.
+func (producer *NATSJetStreamProducer) Produce(jsonData json.RawMessage, _ interface{}) (int, string, string) {
+	parsedJSON := gjson.ParseBytes(jsonData)
+	message := parsedJSON.Get("message")
+	if !message.Exists() {
+		return 400, "Failure", "[NATSJetStream] error :: message from payload not found"
+	}
+	subject, err := common.ExpandTemplate(producer.subject, message, escapeSubjectToken)
+	if err != nil {
+		return 400, "Failure", "[NATSJetStream] error :: resolving subject: " + err.Error()
+	}
+
+	msg := nats.NewMsg(subject)
+	msg.Data = []byte(message.Raw)
+	if key := common.OrderingKey(parsedJSON); key != "" {
+		msg.Header.Set(OrderingKeyHeader, key)
+	}
+	var publishOpts []jetstream.PublishOpt
+	if messageID := message.Get("messageId").String(); messageID != "" {
+		publishOpts = append(publishOpts, jetstream.WithMsgID(messageID))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), producer.opts.Timeout)
+	defer cancel()
+	ack, err := producer.publisher.PublishMsg(ctx, msg, publishOpts...)
+	if err != nil {
+		statusCode := statusCodeOf(err)
+		pkgLogger.Errorf("[NATSJetStream] error :: %d : %v", statusCode, err)
+		return statusCode, "Failure", "[NATSJetStream] error :: " + err.Error()
+	}
+	return 200, "Success", fmt.Sprintf("Message delivered to stream: %s, sequence: %d, duplicate: %t", ack.Stream, ack.Sequence, ack.Duplicate)
+}
+
+func (producer *NATSJetStreamProducer) Close() error {
+	if producer.conn != nil {
+		producer.conn.Close()
+	}
+	return nil
+}
+
+// escapeSubjectToken replaces characters having a special meaning in subjects
+func escapeSubjectToken(value string) string {
+	return strings.NewReplacer(".", "_", "*", "_", ">", "_", " ", "_", "\t", "_").Replace(value)
+}
+
+// statusCodeOf maps publishing errors to status codes, errors caused by the destination's configuration or the message are not retried
+func statusCodeOf(err error) int {
+	var apiErr *jetstream.APIError
+	switch {
+	case errors.Is(err, jetstream.ErrNoStreamResponse), // no stream is bound to the subject
+		errors.Is(err, nats.ErrBadSubject),
+		errors.Is(err, nats.ErrMaxPayload),
+		errors.Is(err, nats.ErrAuthorization):
+		return 400
+	case errors.As(err, &apiErr) && apiErr.Code >= 400 && apiErr.Code < 500:
+		return 400
+	}
+	return 500
+}
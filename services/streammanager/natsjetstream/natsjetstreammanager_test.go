@@ -0,0 +1,150 @@
+package natsjetstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
+
+type fakePublisher struct {
+	err  error
+	msgs []*nats.Msg
+	opts [][]jetstream.PublishOpt
+}
+
+func (p *fakePublisher) PublishMsg(_ context.Context, msg *nats.Msg, opts ...jetstream.PublishOpt) (*jetstream.PubAck, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	p.msgs = append(p.msgs, msg)
+	p.opts = append(p.opts, opts)
+	return &jetstream.PubAck{Stream: "EVENTS", Sequence: uint64(len(p.msgs))}, nil
+}
+
+func TestNewProducer(t *testing.T) {
+	_, err := NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{"subject": "events"}}, common.Opts{})
+	require.EqualError(t, err, "invalid configuration provided, missing url")
+
+	_, err = NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{"url": "nats://localhost:4222"}}, common.Opts{})
+	require.EqualError(t, err, "invalid configuration provided, missing subject")
+
+	_, err = NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{
+		"url": "nats://localhost:4222", "subject": "events", "authenticationType": "kerberos",
+	}}, common.Opts{})
+	require.EqualError(t, err, `invalid configuration provided, unsupported authentication type "kerberos"`)
+}
+
+func TestProduce(t *testing.T) {
+	t.Run("message is published to the templated subject", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		producer := &NATSJetStreamProducer{subject: "events.{{type}}.{{event}}", opts: common.Opts{Timeout: time.Second}, publisher: publisher}
+
+		statusCode, respStatus, responseMessage := producer.Produce([]byte(`{"userId":"user-1","message":{"type":"track","event":"order.completed","messageId":"msg-1"}}`), nil)
+		require.Equal(t, 200, statusCode)
+		require.Equal(t, "Success", respStatus)
+		require.Equal(t, "Message delivered to stream: EVENTS, sequence: 1, duplicate: false", responseMessage)
+
+		require.Len(t, publisher.msgs, 1)
+		require.Equal(t, "events.track.order_completed", publisher.msgs[0].Subject)
+		require.JSONEq(t, `{"type":"track","event":"order.completed","messageId":"msg-1"}`, string(publisher.msgs[0].Data))
+		require.Equal(t, "user-1", publisher.msgs[0].Header.Get(OrderingKeyHeader))
+		require.Len(t, publisher.opts[0], 1, "message id should be used for deduplication")
+	})
+
+	t.Run("invalid payloads", func(t *testing.T) {
+		producer := &NATSJetStreamProducer{subject: "events.{{type}}", opts: common.Opts{Timeout: time.Second}, publisher: &fakePublisher{}}
+		statusCode, respStatus, responseMessage := producer.Produce([]byte(`{"userId":"user-1"}`), nil)
+		require.Equal(t, 400, statusCode)
+		require.Equal(t, "Failure", respStatus)
+		require.Equal(t, "[NATSJetStream] error :: message from payload not found", responseMessage)
+
+		statusCode, _, responseMessage = producer.Produce([]byte(`{"message":{"event":"signup"}}`), nil)
+		require.Equal(t, 400, statusCode)
+		require.Contains(t, responseMessage, "resolving subject")
+	})
+
+	t.Run("errors are mapped to status codes", func(t *testing.T) {
+		publisher := &fakePublisher{}
+		producer := &NATSJetStreamProducer{subject: "events", opts: common.Opts{Timeout: time.Second}, publisher: publisher}
+		for _, tc := range []struct {
+			err        error
+			statusCode int
+		}{
+			{err: jetstream.ErrNoStreamResponse, statusCode: 400},
+			{err: fmt.Errorf("publishing: %w", nats.ErrMaxPayload), statusCode: 400},
+			{err: &jetstream.APIError{Code: 400, Description: "bad request"}, statusCode: 400},
+			{err: &jetstream.APIError{Code: 503, Description: "unavailable"}, statusCode: 500},
+			{err: nats.ErrTimeout, statusCode: 500},
+			{err: errors.New("connection reset"), statusCode: 500},
+		} {
+			publisher.err = tc.err
+			statusCode, respStatus, _ := producer.Produce([]byte(`{"message":{"event":"signup"}}`), nil)
+			require.Equal(t, tc.statusCode, statusCode, tc.err.Error())
+			require.Equal(t, "Failure", respStatus)
+		}
+	})
+}
+
+func TestProduceWithNATSJetStream(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	container, err := pool.RunWithOptions(&dockertest.RunOptions{
+		Repository:   "nats",
+		Tag:          "2.10-alpine",
+		Cmd:          []string{"-js"},
+		ExposedPorts: []string{"4222/tcp"},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = pool.Purge(container) })
+	url := fmt.Sprintf("nats://localhost:%s", container.GetPort("4222/tcp"))
+
+	var conn *nats.Conn
+	require.NoError(t, pool.Retry(func() (err error) {
+		conn, err = nats.Connect(url)
+		return err
+	}))
+	t.Cleanup(conn.Close)
+	js, err := jetstream.New(conn)
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	stream, err := js.CreateStream(ctx, jetstream.StreamConfig{Name: "EVENTS", Subjects: []string{"events.>"}})
+	require.NoError(t, err)
+
+	producer, err := NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{
+		"url":     url,
+		"subject": "events.{{type}}",
+	}}, common.Opts{Timeout: 10 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = producer.Close() })
+
+	payload := []byte(`{"userId":"user-1","message":{"type":"track","event":"signup","messageId":"msg-1"}}`)
+	statusCode, respStatus, responseMessage := producer.Produce(payload, nil)
+	require.Equal(t, 200, statusCode, responseMessage)
+	require.Equal(t, "Success", respStatus)
+	statusCode, _, responseMessage = producer.Produce(payload, nil)
+	require.Equal(t, 200, statusCode, responseMessage)
+	require.Contains(t, responseMessage, "duplicate: true")
+
+	msg, err := stream.GetLastMsgForSubject(ctx, "events.track")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, msg.Sequence, "duplicate messages should not be stored")
+	require.JSONEq(t, `{"type":"track","event":"signup","messageId":"msg-1"}`, string(msg.Data))
+	require.Equal(t, "user-1", msg.Header.Get(OrderingKeyHeader))
+
+	statusCode, _, _ = producer.Produce([]byte(`{"message":{"type":"identify"}}`), nil)
+	require.Equal(t, 200, statusCode)
+	producer.subject = "unbound.{{type}}"
+	statusCode, _, _ = producer.Produce([]byte(`{"message":{"type":"identify"}}`), nil)
+	require.Equal(t, 400, statusCode, "publishing to a subject without a stream should be aborted")
+}
@@ -0,0 +1,236 @@
+package pulsar
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/tidwall/gjson"
+
+	"github.com/rudderlabs/rudder-go-kit/config"
+	"github.com/rudderlabs/rudder-go-kit/jsonrs"
+	"github.com/rudderlabs/rudder-go-kit/logger"
+	kitsync "github.com/rudderlabs/rudder-go-kit/sync"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	internalpulsar "github.com/rudderlabs/rudder-server/internal/pulsar"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
+
+// Authentication types supported by the destination
+const (
+	authenticationNone   = "none"
+	authenticationToken  = "token"
+	authenticationBasic  = "basic"
+	authenticationOAuth2 = "oauth2"
+	authenticationTLS    = "tls"
+)
+
+var pkgLogger logger.Logger
+
+func init() {
+	pkgLogger = logger.NewLogger().Child("streammanager").Child("pulsar")
+}
+
+// Config is the config that is required to send data to Pulsar
+type Config struct {
+	ServiceURL string `json:"serviceUrl"`
+	// Topic is the topic messages are sent to, it may contain placeholders to be replaced with event fields, e.g. persistent://public/default/{{type}}
+	Topic                      string `json:"topic"`
+	AuthenticationType         string `json:"authenticationType"`
+	Token                      string `json:"token"`
+	Username                   string `json:"username"`
+	Password                   string `json:"password"`
+	OAuth2IssuerURL            string `json:"oauth2IssuerUrl"`
+	OAuth2Audience             string `json:"oauth2Audience"`
+	OAuth2Scope                string `json:"oauth2Scope"`
+	OAuth2Credentials          string `json:"oauth2Credentials"` // contents of the oauth2 credentials (key) file
+	TLSCertificate             string `json:"tlsCertificate"`    // PEM encoded client certificate
+	TLSKey                     string `json:"tlsKey"`            // PEM encoded client private key
+	TLSAllowInsecureConnection bool   `json:"tlsAllowInsecureConnection"`
+}
+
+// PulsarProducer sends messages to Pulsar topics, keeping a producer for the topics messages have been sent to most recently
+type PulsarProducer struct {
+	topic       string
+	opts        common.Opts
+	newProducer func(topic string) (internalpulsar.ProducerAdapter, error)
+	close       func()
+
+	producers      *lru.Cache[string, internalpulsar.ProducerAdapter] // producers are closed when evicted
+	producersLocks *kitsync.PartitionLocker                           // per topic locks, for creating each topic's producer only once
+}
+
+// newPulsarProducer creates a producer keeping up to maxProducers topic producers, closing the least recently used one when exceeding them
+func newPulsarProducer(topic string, o common.Opts, newProducer func(topic string) (internalpulsar.ProducerAdapter, error), closeClient func(), maxProducers int) (*PulsarProducer, error) {
+	producers, err := lru.NewWithEvict(maxProducers, func(_ string, p internalpulsar.ProducerAdapter) {
+		p.Close()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration provided, creating producers cache: %w", err)
+	}
+	return &PulsarProducer{
+		topic:          topic,
+		opts:           o,
+		newProducer:    newProducer,
+		close:          closeClient,
+		producers:      producers,
+		producersLocks: kitsync.NewPartitionLocker(),
+	}, nil
+}
+
+// NewProducer creates a producer based on destination config
+func NewProducer(destination *backendconfig.DestinationT, o common.Opts) (*PulsarProducer, error) {
+	maxProducers := config.GetIntVar(100, 1, "StreamManager.Pulsar.maxProducers")
+	var config Config
+	jsonConfig, err := jsonrs.Marshal(destination.Config)
+	if err != nil {
+		return nil, fmt.Errorf("[Pulsar] Error while marshalling destination config: %w", err)
+	}
+	if err := jsonrs.Unmarshal(jsonConfig, &config); err != nil {
+		return nil, fmt.Errorf("[Pulsar] Error while unmarshalling destination config: %w", err)
+	}
+	if config.Topic == "" {
+		return nil, errors.New("invalid configuration provided, missing topic")
+	}
+	auth, err := authentication(config)
+	if err != nil {
+		return nil, err
+	}
+	client, err := internalpulsar.NewClientWithOptions(pulsar.ClientOptions{
+		URL:                        config.ServiceURL,
+		OperationTimeout:           o.Timeout,
+		ConnectionTimeout:          o.Timeout,
+		Authentication:             auth,
+		TLSAllowInsecureConnection: config.TLSAllowInsecureConnection,
+	}, pkgLogger)
+	if err != nil {
+		return nil, err
+	}
+	producer, err := newPulsarProducer(config.Topic, o, func(topic string) (internalpulsar.ProducerAdapter, error) {
+		return client.NewProducer(pulsar.ProducerOptions{
+			Topic:              topic,
+			BatcherBuilderType: pulsar.KeyBasedBatchBuilder,
+		})
+	}, client.Close, maxProducers)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	return producer, nil
+}
+
+// authentication returns the authentication configured for the destination, nil if none is configured
+func authentication(config Config) (pulsar.Authentication, error) {
+	switch strings.ToLower(config.AuthenticationType) {
+	case "", authenticationNone:
+		return nil, nil
+	case authenticationToken:
+		if config.Token == "" {
+			return nil, errors.New("invalid configuration provided, missing token")
+		}
+		return pulsar.NewAuthenticationToken(config.Token), nil
+	case authenticationBasic:
+		return pulsar.NewAuthenticationBasic(config.Username, config.Password)
+	case authenticationOAuth2:
+		if config.OAuth2IssuerURL == "" || config.OAuth2Credentials == "" {
+			return nil, errors.New("invalid configuration provided, missing oauth2 issuer url or credentials")
+		}
+		return pulsar.NewAuthenticationOAuth2(map[string]string{
+			"type":       "client_credentials",
+			"issuerUrl":  config.OAuth2IssuerURL,
+			"audience":   config.OAuth2Audience,
+			"scope":      config.OAuth2Scope,
+			"privateKey": "data:application/json;base64," + base64.StdEncoding.EncodeToString([]byte(config.OAuth2Credentials)),
+		}), nil
+	case authenticationTLS:
+		certificate, err := tls.X509KeyPair([]byte(config.TLSCertificate), []byte(config.TLSKey))
+		if err != nil {
+			return nil, fmt.Errorf("invalid configuration provided, parsing tls certificate: %w", err)
+		}
+		return pulsar.NewAuthenticationFromTLSCertSupplier(func() (*tls.Certificate, error) { return &certificate, nil }), nil
+	default:
+		return nil, fmt.Errorf("invalid configuration provided, unsupported authentication type %q", config.AuthenticationType)
+	}
+}
+
+// Produce sends the message of the transformed event to the topic it maps to, using the event's userId as the key and ordering key
+func (producer *PulsarProducer) Produce(jsonData json.RawMessage, _ interface{}) (int, string, string) {
+	parsedJSON := gjson.ParseBytes(jsonData)
+	message := parsedJSON.Get("message")
+	if !message.Exists() {
+		return 400, "Failure", "[Pulsar] error :: message from payload not found"
+	}
+	topic, err := common.ExpandTemplate(producer.topic, message, escapeTopicValue)
+	if err != nil {
+		return 400, "Failure", "[Pulsar] error :: resolving topic: " + err.Error()
+	}
+	p, err := producer.producerFor(topic)
+	if err != nil {
+		statusCode := statusCodeOf(err)
+		pkgLogger.Errorf("[Pulsar] error :: creating producer for topic %s: %v", topic, err)
+		return statusCode, "Failure", "[Pulsar] error :: creating producer: " + err.Error()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), producer.opts.Timeout)
+	defer cancel()
+	key := common.OrderingKey(parsedJSON)
+	if err := p.SendMessage(ctx, key, key, []byte(message.Raw)); err != nil {
+		statusCode := statusCodeOf(err)
+		pkgLogger.Errorf("[Pulsar] error :: %d : %v", statusCode, err)
+		return statusCode, "Failure", "[Pulsar] error :: " + err.Error()
+	}
+	return 200, "Success", fmt.Sprintf("Message delivered to topic: %s", topic)
+}
+
+// producerFor returns the producer of a topic, creating it if it doesn't exist yet.
+// Producers of different topics are created concurrently, while concurrent calls for the same topic wait for its producer to be created.
+func (producer *PulsarProducer) producerFor(topic string) (internalpulsar.ProducerAdapter, error) {
+	if p, ok := producer.producers.Get(topic); ok {
+		return p, nil
+	}
+	producer.producersLocks.Lock(topic)
+	defer producer.producersLocks.Unlock(topic)
+	if p, ok := producer.producers.Get(topic); ok {
+		return p, nil
+	}
+	p, err := producer.newProducer(topic)
+	if err != nil {
+		return nil, err
+	}
+	producer.producers.Add(topic, p)
+	return p, nil
+}
+
+func (producer *PulsarProducer) Close() error {
+	producer.producers.Purge() // closes all producers
+	if producer.close != nil {
+		producer.close()
+	}
+	return nil
+}
+
+// escapeTopicValue replaces characters having a special meaning in topic names
+func escapeTopicValue(value string) string {
+	return strings.NewReplacer("/", "_", ":", "_", " ", "_").Replace(value)
+}
+
+// statusCodeOf maps pulsar errors to status codes, errors caused by the destination's configuration or the message are not retried
+func statusCodeOf(err error) int {
+	var pulsarErr *pulsar.Error
+	if errors.As(err, &pulsarErr) {
+		switch pulsarErr.Result() {
+		case pulsar.InvalidConfiguration, pulsar.InvalidTopicName, pulsar.InvalidURL, pulsar.TopicNotFound, pulsar.TopicTerminated,
+			pulsar.AuthenticationError, pulsar.AuthorizationError, pulsar.MessageTooBig, pulsar.InvalidMessage:
+			return 400
+		case pulsar.ProducerQueueIsFull, pulsar.ProducerBlockedQuotaExceededError, pulsar.ProducerBlockedQuotaExceededException, pulsar.ClientMemoryBufferIsFull:
+			return 429
+		}
+	}
+	return 500
+}
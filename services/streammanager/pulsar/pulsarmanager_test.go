@@ -0,0 +1,214 @@
+package pulsar
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/ory/dockertest/v3"
+	"github.com/stretchr/testify/require"
+
+	resource "github.com/rudderlabs/rudder-go-kit/testhelper/docker/resource/pulsar"
+	backendconfig "github.com/rudderlabs/rudder-server/backend-config"
+	internalpulsar "github.com/rudderlabs/rudder-server/internal/pulsar"
+	"github.com/rudderlabs/rudder-server/services/streammanager/common"
+)
+
+type sentMessage struct {
+	key, orderingKey string
+	payload          []byte
+}
+
+type fakeProducer struct {
+	err    error
+	sent   []sentMessage
+	closed bool
+}
+
+func (p *fakeProducer) SendMessage(_ context.Context, key, orderingKey string, msg []byte) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.sent = append(p.sent, sentMessage{key: key, orderingKey: orderingKey, payload: msg})
+	return nil
+}
+
+func (*fakeProducer) SendMessageAsync(context.Context, string, string, []byte, func(pulsar.MessageID, *pulsar.ProducerMessage, error)) {
+}
+func (p *fakeProducer) Close()     { p.closed = true }
+func (*fakeProducer) Flush() error { return nil }
+
+func newTestProducer(t *testing.T, topic string, producers map[string]*fakeProducer, maxProducers int) *PulsarProducer {
+	t.Helper()
+	producer, err := newPulsarProducer(topic, common.Opts{Timeout: time.Second}, func(topic string) (internalpulsar.ProducerAdapter, error) {
+		if p, ok := producers[topic]; ok {
+			return p, nil
+		}
+		return nil, errors.New("unknown topic")
+	}, nil, maxProducers)
+	require.NoError(t, err)
+	return producer
+}
+
+func TestNewProducer(t *testing.T) {
+	t.Run("missing topic", func(t *testing.T) {
+		_, err := NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{"serviceUrl": "pulsar://localhost:6650"}}, common.Opts{})
+		require.EqualError(t, err, "invalid configuration provided, missing topic")
+	})
+	t.Run("missing service url", func(t *testing.T) {
+		_, err := NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{"topic": "events"}}, common.Opts{})
+		require.EqualError(t, err, "pulsar url is empty")
+	})
+	t.Run("invalid authentication", func(t *testing.T) {
+		_, err := NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{
+			"serviceUrl": "pulsar://localhost:6650", "topic": "events", "authenticationType": "kerberos",
+		}}, common.Opts{})
+		require.EqualError(t, err, `invalid configuration provided, unsupported authentication type "kerberos"`)
+
+		_, err = NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{
+			"serviceUrl": "pulsar://localhost:6650", "topic": "events", "authenticationType": "token",
+		}}, common.Opts{})
+		require.EqualError(t, err, "invalid configuration provided, missing token")
+
+		_, err = NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{
+			"serviceUrl": "pulsar://localhost:6650", "topic": "events", "authenticationType": "tls", "tlsCertificate": "invalid",
+		}}, common.Opts{})
+		require.ErrorContains(t, err, "parsing tls certificate")
+	})
+}
+
+func TestProduce(t *testing.T) {
+	t.Run("message is sent to the templated topic with the user id as key", func(t *testing.T) {
+		tracks := &fakeProducer{}
+		producer := newTestProducer(t, "persistent://public/default/{{type}}-{{context.source}}", map[string]*fakeProducer{
+			"persistent://public/default/track-web_app": tracks,
+		}, 10)
+		statusCode, respStatus, responseMessage := producer.Produce([]byte(`{"userId":"user-1","message":{"type":"track","context":{"source":"web/app"}}}`), nil)
+		require.Equal(t, 200, statusCode)
+		require.Equal(t, "Success", respStatus)
+		require.Equal(t, "Message delivered to topic: persistent://public/default/track-web_app", responseMessage)
+
+		statusCode, _, _ = producer.Produce([]byte(`{"message":{"type":"track","anonymousId":"anon-1","context":{"source":"web/app"}}}`), nil)
+		require.Equal(t, 200, statusCode)
+		require.Equal(t, []sentMessage{
+			{key: "user-1", orderingKey: "user-1", payload: []byte(`{"type":"track","context":{"source":"web/app"}}`)},
+			{key: "anon-1", orderingKey: "anon-1", payload: []byte(`{"type":"track","anonymousId":"anon-1","context":{"source":"web/app"}}`)},
+		}, tracks.sent)
+
+		require.NoError(t, producer.Close())
+		require.True(t, tracks.closed)
+	})
+
+	t.Run("invalid payloads", func(t *testing.T) {
+		producer := newTestProducer(t, "{{type}}", nil, 10)
+		statusCode, respStatus, responseMessage := producer.Produce([]byte(`{"userId":"user-1"}`), nil)
+		require.Equal(t, 400, statusCode)
+		require.Equal(t, "Failure", respStatus)
+		require.Equal(t, "[Pulsar] error :: message from payload not found", responseMessage)
+
+		statusCode, _, responseMessage = producer.Produce([]byte(`{"message":{"event":"signup"}}`), nil)
+		require.Equal(t, 400, statusCode)
+		require.Contains(t, responseMessage, "resolving topic")
+	})
+
+	t.Run("errors are mapped to status codes", func(t *testing.T) {
+		failing := &fakeProducer{}
+		producer := newTestProducer(t, "events", map[string]*fakeProducer{"events": failing}, 10)
+		for _, tc := range []struct {
+			err        error
+			statusCode int
+		}{
+			{err: errors.New("connection reset"), statusCode: 500},
+			{err: context.DeadlineExceeded, statusCode: 500},
+		} {
+			failing.err = tc.err
+			statusCode, respStatus, _ := producer.Produce([]byte(`{"message":{"event":"signup"}}`), nil)
+			require.Equal(t, tc.statusCode, statusCode)
+			require.Equal(t, "Failure", respStatus)
+		}
+	})
+}
+
+func TestProducers(t *testing.T) {
+	t.Run("least recently used producers are closed when exceeding the maximum number of producers", func(t *testing.T) {
+		tracks, identifies, pages := &fakeProducer{}, &fakeProducer{}, &fakeProducer{}
+		producer := newTestProducer(t, "{{type}}", map[string]*fakeProducer{"track": tracks, "identify": identifies, "page": pages}, 2)
+		for _, eventType := range []string{"track", "identify", "track", "page"} {
+			statusCode, _, _ := producer.Produce([]byte(`{"message":{"type":"`+eventType+`"}}`), nil)
+			require.Equal(t, 200, statusCode)
+		}
+		require.True(t, identifies.closed, "least recently used producer should be closed")
+		require.False(t, tracks.closed)
+		require.False(t, pages.closed)
+
+		require.NoError(t, producer.Close())
+		require.True(t, tracks.closed)
+		require.True(t, pages.closed)
+	})
+
+	t.Run("creating a topic's producer doesn't block the other topics", func(t *testing.T) {
+		creating, created := make(chan struct{}), make(chan struct{})
+		tracks, identifies := &fakeProducer{}, &fakeProducer{}
+		producer, err := newPulsarProducer("{{type}}", common.Opts{Timeout: time.Second}, func(topic string) (internalpulsar.ProducerAdapter, error) {
+			if topic == "track" {
+				close(creating)
+				<-created
+				return tracks, nil
+			}
+			return identifies, nil
+		}, nil, 10)
+		require.NoError(t, err)
+
+		done := make(chan int)
+		go func() {
+			statusCode, _, _ := producer.Produce([]byte(`{"message":{"type":"track"}}`), nil)
+			done <- statusCode
+		}()
+		<-creating
+		statusCode, _, _ := producer.Produce([]byte(`{"message":{"type":"identify"}}`), nil)
+		require.Equal(t, 200, statusCode)
+		close(created)
+		require.Equal(t, 200, <-done)
+		require.Len(t, tracks.sent, 1)
+		require.NoError(t, producer.Close())
+	})
+}
+
+func TestProduceWithPulsar(t *testing.T) {
+	pool, err := dockertest.NewPool("")
+	require.NoError(t, err)
+	pulsarContainer, err := resource.Setup(pool, t)
+	require.NoError(t, err)
+
+	producer, err := NewProducer(&backendconfig.DestinationT{Config: map[string]interface{}{
+		"serviceUrl": pulsarContainer.URL,
+		"topic":      "persistent://public/default/events-{{type}}",
+	}}, common.Opts{Timeout: 30 * time.Second})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = producer.Close() })
+
+	client, err := pulsar.NewClient(pulsar.ClientOptions{URL: pulsarContainer.URL})
+	require.NoError(t, err)
+	t.Cleanup(client.Close)
+	consumer, err := client.Subscribe(pulsar.ConsumerOptions{
+		Topic:                       "persistent://public/default/events-track",
+		SubscriptionName:            "test-subscription",
+		SubscriptionInitialPosition: pulsar.SubscriptionPositionEarliest,
+	})
+	require.NoError(t, err)
+	t.Cleanup(consumer.Close)
+
+	statusCode, respStatus, responseMessage := producer.Produce([]byte(`{"userId":"user-1","message":{"type":"track","event":"signup"}}`), nil)
+	require.Equal(t, 200, statusCode, responseMessage)
+	require.Equal(t, "Success", respStatus)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	msg, err := consumer.Receive(ctx)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type":"track","event":"signup"}`, string(msg.Payload()))
+	require.Equal(t, "user-1", msg.Key())
+	require.Equal(t, "user-1", msg.OrderingKey())
+}
@@ -17,7 +17,9 @@ import (
 	"github.com/rudderlabs/rudder-server/services/streammanager/kafka"
 	"github.com/rudderlabs/rudder-server/services/streammanager/kinesis"
 	"github.com/rudderlabs/rudder-server/services/streammanager/lambda"
+	"github.com/rudderlabs/rudder-server/services/streammanager/natsjetstream"
 	"github.com/rudderlabs/rudder-server/services/streammanager/personalize"
+	"github.com/rudderlabs/rudder-server/services/streammanager/pulsar"
 	"github.com/rudderlabs/rudder-server/services/streammanager/wunderkind"
 )
 
@@ -51,6 +53,10 @@ func NewProducer(destination *backendconfig.DestinationT, opts common.Opts) (com
 		return lambda.NewProducer(destination, opts)
 	case "GOOGLE_CLOUD_FUNCTION":
 		return googlecloudfunction.NewProducer(destination, opts)
+	case "PULSAR":
+		return pulsar.NewProducer(destination, opts)
+	case "NATS_JETSTREAM":
+		return natsjetstream.NewProducer(destination, opts)
 	case "WUNDERKIND":
 		return wunderkind.NewProducer(config.Default, logger.NewLogger().Child("streammanager"))
 	default:
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS dedup_keys (
+    key TEXT PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS dedup_keys_expires_at_idx ON dedup_keys (expires_at);
@@ -22,9 +22,11 @@ const (
 )
 
 const (
-	DiffStatus        = "diff"
-	BotFlaggedStatus  = "bot_flagged"
-	BotDetectedStatus = "bot_detected"
+	DiffStatus          = "diff"
+	BotFlaggedStatus    = "bot_flagged"
+	BotDetectedStatus   = "bot_detected"
+	BotDroppedStatus    = "bot_dropped"
+	ConsentDeniedStatus = "consent_denied"
 
 	// Module names
 	GATEWAY                = "gateway"